@@ -3,14 +3,26 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	AI       AIConfig
-	OAuth    OAuthConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	JWT            JWTConfig
+	AI             AIConfig
+	OAuth          OAuthConfig
+	Payment        PaymentConfig
+	Inventory      InventoryConfig
+	Sale           SaleConfig
+	Search         SearchConfig
+	Recommendation RecommendationConfig
+	Storage        StorageConfig
+	ImageProxy     ImageProxyConfig
+	Placeholder    PlaceholderConfig
+	Shipping       ShippingConfig
+	Returns        ReturnConfig
+	StockHistory   StockHistoryConfig
 }
 
 type ServerConfig struct {
@@ -95,6 +107,111 @@ type LineOAuthConfig struct {
 	Scopes       []string `json:"scopes"`
 }
 
+// PaymentConfig 金流配置
+type PaymentConfig struct {
+	Provider        string            `json:"provider"` // "mock" 或 "ecpay"，必須明確設定
+	CallbackBaseURL string            `json:"callback_base_url"`
+	ClientBackURL   string            `json:"client_back_url"`
+	Mock            MockPaymentConfig `json:"mock"`
+	ECPay           ECPayConfig       `json:"ecpay"`
+}
+
+// MockPaymentConfig 本地模擬金流配置
+type MockPaymentConfig struct {
+	Secret          string `json:"secret"`           // 回調簽章密鑰，使用模擬金流時必須設定
+	SimulateEnabled bool   `json:"simulate_enabled"` // 開發用：開放客戶自行模擬付款結果，Release 模式下不得啟用
+}
+
+// ECPayConfig 綠界金流配置（APIURL 可指向本地模擬伺服器）
+type ECPayConfig struct {
+	APIURL     string `json:"api_url"`
+	MerchantID string `json:"merchant_id"`
+	HashKey    string `json:"hash_key"`
+	HashIV     string `json:"hash_iv"`
+}
+
+// InventoryConfig 庫存配置
+type InventoryConfig struct {
+	ReservationTTLMinutes int `json:"reservation_ttl_minutes"` // 結帳保留庫存的有效時間
+	SweepIntervalSeconds  int `json:"sweep_interval_seconds"`  // 釋放過期保留的排程間隔
+}
+
+// SearchConfig 商品全文檢索配置
+type SearchConfig struct {
+	Tokenizer string `json:"tokenizer"` // cjk（中日韓逐字）、unicode61 或 trigram，變更後啟動時重建索引
+}
+
+// RecommendationConfig 商品推薦配置
+type RecommendationConfig struct {
+	RefreshIntervalMinutes int `json:"refresh_interval_minutes"` // 批次重算推薦的排程間隔
+	ViewWindowDays         int `json:"view_window_days"`         // 共同瀏覽只計算這段期間內的瀏覽紀錄
+	MaxItems               int `json:"max_items"`                // 每個商品與每位顧客保留的推薦數
+}
+
+// StorageConfig 上傳檔案儲存配置
+type StorageConfig struct {
+	Driver        string          `json:"driver"`          // "local" 或 "s3"
+	LocalDir      string          `json:"local_dir"`       // local 儲存的根目錄
+	PublicBaseURL string          `json:"public_base_url"` // local 儲存對外的網址前綴
+	MaxUploadMB   int             `json:"max_upload_mb"`   // 單一圖片大小上限
+	S3            S3StorageConfig `json:"s3"`
+}
+
+// S3StorageConfig S3 相容物件儲存配置（Endpoint 可指向本地 MinIO）
+type S3StorageConfig struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	PublicURL string `json:"public_url"` // 物件對外的網址前綴，未設定時為 Endpoint/Bucket
+}
+
+// ImageProxyConfig 外部圖片代理配置
+type ImageProxyConfig struct {
+	AllowedHosts    []string `json:"allowed_hosts"`     // 允許代理的網域，子網域一併允許，轉址目標也必須在清單內
+	AllowPrivate    bool     `json:"allow_private"`     // 允許連線到內網與本機位址，僅供本地開發使用
+	MaxImageMB      int      `json:"max_image_mb"`      // 單一圖片大小上限
+	TimeoutSeconds  int      `json:"timeout_seconds"`   // 向來源網站請求的逾時
+	CacheDir        string   `json:"cache_dir"`         // 磁碟快取目錄
+	CacheMaxMB      int      `json:"cache_max_mb"`      // 磁碟快取容量上限，超過時淘汰最久未使用的圖片
+	CacheTTLMinutes int      `json:"cache_ttl_minutes"` // 來源未提供 Cache-Control 時的新鮮期，過期後向來源重新驗證
+}
+
+// PlaceholderConfig 佔位圖片產生配置
+type PlaceholderConfig struct {
+	FontPaths    []string `json:"font_paths"`    // 依序嘗試載入的字型檔（TTF、OTF、TTC），缺字時改用下一個字型
+	MaxSize      int      `json:"max_size"`      // 圖片寬高上限
+	CacheEntries int      `json:"cache_entries"` // 記憶體快取保留的圖片數
+}
+
+// ShippingConfig 運費計算配置
+type ShippingConfig struct {
+	DefaultWeightKg   float64 `json:"default_weight_kg"`  // 商品未設定重量時使用的重量（公斤）
+	VolumetricDivisor float64 `json:"volumetric_divisor"` // 材積重換算除數（立方公分/公斤），運送方式未設定時使用
+}
+
+// ReturnConfig 退貨退款配置
+type ReturnConfig struct {
+	WindowDays int `json:"window_days"`  // 訂單送達後可申請退貨的天數，0 表示不限制
+	MaxPhotoMB int `json:"max_photo_mb"` // 單張退貨照片大小上限
+	// 退款中超過此分鐘數仍未記錄結果（例如處理中斷）時可重新發起退款
+	RefundPendingTimeoutMinutes int `json:"refund_pending_timeout_minutes"`
+}
+
+// StockHistoryConfig 股價歷史保留配置
+type StockHistoryConfig struct {
+	TickRetentionDays          int `json:"tick_retention_days"`            // 逐筆行情保留天數，K 棒已於寫入時同步更新
+	MinuteBarRetentionDays     int `json:"minute_bar_retention_days"`      // 1 分 K 保留天數，之後只保留 5 分 K
+	FiveMinuteBarRetentionDays int `json:"five_minute_bar_retention_days"` // 5 分 K 保留天數，之後只保留日 K
+	CompactIntervalMinutes     int `json:"compact_interval_minutes"`       // 清理過期資料的排程間隔
+}
+
+// SaleConfig 限時特價配置
+type SaleConfig struct {
+	SchedulerIntervalSeconds int `json:"scheduler_interval_seconds"` // 檢查特價活動開始與結束的排程間隔
+}
+
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
@@ -150,6 +267,96 @@ func Load() *Config {
 				Scopes:       []string{"profile", "openid"},
 			},
 		},
+		Payment: PaymentConfig{
+			Provider:        os.Getenv("PAYMENT_PROVIDER"),
+			CallbackBaseURL: getEnv("PAYMENT_CALLBACK_BASE_URL", "http://localhost:8080"),
+			ClientBackURL:   getEnv("PAYMENT_CLIENT_BACK_URL", "http://localhost:8080/customer/dashboard"),
+			Mock: MockPaymentConfig{
+				Secret:          os.Getenv("PAYMENT_MOCK_SECRET"),
+				SimulateEnabled: getEnv("PAYMENT_MOCK_SIMULATE", "false") == "true",
+			},
+			ECPay: ECPayConfig{
+				// 官方公開的測試商店參數任何人都能用來簽署回調，因此不提供預設值
+				APIURL:     os.Getenv("ECPAY_API_URL"),
+				MerchantID: os.Getenv("ECPAY_MERCHANT_ID"),
+				HashKey:    os.Getenv("ECPAY_HASH_KEY"),
+				HashIV:     os.Getenv("ECPAY_HASH_IV"),
+			},
+		},
+		Inventory: InventoryConfig{
+			ReservationTTLMinutes: getEnvAsInt("RESERVATION_TTL_MINUTES", 15),
+			SweepIntervalSeconds:  getEnvAsInt("RESERVATION_SWEEP_SECONDS", 60),
+		},
+		Sale: SaleConfig{
+			SchedulerIntervalSeconds: getEnvAsInt("SALE_SCHEDULER_SECONDS", 30),
+		},
+		Search: SearchConfig{
+			Tokenizer: getEnv("SEARCH_TOKENIZER", "cjk"),
+		},
+		Recommendation: RecommendationConfig{
+			RefreshIntervalMinutes: getEnvAsInt("RECOMMENDATION_REFRESH_MINUTES", 60),
+			ViewWindowDays:         getEnvAsInt("RECOMMENDATION_VIEW_WINDOW_DAYS", 90),
+			MaxItems:               getEnvAsInt("RECOMMENDATION_MAX_ITEMS", 20),
+		},
+		Storage: StorageConfig{
+			Driver:        getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:      getEnv("STORAGE_LOCAL_DIR", "data/uploads"),
+			PublicBaseURL: getEnv("STORAGE_PUBLIC_BASE_URL", "/uploads"),
+			MaxUploadMB:   getEnvAsInt("STORAGE_MAX_UPLOAD_MB", 10),
+			S3: S3StorageConfig{
+				Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    getEnv("S3_BUCKET", "products"),
+				AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_SECRET_KEY", ""),
+				PublicURL: getEnv("S3_PUBLIC_URL", ""),
+			},
+		},
+		ImageProxy: ImageProxyConfig{
+			AllowedHosts: getEnvAsList("IMAGE_PROXY_ALLOWED_HOSTS", []string{
+				"via.placeholder.com",
+				"picsum.photos",
+				"httpbin.org",
+				"images.unsplash.com",
+				"loremflickr.com",
+				"placeimg.com",
+				"images.pexels.com",
+			}),
+			AllowPrivate:    getEnv("IMAGE_PROXY_ALLOW_PRIVATE", "false") == "true",
+			MaxImageMB:      getEnvAsInt("IMAGE_PROXY_MAX_IMAGE_MB", 10),
+			TimeoutSeconds:  getEnvAsInt("IMAGE_PROXY_TIMEOUT_SECONDS", 15),
+			CacheDir:        getEnv("IMAGE_PROXY_CACHE_DIR", "data/image-cache"),
+			CacheMaxMB:      getEnvAsInt("IMAGE_PROXY_CACHE_MAX_MB", 256),
+			CacheTTLMinutes: getEnvAsInt("IMAGE_PROXY_CACHE_TTL_MINUTES", 60),
+		},
+		Placeholder: PlaceholderConfig{
+			FontPaths: getEnvAsList("PLACEHOLDER_FONT_PATHS", []string{
+				"/usr/share/fonts/noto/NotoSansCJK-Regular.ttc",
+				"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+				"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+				"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+				"/usr/share/fonts/truetype/wqy/wqy-zenhei.ttc",
+				"/System/Library/Fonts/PingFang.ttc",
+				"C:\\Windows\\Fonts\\msjh.ttc",
+			}),
+			MaxSize:      getEnvAsInt("PLACEHOLDER_MAX_SIZE", 2000),
+			CacheEntries: getEnvAsInt("PLACEHOLDER_CACHE_ENTRIES", 256),
+		},
+		Shipping: ShippingConfig{
+			DefaultWeightKg:   getEnvAsFloat("SHIPPING_DEFAULT_WEIGHT_KG", 0.5),
+			VolumetricDivisor: getEnvAsFloat("SHIPPING_VOLUMETRIC_DIVISOR", 6000),
+		},
+		Returns: ReturnConfig{
+			WindowDays:                  getEnvAsInt("RETURN_WINDOW_DAYS", 7),
+			MaxPhotoMB:                  getEnvAsInt("RETURN_MAX_PHOTO_MB", 5),
+			RefundPendingTimeoutMinutes: getEnvAsInt("RETURN_REFUND_PENDING_TIMEOUT_MINUTES", 10),
+		},
+		StockHistory: StockHistoryConfig{
+			TickRetentionDays:          getEnvAsInt("STOCK_TICK_RETENTION_DAYS", 7),
+			MinuteBarRetentionDays:     getEnvAsInt("STOCK_1M_BAR_RETENTION_DAYS", 30),
+			FiveMinuteBarRetentionDays: getEnvAsInt("STOCK_5M_BAR_RETENTION_DAYS", 365),
+			CompactIntervalMinutes:     getEnvAsInt("STOCK_HISTORY_COMPACT_MINUTES", 60),
+		},
 	}
 }
 
@@ -169,6 +376,21 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsList 讀取以逗號分隔的清單，忽略空白項目
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnvAsFloat(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddressController 客戶通訊錄控制器
+type AddressController struct {
+	addressService *services.AddressService
+}
+
+// NewAddressController 創建客戶通訊錄控制器
+func NewAddressController(addressService *services.AddressService) *AddressController {
+	return &AddressController{
+		addressService: addressService,
+	}
+}
+
+// GetRegions 獲取臺灣縣市、鄉鎮市區與郵遞區號
+// @Summary 獲取縣市與鄉鎮市區
+// @Tags 通訊錄
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/addresses/regions [get]
+func (c *AddressController) GetRegions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"cities": c.addressService.Regions(),
+	})
+}
+
+// GetAddresses 獲取通訊錄
+// @Summary 獲取通訊錄
+// @Description 獲取客戶儲存的所有地址，預設地址在前
+// @Tags 通訊錄
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/addresses [get]
+func (c *AddressController) GetAddresses(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	addresses, err := c.addressService.ListAddresses(customerID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取通訊錄失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"addresses": addresses,
+		"total":     len(addresses),
+	})
+}
+
+// CreateAddress 新增地址
+// @Summary 新增地址
+// @Description 第一筆地址自動成為預設收件與帳單地址，未填郵遞區號時依鄉鎮市區帶入
+// @Tags 通訊錄
+// @Accept json
+// @Produce json
+// @Param request body models.AddressRequest true "地址內容"
+// @Success 201 {object} models.CustomerAddress
+// @Failure 400 {object} map[string]string
+// @Router /api/addresses [post]
+func (c *AddressController) CreateAddress(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.AddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	address, err := c.addressService.CreateAddress(customerID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "新增地址失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "地址新增成功",
+		"address": address,
+	})
+}
+
+// UpdateAddress 更新地址
+// @Summary 更新地址
+// @Tags 通訊錄
+// @Accept json
+// @Produce json
+// @Param id path int true "地址ID"
+// @Param request body models.AddressRequest true "地址內容"
+// @Success 200 {object} models.CustomerAddress
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/addresses/{id} [put]
+func (c *AddressController) UpdateAddress(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := addressIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.AddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	address, err := c.addressService.UpdateAddress(customerID, id, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "更新地址失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "地址更新成功",
+		"address": address,
+	})
+}
+
+// SetDefaultAddress 設定預設地址
+// @Summary 設定預設地址
+// @Tags 通訊錄
+// @Accept json
+// @Produce json
+// @Param id path int true "地址ID"
+// @Param request body models.SetDefaultAddressRequest true "地址用途（shipping 或 billing）"
+// @Success 200 {object} models.CustomerAddress
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/addresses/{id}/default [put]
+func (c *AddressController) SetDefaultAddress(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := addressIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.SetDefaultAddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	address, err := c.addressService.SetDefault(customerID, id, req.Usage)
+	if err != nil {
+		respondDomainError(ctx, err, "設定預設地址失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已設為預設地址",
+		"address": address,
+	})
+}
+
+// DeleteAddress 刪除地址
+// @Summary 刪除地址
+// @Description 刪除預設地址時改以最近新增的地址作為預設，已下單的訂單保留地址快照不受影響
+// @Tags 通訊錄
+// @Produce json
+// @Param id path int true "地址ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/addresses/{id} [delete]
+func (c *AddressController) DeleteAddress(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := addressIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.addressService.DeleteAddress(customerID, id); err != nil {
+		respondDomainError(ctx, err, "刪除地址失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "地址已刪除",
+	})
+}
+
+// addressIDParam 解析路徑中的地址ID，格式錯誤時直接回應 400
+func addressIDParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的地址ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
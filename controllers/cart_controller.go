@@ -118,7 +118,7 @@ func (c *CartController) AddToCart(ctx *gin.Context) {
 		return
 	}
 
-	err := c.cartService.AddToCart(customerID, req.ProductID, req.Quantity)
+	err := c.cartService.AddToCart(customerID, req.ProductID, req.VariantID, req.Quantity)
 	if err != nil {
 		// 檢查是否為購物車錯誤
 		if cartErr, ok := err.(*models.CartError); ok {
@@ -157,6 +157,7 @@ func (c *CartController) AddToCart(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param productId path int true "商品ID"
+// @Param variant_id query int false "規格ID"
 // @Param request body UpdateCartItemRequest true "更新商品請求"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
@@ -209,7 +210,8 @@ func (c *CartController) UpdateCartItem(ctx *gin.Context) {
 		return
 	}
 
-	err = c.cartService.UpdateCartItem(customerID, productID, req.Quantity)
+	variantID, _ := strconv.Atoi(ctx.DefaultQuery("variant_id", "0"))
+	err = c.cartService.UpdateCartItem(customerID, productID, variantID, req.Quantity)
 	if err != nil {
 		// 檢查是否為購物車錯誤
 		if cartErr, ok := err.(*models.CartError); ok {
@@ -248,6 +250,7 @@ func (c *CartController) UpdateCartItem(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param productId path int true "商品ID"
+// @Param variant_id query int false "規格ID"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -283,7 +286,8 @@ func (c *CartController) RemoveFromCart(ctx *gin.Context) {
 		return
 	}
 
-	err = c.cartService.RemoveFromCart(customerID, productID)
+	variantID, _ := strconv.Atoi(ctx.DefaultQuery("variant_id", "0"))
+	err = c.cartService.RemoveFromCart(customerID, productID, variantID)
 	if err != nil {
 		// 檢查是否為購物車錯誤
 		if cartErr, ok := err.(*models.CartError); ok {
@@ -442,9 +446,119 @@ func (c *CartController) GetCartItemCount(ctx *gin.Context) {
 	})
 }
 
+// ApplyCoupon 套用優惠券
+// @Summary 套用優惠券
+// @Description 將優惠券代碼套用到購物車，回傳套用後的購物車摘要
+// @Tags 購物車
+// @Accept json
+// @Produce json
+// @Param request body models.ApplyCouponRequest true "優惠券代碼"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/cart/coupon [post]
+func (c *CartController) ApplyCoupon(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ApplyCouponRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	if err := c.cartService.ApplyCoupon(customerID, req.Code); err != nil {
+		respondDomainError(ctx, err, "套用優惠券失敗")
+		return
+	}
+
+	summary, err := c.cartService.GetCartSummary(customerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "獲取購物車摘要失敗: " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// SelectShipping 選擇運送方式
+// @Summary 選擇運送方式
+// @Description 選擇購物車中某商戶的運送方式，回傳重新計算運費後的購物車摘要
+// @Tags 購物車
+// @Accept json
+// @Produce json
+// @Param request body models.SelectShippingRequest true "商戶與運送方式"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/cart/shipping [put]
+func (c *CartController) SelectShipping(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.SelectShippingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	if err := c.cartService.SelectShipping(customerID, req.MerchantID, req.ShippingMethodID); err != nil {
+		respondDomainError(ctx, err, "選擇運送方式失敗")
+		return
+	}
+
+	summary, err := c.cartService.GetCartSummary(customerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "獲取購物車摘要失敗: " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// RemoveCoupon 移除優惠券
+// @Summary 移除優惠券
+// @Description 移除購物車套用的優惠券
+// @Tags 購物車
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/cart/coupon [delete]
+func (c *CartController) RemoveCoupon(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.cartService.RemoveCoupon(customerID); err != nil {
+		respondDomainError(ctx, err, "移除優惠券失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "已移除優惠券",
+	})
+}
+
 // 請求結構體
 type AddToCartRequest struct {
 	ProductID int `json:"product_id" binding:"required"`
+	VariantID int `json:"variant_id"` // 有規格的商品必填
 	Quantity  int `json:"quantity" binding:"required,min=1"`
 }
 
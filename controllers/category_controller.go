@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryController 商品分類控制器（分類樹瀏覽與管理員維護）
+type CategoryController struct {
+	categoryService *services.CategoryService
+}
+
+// NewCategoryController 創建商品分類控制器
+func NewCategoryController(categoryService *services.CategoryService) *CategoryController {
+	return &CategoryController{
+		categoryService: categoryService,
+	}
+}
+
+// GetCategoryTree 獲取分類樹
+// @Summary 獲取分類樹
+// @Description 獲取啟用中的分類樹，每個分類附直接歸屬的商品數
+// @Tags 商品分類
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/categories/tree [get]
+func (c *CategoryController) GetCategoryTree(ctx *gin.Context) {
+	tree, err := c.categoryService.GetTree(true)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取分類失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"categories": tree,
+	})
+}
+
+// GetCategoryProducts 獲取分類商品
+// @Summary 獲取分類商品
+// @Description 獲取分類及其所有子分類下的商品，並附上分類的麵包屑路徑
+// @Tags 商品分類
+// @Produce json
+// @Param id path int true "分類ID"
+// @Param limit query int false "每頁數量" default(12)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/categories/{id}/products [get]
+func (c *CategoryController) GetCategoryProducts(ctx *gin.Context) {
+	categoryID, ok := categoryIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "12"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 12
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	products, breadcrumbs, err := c.categoryService.GetCategoryProducts(categoryID, limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取分類商品失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"products":    products,
+		"breadcrumbs": breadcrumbs,
+	})
+}
+
+// GetAdminCategories 管理員獲取分類樹
+// @Summary 獲取所有分類
+// @Description 包含已停用的分類
+// @Tags 商品分類
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/api/categories [get]
+func (c *CategoryController) GetAdminCategories(ctx *gin.Context) {
+	tree, err := c.categoryService.GetTree(false)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取分類失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"categories": tree,
+	})
+}
+
+// CreateCategory 新增分類
+// @Summary 新增分類
+// @Tags 商品分類
+// @Accept json
+// @Produce json
+// @Param request body models.CategoryRequest true "分類內容"
+// @Success 201 {object} models.ProductCategory
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api/categories [post]
+func (c *CategoryController) CreateCategory(ctx *gin.Context) {
+	var req models.CategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := c.categoryService.CreateCategory(&req)
+	if err != nil {
+		respondDomainError(ctx, err, "新增分類失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"message":  "分類已新增",
+		"category": category,
+	})
+}
+
+// UpdateCategory 修改分類
+// @Summary 修改分類
+// @Description 可修改名稱或移動到其他父分類之下，歸屬商品的分類名稱會一併更新
+// @Tags 商品分類
+// @Accept json
+// @Produce json
+// @Param id path int true "分類ID"
+// @Param request body models.CategoryRequest true "分類內容"
+// @Success 200 {object} models.ProductCategory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api/categories/{id} [put]
+func (c *CategoryController) UpdateCategory(ctx *gin.Context) {
+	categoryID, ok := categoryIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := c.categoryService.UpdateCategory(categoryID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "修改分類失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "分類已更新",
+		"category": category,
+	})
+}
+
+// DeleteCategory 刪除分類
+// @Summary 刪除分類
+// @Description 分類下仍有子分類或商品時無法刪除
+// @Tags 商品分類
+// @Produce json
+// @Param id path int true "分類ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api/categories/{id} [delete]
+func (c *CategoryController) DeleteCategory(ctx *gin.Context) {
+	categoryID, ok := categoryIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.categoryService.DeleteCategory(categoryID); err != nil {
+		respondDomainError(ctx, err, "刪除分類失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "分類已刪除",
+	})
+}
+
+// categoryIDParam 解析路徑中的分類ID，失敗時直接回應 400
+func categoryIDParam(ctx *gin.Context) (int, bool) {
+	categoryID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的分類ID",
+		})
+		return 0, false
+	}
+	return categoryID, true
+}
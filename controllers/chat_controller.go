@@ -28,6 +28,11 @@ func NewChatController(chatService *services.ChatService) *ChatController {
 	}
 }
 
+// SetIndicatorService 設置技術指標服務，股票問答時提供實際計算的技術指標給AI
+func (cc *ChatController) SetIndicatorService(indicatorService *services.IndicatorService) {
+	cc.chatService.SetIndicatorService(indicatorService)
+}
+
 // CreateConversation 创建新对话
 func (cc *ChatController) CreateConversation(c *gin.Context) {
 	var req models.CreateConversationRequest
@@ -1,100 +1,100 @@
 package controllers
 
 import (
-	"fmt"
+	"bytes"
 	"net/http"
 	"strconv"
+	"time"
+	"go-simple-app/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-type ImageController struct{}
+type ImageController struct {
+	placeholderService *services.PlaceholderService
+}
 
-func NewImageController() *ImageController {
-	return &ImageController{}
+func NewImageController(placeholderService *services.PlaceholderService) *ImageController {
+	return &ImageController{
+		placeholderService: placeholderService,
+	}
 }
 
 // GenerateProductImage 生成商品圖片
+// @Summary 生成商品佔位圖片
+// @Description 伺服器端繪製的商品佔位圖，預設輸出 PNG，可指定 format=webp 或 svg；相同參數的結果會被快取
+// @Tags 圖片
+// @Produce image/png,image/webp,image/svg+xml
+// @Param w query int false "寬度" default(300)
+// @Param h query int false "高度" default(300)
+// @Param text query string false "文字" default(商品圖片)
+// @Param format query string false "png、webp 或 svg" default(png)
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Router /api/image/product [get]
 func (c *ImageController) GenerateProductImage(ctx *gin.Context) {
-	// 獲取參數
-	width := ctx.DefaultQuery("w", "300")
-	height := ctx.DefaultQuery("h", "300")
-	text := ctx.Query("text")
-	
-	// 轉換為整數
-	w, err := strconv.Atoi(width)
-	if err != nil {
-		w = 300
-	}
-	
-	h, err := strconv.Atoi(height)
-	if err != nil {
-		h = 300
-	}
-	
-	// 如果沒有提供文字，使用預設
-	if text == "" {
-		text = "商品圖片"
-	}
-	
-	// 生成SVG圖片
-	svg := fmt.Sprintf(`<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">
-		<rect width="%d" height="%d" fill="#f8f9fa" stroke="#dee2e6" stroke-width="2"/>
-		<rect x="10" y="10" width="%d" height="%d" fill="#e9ecef" stroke="#adb5bd" stroke-width="1"/>
-		<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-family="Arial, sans-serif" font-size="%d" fill="#495057">
-			%s
-		</text>
-		<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-family="Arial, sans-serif" font-size="%d" fill="#6c757d">
-			%dx%d
-		</text>
-	</svg>`, 
-		w, h, w, h, w-20, h-20, w/2, h/2-10, w/20, text, w/2, h/2+20, w/25, w, h)
-	
-	// 設置響應頭
-	ctx.Header("Content-Type", "image/svg+xml")
-	ctx.Header("Cache-Control", "public, max-age=3600")
-	
-	// 返回SVG
-	ctx.String(http.StatusOK, svg)
+	c.renderPlaceholder(ctx, services.PlaceholderStyleProduct, "商品圖片")
 }
 
 // GeneratePlaceholderImage 生成佔位符圖片
+// @Summary 生成佔位圖片
+// @Description 伺服器端繪製的圖片載入中佔位圖，預設輸出 PNG，可指定 format=webp 或 svg；相同參數的結果會被快取
+// @Tags 圖片
+// @Produce image/png,image/webp,image/svg+xml
+// @Param w query int false "寬度" default(300)
+// @Param h query int false "高度" default(300)
+// @Param text query string false "文字" default(圖片載入中)
+// @Param format query string false "png、webp 或 svg" default(png)
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Router /api/image/placeholder [get]
 func (c *ImageController) GeneratePlaceholderImage(ctx *gin.Context) {
-	// 獲取參數
-	width := ctx.DefaultQuery("w", "300")
-	height := ctx.DefaultQuery("h", "300")
-	text := ctx.Query("text")
-	
-	// 轉換為整數
-	w, err := strconv.Atoi(width)
+	c.renderPlaceholder(ctx, services.PlaceholderStylePlaceholder, "圖片載入中")
+}
+
+// renderPlaceholder 解析共用參數並輸出佔位圖片，支援 If-None-Match 條件請求
+func (c *ImageController) renderPlaceholder(ctx *gin.Context, style, defaultText string) {
+	// 轉換為整數，無效時使用預設尺寸
+	w, err := strconv.Atoi(ctx.DefaultQuery("w", "300"))
 	if err != nil {
 		w = 300
 	}
-	
-	h, err := strconv.Atoi(height)
+	h, err := strconv.Atoi(ctx.DefaultQuery("h", "300"))
 	if err != nil {
 		h = 300
 	}
-	
+
 	// 如果沒有提供文字，使用預設
+	text := ctx.Query("text")
 	if text == "" {
-		text = "圖片載入中"
+		text = defaultText
 	}
-	
-	// 生成簡單的佔位符SVG
-	svg := fmt.Sprintf(`<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">
-		<rect width="%d" height="%d" fill="#f8f9fa"/>
-		<rect x="%d" y="%d" width="%d" height="%d" fill="#e9ecef" rx="8"/>
-		<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-family="Arial, sans-serif" font-size="%d" fill="#6c757d">
-			%s
-		</text>
-	</svg>`, 
-		w, h, w, h, w/4, h/4, w/2, h/2, w/2, h/2, w/15, text)
-	
+
+	image, err := c.placeholderService.Render(services.PlaceholderOptions{
+		Style:  style,
+		Width:  w,
+		Height: h,
+		Text:   text,
+		Format: ctx.Query("format"),
+	})
+	if err != nil {
+		if err == services.ErrInvalidPlaceholderFormat {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "生成圖片失敗: " + err.Error(),
+		})
+		return
+	}
+
 	// 設置響應頭
-	ctx.Header("Content-Type", "image/svg+xml")
-	ctx.Header("Cache-Control", "public, max-age=3600")
-	
-	// 返回SVG
-	ctx.String(http.StatusOK, svg)
+	ctx.Header("Content-Type", image.ContentType)
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Header("ETag", image.ETag)
+	ctx.Header("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(ctx.Writer, ctx.Request, "", time.Time{}, bytes.NewReader(image.Data))
 }
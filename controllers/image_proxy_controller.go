@@ -1,27 +1,40 @@
 package controllers
 
 import (
-	"strings"
-	"io"
+	"bytes"
+	"errors"
 	"net/http"
-	"time"
+	"net/url"
+	"go-simple-app/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ImageProxyController struct {
-	client *http.Client
+	proxyService *services.ImageProxyService
 }
 
-func NewImageProxyController() *ImageProxyController {
+func NewImageProxyController(proxyService *services.ImageProxyService) *ImageProxyController {
 	return &ImageProxyController{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		proxyService: proxyService,
 	}
 }
 
 // ProxyImage 代理外部圖片
+// @Summary 代理外部圖片
+// @Description 只代理白名單網域的圖片，拒絕解析到內網位址的網址與轉址到白名單外的網址；
+// @Description 依內容確認為圖片後存入磁碟快取，回應帶 ETag 與 Last-Modified 供用戶端條件請求
+// @Tags 圖片
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param url query string true "圖片網址"
+// @Success 200 {file} binary
+// @Success 304 "圖片未變更"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/image/proxy [get]
 func (c *ImageProxyController) ProxyImage(ctx *gin.Context) {
 	// 獲取圖片URL參數
 	imageURL := ctx.Query("url")
@@ -32,63 +45,33 @@ func (c *ImageProxyController) ProxyImage(ctx *gin.Context) {
 		return
 	}
 
-	// 驗證URL格式
-	if !isValidImageURL(imageURL) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "無效的圖片URL",
-		})
-		return
-	}
-
-	// 創建請求
-	req, err := http.NewRequest("GET", imageURL, nil)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "無法創建請求: " + err.Error(),
-		})
-		return
-	}
-
-	// 設置請求頭
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Accept", "image/*,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-
-	// 發送請求
-	resp, err := c.client.Do(req)
+	image, err := c.proxyService.Fetch(ctx.Request.Context(), imageURL)
 	if err != nil {
+		var proxyErr *services.ImageProxyError
+		if errors.As(err, &proxyErr) {
+			ctx.JSON(proxyErr.Status, gin.H{
+				"error": proxyErr.Message,
+				"code":  proxyErr.Code,
+			})
+			return
+		}
 		ctx.JSON(http.StatusBadGateway, gin.H{
 			"error": "無法獲取圖片: " + err.Error(),
 		})
 		return
 	}
-	defer resp.Body.Close()
 
-	// 檢查響應狀態
-	if resp.StatusCode != http.StatusOK {
-		ctx.JSON(http.StatusBadGateway, gin.H{
-			"error": "圖片服務返回錯誤狀態: " + resp.Status,
-		})
-		return
-	}
-
-	// 設置響應頭
-	ctx.Header("Content-Type", resp.Header.Get("Content-Type"))
+	// 設置響應頭，內容類型以實際圖片格式為準並禁止瀏覽器另行判斷
+	ctx.Header("Content-Type", image.Entry.ContentType)
 	ctx.Header("Cache-Control", "public, max-age=3600")
 	ctx.Header("Access-Control-Allow-Origin", "*")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Content-Security-Policy", "default-src 'none'")
+	ctx.Header("ETag", `"`+image.Entry.Digest+`"`)
+	ctx.Header("X-Cache", image.Cache)
 
-	// 複製圖片數據
-	_, err = io.Copy(ctx.Writer, resp.Body)
-	if err != nil {
-		// 如果複製失敗，返回錯誤
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "圖片傳輸失敗: " + err.Error(),
-		})
-		return
-	}
+	// ServeContent 處理 If-None-Match / If-Modified-Since 與 Range 請求
+	http.ServeContent(ctx.Writer, ctx.Request, "", image.ModTime(), bytes.NewReader(image.Data))
 }
 
 // GenerateExternalImage 生成外部圖片URL
@@ -114,47 +97,10 @@ func (c *ImageProxyController) GenerateExternalImage(ctx *gin.Context) {
 	}
 
 	// 返回代理URL
-	proxyURL := "/api/image/proxy?url=" + imageURL
+	proxyURL := "/api/image/proxy?url=" + url.QueryEscape(imageURL)
 	ctx.JSON(http.StatusOK, gin.H{
 		"original_url": imageURL,
 		"proxy_url":    proxyURL,
 		"service":      service,
 	})
 }
-
-// isValidImageURL 驗證圖片URL
-func isValidImageURL(url string) bool {
-	// 簡單的URL驗證
-	if len(url) < 10 {
-		return false
-	}
-	
-	// 檢查是否以http或https開頭
-	if url[:4] != "http" {
-		return false
-	}
-	
-	// 檢查是否包含圖片相關的域名或路徑
-	validDomains := []string{
-		"via.placeholder.com",
-		"picsum.photos",
-		"httpbin.org",
-		"images.unsplash.com",
-		"loremflickr.com",
-		"placeimg.com",
-		"images.pexels.com",
-	}
-	
-	for _, domain := range validDomains {
-		if contains(url, domain) {
-			return true
-		}
-	}
-	
-	return false
-}
-
-// contains 檢查字符串是否包含子字符串
-func contains(s, substr string) bool {
-	return strings.Contains(s, substr)
-}
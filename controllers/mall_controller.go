@@ -3,18 +3,24 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"go-simple-app/models"
+	"go-simple-app/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MallController struct {
-	productRepo *models.ProductRepository
+	productRepo   *models.ProductRepository
+	searchService *services.SearchService
+	mallService   *services.MallService
 }
 
-func NewMallController(productRepo *models.ProductRepository) *MallController {
+func NewMallController(productRepo *models.ProductRepository, searchService *services.SearchService, mallService *services.MallService) *MallController {
 	return &MallController{
-		productRepo: productRepo,
+		productRepo:   productRepo,
+		searchService: searchService,
+		mallService:   mallService,
 	}
 }
 
@@ -67,29 +73,128 @@ func (c *MallController) GetFeaturedProducts(ctx *gin.Context) {
 }
 
 // GetProducts 獲取商品列表
+// @Summary 篩選商品列表
+// @Description 可組合分類、品牌、價格區間、評分、特價、有庫存與標籤篩選並排序，回應附各篩選面向的商品數供側邊欄使用
+// @Tags 商城
+// @Produce json
+// @Param q query string false "關鍵字"
+// @Param category_id query int false "分類ID（包含子分類）"
+// @Param category query string false "分類名稱"
+// @Param brand query string false "品牌，可重複或以逗號分隔"
+// @Param min_price query number false "最低價格"
+// @Param max_price query number false "最高價格"
+// @Param min_rating query number false "最低評分"
+// @Param on_sale query bool false "僅特價商品"
+// @Param in_stock query bool false "僅有庫存商品"
+// @Param tags query string false "標籤，可重複或以逗號分隔，需全部符合"
+// @Param sort query string false "排序 relevance|newest|price_asc|price_desc|rating|popular"
+// @Param limit query int false "每頁數量" default(12)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} services.ProductListResult
+// @Failure 400 {object} map[string]string
+// @Router /api/products [get]
 func (c *MallController) GetProducts(ctx *gin.Context) {
-	limitStr := ctx.DefaultQuery("limit", "12")
-	offsetStr := ctx.DefaultQuery("offset", "0")
-	
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		limit = 12
+	filter, ok := parseProductFilter(ctx)
+	if !ok {
+		return
 	}
-	
-	offset, err := strconv.Atoi(offsetStr)
+
+	result, err := c.mallService.SearchProductsWithFilters(filter)
 	if err != nil {
-		offset = 0
+		respondDomainError(ctx, err, "獲取商品失敗")
+		return
 	}
 
-	products, err := c.productRepo.GetAll(limit, offset)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "獲取商品失敗",
+	ctx.JSON(http.StatusOK, result)
+}
+
+// parseProductFilter 解析商品列表的篩選參數，格式錯誤時直接回應 400
+func parseProductFilter(ctx *gin.Context) (*models.ProductFilter, bool) {
+	filter := &models.ProductFilter{
+		Keyword:  strings.TrimSpace(ctx.Query("q")),
+		Category: strings.TrimSpace(ctx.Query("category")),
+		Brands:   queryList(ctx, "brand"),
+		Tags:     queryList(ctx, "tags"),
+		Sort:     ctx.Query("sort"),
+		Limit:    12,
+	}
+
+	badRequest := func(message string) (*models.ProductFilter, bool) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": message,
 		})
-		return
+		return nil, false
 	}
 
-	ctx.JSON(http.StatusOK, products)
+	if limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "12")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	if value := ctx.Query("category_id"); value != "" {
+		categoryID, err := strconv.Atoi(value)
+		if err != nil || categoryID <= 0 {
+			return badRequest("無效的分類ID")
+		}
+		filter.CategoryID = categoryID
+	}
+
+	floatParams := []struct {
+		name    string
+		target  **float64
+		message string
+	}{
+		{"min_price", &filter.MinPrice, "無效的最低價格"},
+		{"max_price", &filter.MaxPrice, "無效的最高價格"},
+		{"min_rating", &filter.MinRating, "無效的評分"},
+	}
+	for _, param := range floatParams {
+		value := ctx.Query(param.name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || number < 0 {
+			return badRequest(param.message)
+		}
+		*param.target = &number
+	}
+
+	boolParams := []struct {
+		name   string
+		target *bool
+	}{
+		{"on_sale", &filter.OnSale},
+		{"in_stock", &filter.InStock},
+	}
+	for _, param := range boolParams {
+		value := ctx.Query(param.name)
+		if value == "" {
+			continue
+		}
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return badRequest("無效的參數 " + param.name)
+		}
+		*param.target = flag
+	}
+
+	return filter, true
+}
+
+// queryList 讀取可重複或以逗號分隔的查詢參數
+func queryList(ctx *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range ctx.QueryArray(key) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
 }
 
 // GetProductsByCategory 根據分類獲取商品
@@ -127,6 +232,7 @@ func (c *MallController) GetProductsByCategory(ctx *gin.Context) {
 }
 
 // SearchProducts 搜尋商品
+// 依相關度排序，每筆結果附 score 與 highlight（命中詞以 <mark> 標示，其餘文字已跳脫 HTML）
 func (c *MallController) SearchProducts(ctx *gin.Context) {
 	keyword := ctx.Query("q")
 	if keyword == "" {
@@ -149,7 +255,7 @@ func (c *MallController) SearchProducts(ctx *gin.Context) {
 		offset = 0
 	}
 
-	products, err := c.productRepo.Search(keyword, limit, offset)
+	results, _, err := c.searchService.Search(keyword, limit, offset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "搜尋商品失敗",
@@ -157,7 +263,7 @@ func (c *MallController) SearchProducts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, products)
+	ctx.JSON(http.StatusOK, results)
 }
 
 // GetProduct 獲取單個商品詳情
@@ -179,12 +285,103 @@ func (c *MallController) GetProduct(ctx *gin.Context) {
 		return
 	}
 
-	// 增加瀏覽次數
+	// 載入分類麵包屑與規格供選購
+	if err := c.productRepo.LoadBreadcrumbs(product); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "獲取商品分類失敗",
+		})
+		return
+	}
+	if err := c.productRepo.LoadVariants(product); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "獲取商品規格失敗",
+		})
+		return
+	}
+
+	// 增加瀏覽次數，登入顧客另記錄瀏覽紀錄供推薦使用
 	go c.productRepo.IncrementViewCount(id)
+	if customerID := optionalCustomerID(ctx); customerID > 0 {
+		go c.mallService.RecordProductView(customerID, id)
+	}
 
 	ctx.JSON(http.StatusOK, product)
 }
 
+// GetRelatedProducts 獲取相關商品
+// @Summary 獲取相關商品
+// @Description 買了這個商品的顧客也買了（依共同購買、加入購物車與瀏覽批次計算），不足時以同分類熱銷商品補齊
+// @Tags 商城
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param limit query int false "數量" default(8)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/products/{id}/related [get]
+func (c *MallController) GetRelatedProducts(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	products, err := c.mallService.GetRelatedProducts(id, recommendationLimit(ctx))
+	if err != nil {
+		respondDomainError(ctx, err, "獲取相關商品失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"products": products,
+	})
+}
+
+// GetRecommendations 獲取推薦商品
+// @Summary 獲取推薦商品
+// @Description 登入顧客依其購買、購物車與瀏覽紀錄取得個人化推薦；未登入或推薦不足時以熱銷商品補齊
+// @Tags 商城
+// @Produce json
+// @Param limit query int false "數量" default(8)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/recommendations [get]
+func (c *MallController) GetRecommendations(ctx *gin.Context) {
+	customerID := optionalCustomerID(ctx)
+
+	products, err := c.mallService.GetRecommendedProducts(customerID, recommendationLimit(ctx))
+	if err != nil {
+		respondDomainError(ctx, err, "獲取推薦商品失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"products":     products,
+		"personalized": customerID > 0,
+	})
+}
+
+func recommendationLimit(ctx *gin.Context) int {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "8"))
+	if err != nil || limit <= 0 || limit > 50 {
+		return 8
+	}
+	return limit
+}
+
+// optionalCustomerID 經選擇性認證的公開 API 中獲取登入顧客ID，未登入或非顧客時回傳 0
+func optionalCustomerID(ctx *gin.Context) int {
+	user, exists := ctx.Get("user")
+	if !exists {
+		return 0
+	}
+	userInterface, ok := user.(models.UserInterface)
+	if !ok || userInterface.GetRole() != "customer" {
+		return 0
+	}
+	return userInterface.GetID()
+}
+
 // ShowProductPage 顯示商品詳情頁面
 func (c *MallController) ShowProductPage(ctx *gin.Context) {
 	idStr := ctx.Param("id")
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MerchantInventoryController 商戶庫存控制器
+type MerchantInventoryController struct {
+	inventoryService *services.InventoryService
+}
+
+// NewMerchantInventoryController 創建商戶庫存控制器
+func NewMerchantInventoryController(inventoryService *services.InventoryService) *MerchantInventoryController {
+	return &MerchantInventoryController{
+		inventoryService: inventoryService,
+	}
+}
+
+// GetMovements 獲取商戶的庫存異動帳
+// @Summary 獲取庫存異動帳
+// @Description 依時間倒序列出商戶商品的庫存異動，可用 product_id 篩選單一商品
+// @Tags 商戶庫存
+// @Produce json
+// @Param product_id query int false "商品ID"
+// @Param limit query int false "每頁筆數"
+// @Param offset query int false "偏移量"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /merchant/api/inventory/movements [get]
+func (c *MerchantInventoryController) GetMovements(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, _ := strconv.Atoi(ctx.DefaultQuery("product_id", "0"))
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	movements, total, err := c.inventoryService.GetMovements(merchantID, productID, limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取庫存異動失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"movements": movements,
+		"total":     total,
+	})
+}
+
+// AdjustStock 手動調整商品庫存
+// @Summary 手動調整庫存
+// @Description 以增減量調整商品現有庫存並寫入異動帳，調整後不可低於保留數量
+// @Tags 商戶庫存
+// @Accept json
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param request body models.StockAdjustmentRequest true "調整內容"
+// @Success 201 {object} models.InventoryMovement
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/products/{id}/stock-adjustments [post]
+func (c *MerchantInventoryController) AdjustStock(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	var req models.StockAdjustmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	movement, err := c.inventoryService.AdjustStock(merchantID, productID, req.VariantID, req.Delta, req.Note)
+	if err != nil {
+		respondDomainError(ctx, err, "調整庫存失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"message":  "庫存已調整",
+		"movement": movement,
+	})
+}
+
+// GetVariants 獲取商品規格
+// @Summary 獲取商品規格
+// @Description 獲取商品的選項軸與所有規格（含已停用）
+// @Tags 商戶庫存
+// @Produce json
+// @Param id path int true "商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/products/{id}/variants [get]
+func (c *MerchantInventoryController) GetVariants(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	options, variants, err := c.inventoryService.GetProductVariants(merchantID, productID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取商品規格失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"options":  options,
+		"variants": variants,
+	})
+}
+
+// SetVariants 設定商品規格
+// @Summary 設定商品規格
+// @Description 以選項軸與規格清單取代商品現有規格，依 SKU 比對既有規格，未列出的規格會停用，商品庫存為啟用規格的加總
+// @Tags 商戶庫存
+// @Accept json
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param request body models.ProductVariantsRequest true "規格內容"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /merchant/api/products/{id}/variants [put]
+func (c *MerchantInventoryController) SetVariants(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	var req models.ProductVariantsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	options, variants, err := c.inventoryService.SetProductVariants(merchantID, productID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "設定商品規格失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "商品規格已更新",
+		"options":  options,
+		"variants": variants,
+	})
+}
+
+// GetReconciliation 比對現有庫存與異動帳
+// @Summary 庫存對帳
+// @Description 比對商戶商品的現有庫存與異動帳加總，列出差異
+// @Tags 商戶庫存
+// @Produce json
+// @Param product_id query int false "商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /merchant/api/inventory/reconcile [get]
+func (c *MerchantInventoryController) GetReconciliation(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, _ := strconv.Atoi(ctx.DefaultQuery("product_id", "0"))
+
+	results, err := c.inventoryService.Reconcile(merchantID, productID)
+	if err != nil {
+		respondDomainError(ctx, err, "庫存對帳失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"results":      results,
+		"inconsistent": countInconsistent(results),
+	})
+}
+
+// ApplyReconciliation 依異動帳重設現有庫存
+// @Summary 套用庫存對帳
+// @Description 將與異動帳不一致的商品現有庫存重設為異動帳加總並寫入對帳調整異動，重設後低於保留數量時整批不套用
+// @Tags 商戶庫存
+// @Produce json
+// @Param product_id query int false "商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /merchant/api/inventory/reconcile [post]
+func (c *MerchantInventoryController) ApplyReconciliation(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, _ := strconv.Atoi(ctx.DefaultQuery("product_id", "0"))
+
+	results, updated, err := c.inventoryService.ApplyReconciliation(merchantID, productID)
+	if err != nil {
+		respondDomainError(ctx, err, "套用庫存對帳失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"updated":      updated,
+		"results":      results,
+		"inconsistent": countInconsistent(results),
+	})
+}
+
+// countInconsistent 計算對帳結果中不一致的商品數
+func countInconsistent(results []models.InventoryReconciliation) int {
+	count := 0
+	for _, result := range results {
+		if !result.Consistent {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MerchantOrderController 商戶訂單控制器
+type MerchantOrderController struct {
+	orderService   *services.OrderService
+	paymentService *services.PaymentService
+}
+
+// NewMerchantOrderController 創建商戶訂單控制器
+func NewMerchantOrderController(orderService *services.OrderService, paymentService *services.PaymentService) *MerchantOrderController {
+	return &MerchantOrderController{
+		orderService:   orderService,
+		paymentService: paymentService,
+	}
+}
+
+// GetMerchantOrders 獲取商戶的訂單列表
+func (c *MerchantOrderController) GetMerchantOrders(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	status := ctx.Query("status")
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	orders, total, err := c.orderService.GetMerchantOrders(merchantID, status, limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取訂單列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"total":  total,
+	})
+}
+
+// GetMerchantOrder 獲取商戶的單一訂單
+func (c *MerchantOrderController) GetMerchantOrder(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的訂單ID",
+		})
+		return
+	}
+
+	order, err := c.orderService.GetMerchantOrder(merchantID, orderID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取訂單失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"order":         order,
+		"next_statuses": models.NextOrderStatuses(order.Status, models.OrderActorMerchant),
+	})
+}
+
+// UpdateMerchantOrderStatus 更新訂單狀態（出貨、送達、退款等）
+func (c *MerchantOrderController) UpdateMerchantOrderStatus(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的訂單ID",
+		})
+		return
+	}
+
+	var req models.UpdateOrderStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	var order *models.Order
+	if req.Status == models.OrderStatusRefunded {
+		// 退款需先經金流退還款項，成功後才轉為已退款
+		order, err = c.paymentService.RefundMerchantOrder(ctx.Request.Context(), merchantID, orderID, req.Note)
+	} else {
+		order, err = c.orderService.UpdateMerchantOrderStatus(merchantID, orderID, req.Status, req.Note)
+	}
+	if err != nil {
+		respondDomainError(ctx, err, "更新訂單狀態失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "訂單狀態已更新",
+		"order":   order,
+	})
+}
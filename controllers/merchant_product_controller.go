@@ -4,17 +4,20 @@ import (
 	"net/http"
 	"strconv"
 	"go-simple-app/models"
+	"go-simple-app/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MerchantProductController struct {
-	productRepo *models.ProductRepository
+	productRepo      *models.ProductRepository
+	inventoryService *services.InventoryService
 }
 
-func NewMerchantProductController(productRepo *models.ProductRepository) *MerchantProductController {
+func NewMerchantProductController(productRepo *models.ProductRepository, inventoryService *services.InventoryService) *MerchantProductController {
 	return &MerchantProductController{
-		productRepo: productRepo,
+		productRepo:      productRepo,
+		inventoryService: inventoryService,
 	}
 }
 
@@ -135,14 +138,9 @@ func (c *MerchantProductController) CreateMerchantProduct(ctx *gin.Context) {
 		return
 	}
 
-	// 設置商戶ID
-	product.MerchantID = merchantID
-
-	// 創建商品
-	if err := c.productRepo.Create(&product); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "創建商品失敗: " + err.Error(),
-		})
+	// 創建商品（同時記錄期初庫存）
+	if err := c.inventoryService.CreateMerchantProduct(merchantID, &product); err != nil {
+		respondDomainError(ctx, err, "創建商品失敗")
 		return
 	}
 
@@ -266,10 +264,9 @@ func (c *MerchantProductController) UpdateMerchantProduct(ctx *gin.Context) {
 	updateData.ID = id
 	updateData.MerchantID = merchantID // 確保商戶ID不變
 
-	if err := c.productRepo.Update(&updateData); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "更新商品失敗: " + err.Error(),
-		})
+	// 庫存有變動時會寫入庫存異動帳
+	if err := c.inventoryService.UpdateMerchantProduct(merchantID, &updateData); err != nil {
+		respondDomainError(ctx, err, "更新商品失敗")
 		return
 	}
 
@@ -328,10 +325,10 @@ func (c *MerchantProductController) ToggleMerchantProductStatus(ctx *gin.Context
 		return
 	}
 
-	// 切換狀態
+	// 切換狀態，只更新 is_active，避免以讀取時的舊資料覆寫其他欄位（例如期間內變動的庫存）
 	product.IsActive = !product.IsActive
 
-	if err := c.productRepo.Update(product); err != nil {
+	if err := c.productRepo.SetActive(product.ID, product.IsActive); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "切換商品狀態失敗: " + err.Error(),
 		})
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MerchantProductImageController 商戶商品圖片控制器
+type MerchantProductImageController struct {
+	imageService *services.ProductImageService
+}
+
+// NewMerchantProductImageController 創建商戶商品圖片控制器
+func NewMerchantProductImageController(imageService *services.ProductImageService) *MerchantProductImageController {
+	return &MerchantProductImageController{
+		imageService: imageService,
+	}
+}
+
+// GetImages 獲取商品的上傳圖片
+// @Summary 獲取商品圖片
+// @Tags 商戶商品
+// @Produce json
+// @Param id path int true "商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/products/{id}/images [get]
+func (c *MerchantProductImageController) GetImages(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	images, err := c.imageService.GetImages(merchantID, productID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取商品圖片失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"images": images,
+	})
+}
+
+// UploadImages 上傳商品圖片
+// @Summary 上傳商品圖片
+// @Description 以 multipart 欄位 file 上傳一或多張圖片（JPEG、PNG、GIF，依內容判斷格式），
+// @Description 會產生 small/medium/large 縮圖並加入商品的 images；商品沒有主圖時以第一張圖片的 medium 縮圖為主圖
+// @Tags 商戶商品
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param file formData file true "圖片檔案，可重複"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/products/{id}/images [post]
+func (c *MerchantProductImageController) UploadImages(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.imageService.MaxBytes()*5)
+	form, err := ctx.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請以 file 欄位上傳圖片",
+		})
+		return
+	}
+
+	images := []*models.ProductImage{}
+	for _, header := range form.File["file"] {
+		file, err := header.Open()
+		if err != nil {
+			respondDomainError(ctx, err, "讀取上傳圖片失敗")
+			return
+		}
+		image, err := c.imageService.Upload(ctx.Request.Context(), merchantID, productID, file)
+		file.Close()
+		if err != nil {
+			// 前面的圖片已上傳成功時一併回傳，讓前端知道哪些檔案需要重傳
+			if domainErr, ok := err.(*models.DomainError); ok && len(images) > 0 {
+				ctx.JSON(domainErr.HTTPStatus(), gin.H{
+					"error":    header.Filename + ": " + domainErr.Message,
+					"code":     domainErr.Code,
+					"uploaded": images,
+				})
+				return
+			}
+			respondDomainError(ctx, err, "上傳圖片失敗")
+			return
+		}
+		images = append(images, image)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "圖片已上傳",
+		"images":  images,
+	})
+}
+
+// DeleteImage 刪除商品圖片
+// @Summary 刪除商品圖片
+// @Tags 商戶商品
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param imageId path int true "圖片ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/products/{id}/images/{imageId} [delete]
+func (c *MerchantProductImageController) DeleteImage(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(ctx)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.Atoi(ctx.Param("imageId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的圖片ID",
+		})
+		return
+	}
+
+	if err := c.imageService.DeleteImage(ctx.Request.Context(), merchantID, productID, imageID); err != nil {
+		respondDomainError(ctx, err, "刪除圖片失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "圖片已刪除",
+	})
+}
+
+// productIDParam 解析路徑中的商品ID，失敗時直接回應 400
+func productIDParam(ctx *gin.Context) (int, bool) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return 0, false
+	}
+	return productID, true
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"go-simple-app/logger"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize 匯入檔案大小上限
+const maxImportFileSize = 10 << 20
+
+// MerchantProductImportController 商戶商品批次匯入匯出控制器
+type MerchantProductImportController struct {
+	importService *services.ProductImportService
+}
+
+// NewMerchantProductImportController 創建商戶商品匯入匯出控制器
+func NewMerchantProductImportController(importService *services.ProductImportService) *MerchantProductImportController {
+	return &MerchantProductImportController{
+		importService: importService,
+	}
+}
+
+// ImportProducts 批次匯入商品
+// @Summary 批次匯入商品
+// @Description 上傳 CSV（第一列為欄位名稱）或 JSON（商品物件陣列），以 SKU 對應既有商品更新，否則新增。
+// @Description dry_run=true 時只回傳驗證報告；正式匯入時任何一列有錯誤則整批不寫入並回傳報告
+// @Tags 商戶商品
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Accept json
+// @Produce json
+// @Param file formData file false "匯入檔案（亦可直接以請求內容上傳）"
+// @Param format query string false "檔案格式 csv|json，未指定時依副檔名或 Content-Type 判斷"
+// @Param dry_run query bool false "只驗證不寫入"
+// @Success 200 {object} models.ProductImportReport
+// @Failure 400 {object} map[string]interface{}
+// @Router /merchant/api/products/import [post]
+func (c *MerchantProductImportController) ImportProducts(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的參數 dry_run",
+		})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportFileSize)
+
+	format := strings.ToLower(ctx.Query("format"))
+	var body io.Reader = ctx.Request.Body
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		file, header, err := ctx.Request.FormFile("file")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "請上傳匯入檔案: " + err.Error(),
+			})
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+	if format == "" {
+		format = services.ProductFileCSV
+		if strings.Contains(ctx.ContentType(), "json") {
+			format = services.ProductFileJSON
+		}
+	}
+
+	rows, err := c.importService.ParseImportFile(format, body)
+	if err != nil {
+		respondDomainError(ctx, err, "解析匯入檔案失敗")
+		return
+	}
+
+	report, err := c.importService.Import(merchantID, rows, dryRun)
+	if err != nil {
+		respondDomainError(ctx, err, "匯入商品失敗")
+		return
+	}
+
+	if !dryRun && !report.Applied {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":  fmt.Sprintf("有 %d 列資料錯誤，未匯入任何商品", report.Failed),
+			"report": report,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// ExportProducts 匯出商品目錄
+// @Summary 匯出商品目錄
+// @Description 以串流方式匯出商戶所有商品，欄位與匯入相同，可修改後直接匯入
+// @Tags 商戶商品
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "檔案格式 csv|json" default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /merchant/api/products/export [get]
+func (c *MerchantProductImportController) ExportProducts(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(ctx.DefaultQuery("format", services.ProductFileCSV))
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case services.ProductFileCSV:
+	case services.ProductFileJSON:
+		contentType = "application/json; charset=utf-8"
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "不支援的檔案格式，僅支援 csv 與 json",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102"), format)
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Status(http.StatusOK)
+
+	// 已開始輸出後無法再變更狀態碼，只能記錄錯誤
+	if err := c.importService.Export(merchantID, format, ctx.Writer); err != nil {
+		logger.Error("匯出商品失敗", err)
+	}
+}
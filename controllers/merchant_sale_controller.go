@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MerchantSaleController 商戶限時特價控制器
+type MerchantSaleController struct {
+	saleService *services.SaleService
+}
+
+// NewMerchantSaleController 創建商戶限時特價控制器
+func NewMerchantSaleController(saleService *services.SaleService) *MerchantSaleController {
+	return &MerchantSaleController{
+		saleService: saleService,
+	}
+}
+
+// GetCampaigns 獲取商戶的特價活動
+// @Summary 獲取特價活動
+// @Tags 限時特價
+// @Produce json
+// @Param status query string false "活動狀態 scheduled/active/ended/cancelled"
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/api/sales [get]
+func (c *MerchantSaleController) GetCampaigns(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	campaigns, err := c.saleService.GetMerchantCampaigns(merchantID, ctx.Query("status"))
+	if err != nil {
+		respondDomainError(ctx, err, "獲取特價活動失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"campaigns": campaigns,
+		"total":     len(campaigns),
+	})
+}
+
+// CreateCampaign 建立特價活動
+// @Summary 建立特價活動
+// @Description 排程商品於指定期間以特價販售，可設定特價數量上限
+// @Tags 限時特價
+// @Accept json
+// @Produce json
+// @Param request body models.CreateSaleCampaignRequest true "活動內容"
+// @Success 201 {object} models.SaleCampaign
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/sales [post]
+func (c *MerchantSaleController) CreateCampaign(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CreateSaleCampaignRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	campaign, err := c.saleService.CreateCampaign(merchantID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "建立特價活動失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"message":  "特價活動已建立",
+		"campaign": campaign,
+	})
+}
+
+// CancelCampaign 取消特價活動
+// @Summary 取消特價活動
+// @Description 取消尚未開始或進行中的特價活動，進行中的活動會立即還原價格
+// @Tags 限時特價
+// @Produce json
+// @Param id path int true "活動ID"
+// @Success 200 {object} models.SaleCampaign
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/sales/{id}/cancel [post]
+func (c *MerchantSaleController) CancelCampaign(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	campaignID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的活動ID",
+		})
+		return
+	}
+
+	campaign, err := c.saleService.CancelCampaign(merchantID, campaignID)
+	if err != nil {
+		respondDomainError(ctx, err, "取消特價活動失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "特價活動已取消",
+		"campaign": campaign,
+	})
+}
@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderController 訂單控制器
+type OrderController struct {
+	orderService     *services.OrderService
+	inventoryService *services.InventoryService
+}
+
+// NewOrderController 創建訂單控制器
+func NewOrderController(orderService *services.OrderService, inventoryService *services.InventoryService) *OrderController {
+	return &OrderController{
+		orderService:     orderService,
+		inventoryService: inventoryService,
+	}
+}
+
+// StartCheckout 開始結帳並保留購物車商品庫存
+// @Summary 開始結帳
+// @Description 為當前購物車商品保留庫存，保留逾時未下單會自動釋放，並回傳各商戶可選的運送方式與運費
+// @Tags 訂單
+// @Produce json
+// @Success 201 {object} services.CheckoutReservation
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/orders/checkout [post]
+func (c *OrderController) StartCheckout(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	checkout, err := c.inventoryService.StartCheckout(customerID)
+	if err != nil {
+		respondDomainError(ctx, err, "保留庫存失敗")
+		return
+	}
+
+	shipping, err := c.orderService.QuoteShipping(customerID)
+	if err != nil {
+		respondDomainError(ctx, err, "計算運費失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"message":  "已保留庫存",
+		"checkout": checkout,
+		"shipping": shipping,
+	})
+}
+
+// GetCheckout 獲取目前結帳保留
+// @Summary 獲取結帳保留
+// @Description 獲取當前客戶仍有效的庫存保留與到期時間，以及各商戶可選的運送方式與運費
+// @Tags 訂單
+// @Produce json
+// @Success 200 {object} services.CheckoutReservation
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/orders/checkout [get]
+func (c *OrderController) GetCheckout(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	checkout, err := c.inventoryService.GetCheckout(customerID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取結帳保留失敗")
+		return
+	}
+
+	shipping, err := c.orderService.QuoteShipping(customerID)
+	if err != nil {
+		respondDomainError(ctx, err, "計算運費失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"checkout": checkout,
+		"shipping": shipping,
+	})
+}
+
+// CancelCheckout 取消結帳並釋放保留庫存
+// @Summary 取消結帳
+// @Description 釋放當前客戶所有庫存保留
+// @Tags 訂單
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/orders/checkout [delete]
+func (c *OrderController) CancelCheckout(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.inventoryService.CancelCheckout(customerID); err != nil {
+		respondDomainError(ctx, err, "釋放保留庫存失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已釋放保留庫存",
+	})
+}
+
+// PlaceOrder 將購物車結帳為訂單
+// @Summary 下單
+// @Description 將當前客戶的購物車轉為訂單，多商戶購物車會拆分為多張子訂單；可指定通訊錄地址，未提供地址時使用預設地址，訂單保存地址快照
+// @Tags 訂單
+// @Accept json
+// @Produce json
+// @Param request body models.PlaceOrderRequest true "下單請求"
+// @Success 201 {object} models.OrderGroup
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/orders [post]
+func (c *OrderController) PlaceOrder(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.PlaceOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	group, err := c.orderService.PlaceOrder(customerID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "下單失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "訂單建立成功",
+		"group":   group,
+	})
+}
+
+// GetOrders 獲取客戶訂單列表
+// @Summary 獲取訂單列表
+// @Description 獲取當前客戶的訂單列表
+// @Tags 訂單
+// @Produce json
+// @Param limit query int false "每頁筆數"
+// @Param offset query int false "偏移量"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/orders [get]
+func (c *OrderController) GetOrders(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	orders, total, err := c.orderService.GetCustomerOrders(customerID, limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取訂單列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"total":  total,
+	})
+}
+
+// GetOrder 獲取單一訂單詳情
+// @Summary 獲取訂單詳情
+// @Description 獲取當前客戶的指定訂單
+// @Tags 訂單
+// @Produce json
+// @Param id path int true "訂單ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/orders/{id} [get]
+func (c *OrderController) GetOrder(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的訂單ID",
+		})
+		return
+	}
+
+	order, err := c.orderService.GetCustomerOrder(customerID, orderID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取訂單失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// GetOrderGroup 獲取一次結帳的訂單群組
+// @Summary 獲取訂單群組
+// @Description 獲取一次結帳產生的所有子訂單與付款金額
+// @Tags 訂單
+// @Produce json
+// @Param id path int true "訂單群組ID"
+// @Success 200 {object} models.OrderGroup
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/orders/groups/{id} [get]
+func (c *OrderController) GetOrderGroup(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	groupID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的訂單群組ID",
+		})
+		return
+	}
+
+	group, err := c.orderService.GetCustomerOrderGroup(customerID, groupID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取訂單群組失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, group)
+}
+
+// CancelOrder 客戶取消訂單
+// @Summary 取消訂單
+// @Description 取消尚未付款的訂單，庫存會被還原
+// @Tags 訂單
+// @Accept json
+// @Produce json
+// @Param id path int true "訂單ID"
+// @Param request body models.CancelOrderRequest false "取消原因"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/orders/{id}/cancel [post]
+func (c *OrderController) CancelOrder(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的訂單ID",
+		})
+		return
+	}
+
+	// 取消原因為選填
+	var req models.CancelOrderRequest
+	_ = ctx.ShouldBindJSON(&req)
+
+	order, err := c.orderService.CancelCustomerOrder(customerID, orderID, req.Note)
+	if err != nil {
+		respondDomainError(ctx, err, "取消訂單失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "訂單已取消",
+		"order":   order,
+	})
+}
+
+// CompleteOrder 客戶確認收貨
+// @Summary 確認收貨
+// @Description 將已送達的訂單標記為完成
+// @Tags 訂單
+// @Produce json
+// @Param id path int true "訂單ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/orders/{id}/complete [post]
+func (c *OrderController) CompleteOrder(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的訂單ID",
+		})
+		return
+	}
+
+	order, err := c.orderService.CompleteCustomerOrder(customerID, orderID, nil)
+	if err != nil {
+		respondDomainError(ctx, err, "確認收貨失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "訂單已完成",
+		"order":   order,
+	})
+}
+
+// currentUserID 從JWT token中獲取當前用戶ID，失敗時直接回應 401
+func currentUserID(ctx *gin.Context) (int, bool) {
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授權訪問",
+		})
+		return 0, false
+	}
+
+	userInterface, ok := user.(models.UserInterface)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "用戶信息格式錯誤",
+		})
+		return 0, false
+	}
+
+	return userInterface.GetID(), true
+}
+
+// respondDomainError 將業務錯誤轉為 HTTP 回應，狀態碼由錯誤本身決定
+func respondDomainError(ctx *gin.Context, err error, message string) {
+	if cartErr, ok := err.(*models.CartError); ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": cartErr.Message,
+			"code":  cartErr.Code,
+		})
+		return
+	}
+
+	if domainErr, ok := err.(*models.DomainError); ok {
+		ctx.JSON(domainErr.HTTPStatus(), gin.H{
+			"error": domainErr.Message,
+			"code":  domainErr.Code,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, gin.H{
+		"error": message + ": " + err.Error(),
+	})
+}
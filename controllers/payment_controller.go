@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentController 付款控制器
+type PaymentController struct {
+	paymentService *services.PaymentService
+}
+
+// NewPaymentController 創建付款控制器
+func NewPaymentController(paymentService *services.PaymentService) *PaymentController {
+	return &PaymentController{
+		paymentService: paymentService,
+	}
+}
+
+// CreatePayment 為訂單群組建立付款
+// @Summary 建立付款
+// @Description 為一次結帳的訂單群組建立付款，回傳導向金流付款頁所需資訊
+// @Tags 付款
+// @Accept json
+// @Produce json
+// @Param request body models.CreatePaymentRequest true "付款請求"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/payments [post]
+func (c *PaymentController) CreatePayment(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	payment, intent, err := c.paymentService.CreatePayment(ctx.Request.Context(), customerID, req.GroupID)
+	if err != nil {
+		respondPaymentError(ctx, err, "建立付款失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"payment": payment,
+		"intent":  intent,
+	})
+}
+
+// GetPayment 獲取付款狀態
+// @Summary 獲取付款狀態
+// @Tags 付款
+// @Produce json
+// @Param number path string true "付款編號"
+// @Success 200 {object} models.Payment
+// @Failure 404 {object} map[string]string
+// @Router /api/payments/{number} [get]
+func (c *PaymentController) GetPayment(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	payment, err := c.paymentService.GetCustomerPayment(customerID, ctx.Param("number"))
+	if err != nil {
+		respondPaymentError(ctx, err, "獲取付款失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payment)
+}
+
+// ConfirmPayment 向金流商查詢交易結果
+// @Summary 查詢金流交易
+// @Description 向金流商查詢交易結果，訂單狀態仍以金流回調為準
+// @Tags 付款
+// @Produce json
+// @Param number path string true "付款編號"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/payments/{number}/confirm [post]
+func (c *PaymentController) ConfirmPayment(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := c.paymentService.ConfirmPayment(ctx.Request.Context(), customerID, ctx.Param("number"))
+	if err != nil {
+		respondPaymentError(ctx, err, "查詢交易失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// SimulateMockPayment 模擬金流付款結果（僅在開發環境設定 PAYMENT_MOCK_SIMULATE=true 時註冊）
+// @Summary 模擬付款
+// @Tags 付款
+// @Accept json
+// @Produce json
+// @Param number path string true "付款編號"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /api/payments/mock/{number}/simulate [post]
+func (c *PaymentController) SimulateMockPayment(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	_ = ctx.ShouldBindJSON(&req)
+	if req.Status == "" {
+		req.Status = models.PaymentStatusPaid
+	}
+
+	if err := c.paymentService.SimulateMockPayment(ctx.Request.Context(), customerID, ctx.Param("number"), req.Status); err != nil {
+		respondPaymentError(ctx, err, "模擬付款失敗")
+		return
+	}
+
+	payment, err := c.paymentService.GetCustomerPayment(customerID, ctx.Param("number"))
+	if err != nil {
+		respondPaymentError(ctx, err, "獲取付款失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"payment": payment,
+	})
+}
+
+// HandleCallback 接收金流商回調（不需登入，以簽章驗證來源）
+// @Summary 金流回調
+// @Tags 付款
+// @Param provider path string true "金流服務商"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Router /api/payments/callback/{provider} [post]
+func (c *PaymentController) HandleCallback(ctx *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, 64<<10))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	// 重送的回調同樣回應成功，避免金流商持續重試
+	if _, err := c.paymentService.HandleCallback(ctx.Request.Context(), ctx.Param("provider"), ctx.Request.Header, body); err != nil {
+		if domainErr, ok := err.(*models.DomainError); ok {
+			ctx.String(http.StatusBadRequest, "0|"+domainErr.Code)
+			return
+		}
+		if _, ok := err.(*services.PaymentError); ok {
+			ctx.String(http.StatusBadRequest, "0|"+err.Error())
+			return
+		}
+		ctx.String(http.StatusInternalServerError, "0|ERROR")
+		return
+	}
+
+	ctx.String(http.StatusOK, c.paymentService.Provider().CallbackAck())
+}
+
+// respondPaymentError 將付款相關錯誤轉為 HTTP 回應
+func respondPaymentError(ctx *gin.Context, err error, message string) {
+	if paymentErr, ok := err.(*services.PaymentError); ok {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error":    message + ": " + paymentErr.Message,
+			"provider": paymentErr.Provider,
+		})
+		return
+	}
+
+	respondDomainError(ctx, err, message)
+}
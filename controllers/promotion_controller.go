@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromotionController 促銷管理控制器（商戶管理自己的促銷，管理員管理全站促銷）
+type PromotionController struct {
+	promotionService *services.PromotionService
+}
+
+// NewPromotionController 創建促銷控制器
+func NewPromotionController(promotionService *services.PromotionService) *PromotionController {
+	return &PromotionController{
+		promotionService: promotionService,
+	}
+}
+
+// GetMerchantPromotions 獲取商戶的促銷列表
+// @Summary 獲取商戶促銷
+// @Tags 促銷
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/api/promotions [get]
+func (c *PromotionController) GetMerchantPromotions(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.listPromotions(ctx, &merchantID)
+}
+
+// CreateMerchantPromotion 商戶建立促銷或優惠券，範圍固定為自己的商品
+// @Summary 建立商戶促銷
+// @Tags 促銷
+// @Accept json
+// @Produce json
+// @Param request body models.PromotionRequest true "促銷內容"
+// @Success 201 {object} models.Promotion
+// @Failure 400 {object} map[string]string
+// @Router /merchant/api/promotions [post]
+func (c *PromotionController) CreateMerchantPromotion(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.createPromotion(ctx, &merchantID)
+}
+
+// UpdateMerchantPromotion 商戶更新自己的促銷
+// @Summary 更新商戶促銷
+// @Tags 促銷
+// @Accept json
+// @Produce json
+// @Param id path int true "促銷ID"
+// @Param request body models.PromotionRequest true "促銷內容"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/promotions/{id} [put]
+func (c *PromotionController) UpdateMerchantPromotion(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.updatePromotion(ctx, &merchantID)
+}
+
+// GetAdminPromotions 管理員獲取所有促銷
+// @Summary 獲取所有促銷
+// @Tags 促銷
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/api/promotions [get]
+func (c *PromotionController) GetAdminPromotions(ctx *gin.Context) {
+	c.listPromotions(ctx, nil)
+}
+
+// CreateAdminPromotion 管理員建立促銷，未指定 merchant_id 時為全站促銷
+// @Summary 建立全站促銷
+// @Tags 促銷
+// @Accept json
+// @Produce json
+// @Param request body models.PromotionRequest true "促銷內容"
+// @Success 201 {object} models.Promotion
+// @Failure 400 {object} map[string]string
+// @Router /admin/api/promotions [post]
+func (c *PromotionController) CreateAdminPromotion(ctx *gin.Context) {
+	c.createPromotion(ctx, nil)
+}
+
+// UpdateAdminPromotion 管理員更新任一促銷
+// @Summary 更新促銷
+// @Tags 促銷
+// @Accept json
+// @Produce json
+// @Param id path int true "促銷ID"
+// @Param request body models.PromotionRequest true "促銷內容"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/api/promotions/{id} [put]
+func (c *PromotionController) UpdateAdminPromotion(ctx *gin.Context) {
+	c.updatePromotion(ctx, nil)
+}
+
+func (c *PromotionController) listPromotions(ctx *gin.Context, merchantID *int) {
+	promotions, err := c.promotionService.ListPromotions(merchantID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取促銷列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"promotions": promotions,
+		"total":      len(promotions),
+	})
+}
+
+func (c *PromotionController) createPromotion(ctx *gin.Context, merchantID *int) {
+	var req models.PromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	promotion, err := c.promotionService.CreatePromotion(&req, merchantID)
+	if err != nil {
+		respondDomainError(ctx, err, "建立促銷失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"message":   "促銷建立成功",
+		"promotion": promotion,
+	})
+}
+
+func (c *PromotionController) updatePromotion(ctx *gin.Context, merchantID *int) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的促銷ID",
+		})
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	promotion, err := c.promotionService.UpdatePromotion(id, &req, merchantID)
+	if err != nil {
+		respondDomainError(ctx, err, "更新促銷失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"message":   "促銷更新成功",
+		"promotion": promotion,
+	})
+}
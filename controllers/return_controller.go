@@ -0,0 +1,544 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnController 退貨退款控制器（客戶申請退貨、商戶審核、管理員檢視與處理所有退貨）
+type ReturnController struct {
+	returnService *services.ReturnService
+}
+
+// NewReturnController 創建退貨退款控制器
+func NewReturnController(returnService *services.ReturnService) *ReturnController {
+	return &ReturnController{
+		returnService: returnService,
+	}
+}
+
+// GetReturns 獲取客戶的退貨申請
+// @Summary 獲取退貨申請列表
+// @Tags 退貨退款
+// @Produce json
+// @Param status query string false "退貨狀態 requested/approved/refunded/rejected/cancelled"
+// @Param limit query int false "每頁數量" default(20)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/returns [get]
+func (c *ReturnController) GetReturns(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	limit, offset := reviewPagination(ctx)
+	returns, total, err := c.returnService.ListCustomerReturns(customerID, ctx.Query("status"), limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取退貨申請列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"returns": returns,
+		"total":   total,
+	})
+}
+
+// GetReturn 獲取客戶的單一退貨申請
+// @Summary 獲取退貨申請詳情
+// @Description 包含退貨商品、照片與處理紀錄
+// @Tags 退貨退款
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Router /api/returns/{id} [get]
+func (c *ReturnController) GetReturn(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	ret, err := c.returnService.GetCustomerReturn(customerID, id)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取退貨申請失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"return": ret,
+	})
+}
+
+// CreateReturn 申請退貨
+// @Summary 申請退貨
+// @Description 訂單送達後於退貨期限內，依訂單商品申請退貨並填寫原因；照片於申請後另外上傳
+// @Tags 退貨退款
+// @Accept json
+// @Produce json
+// @Param request body models.CreateReturnRequest true "退貨內容"
+// @Success 201 {object} models.ReturnRequest
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/returns [post]
+func (c *ReturnController) CreateReturn(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReturnRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.returnService.CreateReturn(customerID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "申請退貨失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "退貨申請已送出，請等待商家審核",
+		"return":  ret,
+	})
+}
+
+// CancelReturn 取消退貨申請
+// @Summary 取消退貨申請
+// @Description 只能取消尚未審核的申請
+// @Tags 退貨退款
+// @Accept json
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Param request body models.CancelOrderRequest false "取消原因"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/returns/{id}/cancel [post]
+func (c *ReturnController) CancelReturn(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CancelOrderRequest
+	_ = ctx.ShouldBindJSON(&req)
+
+	ret, err := c.returnService.CancelCustomerReturn(customerID, id, req.Note)
+	if err != nil {
+		respondDomainError(ctx, err, "取消退貨申請失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "退貨申請已取消",
+		"return":  ret,
+	})
+}
+
+// UploadReturnPhotos 上傳退貨商品照片
+// @Summary 上傳退貨商品照片
+// @Description 以 multipart 欄位 file 上傳一或多張照片（JPEG、PNG、GIF），每個退貨商品最多 5 張，只能在審核前上傳
+// @Tags 退貨退款
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Param itemId path int true "退貨商品ID"
+// @Param file formData file true "照片檔案，可重複"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/returns/{id}/items/{itemId}/photos [post]
+func (c *ReturnController) UploadReturnPhotos(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+	itemID, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的退貨商品ID",
+		})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.returnService.MaxPhotoBytes()*models.MaxReturnPhotosPerItem)
+	form, err := ctx.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請以 file 欄位上傳照片",
+		})
+		return
+	}
+
+	photos := []*models.ReturnPhoto{}
+	for _, header := range form.File["file"] {
+		file, err := header.Open()
+		if err != nil {
+			respondDomainError(ctx, err, "讀取上傳照片失敗")
+			return
+		}
+		photo, err := c.returnService.AddPhoto(ctx.Request.Context(), customerID, id, itemID, file)
+		file.Close()
+		if err != nil {
+			// 前面的照片已上傳成功時一併回傳，讓前端知道哪些檔案需要重傳
+			if domainErr, ok := err.(*models.DomainError); ok && len(photos) > 0 {
+				ctx.JSON(domainErr.HTTPStatus(), gin.H{
+					"error":    header.Filename + ": " + domainErr.Message,
+					"code":     domainErr.Code,
+					"uploaded": photos,
+				})
+				return
+			}
+			respondDomainError(ctx, err, "上傳照片失敗")
+			return
+		}
+		photos = append(photos, photo)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "照片已上傳",
+		"photos":  photos,
+	})
+}
+
+// GetMerchantReturns 獲取商戶的退貨申請
+// @Summary 獲取商戶退貨申請列表
+// @Tags 退貨退款
+// @Produce json
+// @Param status query string false "退貨狀態 requested/approved/refunded/rejected/cancelled"
+// @Param limit query int false "每頁數量" default(20)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/api/returns [get]
+func (c *ReturnController) GetMerchantReturns(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.listReturns(ctx, &merchantID)
+}
+
+// GetMerchantReturn 獲取商戶的單一退貨申請
+// @Summary 獲取商戶退貨申請詳情
+// @Tags 退貨退款
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/returns/{id} [get]
+func (c *ReturnController) GetMerchantReturn(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.getReturn(ctx, &merchantID)
+}
+
+// ApproveMerchantReturn 商戶核准退貨
+// @Summary 核准退貨
+// @Description 核准後依 restock（預設 true）回補庫存，並經金流退還退款金額；退款失敗時申請維持 approved，可重試退款
+// @Tags 退貨退款
+// @Accept json
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Param request body models.ReturnDecisionRequest false "核准說明與是否回補庫存"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /merchant/api/returns/{id}/approve [post]
+func (c *ReturnController) ApproveMerchantReturn(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.approveReturn(ctx, &merchantID, models.OrderActorMerchant, merchantID)
+}
+
+// RejectMerchantReturn 商戶拒絕退貨
+// @Summary 拒絕退貨
+// @Tags 退貨退款
+// @Accept json
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Param request body models.ReturnDecisionRequest true "拒絕原因（note 必填）"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /merchant/api/returns/{id}/reject [post]
+func (c *ReturnController) RejectMerchantReturn(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.rejectReturn(ctx, &merchantID, models.OrderActorMerchant, merchantID)
+}
+
+// RetryMerchantRefund 商戶重試退款
+// @Summary 重試退款
+// @Description 已核准但金流退款失敗的退貨可重新發起退款
+// @Tags 退貨退款
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /merchant/api/returns/{id}/refund [post]
+func (c *ReturnController) RetryMerchantRefund(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.retryRefund(ctx, &merchantID, models.OrderActorMerchant, merchantID)
+}
+
+// GetAdminReturns 管理員獲取所有退貨申請
+// @Summary 獲取所有退貨申請
+// @Tags 退貨退款
+// @Produce json
+// @Param status query string false "退貨狀態 requested/approved/refunded/rejected/cancelled"
+// @Param merchant_id query int false "商戶ID"
+// @Param limit query int false "每頁數量" default(20)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/api/returns [get]
+func (c *ReturnController) GetAdminReturns(ctx *gin.Context) {
+	var merchantID *int
+	if value := ctx.Query("merchant_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "無效的商戶ID",
+			})
+			return
+		}
+		merchantID = &id
+	}
+
+	c.listReturns(ctx, merchantID)
+}
+
+// GetAdminReturn 管理員獲取退貨申請詳情
+// @Summary 獲取退貨申請詳情（含稽核與金流退款紀錄）
+// @Tags 退貨退款
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Router /admin/api/returns/{id} [get]
+func (c *ReturnController) GetAdminReturn(ctx *gin.Context) {
+	c.getReturn(ctx, nil)
+}
+
+// ApproveAdminReturn 管理員核准退貨（處理爭議或代商戶審核）
+// @Summary 管理員核准退貨
+// @Tags 退貨退款
+// @Accept json
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Param request body models.ReturnDecisionRequest false "核准說明與是否回補庫存"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api/returns/{id}/approve [post]
+func (c *ReturnController) ApproveAdminReturn(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.approveReturn(ctx, nil, models.OrderActorAdmin, adminID)
+}
+
+// RejectAdminReturn 管理員拒絕退貨
+// @Summary 管理員拒絕退貨
+// @Tags 退貨退款
+// @Accept json
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Param request body models.ReturnDecisionRequest true "拒絕原因（note 必填）"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api/returns/{id}/reject [post]
+func (c *ReturnController) RejectAdminReturn(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.rejectReturn(ctx, nil, models.OrderActorAdmin, adminID)
+}
+
+// RetryAdminRefund 管理員重試退款
+// @Summary 管理員重試退款
+// @Tags 退貨退款
+// @Produce json
+// @Param id path int true "退貨申請ID"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api/returns/{id}/refund [post]
+func (c *ReturnController) RetryAdminRefund(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.retryRefund(ctx, nil, models.OrderActorAdmin, adminID)
+}
+
+func (c *ReturnController) listReturns(ctx *gin.Context, merchantID *int) {
+	limit, offset := reviewPagination(ctx)
+	returns, total, err := c.returnService.ListReturns(merchantID, ctx.Query("status"), limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取退貨申請列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"returns": returns,
+		"total":   total,
+	})
+}
+
+func (c *ReturnController) getReturn(ctx *gin.Context, merchantID *int) {
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	ret, err := c.returnService.GetReturn(merchantID, id)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取退貨申請失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"return": ret,
+	})
+}
+
+func (c *ReturnController) approveReturn(ctx *gin.Context, merchantID *int, actorType string, actorID int) {
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ReturnDecisionRequest
+	_ = ctx.ShouldBindJSON(&req)
+
+	ret, err := c.returnService.ApproveReturn(ctx.Request.Context(), merchantID, actorType, actorID, id, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "核准退貨失敗")
+		return
+	}
+
+	respondRefundResult(ctx, ret, "退貨已核准並完成退款")
+}
+
+func (c *ReturnController) rejectReturn(ctx *gin.Context, merchantID *int, actorType string, actorID int) {
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ReturnDecisionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.returnService.RejectReturn(merchantID, actorType, actorID, id, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "拒絕退貨失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已拒絕退貨申請",
+		"return":  ret,
+	})
+}
+
+func (c *ReturnController) retryRefund(ctx *gin.Context, merchantID *int, actorType string, actorID int) {
+	id, ok := returnIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	ret, err := c.returnService.RetryRefund(ctx.Request.Context(), merchantID, actorType, actorID, id)
+	if err != nil {
+		respondDomainError(ctx, err, "重試退款失敗")
+		return
+	}
+
+	respondRefundResult(ctx, ret, "退款完成")
+}
+
+// respondRefundResult 回應核准或重試退款的結果，金流退款失敗時仍回應 200 並提示可重試
+func respondRefundResult(ctx *gin.Context, ret *models.ReturnRequest, message string) {
+	if ret.RefundStatus != nil && *ret.RefundStatus == models.ReturnRefundFailed {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "退貨已核准，但金流退款失敗，請稍後重試退款",
+			"return":  ret,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"return":  ret,
+	})
+}
+
+// returnIDParam 解析路徑中的退貨申請ID，格式錯誤時直接回應 400
+func returnIDParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的退貨申請ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
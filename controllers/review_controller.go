@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewController 商品評價控制器（顧客評價、商戶回覆、管理員審核）
+type ReviewController struct {
+	reviewService *services.ReviewService
+}
+
+// NewReviewController 創建評價控制器
+func NewReviewController(reviewService *services.ReviewService) *ReviewController {
+	return &ReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// GetProductReviews 獲取商品評價
+// @Summary 獲取商品評價
+// @Description 獲取商品已發佈的評價與星等統計
+// @Tags 商品評價
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param limit query int false "每頁數量" default(20)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/products/{id}/reviews [get]
+func (c *ReviewController) GetProductReviews(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	limit, offset := reviewPagination(ctx)
+	reviews, summary, err := c.reviewService.GetProductReviews(productID, limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取商品評價失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"summary": summary,
+		"total":   summary.ReviewCount,
+	})
+}
+
+// CreateReview 顧客評價商品
+// @Summary 評價商品
+// @Description 已收到商品的顧客可給予 1-5 星評價與文字內容，每個商品限評價一次
+// @Tags 商品評價
+// @Accept json
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param request body models.ReviewRequest true "評價內容"
+// @Success 201 {object} models.ProductReview
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/products/{id}/reviews [post]
+func (c *ReviewController) CreateReview(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	review, err := c.reviewService.CreateReview(customerID, productID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "評價商品失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "評價已送出",
+		"review":  review,
+	})
+}
+
+// UpdateReview 顧客修改自己的評價
+// @Summary 修改評價
+// @Tags 商品評價
+// @Accept json
+// @Produce json
+// @Param id path int true "評價ID"
+// @Param request body models.ReviewRequest true "評價內容"
+// @Success 200 {object} models.ProductReview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/reviews/{id} [put]
+func (c *ReviewController) UpdateReview(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := reviewIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	review, err := c.reviewService.UpdateReview(customerID, reviewID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "修改評價失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "評價已更新",
+		"review":  review,
+	})
+}
+
+// GetMerchantReviews 商戶獲取自己商品的評價
+// @Summary 獲取商戶商品評價
+// @Description 包含已被管理員隱藏的評價
+// @Tags 商品評價
+// @Produce json
+// @Param product_id query int false "商品ID"
+// @Param limit query int false "每頁數量" default(20)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/api/reviews [get]
+func (c *ReviewController) GetMerchantReviews(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, _ := strconv.Atoi(ctx.DefaultQuery("product_id", "0"))
+	limit, offset := reviewPagination(ctx)
+	reviews, total, err := c.reviewService.GetMerchantReviews(merchantID, productID, limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取商品評價失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+	})
+}
+
+// ReplyReview 商戶回覆評價
+// @Summary 回覆評價
+// @Description 商戶回覆自己商品的評價，再次回覆會覆蓋原回覆
+// @Tags 商品評價
+// @Accept json
+// @Produce json
+// @Param id path int true "評價ID"
+// @Param request body models.ReviewReplyRequest true "回覆內容"
+// @Success 200 {object} models.ProductReview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/reviews/{id}/reply [put]
+func (c *ReviewController) ReplyReview(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := reviewIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewReplyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	review, err := c.reviewService.ReplyReview(merchantID, reviewID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "回覆評價失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "回覆已送出",
+		"review":  review,
+	})
+}
+
+// GetAdminReviews 管理員獲取評價
+// @Summary 獲取所有評價
+// @Tags 商品評價
+// @Produce json
+// @Param status query string false "評價狀態 published/hidden"
+// @Param limit query int false "每頁數量" default(20)
+// @Param offset query int false "偏移量" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/api/reviews [get]
+func (c *ReviewController) GetAdminReviews(ctx *gin.Context) {
+	limit, offset := reviewPagination(ctx)
+	reviews, total, err := c.reviewService.ListReviews(ctx.Query("status"), limit, offset)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取評價列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+	})
+}
+
+// ModerateReview 管理員審核評價
+// @Summary 審核評價
+// @Description 隱藏或恢復評價，商品評分會同步重新計算
+// @Tags 商品評價
+// @Accept json
+// @Produce json
+// @Param id path int true "評價ID"
+// @Param request body models.ReviewModerationRequest true "審核內容"
+// @Success 200 {object} models.ProductReview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/api/reviews/{id}/moderation [put]
+func (c *ReviewController) ModerateReview(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := reviewIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewModerationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	review, err := c.reviewService.ModerateReview(adminID, reviewID, &req)
+	if err != nil {
+		respondDomainError(ctx, err, "審核評價失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "評價狀態已更新",
+		"review":  review,
+	})
+}
+
+// reviewIDParam 解析路徑中的評價ID，失敗時直接回應 400
+func reviewIDParam(ctx *gin.Context) (int, bool) {
+	reviewID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的評價ID",
+		})
+		return 0, false
+	}
+	return reviewID, true
+}
+
+// reviewPagination 解析評價列表的分頁參數
+func reviewPagination(ctx *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShippingController 運送方式管理控制器（商戶管理自己的運送方式，管理員管理平台預設）
+type ShippingController struct {
+	shippingService *services.ShippingService
+}
+
+// NewShippingController 創建運送方式控制器
+func NewShippingController(shippingService *services.ShippingService) *ShippingController {
+	return &ShippingController{
+		shippingService: shippingService,
+	}
+}
+
+// GetMerchantShippingMethods 獲取商戶的運送方式列表
+// @Summary 獲取商戶運送方式
+// @Description 獲取商戶自己的運送方式，沒有啟用中的運送方式時購物車使用平台預設
+// @Tags 運送方式
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /merchant/api/shipping-methods [get]
+func (c *ShippingController) GetMerchantShippingMethods(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.listMethods(ctx, &merchantID)
+}
+
+// CreateMerchantShippingMethod 商戶建立運送方式
+// @Summary 建立商戶運送方式
+// @Tags 運送方式
+// @Accept json
+// @Produce json
+// @Param request body models.ShippingMethodRequest true "運送方式內容"
+// @Success 201 {object} models.ShippingMethod
+// @Failure 400 {object} map[string]string
+// @Router /merchant/api/shipping-methods [post]
+func (c *ShippingController) CreateMerchantShippingMethod(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.createMethod(ctx, &merchantID)
+}
+
+// UpdateMerchantShippingMethod 商戶更新自己的運送方式
+// @Summary 更新商戶運送方式
+// @Tags 運送方式
+// @Accept json
+// @Produce json
+// @Param id path int true "運送方式ID"
+// @Param request body models.ShippingMethodRequest true "運送方式內容"
+// @Success 200 {object} models.ShippingMethod
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /merchant/api/shipping-methods/{id} [put]
+func (c *ShippingController) UpdateMerchantShippingMethod(ctx *gin.Context) {
+	merchantID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	c.updateMethod(ctx, &merchantID)
+}
+
+// GetAdminShippingMethods 管理員獲取所有運送方式
+// @Summary 獲取所有運送方式
+// @Tags 運送方式
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/api/shipping-methods [get]
+func (c *ShippingController) GetAdminShippingMethods(ctx *gin.Context) {
+	c.listMethods(ctx, nil)
+}
+
+// CreateAdminShippingMethod 管理員建立運送方式，未指定 merchant_id 時為平台預設
+// @Summary 建立平台運送方式
+// @Tags 運送方式
+// @Accept json
+// @Produce json
+// @Param request body models.ShippingMethodRequest true "運送方式內容"
+// @Success 201 {object} models.ShippingMethod
+// @Failure 400 {object} map[string]string
+// @Router /admin/api/shipping-methods [post]
+func (c *ShippingController) CreateAdminShippingMethod(ctx *gin.Context) {
+	c.createMethod(ctx, nil)
+}
+
+// UpdateAdminShippingMethod 管理員更新任一運送方式，未帶 merchant_id 時保留原歸屬，帶 null 時改為平台預設
+// @Summary 更新運送方式
+// @Tags 運送方式
+// @Accept json
+// @Produce json
+// @Param id path int true "運送方式ID"
+// @Param request body models.ShippingMethodRequest true "運送方式內容"
+// @Success 200 {object} models.ShippingMethod
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/api/shipping-methods/{id} [put]
+func (c *ShippingController) UpdateAdminShippingMethod(ctx *gin.Context) {
+	c.updateMethod(ctx, nil)
+}
+
+func (c *ShippingController) listMethods(ctx *gin.Context, merchantID *int) {
+	methods, err := c.shippingService.ListMethods(merchantID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取運送方式列表失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"shipping_methods": methods,
+		"total":            len(methods),
+	})
+}
+
+func (c *ShippingController) createMethod(ctx *gin.Context, merchantID *int) {
+	var req models.ShippingMethodRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	method, err := c.shippingService.CreateMethod(&req, merchantID)
+	if err != nil {
+		respondDomainError(ctx, err, "建立運送方式失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success":         true,
+		"message":         "運送方式建立成功",
+		"shipping_method": method,
+	})
+}
+
+func (c *ShippingController) updateMethod(ctx *gin.Context, merchantID *int) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的運送方式ID",
+		})
+		return
+	}
+
+	var req models.ShippingMethodRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	method, err := c.shippingService.UpdateMethod(id, &req, merchantID)
+	if err != nil {
+		respondDomainError(ctx, err, "更新運送方式失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"message":         "運送方式更新成功",
+		"shipping_method": method,
+	})
+}
@@ -94,6 +94,91 @@ func (sc *StockController) GetStock(c *gin.Context) {
 	})
 }
 
+// GetStockHistory 獲取股票歷史K棒
+// @Summary 獲取股票歷史K棒
+// @Description 依週期查詢 OHLCV K 棒，from、to 可為 RFC3339 時間或 YYYY-MM-DD（台灣時間，to 含當日），未指定時依週期回傳最近一段期間
+// @Tags 股票
+// @Produce json
+// @Param code path string true "股票代碼"
+// @Param interval query string false "週期：1m、5m、1d（預設 1d）"
+// @Param from query string false "起始時間"
+// @Param to query string false "結束時間"
+// @Success 200 {object} models.StockHistory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/stock/stocks/{code}/history [get]
+func (sc *StockController) GetStockHistory(c *gin.Context) {
+	history, err := sc.stockService.GetStockHistory(c.Param("code"), c.Query("interval"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		respondDomainError(c, err, "獲取股票歷史失敗")
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// GetStockCandles 獲取股票K線
+// @Summary 獲取股票K線
+// @Description 依週期彙總 K 線，分 K 依交易時段對齊，休市日與無成交時段不補空 K 線；最後一根未收盤的 K 線 complete 為 false
+// @Tags 股票
+// @Produce json
+// @Param code path string true "股票代碼"
+// @Param resolution query string false "週期：1m、5m、15m、60m、day、week、month（預設 day）"
+// @Param from query string false "起始時間，RFC3339 或 YYYY-MM-DD"
+// @Param to query string false "結束時間，RFC3339 或 YYYY-MM-DD（含當日）"
+// @Success 200 {object} models.CandleSeries
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/stock/stocks/{code}/candles [get]
+func (sc *StockController) GetStockCandles(c *gin.Context) {
+	candles, err := sc.stockService.GetStockCandles(c.Param("code"), c.Query("resolution"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		respondDomainError(c, err, "獲取股票K線失敗")
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    candles,
+	})
+}
+
+// GetStockIndicators 獲取股票技術指標
+// @Summary 獲取股票技術指標
+// @Description 依歷史 K 線計算 MA(5,10,20,60)、EMA(12,26)、RSI(14)、MACD(12,26,9)、KD(9,3,3) 與布林通道(20,2)，回傳最新指標、判讀訊號與最近 limit 筆序列
+// @Tags 股票
+// @Produce json
+// @Param code path string true "股票代碼"
+// @Param resolution query string false "週期：1m、5m、15m、60m、day、week、month（預設 day）"
+// @Param limit query int false "回傳的序列筆數（預設 60，最多 500，0 表示只回傳最新指標）"
+// @Success 200 {object} models.StockIndicators
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/stock/stocks/{code}/indicators [get]
+func (sc *StockController) GetStockIndicators(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "60"))
+	if err != nil || limit < 0 {
+		limit = 60
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	
+	result, err := sc.stockService.GetStockIndicators(c.Param("code"), c.Query("resolution"), limit)
+	if err != nil {
+		respondDomainError(c, err, "獲取技術指標失敗")
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // UpdateStockPrices 更新股票價格（從台灣證交所）
 func (sc *StockController) UpdateStockPrices(c *gin.Context) {
 	err := sc.stockService.UpdateStockPricesFromTSE()
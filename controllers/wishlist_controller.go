@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"go-simple-app/models"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WishlistController 收藏清單控制器
+type WishlistController struct {
+	wishlistService *services.WishlistService
+}
+
+// NewWishlistController 創建收藏清單控制器
+func NewWishlistController(wishlistService *services.WishlistService) *WishlistController {
+	return &WishlistController{
+		wishlistService: wishlistService,
+	}
+}
+
+// GetWishlist 獲取收藏清單
+// @Summary 獲取收藏清單
+// @Description 獲取顧客收藏的商品，available 表示目前是否可購買
+// @Tags 收藏清單
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/wishlist [get]
+func (c *WishlistController) GetWishlist(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	items, err := c.wishlistService.GetWishlist(customerID)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取收藏清單失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"total": len(items),
+	})
+}
+
+// AddToWishlist 加入收藏
+// @Summary 加入收藏
+// @Description 已收藏時更新補貨通知設定
+// @Tags 收藏清單
+// @Accept json
+// @Produce json
+// @Param request body models.AddWishlistRequest true "收藏內容"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/wishlist [post]
+func (c *WishlistController) AddToWishlist(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.AddWishlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "請求參數錯誤: " + err.Error(),
+		})
+		return
+	}
+
+	if err := c.wishlistService.AddItem(customerID, &req); err != nil {
+		respondDomainError(ctx, err, "加入收藏失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已加入收藏",
+	})
+}
+
+// RemoveFromWishlist 移除收藏
+// @Summary 移除收藏
+// @Tags 收藏清單
+// @Produce json
+// @Param productId path int true "商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/wishlist/{productId} [delete]
+func (c *WishlistController) RemoveFromWishlist(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.Atoi(ctx.Param("productId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的商品ID",
+		})
+		return
+	}
+
+	if err := c.wishlistService.RemoveItem(customerID, productID); err != nil {
+		respondDomainError(ctx, err, "移除收藏失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已移除收藏",
+	})
+}
+
+// GetNotifications 獲取補貨通知
+// @Summary 獲取補貨通知
+// @Tags 收藏清單
+// @Produce json
+// @Param unread query bool false "只顯示未讀"
+// @Param limit query int false "數量上限" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/wishlist/notifications [get]
+func (c *WishlistController) GetNotifications(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	unreadOnly, _ := strconv.ParseBool(ctx.DefaultQuery("unread", "false"))
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	notifications, unread, err := c.wishlistService.GetNotifications(customerID, unreadOnly, limit)
+	if err != nil {
+		respondDomainError(ctx, err, "獲取補貨通知失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread_count":  unread,
+	})
+}
+
+// MarkNotificationRead 標記通知已讀
+// @Summary 標記通知已讀
+// @Tags 收藏清單
+// @Produce json
+// @Param id path int true "通知ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /api/wishlist/notifications/{id}/read [put]
+func (c *WishlistController) MarkNotificationRead(ctx *gin.Context) {
+	customerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	notificationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "無效的通知ID",
+		})
+		return
+	}
+
+	if err := c.wishlistService.MarkNotificationRead(customerID, notificationID); err != nil {
+		respondDomainError(ctx, err, "標記通知失敗")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "通知已標記為已讀",
+	})
+}
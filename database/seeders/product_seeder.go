@@ -163,12 +163,12 @@ func (s *ProductSeeder) Run() error {
 	}
 
 	for _, product := range products {
-		query := `INSERT INTO products (name, description, price, original_price, category, sub_category, 
+		query := `INSERT INTO products (name, description, price, original_price, category, category_id, sub_category, 
 		                               brand, stock, image_url, is_featured, is_on_sale, merchant_id, 
 		                               view_count, sales_count, rating, review_count, created_at, updated_at) 
-		          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+		          VALUES (?, ?, ?, ?, ?, (SELECT id FROM product_categories WHERE name = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 		_, err := s.db.Exec(query, product.Name, product.Description, product.Price, product.OriginalPrice,
-			product.Category, product.SubCategory, product.Brand, product.Stock, product.ImageURL,
+			product.Category, product.Category, product.SubCategory, product.Brand, product.Stock, product.ImageURL,
 			product.IsFeatured, product.IsOnSale, product.MerchantID, 0, 0, 0.0, 0)
 		if err != nil {
 			return err
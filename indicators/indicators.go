@@ -0,0 +1,199 @@
+// Package indicators 技術指標計算
+// 所有函式輸入依時間排序的序列，回傳與輸入等長的序列；資料不足、尚無法計算的位置為 NaN
+package indicators
+
+import "math"
+
+// SMA 簡單移動平均
+func SMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 {
+		return out
+	}
+
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA 指數移動平均，以前 period 筆的簡單平均為起始值；開頭的 NaN 會略過，可直接用於 DIF 等衍生序列
+func EMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 {
+		return out
+	}
+
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	seed := start + period - 1
+	if seed >= len(values) {
+		return out
+	}
+
+	var sum float64
+	for i := start; i <= seed; i++ {
+		sum += values[i]
+	}
+	out[seed] = sum / float64(period)
+
+	k := 2 / float64(period+1)
+	for i := seed + 1; i < len(values); i++ {
+		out[i] = values[i]*k + out[i-1]*(1-k)
+	}
+	return out
+}
+
+// RSI 相對強弱指標（Wilder 平滑），需要 period+1 筆收盤價才有第一個值
+func RSI(closes []float64, period int) []float64 {
+	out := nanSeries(len(closes))
+	if period <= 0 || len(closes) <= period {
+		return out
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		gain, loss := priceChange(closes[i-1], closes[i])
+		avgGain += gain
+		avgLoss += loss
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	out[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(closes); i++ {
+		gain, loss := priceChange(closes[i-1], closes[i])
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		out[i] = rsiValue(avgGain, avgLoss)
+	}
+	return out
+}
+
+func priceChange(prev, cur float64) (gain, loss float64) {
+	if cur > prev {
+		return cur - prev, 0
+	}
+	return 0, prev - cur
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+// MACDResult MACD 指標：DIF 為快慢 EMA 差，Signal 為 DIF 的 EMA（台股稱 MACD 或 DEA），Histogram 為兩者差（OSC）
+type MACDResult struct {
+	DIF       []float64
+	Signal    []float64
+	Histogram []float64
+}
+
+// MACD 指數平滑異同移動平均線，常用參數為 12、26、9
+func MACD(closes []float64, fast, slow, signal int) MACDResult {
+	fastEMA := EMA(closes, fast)
+	slowEMA := EMA(closes, slow)
+
+	dif := nanSeries(len(closes))
+	for i := range closes {
+		if !math.IsNaN(fastEMA[i]) && !math.IsNaN(slowEMA[i]) {
+			dif[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+
+	signalLine := EMA(dif, signal)
+	histogram := nanSeries(len(closes))
+	for i := range closes {
+		if !math.IsNaN(signalLine[i]) {
+			histogram[i] = dif[i] - signalLine[i]
+		}
+	}
+
+	return MACDResult{DIF: dif, Signal: signalLine, Histogram: histogram}
+}
+
+// KDResult 隨機指標
+type KDResult struct {
+	K []float64
+	D []float64
+}
+
+// KD 台股慣用的隨機指標：RSV 取 period 期內高低點，K、D 分別以 1/kSmooth、1/dSmooth 權重平滑，起始值為 50
+// 常用參數為 9、3、3
+func KD(highs, lows, closes []float64, period, kSmooth, dSmooth int) KDResult {
+	n := len(closes)
+	result := KDResult{K: nanSeries(n), D: nanSeries(n)}
+	if period <= 0 || kSmooth <= 0 || dSmooth <= 0 || len(highs) != n || len(lows) != n {
+		return result
+	}
+
+	k, d := 50.0, 50.0
+	for i := period - 1; i < n; i++ {
+		high, low := highs[i], lows[i]
+		for j := i - period + 1; j < i; j++ {
+			high = math.Max(high, highs[j])
+			low = math.Min(low, lows[j])
+		}
+
+		rsv := 50.0
+		if high > low {
+			rsv = (closes[i] - low) / (high - low) * 100
+		}
+		k = k*float64(kSmooth-1)/float64(kSmooth) + rsv/float64(kSmooth)
+		d = d*float64(dSmooth-1)/float64(dSmooth) + k/float64(dSmooth)
+		result.K[i] = k
+		result.D[i] = d
+	}
+	return result
+}
+
+// BollingerResult 布林通道
+type BollingerResult struct {
+	Upper  []float64
+	Middle []float64
+	Lower  []float64
+}
+
+// Bollinger 布林通道：中軌為 period 期簡單平均，上下軌為中軌加減 multiplier 倍標準差（母體標準差）
+// 常用參數為 20、2
+func Bollinger(closes []float64, period int, multiplier float64) BollingerResult {
+	n := len(closes)
+	result := BollingerResult{Upper: nanSeries(n), Middle: SMA(closes, period), Lower: nanSeries(n)}
+
+	for i := range closes {
+		mean := result.Middle[i]
+		if math.IsNaN(mean) {
+			continue
+		}
+		var variance float64
+		for j := i - period + 1; j <= i; j++ {
+			variance += (closes[j] - mean) * (closes[j] - mean)
+		}
+		std := math.Sqrt(variance / float64(period))
+		result.Upper[i] = mean + multiplier*std
+		result.Lower[i] = mean - multiplier*std
+	}
+	return result
+}
+
+func nanSeries(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
@@ -0,0 +1,240 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+var nan = math.NaN()
+
+// stockChartsMACloses StockCharts「Moving Averages」教學的 10 日均線範例收盤價
+var stockChartsMACloses = []float64{
+	22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+	22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+	23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17,
+}
+
+// stockChartsRSICloses StockCharts「RSI」教學的 14 日 RSI 範例收盤價
+var stockChartsRSICloses = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+	43.42, 42.66, 43.13,
+}
+
+func TestMovingAverages(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []float64
+		want []float64
+		tol  float64
+	}{
+		{
+			// StockCharts 表格數值（四捨五入到小數第二位）
+			name: "SMA(10)",
+			got:  SMA(stockChartsMACloses, 10),
+			want: []float64{nan, nan, nan, nan, nan, nan, nan, nan, nan,
+				22.22, 22.21, 22.23, 22.26, 22.30, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21,
+				23.38, 23.53, 23.65, 23.71, 23.68, 23.61, 23.50, 23.43, 23.28, 23.13},
+			tol: 0.006,
+		},
+		{
+			name: "EMA(10)",
+			got:  EMA(stockChartsMACloses, 10),
+			want: []float64{nan, nan, nan, nan, nan, nan, nan, nan, nan,
+				22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34,
+				23.43, 23.51, 23.53, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92},
+			tol: 0.006,
+		},
+		{
+			name: "SMA 期數為 0",
+			got:  SMA([]float64{1, 2, 3}, 0),
+			want: []float64{nan, nan, nan},
+		},
+		{
+			name: "EMA 資料不足",
+			got:  EMA([]float64{1, 2}, 3),
+			want: []float64{nan, nan},
+		},
+		{
+			// 開頭的 NaN 略過，以第一個有效值起算
+			name: "EMA 略過開頭 NaN",
+			got:  EMA([]float64{nan, nan, 1, 2, 3, 4}, 3),
+			want: []float64{nan, nan, nan, nan, 2, 3},
+			tol:  1e-9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, tt.got, tt.want, tt.tol)
+		})
+	}
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		period int
+		want   []float64
+		tol    float64
+	}{
+		{
+			// StockCharts 表格以未四捨五入的收盤價計算，與表列收盤價算出的結果相差不到 0.1
+			name:   "StockCharts RSI(14)",
+			closes: stockChartsRSICloses,
+			period: 14,
+			want: []float64{nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan,
+				70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+				54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77},
+			tol: 0.1,
+		},
+		{
+			name:   "只漲不跌",
+			closes: []float64{1, 2, 3, 4},
+			period: 3,
+			want:   []float64{nan, nan, nan, 100},
+		},
+		{
+			name:   "只跌不漲",
+			closes: []float64{4, 3, 2, 1},
+			period: 3,
+			want:   []float64{nan, nan, nan, 0},
+		},
+		{
+			name:   "價格不變",
+			closes: []float64{5, 5, 5, 5},
+			period: 3,
+			want:   []float64{nan, nan, nan, 50},
+		},
+		{
+			name:   "資料不足",
+			closes: []float64{1, 2, 3},
+			period: 3,
+			want:   []float64{nan, nan, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, RSI(tt.closes, tt.period), tt.want, tt.tol)
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	// MACD(3,6,3)：EMA3 起始值 44.1933、EMA6 起始值 44.225，第 6 筆 DIF = 44.4729 - 44.225
+	result := MACD(stockChartsRSICloses[:12], 3, 6, 3)
+
+	tests := []struct {
+		name string
+		got  []float64
+		want []float64
+	}{
+		{"DIF", result.DIF, []float64{nan, nan, nan, nan, nan, 0.2479, 0.3115, 0.3582, 0.4138, 0.4259, 0.3287, 0.2770}},
+		{"Signal", result.Signal, []float64{nan, nan, nan, nan, nan, nan, nan, 0.3059, 0.3598, 0.3929, 0.3608, 0.3189}},
+		{"Histogram", result.Histogram, []float64{nan, nan, nan, nan, nan, nan, nan, 0.0524, 0.0539, 0.0330, -0.0321, -0.0419}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, tt.got, tt.want, 0.0001)
+		})
+	}
+}
+
+func TestKD(t *testing.T) {
+	highs := []float64{44.50, 44.30, 44.40, 44.00, 44.60, 45.00, 45.30, 45.60, 46.00, 46.20, 46.10, 46.20, 45.90}
+	lows := []float64{44.00, 43.90, 43.90, 43.40, 44.10, 44.50, 44.80, 45.20, 45.60, 45.90, 45.70, 45.80, 45.50}
+	closes := stockChartsRSICloses[:13]
+
+	tests := []struct {
+		name   string
+		highs  []float64
+		lows   []float64
+		closes []float64
+		wantK  []float64
+		wantD  []float64
+	}{
+		{
+			// 第 9 筆 RSV = (45.84 - 43.40) / (46.00 - 43.40) = 93.85，K = 50×2/3 + 93.85/3
+			name:   "KD(9,3,3)",
+			highs:  highs,
+			lows:   lows,
+			closes: closes,
+			wantK:  []float64{nan, nan, nan, nan, nan, nan, nan, nan, 64.6154, 74.9817, 79.6306, 84.3966, 80.2327},
+			wantD:  []float64{nan, nan, nan, nan, nan, nan, nan, nan, 54.8718, 61.5751, 67.5936, 73.1946, 75.5406},
+		},
+		{
+			// 最高價等於最低價時 RSV 視為 50
+			name:   "價格不變",
+			highs:  []float64{10, 10, 10, 10, 10, 10, 10, 10, 10},
+			lows:   []float64{10, 10, 10, 10, 10, 10, 10, 10, 10},
+			closes: []float64{10, 10, 10, 10, 10, 10, 10, 10, 10},
+			wantK:  []float64{nan, nan, nan, nan, nan, nan, nan, nan, 50},
+			wantD:  []float64{nan, nan, nan, nan, nan, nan, nan, nan, 50},
+		},
+		{
+			name:   "高低價長度不符",
+			highs:  highs[:12],
+			lows:   lows,
+			closes: closes,
+			wantK:  []float64{nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan},
+			wantD:  []float64{nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := KD(tt.highs, tt.lows, tt.closes, 9, 3, 3)
+			assertSeries(t, result.K, tt.wantK, 0.0001)
+			assertSeries(t, result.D, tt.wantD, 0.0001)
+		})
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	tests := []struct {
+		name      string
+		closes    []float64
+		index     int
+		wantUpper float64
+		wantMid   float64
+		wantLower float64
+	}{
+		// 參考值以 20 筆收盤價的平均與母體標準差逐步計算
+		{"前 20 筆", stockChartsRSICloses, 19, 47.1153, 45.4090, 43.7027},
+		{"最後 20 筆", stockChartsRSICloses, 32, 47.6202, 45.2410, 42.8618},
+		{"價格不變時通道收斂", []float64{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8}, 19, 8, 8, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Bollinger(tt.closes, 20, 2)
+			if !math.IsNaN(result.Upper[18]) || !math.IsNaN(result.Lower[18]) {
+				t.Errorf("bands before period = %v / %v, want NaN", result.Upper[18], result.Lower[18])
+			}
+			got := []float64{result.Upper[tt.index], result.Middle[tt.index], result.Lower[tt.index]}
+			assertSeries(t, got, []float64{tt.wantUpper, tt.wantMid, tt.wantLower}, 0.0001)
+		})
+	}
+}
+
+// assertSeries 逐筆比對指標序列，want 為 NaN 的位置要求結果也是 NaN
+func assertSeries(t *testing.T, got, want []float64, tol float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		switch {
+		case math.IsNaN(want[i]):
+			if !math.IsNaN(got[i]) {
+				t.Errorf("[%d] = %.4f, want NaN", i, got[i])
+			}
+		case math.IsNaN(got[i]) || math.Abs(got[i]-want[i]) > tol:
+			t.Errorf("[%d] = %.4f, want %.4f (±%g)", i, got[i], want[i], tol)
+		}
+	}
+}
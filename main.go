@@ -93,15 +93,15 @@ func main() {
 	oauthController := controllers.NewOAuthController(oauthService)
 	logger.Info("Controller層初始化完成")
 
-	// 設置路由
-	router := routes.SetupRoutes(unifiedAuthController, adminController, unifiedAuthService, chatController, oauthController, versionService)
-
-	// 設置 Gin 模式
+	// 設置 Gin 模式（需在設置路由前決定，開發用路由依此判斷是否允許註冊）
 	if cfg.Server.Host == "0.0.0.0" {
 		gin.SetMode(gin.ReleaseMode)
 		logger.Info("Gin設置為Release模式")
 	}
 
+	// 設置路由
+	router := routes.SetupRoutes(unifiedAuthController, adminController, unifiedAuthService, chatController, oauthController, versionService, cfg)
+
 	// 啟動服務器
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -11,26 +11,7 @@ import (
 // 統一認證中間件 - 支持 UnifiedAuthService
 func UnifiedAuthMiddleware(authService *services.UnifiedAuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 從 Header 獲取 token
-		authHeader := c.GetHeader("Authorization")
-		var tokenString string
-
-		if authHeader != "" {
-			// 檢查 Bearer token 格式
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				tokenString = strings.TrimPrefix(authHeader, "Bearer ")
-			} else {
-				tokenString = authHeader
-			}
-		} else {
-			// 從 cookie 獲取 token
-			if cookie, err := c.Cookie("auth_token"); err == nil {
-				tokenString = cookie
-			} else {
-				// 從 query parameter 獲取 token (用於頁面訪問)
-				tokenString = c.Query("token")
-			}
-		}
+		tokenString := requestToken(c)
 
 		if tokenString == "" {
 			// 如果是頁面請求，重定向到登入頁面
@@ -69,3 +50,36 @@ func UnifiedAuthMiddleware(authService *services.UnifiedAuthService) gin.Handler
 		c.Next()
 	}
 }
+
+// 選擇性認證中間件 - 帶有效 token 時存入用戶信息，未登入或 token 無效時仍繼續處理（用於公開 API 的個人化）
+func OptionalUnifiedAuthMiddleware(authService *services.UnifiedAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString := requestToken(c); tokenString != "" {
+			if user, err := authService.ValidateToken(tokenString); err == nil {
+				c.Set("user", user)
+			}
+		}
+		c.Next()
+	}
+}
+
+// requestToken 依序從 Authorization header、cookie 與 query parameter 獲取 token
+func requestToken(c *gin.Context) string {
+	// 從 Header 獲取 token
+	authHeader := c.GetHeader("Authorization")
+	if authHeader != "" {
+		// 檢查 Bearer token 格式
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			return strings.TrimPrefix(authHeader, "Bearer ")
+		}
+		return authHeader
+	}
+
+	// 從 cookie 獲取 token
+	if cookie, err := c.Cookie("auth_token"); err == nil {
+		return cookie
+	}
+
+	// 從 query parameter 獲取 token (用於頁面訪問)
+	return c.Query("token")
+}
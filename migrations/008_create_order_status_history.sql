@@ -0,0 +1,17 @@
+-- 創建訂單狀態變更紀錄表
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL,
+    from_status VARCHAR(20) NOT NULL DEFAULT '', -- 建立訂單時為空字串
+    to_status VARCHAR(20) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL, -- customer, merchant, admin, system
+    actor_id INTEGER NOT NULL DEFAULT 0, -- system 操作時為 0
+    note TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+-- 創建訂單狀態紀錄索引
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_created_at ON order_status_history(created_at);
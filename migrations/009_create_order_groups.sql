@@ -0,0 +1,25 @@
+-- 創建訂單群組表：一次結帳一筆付款，依商戶拆分為多張子訂單
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE orders ADD COLUMN group_id INTEGER REFERENCES order_groups(id);
+
+CREATE TABLE IF NOT EXISTS order_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_number VARCHAR(50) NOT NULL UNIQUE,
+    customer_id INTEGER NOT NULL,
+    total_amount DECIMAL(10,2) NOT NULL, -- 所有子訂單金額合計
+    shipping_fee DECIMAL(10,2) DEFAULT 0,
+    discount_amount DECIMAL(10,2) DEFAULT 0,
+    payment_method VARCHAR(50),
+    payment_status VARCHAR(20) DEFAULT 'pending', -- pending, paid, failed, refunded
+    shipping_address TEXT NOT NULL,
+    billing_address TEXT,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+);
+
+-- 創建訂單群組索引
+CREATE INDEX IF NOT EXISTS idx_order_groups_customer_id ON order_groups(customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_group_id ON orders(group_id);
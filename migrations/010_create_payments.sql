@@ -0,0 +1,37 @@
+-- 創建付款與金流回調紀錄表
+
+CREATE TABLE IF NOT EXISTS payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    payment_number VARCHAR(50) NOT NULL UNIQUE, -- 送往金流商的交易編號
+    group_id INTEGER NOT NULL,
+    customer_id INTEGER NOT NULL,
+    provider VARCHAR(20) NOT NULL, -- mock, ecpay
+    amount DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(10) DEFAULT 'TWD',
+    status VARCHAR(20) DEFAULT 'pending', -- pending, paid, failed, refunded
+    provider_ref VARCHAR(100), -- 金流商的交易編號
+    paid_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES order_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_group_id ON payments(group_id);
+CREATE INDEX IF NOT EXISTS idx_payments_customer_id ON payments(customer_id);
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
+
+-- 金流回調紀錄，(provider, event_id) 唯一以確保同一回調只處理一次
+CREATE TABLE IF NOT EXISTS payment_callbacks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider VARCHAR(20) NOT NULL,
+    event_id VARCHAR(150) NOT NULL,
+    payment_number VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    payload TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(provider, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_callbacks_payment_number ON payment_callbacks(payment_number);
@@ -0,0 +1,21 @@
+-- 庫存保留：結帳開始時暫時保留庫存，逾時由背景排程釋放
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE products ADD COLUMN reserved_stock INTEGER DEFAULT 0; -- 保留中的數量，stock 為現有庫存
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    status VARCHAR(20) DEFAULT 'active', -- active, converted, released, expired
+    group_id INTEGER, -- 轉為訂單後對應的訂單群組
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_customer ON stock_reservations(customer_id, status);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires ON stock_reservations(status, expires_at);
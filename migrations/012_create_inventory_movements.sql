@@ -0,0 +1,33 @@
+-- 庫存異動帳：每次 products.stock 變動都寫入一筆，只能新增不能修改或刪除
+
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL, -- 不設外鍵，商品刪除後仍保留異動紀錄
+    merchant_id INTEGER NOT NULL,
+    delta INTEGER NOT NULL, -- 庫存變動量，正數為入庫、負數為出庫
+    stock_after INTEGER NOT NULL, -- 異動後的現有庫存
+    reason VARCHAR(30) NOT NULL, -- opening, merchant_edit, order_placed, order_cancelled, order_refunded, manual_adjustment
+    actor_type VARCHAR(20) NOT NULL, -- customer, merchant, admin, system
+    actor_id INTEGER NOT NULL DEFAULT 0, -- system 操作時為 0
+    reference_type VARCHAR(20), -- 例如 order
+    reference_id INTEGER,
+    note TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product ON inventory_movements(product_id, id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_merchant ON inventory_movements(merchant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_reference ON inventory_movements(reference_type, reference_id);
+
+-- 禁止修改與刪除異動紀錄
+CREATE TRIGGER IF NOT EXISTS inventory_movements_no_update
+BEFORE UPDATE ON inventory_movements
+BEGIN
+    SELECT RAISE(ABORT, 'inventory_movements is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS inventory_movements_no_delete
+BEFORE DELETE ON inventory_movements
+BEGIN
+    SELECT RAISE(ABORT, 'inventory_movements is append-only');
+END;
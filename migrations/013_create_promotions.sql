@@ -0,0 +1,46 @@
+-- 促銷與優惠券：百分比/固定金額折扣、最低消費、使用次數限制、商戶或全站適用、有效期間
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR(50) UNIQUE, -- 優惠券代碼，NULL 表示符合條件即自動套用的促銷
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    merchant_id INTEGER, -- NULL 表示全站適用
+    discount_type VARCHAR(20) NOT NULL, -- percentage, fixed
+    discount_value DECIMAL(10,2) NOT NULL, -- 百分比（例如 10 代表 9 折）或固定金額
+    max_discount DECIMAL(10,2), -- 百分比折扣的上限金額
+    min_spend DECIMAL(10,2) DEFAULT 0, -- 適用範圍內的最低消費金額
+    usage_limit INTEGER DEFAULT 0, -- 總使用次數上限，0 表示不限
+    per_customer_limit INTEGER DEFAULT 0, -- 每位客戶使用次數上限，0 表示不限
+    used_count INTEGER DEFAULT 0,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    is_active BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_merchant ON promotions(merchant_id, is_active);
+
+-- 促銷使用紀錄，一次結帳對每個促銷寫入一筆
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    promotion_id INTEGER NOT NULL,
+    customer_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES order_groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_customer ON promotion_redemptions(promotion_id, customer_id);
+
+-- 客戶購物車目前套用的優惠券
+CREATE TABLE IF NOT EXISTS cart_coupons (
+    customer_id INTEGER PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+);
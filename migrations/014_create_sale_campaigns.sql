@@ -0,0 +1,30 @@
+-- 限時特價活動：排程於開始時套用特價、結束或售完時還原
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE shopping_cart ADD COLUMN price DECIMAL(10,2); -- 加入或最後同步時的商品價格
+
+UPDATE shopping_cart SET price = (SELECT price FROM products WHERE products.id = shopping_cart.product_id)
+WHERE price IS NULL;
+
+CREATE TABLE IF NOT EXISTS sale_campaigns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    merchant_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    sale_price DECIMAL(10,2) NOT NULL,
+    quantity_cap INTEGER DEFAULT 0, -- 特價數量上限，0 表示不限
+    sold_quantity INTEGER DEFAULT 0,
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    status VARCHAR(20) DEFAULT 'scheduled', -- scheduled, active, ended, cancelled
+    regular_price DECIMAL(10,2), -- 活動開始時的商品原價格，結束時還原
+    regular_original_price DECIMAL(10,2),
+    regular_on_sale BOOLEAN DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_campaigns_status ON sale_campaigns(status, starts_at);
+CREATE INDEX IF NOT EXISTS idx_sale_campaigns_product ON sale_campaigns(product_id, status);
@@ -0,0 +1,15 @@
+-- 商品評價：僅限已收貨的買家評價，商戶可回覆，管理員可隱藏
+-- product_reviews 表已於 004 建立，此處補上驗證購買、商戶回覆與審核欄位
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE product_reviews ADD COLUMN order_id INTEGER; -- 驗證購買的訂單
+ALTER TABLE product_reviews ADD COLUMN status VARCHAR(20) DEFAULT 'published'; -- published, hidden
+ALTER TABLE product_reviews ADD COLUMN merchant_reply TEXT;
+ALTER TABLE product_reviews ADD COLUMN merchant_replied_at DATETIME;
+ALTER TABLE product_reviews ADD COLUMN moderation_note TEXT; -- 管理員隱藏評價的原因
+ALTER TABLE product_reviews ADD COLUMN moderated_by INTEGER;
+ALTER TABLE product_reviews ADD COLUMN moderated_at DATETIME;
+
+-- 每位顧客對同一商品只能有一則評價
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_reviews_product_customer ON product_reviews(product_id, customer_id);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_status ON product_reviews(status, created_at);
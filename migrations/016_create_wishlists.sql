@@ -0,0 +1,33 @@
+-- 顧客收藏清單與補貨通知
+
+CREATE TABLE IF NOT EXISTS wishlists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    notify_on_restock BOOLEAN DEFAULT 1, -- 缺貨或下架的商品重新可購買時通知
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(customer_id, product_id),
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlists_product ON wishlists(product_id, notify_on_restock);
+
+-- 補貨通知佇列：商品恢復可購買時為收藏者排入，delivered_at 為送出時間，read_at 為顧客已讀時間
+CREATE TABLE IF NOT EXISTS wishlist_notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    type VARCHAR(30) DEFAULT 'back_in_stock',
+    message TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending', -- pending, delivered
+    delivered_at DATETIME,
+    read_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_notifications_customer ON wishlist_notifications(customer_id, read_at);
+CREATE INDEX IF NOT EXISTS idx_wishlist_notifications_status ON wishlist_notifications(status, created_at);
@@ -0,0 +1,63 @@
+-- 商品規格：以選項軸（如尺寸、顏色）組合出規格，每個規格有獨立的 SKU、價格與庫存
+-- 有規格的商品，products.stock 與 reserved_stock 為所有啟用規格的加總
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE shopping_cart ADD COLUMN variant_id INTEGER NOT NULL DEFAULT 0; -- 0 表示商品沒有規格
+ALTER TABLE stock_reservations ADD COLUMN variant_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN variant_id INTEGER;
+ALTER TABLE order_items ADD COLUMN variant_name VARCHAR(200); -- 快照規格名稱，例如「黑 / M」
+ALTER TABLE order_items ADD COLUMN variant_sku VARCHAR(100); -- 快照規格 SKU
+
+-- 購物車同一商品可加入不同規格：重建資料表以改為 (customer_id, product_id, variant_id) 唯一
+CREATE TABLE IF NOT EXISTS shopping_cart_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    variant_id INTEGER NOT NULL DEFAULT 0,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    price DECIMAL(10,2), -- 加入或最後同步時的商品價格
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    UNIQUE(customer_id, product_id, variant_id)
+);
+
+INSERT INTO shopping_cart_variants (id, customer_id, product_id, variant_id, quantity, price, created_at, updated_at)
+SELECT id, customer_id, product_id, variant_id, quantity, price, created_at, updated_at FROM shopping_cart;
+
+DROP TABLE shopping_cart;
+ALTER TABLE shopping_cart_variants RENAME TO shopping_cart;
+
+CREATE INDEX IF NOT EXISTS idx_shopping_cart_customer_id ON shopping_cart(customer_id);
+CREATE INDEX IF NOT EXISTS idx_shopping_cart_product_id ON shopping_cart(product_id);
+
+-- 選項軸，例如 尺寸: ["S","M","L"]
+CREATE TABLE IF NOT EXISTS product_options (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    option_values TEXT NOT NULL, -- JSON 陣列
+    position INTEGER DEFAULT 0,
+    UNIQUE(product_id, name),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL,
+    sku VARCHAR(100) NOT NULL UNIQUE,
+    options TEXT NOT NULL, -- JSON 物件，例如 {"尺寸":"M","顏色":"黑"}
+    option_key VARCHAR(255) NOT NULL, -- 依選項軸順序組成，例如 "M|黑"，啟用中的規格不可重複
+    price DECIMAL(10,2), -- NULL 表示沿用商品價格
+    stock INTEGER NOT NULL DEFAULT 0,
+    reserved_stock INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT 1,
+    position INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product ON product_variants(product_id, is_active, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_active_key ON product_variants(product_id, option_key) WHERE is_active = 1;
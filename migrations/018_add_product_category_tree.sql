@@ -0,0 +1,13 @@
+-- 商品分類樹：商品以 category_id 關聯 product_categories，分類以 parent_id 形成階層
+-- products.category / sub_category 保留為分類名稱的快照（根分類與末層分類），供既有查詢使用
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE products ADD COLUMN category_id INTEGER REFERENCES product_categories(id);
+
+-- 依既有的分類名稱回填 category_id
+UPDATE products SET category_id = (
+    SELECT c.id FROM product_categories c WHERE c.name = products.category
+) WHERE category_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+CREATE INDEX IF NOT EXISTS idx_product_categories_parent ON product_categories(parent_id, sort_order);
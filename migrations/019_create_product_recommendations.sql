@@ -0,0 +1,43 @@
+-- 商品推薦：顧客瀏覽紀錄與批次計算的相關商品、個人化推薦
+
+-- 登入顧客的商品瀏覽紀錄，作為共同瀏覽訊號（products.view_count 只有總數）
+CREATE TABLE IF NOT EXISTS product_views (
+    customer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    view_count INTEGER NOT NULL DEFAULT 1,
+    last_viewed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, product_id),
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_views_product ON product_views(product_id, last_viewed_at);
+
+-- 「買了這個的顧客也買了」：批次重算時整表替換，每個商品只保留分數最高的幾筆
+CREATE TABLE IF NOT EXISTS product_relations (
+    product_id INTEGER NOT NULL,
+    related_product_id INTEGER NOT NULL,
+    co_purchase_count INTEGER NOT NULL DEFAULT 0, -- 兩者都買過的顧客數
+    co_cart_count INTEGER NOT NULL DEFAULT 0,     -- 購物車同時有兩者的顧客數
+    co_view_count INTEGER NOT NULL DEFAULT 0,     -- 近期兩者都瀏覽過的顧客數
+    score REAL NOT NULL DEFAULT 0,
+    computed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, related_product_id),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (related_product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_relations_score ON product_relations(product_id, score DESC);
+
+-- 個人化推薦：由顧客購買、購物車與瀏覽過的商品展開相關商品並加權
+CREATE TABLE IF NOT EXISTS customer_recommendations (
+    customer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    score REAL NOT NULL DEFAULT 0,
+    computed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, product_id),
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_recommendations_score ON customer_recommendations(customer_id, score DESC);
@@ -0,0 +1,2 @@
+-- 商戶批次匯入以 SKU 對應既有商品
+CREATE INDEX IF NOT EXISTS idx_products_merchant_sku ON products(merchant_id, sku);
@@ -0,0 +1,20 @@
+-- 商戶上傳的商品圖片，原圖與縮圖存放在儲存後端，products.images 同步為原圖網址陣列
+
+CREATE TABLE IF NOT EXISTS product_images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL,
+    merchant_id INTEGER NOT NULL,
+    storage VARCHAR(20) NOT NULL,      -- 上傳時的儲存後端 local 或 s3
+    storage_key VARCHAR(255) NOT NULL, -- 原圖的物件 key，縮圖位於同一目錄
+    url VARCHAR(500) NOT NULL,
+    thumbnails TEXT NOT NULL,          -- JSON 物件，尺寸名稱對應縮圖網址
+    content_type VARCHAR(50) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product ON product_images(product_id, position);
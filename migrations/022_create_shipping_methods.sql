@@ -0,0 +1,50 @@
+-- 運送方式與運費表：固定運費、依重量級距、依材積重級距，可設定免運門檻；商戶可建立自己的運送方式
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE orders ADD COLUMN shipping_method_id INTEGER;
+ALTER TABLE orders ADD COLUMN shipping_method VARCHAR(100); -- 快照運送方式名稱
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    merchant_id INTEGER, -- NULL 表示平台預設，商戶沒有啟用中的運送方式時使用
+    name VARCHAR(100) NOT NULL,
+    carrier VARCHAR(50) NOT NULL, -- 物流業者，例如 black_cat、post、seven_eleven
+    rate_type VARCHAR(20) NOT NULL, -- flat, weight, volumetric
+    base_fee DECIMAL(10,2) DEFAULT 0, -- 固定運費；級距運費時為額外加收的基本費
+    tiers TEXT, -- JSON 級距 [{"max_weight": 5, "fee": 100}]，依重量由小到大，超過最後一級不可使用
+    volumetric_divisor DECIMAL(10,2), -- 材積重換算除數，NULL 時使用系統預設
+    free_threshold DECIMAL(10,2), -- 商戶折扣後小計達此金額免運，NULL 表示不免運
+    sort_order INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_merchant ON shipping_methods(merchant_id, is_active, sort_order);
+
+-- 客戶購物車各商戶選擇的運送方式
+CREATE TABLE IF NOT EXISTS cart_shipping_selections (
+    customer_id INTEGER NOT NULL,
+    merchant_id INTEGER NOT NULL,
+    shipping_method_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, merchant_id),
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+    FOREIGN KEY (shipping_method_id) REFERENCES shipping_methods(id) ON DELETE CASCADE
+);
+
+-- 平台預設運送方式
+INSERT INTO shipping_methods (name, carrier, rate_type, base_fee, tiers, free_threshold, sort_order)
+SELECT '超商取貨', 'seven_eleven', 'weight', 0, '[{"max_weight": 5, "fee": 60}]', 499, 1
+WHERE NOT EXISTS (SELECT 1 FROM shipping_methods WHERE merchant_id IS NULL AND carrier = 'seven_eleven');
+
+INSERT INTO shipping_methods (name, carrier, rate_type, base_fee, tiers, free_threshold, sort_order)
+SELECT '宅配', 'black_cat', 'volumetric', 0,
+       '[{"max_weight": 5, "fee": 100}, {"max_weight": 10, "fee": 150}, {"max_weight": 20, "fee": 200}]', 999, 2
+WHERE NOT EXISTS (SELECT 1 FROM shipping_methods WHERE merchant_id IS NULL AND carrier = 'black_cat');
+
+INSERT INTO shipping_methods (name, carrier, rate_type, base_fee, tiers, free_threshold, sort_order)
+SELECT '郵局包裹', 'post', 'weight', 0,
+       '[{"max_weight": 1, "fee": 80}, {"max_weight": 5, "fee": 110}, {"max_weight": 10, "fee": 150}, {"max_weight": 20, "fee": 220}]', NULL, 3
+WHERE NOT EXISTS (SELECT 1 FROM shipping_methods WHERE merchant_id IS NULL AND carrier = 'post');
@@ -0,0 +1,27 @@
+-- 客戶通訊錄：多筆收件與帳單地址（臺灣郵遞區號、縣市、鄉鎮市區），各有一筆預設地址
+-- 訂單以 JSON 快照下單時的地址，地址日後修改或刪除不影響訂單
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE orders ADD COLUMN shipping_address_detail TEXT; -- 收件地址快照 {"recipient_name","phone","postal_code","city","district","street"}
+ALTER TABLE orders ADD COLUMN billing_address_detail TEXT; -- 帳單地址快照，格式同上
+ALTER TABLE order_groups ADD COLUMN shipping_address_detail TEXT;
+ALTER TABLE order_groups ADD COLUMN billing_address_detail TEXT;
+
+CREATE TABLE IF NOT EXISTS customer_addresses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INTEGER NOT NULL,
+    label VARCHAR(50), -- 例如「住家」、「公司」
+    recipient_name VARCHAR(100) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    postal_code VARCHAR(6) NOT NULL,
+    city VARCHAR(10) NOT NULL,
+    district VARCHAR(10) NOT NULL,
+    street VARCHAR(200) NOT NULL,
+    is_default_shipping BOOLEAN DEFAULT 0,
+    is_default_billing BOOLEAN DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_addresses_customer ON customer_addresses(customer_id);
@@ -0,0 +1,100 @@
+-- 退貨退款：客戶依訂單商品申請退貨（原因與照片），商戶核准或拒絕，核准後經金流退款並回補庫存
+-- 每個步驟寫入 return_events 以供稽核，金流退款另記錄於 payment_refunds
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE payments ADD COLUMN refunded_amount DECIMAL(10,2) DEFAULT 0; -- 已退款金額，全額退款時付款狀態轉為 refunded
+
+CREATE TABLE IF NOT EXISTS return_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_number VARCHAR(50) NOT NULL UNIQUE,
+    order_id INTEGER NOT NULL,
+    customer_id INTEGER NOT NULL,
+    merchant_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested', -- requested, approved, refunded, rejected, cancelled
+    refund_amount DECIMAL(10,2) NOT NULL, -- 依訂單折扣比例分攤後的退款金額，不含運費
+    refund_status VARCHAR(20), -- pending, succeeded, failed；核准後才有值
+    refund_ref VARCHAR(100), -- 金流商的退款交易編號
+    restock BOOLEAN DEFAULT 0, -- 核准時是否回補庫存
+    customer_note TEXT,
+    resolution_note TEXT, -- 商戶或管理員核准/拒絕的說明
+    resolved_by_type VARCHAR(20), -- merchant, admin
+    resolved_by_id INTEGER,
+    resolved_at DATETIME,
+    refunded_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_requests_order ON return_requests(order_id);
+CREATE INDEX IF NOT EXISTS idx_return_requests_customer ON return_requests(customer_id);
+CREATE INDEX IF NOT EXISTS idx_return_requests_merchant_status ON return_requests(merchant_id, status);
+
+-- 退貨商品，每筆對應一個訂單商品
+CREATE TABLE IF NOT EXISTS return_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_id INTEGER NOT NULL,
+    order_item_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    variant_id INTEGER,
+    product_name VARCHAR(200) NOT NULL, -- 訂單商品名稱快照
+    quantity INTEGER NOT NULL,
+    reason VARCHAR(30) NOT NULL, -- damaged, defective, wrong_item, not_as_described, changed_mind, other
+    reason_detail TEXT,
+    refund_amount DECIMAL(10,2) NOT NULL,
+    FOREIGN KEY (return_id) REFERENCES return_requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_item_id) REFERENCES order_items(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_items_return ON return_items(return_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item ON return_items(order_item_id);
+
+-- 退貨商品照片
+CREATE TABLE IF NOT EXISTS return_photos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_item_id INTEGER NOT NULL,
+    storage VARCHAR(20) NOT NULL, -- local, s3
+    storage_key VARCHAR(255) NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (return_item_id) REFERENCES return_items(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_photos_item ON return_photos(return_item_id);
+
+-- 退貨稽核紀錄，寫入後不可修改或刪除
+CREATE TABLE IF NOT EXISTS return_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_id INTEGER NOT NULL,
+    action VARCHAR(30) NOT NULL, -- requested, photo_added, approved, rejected, cancelled, restocked, refund_succeeded, refund_failed
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL, -- customer, merchant, admin, system
+    actor_id INTEGER NOT NULL DEFAULT 0,
+    note TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (return_id) REFERENCES return_requests(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_events_return ON return_events(return_id);
+
+-- 金流退款紀錄，成功與失敗皆記錄
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    payment_id INTEGER NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL, -- succeeded, failed
+    provider_ref VARCHAR(100),
+    reason TEXT,
+    message TEXT, -- 金流商回應或錯誤訊息
+    reference_type VARCHAR(20), -- return
+    reference_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment ON payment_refunds(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_reference ON payment_refunds(reference_type, reference_id);
@@ -0,0 +1,36 @@
+-- 股價歷史：stock_prices 只保留每支股票最新一筆，盤中每次更新另寫入逐筆行情與 OHLCV K 棒
+-- 逐筆行情保留數天後刪除，1 分 K 與 5 分 K 依保留天數逐層淘汰，日 K 永久保留
+
+-- 盤中逐筆行情，成交量與成交金額為當日累計值；行情未變動時不重複寫入
+CREATE TABLE IF NOT EXISTS stock_ticks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    stock_code VARCHAR(10) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    volume BIGINT NOT NULL DEFAULT 0, -- 當日累計成交量
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0, -- 當日累計成交金額
+    traded_at DATETIME NOT NULL,
+    FOREIGN KEY (stock_code) REFERENCES stocks(code) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_ticks_code_time ON stock_ticks(stock_code, traded_at);
+CREATE INDEX IF NOT EXISTS idx_stock_ticks_time ON stock_ticks(traded_at);
+
+-- OHLCV K 棒，bucket_start 為該週期起始時間（UTC），日 K 為台灣時間當日零時
+-- 分 K 的成交量與成交金額為該週期內的增量，日 K 為當日累計值
+CREATE TABLE IF NOT EXISTS stock_bars (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    stock_code VARCHAR(10) NOT NULL,
+    interval VARCHAR(5) NOT NULL, -- 1m, 5m, 1d
+    bucket_start DATETIME NOT NULL,
+    open_price DECIMAL(10,2) NOT NULL,
+    high_price DECIMAL(10,2) NOT NULL,
+    low_price DECIMAL(10,2) NOT NULL,
+    close_price DECIMAL(10,2) NOT NULL,
+    volume BIGINT NOT NULL DEFAULT 0,
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(stock_code, interval, bucket_start),
+    FOREIGN KEY (stock_code) REFERENCES stocks(code) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_bars_interval_time ON stock_bars(interval, bucket_start);
@@ -0,0 +1,8 @@
+-- 金流退款冪等鍵：同一來源（例如 return:12、order:34）只能有一筆處理中或已成功的退款
+-- 退款先以 pending 寫入並預留付款的退款額度，金流商回應後再更新為 succeeded 或 failed
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE payment_refunds ADD COLUMN idempotency_key VARCHAR(50);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_refunds_idempotency
+    ON payment_refunds(idempotency_key) WHERE idempotency_key IS NOT NULL AND status != 'failed';
@@ -0,0 +1,7 @@
+-- 訂單商品記錄下單時計入的特價活動，取消訂單時據此扣回活動售出數量
+-- 既有訂單商品沒有紀錄，取消時不會扣回
+
+-- 注意：ADD COLUMN 需放在最前面，重複執行時會因欄位已存在而略過本檔其餘語句
+ALTER TABLE order_items ADD COLUMN sale_campaign_id INTEGER REFERENCES sale_campaigns(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_sale_campaign ON order_items(sale_campaign_id);
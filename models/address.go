@@ -0,0 +1,235 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// 地址預設用途
+const (
+	AddressUsageShipping = "shipping"
+	AddressUsageBilling  = "billing"
+)
+
+// MaxCustomerAddresses 每位客戶可儲存的地址數量上限
+const MaxCustomerAddresses = 20
+
+// Address 臺灣地址與收件人，下單時以此結構快照到訂單
+type Address struct {
+	RecipientName string `json:"recipient_name" db:"recipient_name"`
+	Phone         string `json:"phone" db:"phone"`
+	PostalCode    string `json:"postal_code" db:"postal_code"` // 3 碼，或 3+2、3+3 碼郵遞區號
+	City          string `json:"city" db:"city"`
+	District      string `json:"district" db:"district"`
+	Street        string `json:"street" db:"street"` // 路街、巷弄、門牌與樓層
+}
+
+// FullAddress 組合郵遞區號、縣市、鄉鎮市區與街道的完整地址
+func (a *Address) FullAddress() string {
+	return a.PostalCode + " " + a.City + a.District + a.Street
+}
+
+// CustomerAddress 客戶通訊錄中的地址
+type CustomerAddress struct {
+	ID         int     `json:"id" db:"id"`
+	CustomerID int     `json:"customer_id" db:"customer_id"`
+	Label      *string `json:"label,omitempty" db:"label"` // 例如「住家」、「公司」
+	Address
+	IsDefaultShipping bool      `json:"is_default_shipping" db:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing" db:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AddressRequest 建立或更新地址請求
+type AddressRequest struct {
+	Label             *string `json:"label,omitempty"`
+	RecipientName     string  `json:"recipient_name" binding:"required"`
+	Phone             string  `json:"phone" binding:"required"`
+	PostalCode        string  `json:"postal_code"` // 未填時依鄉鎮市區帶入 3 碼郵遞區號
+	City              string  `json:"city" binding:"required"`
+	District          string  `json:"district" binding:"required"`
+	Street            string  `json:"street" binding:"required"`
+	IsDefaultShipping bool    `json:"is_default_shipping"`
+	IsDefaultBilling  bool    `json:"is_default_billing"`
+}
+
+// SetDefaultAddressRequest 設定預設地址請求
+type SetDefaultAddressRequest struct {
+	Usage string `json:"usage" binding:"required"` // shipping 或 billing
+}
+
+// AddressSnapshotValue 將地址快照序列化為 JSON，nil 時寫入 NULL
+func AddressSnapshotValue(address *Address) (interface{}, error) {
+	if address == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(address)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// parseAddressSnapshot 解析訂單中的地址快照 JSON
+func parseAddressSnapshot(value sql.NullString) (*Address, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	address := &Address{}
+	if err := json.Unmarshal([]byte(value.String), address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// AddressRepository 客戶地址數據庫操作
+type AddressRepository struct {
+	db *sql.DB
+}
+
+// NewAddressRepository 創建客戶地址倉庫
+func NewAddressRepository(db *sql.DB) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+// addressColumns 地址查詢欄位，順序需與 scanAddress 一致
+const addressColumns = `id, customer_id, label, recipient_name, phone, postal_code, city, district, street,
+	is_default_shipping, is_default_billing, created_at, updated_at`
+
+// scanAddress 掃描一筆地址資料
+func scanAddress(scanner rowScanner) (*CustomerAddress, error) {
+	address := &CustomerAddress{}
+	err := scanner.Scan(&address.ID, &address.CustomerID, &address.Label, &address.RecipientName, &address.Phone,
+		&address.PostalCode, &address.City, &address.District, &address.Street, &address.IsDefaultShipping,
+		&address.IsDefaultBilling, &address.CreatedAt, &address.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// GetByCustomerID 獲取客戶的所有地址，預設地址在前
+func (r *AddressRepository) GetByCustomerID(customerID int) ([]*CustomerAddress, error) {
+	rows, err := r.db.Query(`SELECT `+addressColumns+` FROM customer_addresses WHERE customer_id = ?
+		ORDER BY is_default_shipping DESC, is_default_billing DESC, id DESC`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []*CustomerAddress{}
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// GetByID 獲取客戶的指定地址
+func (r *AddressRepository) GetByID(customerID, id int) (*CustomerAddress, error) {
+	return scanAddress(r.db.QueryRow(`SELECT `+addressColumns+` FROM customer_addresses WHERE id = ? AND customer_id = ?`,
+		id, customerID))
+}
+
+// GetDefault 獲取客戶指定用途的預設地址
+func (r *AddressRepository) GetDefault(customerID int, usage string) (*CustomerAddress, error) {
+	column := "is_default_shipping"
+	if usage == AddressUsageBilling {
+		column = "is_default_billing"
+	}
+	return scanAddress(r.db.QueryRow(`SELECT `+addressColumns+` FROM customer_addresses
+		WHERE customer_id = ? AND `+column+` = 1`, customerID))
+}
+
+// CountByCustomerID 計算客戶已儲存的地址數量
+func (r *AddressRepository) CountByCustomerID(customerID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM customer_addresses WHERE customer_id = ?`, customerID).Scan(&count)
+	return count, err
+}
+
+// CreateTx 在交易中創建地址
+func (r *AddressRepository) CreateTx(tx *sql.Tx, address *CustomerAddress) error {
+	result, err := tx.Exec(`
+		INSERT INTO customer_addresses (customer_id, label, recipient_name, phone, postal_code, city, district,
+		                                street, is_default_shipping, is_default_billing)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		address.CustomerID, address.Label, address.RecipientName, address.Phone, address.PostalCode, address.City,
+		address.District, address.Street, address.IsDefaultShipping, address.IsDefaultBilling)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	address.ID = int(id)
+
+	return tx.QueryRow(`SELECT created_at, updated_at FROM customer_addresses WHERE id = ?`, address.ID).
+		Scan(&address.CreatedAt, &address.UpdatedAt)
+}
+
+// UpdateTx 在交易中更新地址
+func (r *AddressRepository) UpdateTx(tx *sql.Tx, address *CustomerAddress) error {
+	_, err := tx.Exec(`
+		UPDATE customer_addresses SET label = ?, recipient_name = ?, phone = ?, postal_code = ?, city = ?,
+		                              district = ?, street = ?, is_default_shipping = ?, is_default_billing = ?,
+		                              updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND customer_id = ?`,
+		address.Label, address.RecipientName, address.Phone, address.PostalCode, address.City, address.District,
+		address.Street, address.IsDefaultShipping, address.IsDefaultBilling, address.ID, address.CustomerID)
+	return err
+}
+
+// DeleteTx 在交易中刪除地址
+func (r *AddressRepository) DeleteTx(tx *sql.Tx, customerID, id int) error {
+	result, err := tx.Exec(`DELETE FROM customer_addresses WHERE id = ? AND customer_id = ?`, id, customerID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+// ClearDefaultTx 在交易中取消客戶其他地址的預設用途，保留 exceptID
+func (r *AddressRepository) ClearDefaultTx(tx *sql.Tx, customerID int, usage string, exceptID int) error {
+	column := "is_default_shipping"
+	if usage == AddressUsageBilling {
+		column = "is_default_billing"
+	}
+	_, err := tx.Exec(`UPDATE customer_addresses SET `+column+` = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = ? AND id != ? AND `+column+` = 1`, customerID, exceptID)
+	return err
+}
+
+// EnsureDefaultsTx 在交易中補上缺少的預設地址，以最近建立的地址作為預設
+func (r *AddressRepository) EnsureDefaultsTx(tx *sql.Tx, customerID int) error {
+	for _, column := range []string{"is_default_shipping", "is_default_billing"} {
+		_, err := tx.Exec(`
+			UPDATE customer_addresses SET `+column+` = 1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = (SELECT id FROM customer_addresses WHERE customer_id = ? ORDER BY id DESC LIMIT 1)
+			  AND NOT EXISTS (SELECT 1 FROM customer_addresses WHERE customer_id = ? AND `+column+` = 1)`,
+			customerID, customerID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	ErrAddressNotFound      = notFoundError("ADDRESS_NOT_FOUND", "地址不存在")
+	ErrAddressLimitExceeded = &DomainError{Code: "ADDRESS_LIMIT_EXCEEDED", Message: "地址數量已達上限"}
+)
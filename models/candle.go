@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// K 線週期
+const (
+	CandleResolution1m    = "1m"
+	CandleResolution5m    = "5m"
+	CandleResolution15m   = "15m"
+	CandleResolution60m   = "60m"
+	CandleResolutionDay   = "day"
+	CandleResolutionWeek  = "week"
+	CandleResolutionMonth = "month"
+)
+
+// Candle K 線，週 K 與月 K 的時間為該期間第一個交易日
+type Candle struct {
+	Time     time.Time `json:"time"`
+	Open     float64   `json:"open"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Close    float64   `json:"close"`
+	Volume   int64     `json:"volume"`
+	Amount   float64   `json:"amount"`
+	Complete bool      `json:"complete"` // 週期已結束，之後不會再變動
+}
+
+// CandleSeries K 線查詢結果
+type CandleSeries struct {
+	StockCode  string    `json:"stock_code"`
+	Resolution string    `json:"resolution"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Candles    []Candle  `json:"candles"`
+}
+
+// NormalizeCandleResolution 將週期別名（1d、1w、1M）轉為標準名稱，不支援時回傳空字串
+func NormalizeCandleResolution(resolution string) string {
+	switch resolution {
+	case CandleResolution1m, CandleResolution5m, CandleResolution15m, CandleResolution60m,
+		CandleResolutionDay, CandleResolutionWeek, CandleResolutionMonth:
+		return resolution
+	case "1h":
+		return CandleResolution60m
+	case "1d":
+		return CandleResolutionDay
+	case "1w":
+		return CandleResolutionWeek
+	case "1M":
+		return CandleResolutionMonth
+	}
+	return ""
+}
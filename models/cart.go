@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -21,13 +22,100 @@ type CartItem struct {
 	ID        int      `json:"id" db:"id"`
 	CartID    int      `json:"cart_id" db:"cart_id"`
 	ProductID int      `json:"product_id" db:"product_id"`
+	VariantID int      `json:"variant_id" db:"variant_id"` // 0 表示商品沒有規格
 	Quantity  int      `json:"quantity" db:"quantity"`
-	Price     float64  `json:"price" db:"price"`
+	Price     float64  `json:"price" db:"price"` // 加入或最後同步時的商品價格
 	Product   *Product `json:"product,omitempty"`
+	Variant   *ProductVariant `json:"variant,omitempty"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// UnitPrice 項目目前的單價：有規格時為規格售價，否則為商品售價
+func (item *CartItem) UnitPrice() float64 {
+	if item.Variant != nil {
+		return item.Variant.UnitPrice
+	}
+	if item.Product != nil {
+		return item.Product.Price
+	}
+	return item.Price
+}
+
+// cartItemColumns 購物車項目查詢欄位（含商品與規格），順序需與 scanCartItem 一致，需搭配 cartItemFrom 使用
+const cartItemColumns = `sc.id, sc.product_id, sc.variant_id, sc.quantity, COALESCE(sc.price, v.price, p.price),
+	sc.created_at, sc.updated_at,
+	p.name, p.description, p.price, p.original_price, p.category,
+	p.sub_category, p.brand, p.sku, p.stock, p.reserved_stock, p.image_url, p.images,
+	p.tags, p.is_active, p.is_featured, p.is_on_sale, p.merchant_id,
+	p.view_count, p.sales_count, p.rating, p.review_count, p.weight,
+	p.dimensions, p.created_at as product_created_at, p.updated_at as product_updated_at,
+	v.sku, v.options, v.option_key, v.price, COALESCE(v.price, p.price), v.stock, v.reserved_stock, v.is_active,
+	v.position, v.created_at, v.updated_at`
+
+// cartItemFrom 購物車項目查詢來源
+const cartItemFrom = ` FROM shopping_cart sc
+	JOIN products p ON sc.product_id = p.id
+	LEFT JOIN product_variants v ON v.id = sc.variant_id AND v.product_id = sc.product_id`
+
+// scanCartItem 掃描一筆購物車項目
+func scanCartItem(scanner rowScanner) (CartItem, error) {
+	item := CartItem{}
+	product := &Product{}
+	var variantSKU, variantOptions, variantKey sql.NullString
+	var variantPrice sql.NullFloat64
+	var variantUnitPrice float64
+	var variantStock, variantReserved sql.NullInt64
+	var variantActive sql.NullBool
+	var variantPosition sql.NullInt64
+	var variantCreatedAt, variantUpdatedAt sql.NullTime
+
+	err := scanner.Scan(
+		&item.ID, &item.ProductID, &item.VariantID, &item.Quantity, &item.Price, &item.CreatedAt, &item.UpdatedAt,
+		&product.Name, &product.Description, &product.Price, &product.OriginalPrice,
+		&product.Category, &product.SubCategory, &product.Brand, &product.SKU,
+		&product.Stock, &product.ReservedStock, &product.ImageURL, &product.Images, &product.Tags,
+		&product.IsActive, &product.IsFeatured, &product.IsOnSale, &product.MerchantID,
+		&product.ViewCount, &product.SalesCount, &product.Rating, &product.ReviewCount,
+		&product.Weight, &product.Dimensions, &product.CreatedAt, &product.UpdatedAt,
+		&variantSKU, &variantOptions, &variantKey, &variantPrice, &variantUnitPrice,
+		&variantStock, &variantReserved, &variantActive,
+		&variantPosition, &variantCreatedAt, &variantUpdatedAt)
+	if err != nil {
+		return item, err
+	}
+
+	product.ID = item.ProductID
+	product.AvailableStock = product.Stock - product.ReservedStock
+	item.Product = product
+
+	if item.VariantID > 0 && variantSKU.Valid {
+		variant := &ProductVariant{
+			ID:            item.VariantID,
+			ProductID:     item.ProductID,
+			SKU:           variantSKU.String,
+			Name:          VariantName(variantKey.String),
+			UnitPrice:     variantUnitPrice,
+			Stock:         int(variantStock.Int64),
+			ReservedStock: int(variantReserved.Int64),
+			IsActive:      variantActive.Bool,
+			Position:      int(variantPosition.Int64),
+			CreatedAt:     variantCreatedAt.Time,
+			UpdatedAt:     variantUpdatedAt.Time,
+		}
+		if variantPrice.Valid {
+			variant.Price = &variantPrice.Float64
+		}
+		if err := json.Unmarshal([]byte(variantOptions.String), &variant.Options); err != nil {
+			return item, err
+		}
+		variant.AvailableStock = variant.Stock - variant.ReservedStock
+		item.Variant = variant
+	}
+
+	return item, nil
+}
+
 // CartRepository 購物車數據庫操作
 type CartRepository struct {
 	db *sql.DB
@@ -48,17 +136,9 @@ func (r *CartRepository) GetCartByCustomerID(customerID int) (*Cart, error) {
 	}
 
 	// 獲取購物車項目
-	query := `
-		SELECT sc.id, sc.product_id, sc.quantity, sc.created_at, sc.updated_at,
-		       p.name, p.description, p.price, p.original_price, p.category, 
-		       p.sub_category, p.brand, p.sku, p.stock, p.image_url, p.images, 
-		       p.tags, p.is_active, p.is_featured, p.is_on_sale, p.merchant_id,
-		       p.view_count, p.sales_count, p.rating, p.review_count, p.weight,
-		       p.dimensions, p.created_at as product_created_at, p.updated_at as product_updated_at
-		FROM shopping_cart sc
-		JOIN products p ON sc.product_id = p.id
+	query := `SELECT ` + cartItemColumns + cartItemFrom + `
 		WHERE sc.customer_id = ? AND p.is_active = 1
-		ORDER BY sc.created_at DESC`
+		ORDER BY sc.created_at DESC, sc.id DESC`
 
 	rows, err := r.db.Query(query, customerID)
 	if err != nil {
@@ -68,25 +148,11 @@ func (r *CartRepository) GetCartByCustomerID(customerID int) (*Cart, error) {
 
 	var items []CartItem
 	for rows.Next() {
-		item := CartItem{}
-		product := &Product{}
-		
-		err := rows.Scan(
-			&item.ID, &item.ProductID, &item.Quantity, &item.CreatedAt, &item.UpdatedAt,
-			&product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-			&product.Category, &product.SubCategory, &product.Brand, &product.SKU,
-			&product.Stock, &product.ImageURL, &product.Images, &product.Tags,
-			&product.IsActive, &product.IsFeatured, &product.IsOnSale, &product.MerchantID,
-			&product.ViewCount, &product.SalesCount, &product.Rating, &product.ReviewCount,
-			&product.Weight, &product.Dimensions, &product.CreatedAt, &product.UpdatedAt)
-		
+		item, err := scanCartItem(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		product.ID = item.ProductID
-		item.Product = product
-		item.Price = product.Price
 		item.CartID = cart.ID // 這裡會在後續優化中處理
 		
 		items = append(items, item)
@@ -99,12 +165,27 @@ func (r *CartRepository) GetCartByCustomerID(customerID int) (*Cart, error) {
 	return cart, nil
 }
 
-// AddItemToCart 添加商品到購物車
-func (r *CartRepository) AddItemToCart(customerID, productID, quantity int) error {
-	// 檢查商品是否存在且可用
+// stockAvailability 獲取商品（或商品規格）是否可售、可售數量與目前售價
+func (r *CartRepository) stockAvailability(productID, variantID int) (bool, int, float64, error) {
 	var isActive bool
 	var stock int
-	err := r.db.QueryRow("SELECT is_active, stock FROM products WHERE id = ?", productID).Scan(&isActive, &stock)
+	var price float64
+	if variantID > 0 {
+		err := r.db.QueryRow(`
+			SELECT p.is_active = 1 AND v.is_active = 1, v.stock - v.reserved_stock, COALESCE(v.price, p.price)
+			FROM product_variants v JOIN products p ON p.id = v.product_id
+			WHERE v.id = ? AND v.product_id = ?`, variantID, productID).Scan(&isActive, &stock, &price)
+		return isActive, stock, price, err
+	}
+
+	err := r.db.QueryRow("SELECT is_active, stock - reserved_stock, price FROM products WHERE id = ?", productID).Scan(&isActive, &stock, &price)
+	return isActive, stock, price, err
+}
+
+// AddItemToCart 添加商品到購物車，variantID 為 0 表示商品沒有規格
+func (r *CartRepository) AddItemToCart(customerID, productID, variantID, quantity int) error {
+	// 檢查商品是否存在且可用
+	isActive, stock, price, err := r.stockAvailability(productID, variantID)
 	if err != nil {
 		return err
 	}
@@ -117,13 +198,13 @@ func (r *CartRepository) AddItemToCart(customerID, productID, quantity int) erro
 
 	// 檢查購物車中是否已有該商品
 	var existingQuantity int
-	err = r.db.QueryRow("SELECT quantity FROM shopping_cart WHERE customer_id = ? AND product_id = ?", 
-		customerID, productID).Scan(&existingQuantity)
+	err = r.db.QueryRow("SELECT quantity FROM shopping_cart WHERE customer_id = ? AND product_id = ? AND variant_id = ?", 
+		customerID, productID, variantID).Scan(&existingQuantity)
 	
 	if err == sql.ErrNoRows {
 		// 商品不在購物車中，直接添加
-		query := `INSERT INTO shopping_cart (customer_id, product_id, quantity) VALUES (?, ?, ?)`
-		_, err = r.db.Exec(query, customerID, productID, quantity)
+		query := `INSERT INTO shopping_cart (customer_id, product_id, variant_id, quantity, price) VALUES (?, ?, ?, ?, ?)`
+		_, err = r.db.Exec(query, customerID, productID, variantID, quantity, price)
 		return err
 	} else if err != nil {
 		return err
@@ -135,29 +216,31 @@ func (r *CartRepository) AddItemToCart(customerID, productID, quantity int) erro
 		return ErrInsufficientStock
 	}
 
-	query := `UPDATE shopping_cart SET quantity = ?, updated_at = CURRENT_TIMESTAMP WHERE customer_id = ? AND product_id = ?`
-	_, err = r.db.Exec(query, newQuantity, customerID, productID)
+	query := `UPDATE shopping_cart SET quantity = ?, updated_at = CURRENT_TIMESTAMP WHERE customer_id = ? AND product_id = ? AND variant_id = ?`
+	_, err = r.db.Exec(query, newQuantity, customerID, productID, variantID)
 	return err
 }
 
 // UpdateCartItem 更新購物車商品數量
-func (r *CartRepository) UpdateCartItem(customerID, productID, quantity int) error {
+func (r *CartRepository) UpdateCartItem(customerID, productID, variantID, quantity int) error {
 	if quantity <= 0 {
-		return r.RemoveItemFromCart(customerID, productID)
+		return r.RemoveItemFromCart(customerID, productID, variantID)
 	}
 
 	// 檢查庫存
-	var stock int
-	err := r.db.QueryRow("SELECT stock FROM products WHERE id = ? AND is_active = 1", productID).Scan(&stock)
+	isActive, stock, _, err := r.stockAvailability(productID, variantID)
 	if err != nil {
 		return err
 	}
+	if !isActive {
+		return ErrProductNotAvailable
+	}
 	if stock < quantity {
 		return ErrInsufficientStock
 	}
 
-	query := `UPDATE shopping_cart SET quantity = ?, updated_at = CURRENT_TIMESTAMP WHERE customer_id = ? AND product_id = ?`
-	result, err := r.db.Exec(query, quantity, customerID, productID)
+	query := `UPDATE shopping_cart SET quantity = ?, updated_at = CURRENT_TIMESTAMP WHERE customer_id = ? AND product_id = ? AND variant_id = ?`
+	result, err := r.db.Exec(query, quantity, customerID, productID, variantID)
 	if err != nil {
 		return err
 	}
@@ -174,9 +257,9 @@ func (r *CartRepository) UpdateCartItem(customerID, productID, quantity int) err
 }
 
 // RemoveItemFromCart 從購物車移除商品
-func (r *CartRepository) RemoveItemFromCart(customerID, productID int) error {
-	query := `DELETE FROM shopping_cart WHERE customer_id = ? AND product_id = ?`
-	result, err := r.db.Exec(query, customerID, productID)
+func (r *CartRepository) RemoveItemFromCart(customerID, productID, variantID int) error {
+	query := `DELETE FROM shopping_cart WHERE customer_id = ? AND product_id = ? AND variant_id = ?`
+	result, err := r.db.Exec(query, customerID, productID, variantID)
 	if err != nil {
 		return err
 	}
@@ -199,6 +282,39 @@ func (r *CartRepository) ClearCart(customerID int) error {
 	return err
 }
 
+// ClearCartTx 在交易中清空購物車
+func (r *CartRepository) ClearCartTx(tx *sql.Tx, customerID int) error {
+	_, err := tx.Exec(`DELETE FROM shopping_cart WHERE customer_id = ?`, customerID)
+	return err
+}
+
+// UpdateItemPrice 更新購物車項目記錄的商品價格
+func (r *CartRepository) UpdateItemPrice(customerID, productID, variantID int, price float64) error {
+	query := `UPDATE shopping_cart SET price = ?, updated_at = CURRENT_TIMESTAMP WHERE customer_id = ? AND product_id = ? AND variant_id = ?`
+	_, err := r.db.Exec(query, price, customerID, productID, variantID)
+	return err
+}
+
+// GetCustomerIDsByProductID 獲取購物車中有該商品的客戶
+func (r *CartRepository) GetCustomerIDsByProductID(productID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT customer_id FROM shopping_cart WHERE product_id = ?`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customerIDs []int
+	for rows.Next() {
+		var customerID int
+		if err := rows.Scan(&customerID); err != nil {
+			return nil, err
+		}
+		customerIDs = append(customerIDs, customerID)
+	}
+
+	return customerIDs, rows.Err()
+}
+
 // GetCartItemCount 獲取購物車商品數量
 func (r *CartRepository) GetCartItemCount(customerID int) (int, error) {
 	var count int
@@ -207,40 +323,17 @@ func (r *CartRepository) GetCartItemCount(customerID int) (int, error) {
 	return count, err
 }
 
-// GetCartItemByProductID 根據商品ID獲取購物車項目
-func (r *CartRepository) GetCartItemByProductID(customerID, productID int) (*CartItem, error) {
-	item := &CartItem{}
-	product := &Product{}
-	
-	query := `
-		SELECT sc.id, sc.product_id, sc.quantity, sc.created_at, sc.updated_at,
-		       p.name, p.description, p.price, p.original_price, p.category, 
-		       p.sub_category, p.brand, p.sku, p.stock, p.image_url, p.images, 
-		       p.tags, p.is_active, p.is_featured, p.is_on_sale, p.merchant_id,
-		       p.view_count, p.sales_count, p.rating, p.review_count, p.weight,
-		       p.dimensions, p.created_at as product_created_at, p.updated_at as product_updated_at
-		FROM shopping_cart sc
-		JOIN products p ON sc.product_id = p.id
-		WHERE sc.customer_id = ? AND sc.product_id = ?`
-
-	err := r.db.QueryRow(query, customerID, productID).Scan(
-		&item.ID, &item.ProductID, &item.Quantity, &item.CreatedAt, &item.UpdatedAt,
-		&product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-		&product.Category, &product.SubCategory, &product.Brand, &product.SKU,
-		&product.Stock, &product.ImageURL, &product.Images, &product.Tags,
-		&product.IsActive, &product.IsFeatured, &product.IsOnSale, &product.MerchantID,
-		&product.ViewCount, &product.SalesCount, &product.Rating, &product.ReviewCount,
-		&product.Weight, &product.Dimensions, &product.CreatedAt, &product.UpdatedAt)
+// GetCartItemByProductID 根據商品ID與規格ID獲取購物車項目
+func (r *CartRepository) GetCartItemByProductID(customerID, productID, variantID int) (*CartItem, error) {
+	query := `SELECT ` + cartItemColumns + cartItemFrom + `
+		WHERE sc.customer_id = ? AND sc.product_id = ? AND sc.variant_id = ?`
 
+	item, err := scanCartItem(r.db.QueryRow(query, customerID, productID, variantID))
 	if err != nil {
 		return nil, err
 	}
 
-	product.ID = item.ProductID
-	item.Product = product
-	item.Price = product.Price
-
-	return item, nil
+	return &item, nil
 }
 
 // calculateCartTotal 計算購物車總價
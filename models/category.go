@@ -0,0 +1,397 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ProductCategory 商品分類，以 parent_id 形成分類樹
+type ProductCategory struct {
+	ID           int                `json:"id" db:"id"`
+	Name         string             `json:"name" db:"name"`
+	Description  *string            `json:"description,omitempty" db:"description"`
+	ParentID     *int               `json:"parent_id,omitempty" db:"parent_id"`
+	ImageURL     *string            `json:"image_url,omitempty" db:"image_url"`
+	SortOrder    int                `json:"sort_order" db:"sort_order"`
+	IsActive     bool               `json:"is_active" db:"is_active"`
+	ProductCount int                `json:"product_count" db:"-"` // 直接歸屬此分類的上架商品數
+	Children     []*ProductCategory `json:"children,omitempty" db:"-"`
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// CategoryRequest 新增或修改分類請求
+type CategoryRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description,omitempty"`
+	ParentID    *int    `json:"parent_id,omitempty"` // 未指定時為根分類
+	ImageURL    *string `json:"image_url,omitempty"`
+	SortOrder   int     `json:"sort_order"`
+	IsActive    *bool   `json:"is_active,omitempty"` // 未指定時為啟用
+}
+
+// CategoryRepository 商品分類數據庫操作
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+// NewCategoryRepository 創建商品分類倉庫
+func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// categoryColumns 分類查詢欄位，順序需與 scanCategory 一致
+const categoryColumns = `c.id, c.name, c.description, c.parent_id, c.image_url, c.sort_order, c.is_active,
+	c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM products p WHERE p.category_id = c.id AND p.is_active = 1)`
+
+// scanCategory 掃描一筆分類資料
+func scanCategory(scanner rowScanner) (*ProductCategory, error) {
+	category := &ProductCategory{}
+	err := scanner.Scan(&category.ID, &category.Name, &category.Description, &category.ParentID,
+		&category.ImageURL, &category.SortOrder, &category.IsActive, &category.CreatedAt, &category.UpdatedAt,
+		&category.ProductCount)
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// categoryDescendantsCTE 以第一個參數為根，列出自身與所有子孫分類的 id
+// 遞迴深度限制為 32 層，避免資料異常形成循環時無限遞迴
+const categoryDescendantsCTE = `WITH RECURSIVE category_tree(id, depth) AS (
+		SELECT id, 0 FROM product_categories WHERE id = ?
+		UNION ALL
+		SELECT c.id, t.depth + 1 FROM product_categories c JOIN category_tree t ON c.parent_id = t.id
+		WHERE t.depth < 32
+	)`
+
+// categoryAncestorsCTE 以第一個參數為起點，列出自身與所有祖先分類，depth 0 為自身
+const categoryAncestorsCTE = `WITH RECURSIVE category_path(id, parent_id, name, depth) AS (
+		SELECT id, parent_id, name, 0 FROM product_categories WHERE id = ?
+		UNION ALL
+		SELECT c.id, c.parent_id, c.name, p.depth + 1 FROM product_categories c JOIN category_path p ON c.id = p.parent_id
+		WHERE p.depth < 32
+	)`
+
+// GetAll 獲取所有分類（平面列表），activeOnly 為 true 時只回傳啟用中的分類
+func (r *CategoryRepository) GetAll(activeOnly bool) ([]*ProductCategory, error) {
+	query := `SELECT ` + categoryColumns + ` FROM product_categories c`
+	if activeOnly {
+		query += ` WHERE c.is_active = 1`
+	}
+	query += ` ORDER BY c.sort_order, c.id`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*ProductCategory{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// BuildCategoryTree 將平面分類列表組成分類樹，父分類不在列表中（例如已停用）的分類不會出現在樹中
+func BuildCategoryTree(categories []*ProductCategory) []*ProductCategory {
+	byID := make(map[int]*ProductCategory, len(categories))
+	for _, category := range categories {
+		category.Children = nil
+		byID[category.ID] = category
+	}
+
+	roots := []*ProductCategory{}
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		if parent, ok := byID[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, category)
+		}
+	}
+
+	return roots
+}
+
+// GetByID 根據ID獲取分類
+func (r *CategoryRepository) GetByID(id int) (*ProductCategory, error) {
+	category, err := scanCategory(r.db.QueryRow(`SELECT `+categoryColumns+` FROM product_categories c WHERE c.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// GetAncestors 獲取分類的麵包屑路徑，由根分類排到分類本身
+func (r *CategoryRepository) GetAncestors(id int) ([]*ProductCategory, error) {
+	return getCategoryAncestors(r.db, id)
+}
+
+func getCategoryAncestors(db dbQuerier, id int) ([]*ProductCategory, error) {
+	rows, err := db.Query(categoryAncestorsCTE+`
+		SELECT `+categoryColumns+` FROM category_path p JOIN product_categories c ON c.id = p.id
+		ORDER BY p.depth DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	path := []*ProductCategory{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, category)
+	}
+
+	return path, rows.Err()
+}
+
+// IsDescendant 判斷 id 是否為 ancestorID 本身或其子孫分類
+func (r *CategoryRepository) IsDescendant(ancestorID, id int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(categoryDescendantsCTE+`
+		SELECT COUNT(*) FROM category_tree WHERE id = ?`, ancestorID, id).Scan(&count)
+	return count > 0, err
+}
+
+// Create 新增分類
+func (r *CategoryRepository) Create(category *ProductCategory) error {
+	result, err := r.db.Exec(`
+		INSERT INTO product_categories (name, description, parent_id, image_url, sort_order, is_active)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		category.Name, category.Description, category.ParentID, category.ImageURL, category.SortOrder,
+		category.IsActive)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	category.ID = int(id)
+
+	return r.db.QueryRow(`SELECT created_at, updated_at FROM product_categories WHERE id = ?`, category.ID).
+		Scan(&category.CreatedAt, &category.UpdatedAt)
+}
+
+// Update 修改分類，並同步更新歸屬於此分類子樹的商品分類名稱快照
+func (r *CategoryRepository) Update(category *ProductCategory) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE product_categories SET name = ?, description = ?, parent_id = ?, image_url = ?, sort_order = ?,
+			is_active = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		category.Name, category.Description, category.ParentID, category.ImageURL, category.SortOrder,
+		category.IsActive, category.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+
+	if err := syncCategorySnapshotsTx(tx, category.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// syncCategorySnapshotsTx 分類名稱或位置變更後，重設子樹內商品的 category 與 sub_category 快照
+func syncCategorySnapshotsTx(tx *sql.Tx, categoryID int) error {
+	rows, err := tx.Query(categoryDescendantsCTE+`SELECT id FROM category_tree`, categoryID)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		root, sub, err := categorySnapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE products SET category = ?, sub_category = COALESCE(?, sub_category),
+			updated_at = CURRENT_TIMESTAMP
+			WHERE category_id = ?`, root, sub, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete 刪除分類，仍有子分類或商品時拒絕
+func (r *CategoryRepository) Delete(id int) error {
+	var children, products int
+	err := r.db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM product_categories WHERE parent_id = ?),
+		(SELECT COUNT(*) FROM products WHERE category_id = ?)`, id, id).Scan(&children, &products)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrCategoryHasChildren
+	}
+	if products > 0 {
+		return ErrCategoryInUse
+	}
+
+	result, err := r.db.Exec(`DELETE FROM product_categories WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// categorySnapshot 回傳分類的根分類名稱，以及分類本身不是根分類時的名稱（作為 sub_category）
+func categorySnapshot(db dbExecutor, categoryID int) (string, *string, error) {
+	var root, leaf string
+	var depth int
+	err := db.QueryRow(categoryAncestorsCTE+`
+		SELECT (SELECT name FROM category_path ORDER BY depth DESC LIMIT 1), name,
+		       (SELECT MAX(depth) FROM category_path)
+		FROM category_path WHERE depth = 0`, categoryID).Scan(&root, &leaf, &depth)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, ErrCategoryNotFound
+		}
+		return "", nil, err
+	}
+
+	if depth == 0 {
+		return root, nil, nil
+	}
+	return root, &leaf, nil
+}
+
+// resolveProductCategory 寫入商品前整理分類欄位
+// 指定 category_id 時以分類樹覆寫 category 與 sub_category 快照；只給分類名稱時依名稱補上 category_id
+func resolveProductCategory(db dbExecutor, product *Product) error {
+	if product.CategoryID != nil {
+		root, sub, err := categorySnapshot(db, *product.CategoryID)
+		if err != nil {
+			return err
+		}
+		product.Category = root
+		if sub != nil {
+			product.SubCategory = sub
+		}
+		return nil
+	}
+
+	if product.Category == "" {
+		return nil
+	}
+
+	var id int
+	err := db.QueryRow(`SELECT id FROM product_categories WHERE name = ?`, product.Category).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	product.CategoryID = &id
+	return nil
+}
+
+// GetByCategoryID 獲取分類及其所有子孫分類下的商品
+func (r *ProductRepository) GetByCategoryID(categoryID, limit, offset int) ([]*Product, error) {
+	query := categoryDescendantsCTE + `
+		SELECT ` + productColumns + ` FROM products
+		WHERE is_active = 1 AND category_id IN (SELECT id FROM category_tree)
+		ORDER BY created_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, categoryID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// LoadBreadcrumbs 載入商品所屬分類的麵包屑路徑
+func (r *ProductRepository) LoadBreadcrumbs(product *Product) error {
+	if product.CategoryID == nil {
+		return nil
+	}
+
+	path, err := getCategoryAncestors(r.db, *product.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	product.Breadcrumbs = make([]CategoryBreadcrumb, 0, len(path))
+	for _, category := range path {
+		product.Breadcrumbs = append(product.Breadcrumbs, CategoryBreadcrumb{ID: category.ID, Name: category.Name})
+	}
+	return nil
+}
+
+// CategoryBreadcrumb 麵包屑中的一層分類
+type CategoryBreadcrumb struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+var (
+	ErrCategoryNotFound    = notFoundError("CATEGORY_NOT_FOUND", "分類不存在")
+	ErrCategoryHasChildren = conflictError("CATEGORY_HAS_CHILDREN", "分類下仍有子分類")
+	ErrCategoryInUse       = conflictError("CATEGORY_IN_USE", "分類下仍有商品")
+	ErrCategoryCycle       = &DomainError{Code: "CATEGORY_CYCLE", Message: "不可將分類移到自身或其子分類之下"}
+	ErrDuplicateCategory   = conflictError("DUPLICATE_CATEGORY", "分類名稱已存在")
+)
@@ -0,0 +1,43 @@
+package models
+
+import "net/http"
+
+// DomainError 業務錯誤，Code 供前端判斷錯誤類型，Status 為回應的 HTTP 狀態碼（未設定時為 400）
+// 各領域的錯誤以此型別定義在各自的模型檔案中，控制器依 Status 回應，不需逐一對應錯誤代碼
+type DomainError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"-"`
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+// HTTPStatus 錯誤對應的 HTTP 狀態碼
+func (e *DomainError) HTTPStatus() int {
+	if e.Status == 0 {
+		return http.StatusBadRequest
+	}
+	return e.Status
+}
+
+// WithMessage 建立代碼與狀態碼相同、訊息較具體的錯誤，例如帶出商品名稱
+func (e *DomainError) WithMessage(message string) *DomainError {
+	return &DomainError{Code: e.Code, Message: message, Status: e.Status}
+}
+
+// notFoundError 資源不存在（404）
+func notFoundError(code, message string) *DomainError {
+	return &DomainError{Code: code, Message: message, Status: http.StatusNotFound}
+}
+
+// conflictError 與目前狀態衝突，例如狀態已被變更或資料重複（409）
+func conflictError(code, message string) *DomainError {
+	return &DomainError{Code: code, Message: message, Status: http.StatusConflict}
+}
+
+// forbiddenError 沒有執行此操作的資格（403）
+func forbiddenError(code, message string) *DomainError {
+	return &DomainError{Code: code, Message: message, Status: http.StatusForbidden}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// IndicatorPoint 單根 K 線的技術指標，資料不足尚無法計算的指標為 null
+type IndicatorPoint struct {
+	Time          time.Time `json:"time"`
+	Close         float64   `json:"close"`
+	MA5           *float64  `json:"ma5"`
+	MA10          *float64  `json:"ma10"`
+	MA20          *float64  `json:"ma20"`
+	MA60          *float64  `json:"ma60"`
+	EMA12         *float64  `json:"ema12"`
+	EMA26         *float64  `json:"ema26"`
+	RSI14         *float64  `json:"rsi14"`
+	MACDDIF       *float64  `json:"macd_dif"`       // 快慢 EMA 差（12、26）
+	MACDSignal    *float64  `json:"macd_signal"`    // DIF 的 9 期 EMA
+	MACDHistogram *float64  `json:"macd_histogram"` // DIF 減 Signal
+	K             *float64  `json:"k"`              // KD(9,3,3)
+	D             *float64  `json:"d"`
+	BollUpper     *float64  `json:"boll_upper"` // 布林通道(20,2)
+	BollMiddle    *float64  `json:"boll_middle"`
+	BollLower     *float64  `json:"boll_lower"`
+}
+
+// StockIndicators 股票技術指標查詢結果
+type StockIndicators struct {
+	StockCode  string           `json:"stock_code"`
+	Resolution string           `json:"resolution"`
+	Latest     *IndicatorPoint  `json:"latest"`  // 最新一根 K 線的指標，沒有歷史資料時為 null
+	Signals    []string         `json:"signals"` // 依最新指標判讀的訊號（超買超賣、交叉、均線排列等）
+	Series     []IndicatorPoint `json:"series,omitempty"`
+}
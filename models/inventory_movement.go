@@ -0,0 +1,202 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 庫存異動原因
+const (
+	MovementReasonOpening          = "opening"           // 期初庫存（新增商品或首次建帳）
+	MovementReasonMerchantEdit     = "merchant_edit"     // 商戶編輯商品時修改庫存
+	MovementReasonOrderPlaced      = "order_placed"      // 下單扣減
+	MovementReasonOrderCancelled   = "order_cancelled"   // 取消訂單回補
+	MovementReasonOrderRefunded    = "order_refunded"    // 未出貨退款回補
+	MovementReasonReturnRestocked  = "return_restocked"  // 退貨核准後回補
+	MovementReasonManualAdjustment = "manual_adjustment" // 手動盤點調整
+	MovementReasonReconciliation   = "reconciliation"    // 依異動帳重設現有庫存，差額僅供稽核，不計入異動帳加總
+)
+
+// 異動參照類型
+const (
+	MovementReferenceOrder  = "order"
+	MovementReferenceReturn = "return"
+)
+
+// InventoryMovement 庫存異動紀錄，寫入後不可修改或刪除
+type InventoryMovement struct {
+	ID            int       `json:"id" db:"id"`
+	ProductID     int       `json:"product_id" db:"product_id"`
+	MerchantID    int       `json:"merchant_id" db:"merchant_id"`
+	Delta         int       `json:"delta" db:"delta"`
+	StockAfter    int       `json:"stock_after" db:"stock_after"`
+	Reason        string    `json:"reason" db:"reason"`
+	ActorType     string    `json:"actor_type" db:"actor_type"`
+	ActorID       int       `json:"actor_id" db:"actor_id"`
+	ReferenceType *string   `json:"reference_type,omitempty" db:"reference_type"`
+	ReferenceID   *int      `json:"reference_id,omitempty" db:"reference_id"`
+	Note          *string   `json:"note,omitempty" db:"note"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// StockAdjustmentRequest 手動調整庫存請求
+type StockAdjustmentRequest struct {
+	Delta     int     `json:"delta" binding:"required"`
+	VariantID int     `json:"variant_id"` // 有規格的商品必填
+	Note      *string `json:"note,omitempty"`
+}
+
+// InventoryReconciliation 庫存對帳結果
+type InventoryReconciliation struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Stock       int    `json:"stock"`        // 商品目前的現有庫存
+	LedgerStock int    `json:"ledger_stock"` // 依異動帳加總的庫存
+	Difference  int    `json:"difference"`   // stock - ledger_stock
+	Consistent  bool   `json:"consistent"`
+}
+
+// InventoryMovementRepository 庫存異動數據庫操作
+type InventoryMovementRepository struct {
+	db *sql.DB
+}
+
+// NewInventoryMovementRepository 創建庫存異動倉庫
+func NewInventoryMovementRepository(db *sql.DB) *InventoryMovementRepository {
+	return &InventoryMovementRepository{db: db}
+}
+
+// RecordTx 在交易中寫入一筆異動，商戶ID與異動後庫存由商品目前資料帶入
+// 需在更新 products.stock 之後呼叫
+func (r *InventoryMovementRepository) RecordTx(tx *sql.Tx, movement *InventoryMovement) error {
+	result, err := tx.Exec(`
+		INSERT INTO inventory_movements (product_id, merchant_id, delta, stock_after, reason,
+		                                 actor_type, actor_id, reference_type, reference_id, note)
+		SELECT id, merchant_id, ?, stock, ?, ?, ?, ?, ?, ? FROM products WHERE id = ?`,
+		movement.Delta, movement.Reason, movement.ActorType, movement.ActorID,
+		movement.ReferenceType, movement.ReferenceID, movement.Note, movement.ProductID)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	movement.ID = int(id)
+
+	return tx.QueryRow(`SELECT merchant_id, stock_after, created_at FROM inventory_movements WHERE id = ?`, id).
+		Scan(&movement.MerchantID, &movement.StockAfter, &movement.CreatedAt)
+}
+
+// RecordOrderItemsTx 在交易中為訂單的每個商品寫入異動，sign 為 -1（扣減）或 1（回補）
+// 需在更新 products.stock 之後呼叫
+func (r *InventoryMovementRepository) RecordOrderItemsTx(tx *sql.Tx, orderID, sign int, reason, actorType string, actorID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO inventory_movements (product_id, merchant_id, delta, stock_after, reason,
+		                                 actor_type, actor_id, reference_type, reference_id)
+		SELECT p.id, p.merchant_id, ? * SUM(oi.quantity), p.stock, ?, ?, ?, ?, ?
+		FROM order_items oi JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = ?
+		GROUP BY p.id ORDER BY p.id`,
+		sign, reason, actorType, actorID, MovementReferenceOrder, orderID, orderID)
+	return err
+}
+
+// RecordOpeningBalances 為尚無任何異動的商品補上期初庫存，回傳補登筆數
+func (r *InventoryMovementRepository) RecordOpeningBalances() (int, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO inventory_movements (product_id, merchant_id, delta, stock_after, reason, actor_type, actor_id)
+		SELECT id, merchant_id, stock, stock, ?, ?, 0 FROM products
+		WHERE NOT EXISTS (SELECT 1 FROM inventory_movements m WHERE m.product_id = products.id)`,
+		MovementReasonOpening, OrderActorSystem)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
+
+// GetByMerchantID 獲取商戶的庫存異動，productID 為 0 時不限商品
+func (r *InventoryMovementRepository) GetByMerchantID(merchantID, productID, limit, offset int) ([]InventoryMovement, error) {
+	rows, err := r.db.Query(`
+		SELECT id, product_id, merchant_id, delta, stock_after, reason, actor_type, actor_id,
+		       reference_type, reference_id, note, created_at
+		FROM inventory_movements
+		WHERE merchant_id = ? AND (? = 0 OR product_id = ?)
+		ORDER BY id DESC LIMIT ? OFFSET ?`,
+		merchantID, productID, productID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []InventoryMovement{}
+	for rows.Next() {
+		movement := InventoryMovement{}
+		err := rows.Scan(&movement.ID, &movement.ProductID, &movement.MerchantID, &movement.Delta,
+			&movement.StockAfter, &movement.Reason, &movement.ActorType, &movement.ActorID,
+			&movement.ReferenceType, &movement.ReferenceID, &movement.Note, &movement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+// CountByMerchantID 計算商戶的庫存異動筆數
+func (r *InventoryMovementRepository) CountByMerchantID(merchantID, productID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM inventory_movements
+		WHERE merchant_id = ? AND (? = 0 OR product_id = ?)`,
+		merchantID, productID, productID).Scan(&count)
+	return count, err
+}
+
+// Reconcile 比對商戶商品的現有庫存與異動帳加總，productID 為 0 時比對全部商品
+func (r *InventoryMovementRepository) Reconcile(merchantID, productID int) ([]InventoryReconciliation, error) {
+	return reconcileProducts(r.db, merchantID, productID)
+}
+
+// ReconcileTx 在交易中比對商戶商品的現有庫存與異動帳加總
+func (r *InventoryMovementRepository) ReconcileTx(tx *sql.Tx, merchantID, productID int) ([]InventoryReconciliation, error) {
+	return reconcileProducts(tx, merchantID, productID)
+}
+
+// reconcileProducts 計算現有庫存與異動帳加總，對帳調整異動本身即為重設到異動帳加總的紀錄，不計入加總
+func reconcileProducts(db dbQuerier, merchantID, productID int) ([]InventoryReconciliation, error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.name, p.stock,
+		       (SELECT COALESCE(SUM(m.delta), 0) FROM inventory_movements m
+		        WHERE m.product_id = p.id AND m.reason != ?)
+		FROM products p
+		WHERE p.merchant_id = ? AND (? = 0 OR p.id = ?)
+		ORDER BY p.id`,
+		MovementReasonReconciliation, merchantID, productID, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []InventoryReconciliation{}
+	for rows.Next() {
+		result := InventoryReconciliation{}
+		if err := rows.Scan(&result.ProductID, &result.ProductName, &result.Stock, &result.LedgerStock); err != nil {
+			return nil, err
+		}
+		result.Difference = result.Stock - result.LedgerStock
+		result.Consistent = result.Difference == 0
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+var (
+	ErrMerchantProductNotFound = notFoundError("PRODUCT_NOT_FOUND", "商品不存在")
+	ErrInvalidStockAdjustment  = &DomainError{Code: "INVALID_STOCK_ADJUSTMENT", Message: "調整後庫存不可低於保留數量或為負數"}
+)
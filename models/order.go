@@ -0,0 +1,360 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 訂單狀態
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCompleted = "completed"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+// 付款狀態
+const (
+	PaymentStatusPending  = "pending"
+	PaymentStatusPaid     = "paid"
+	PaymentStatusFailed   = "failed"
+	PaymentStatusRefunded = "refunded"
+)
+
+// Order 訂單模型
+type Order struct {
+	ID                    int                  `json:"id" db:"id"`
+	OrderNumber           string               `json:"order_number" db:"order_number"`
+	GroupID               *int                 `json:"group_id,omitempty" db:"group_id"`
+	CustomerID            int                  `json:"customer_id" db:"customer_id"`
+	MerchantID            int                  `json:"merchant_id" db:"merchant_id"`
+	Status                string               `json:"status" db:"status"`
+	TotalAmount           float64              `json:"total_amount" db:"total_amount"`
+	ShippingFee           float64              `json:"shipping_fee" db:"shipping_fee"`
+	DiscountAmount        float64              `json:"discount_amount" db:"discount_amount"`
+	ShippingMethodID      *int                 `json:"shipping_method_id,omitempty" db:"shipping_method_id"`
+	ShippingMethod        *string              `json:"shipping_method,omitempty" db:"shipping_method"` // 下單時的運送方式名稱快照
+	PaymentMethod         *string              `json:"payment_method,omitempty" db:"payment_method"`
+	PaymentStatus         string               `json:"payment_status" db:"payment_status"`
+	ShippingAddress       string               `json:"shipping_address" db:"shipping_address"`
+	BillingAddress        *string              `json:"billing_address,omitempty" db:"billing_address"`
+	ShippingAddressDetail *Address             `json:"shipping_address_detail,omitempty" db:"shipping_address_detail"` // 從通訊錄選擇時的收件地址快照
+	BillingAddressDetail  *Address             `json:"billing_address_detail,omitempty" db:"billing_address_detail"`
+	Notes                 *string              `json:"notes,omitempty" db:"notes"`
+	Items                 []OrderItem          `json:"items,omitempty"`
+	History               []OrderStatusHistory `json:"history,omitempty"`
+	CreatedAt             time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// OrderItem 訂單商品模型（商品名稱與價格為下單時快照）
+type OrderItem struct {
+	ID           int       `json:"id" db:"id"`
+	OrderID      int       `json:"order_id" db:"order_id"`
+	ProductID    int       `json:"product_id" db:"product_id"`
+	ProductName  string    `json:"product_name" db:"product_name"`
+	ProductPrice float64   `json:"product_price" db:"product_price"`
+	VariantID    *int      `json:"variant_id,omitempty" db:"variant_id"`
+	VariantName  *string   `json:"variant_name,omitempty" db:"variant_name"` // 下單時的規格名稱快照
+	VariantSKU   *string   `json:"variant_sku,omitempty" db:"variant_sku"`
+	Quantity     int       `json:"quantity" db:"quantity"`
+	TotalPrice   float64   `json:"total_price" db:"total_price"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// PlaceOrderRequest 下單請求
+type PlaceOrderRequest struct {
+	ShippingAddressID *int        `json:"shipping_address_id,omitempty"` // 通訊錄中的收件地址，與 shipping_address 皆未提供時使用預設收件地址
+	ShippingAddress   string      `json:"shipping_address,omitempty"`
+	BillingAddressID  *int        `json:"billing_address_id,omitempty"` // 通訊錄中的帳單地址，與 billing_address 皆未提供時使用預設帳單地址
+	BillingAddress    *string     `json:"billing_address,omitempty"`
+	PaymentMethod     *string     `json:"payment_method,omitempty"`
+	Notes             *string     `json:"notes,omitempty"`
+	ShippingMethods   map[int]int `json:"shipping_methods,omitempty"` // 各商戶選擇的運送方式（merchant_id => shipping_method_id），未指定時使用購物車的選擇
+}
+
+// OrderRepository 訂單數據庫操作
+type OrderRepository struct {
+	db *sql.DB
+}
+
+// NewOrderRepository 創建訂單倉庫
+func NewOrderRepository(db *sql.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// orderColumns 訂單查詢欄位，順序需與 scanOrder 一致
+const orderColumns = `id, order_number, group_id, customer_id, merchant_id, status, total_amount, shipping_fee,
+	discount_amount, payment_method, payment_status, shipping_address, billing_address,
+	notes, shipping_method_id, shipping_method, shipping_address_detail, billing_address_detail, created_at, updated_at`
+
+// rowScanner 同時適用於 *sql.Row 與 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrder 掃描一筆訂單資料
+func scanOrder(scanner rowScanner) (*Order, error) {
+	order := &Order{}
+	var shippingDetail, billingDetail sql.NullString
+	err := scanner.Scan(
+		&order.ID, &order.OrderNumber, &order.GroupID, &order.CustomerID, &order.MerchantID, &order.Status,
+		&order.TotalAmount, &order.ShippingFee, &order.DiscountAmount, &order.PaymentMethod,
+		&order.PaymentStatus, &order.ShippingAddress, &order.BillingAddress, &order.Notes,
+		&order.ShippingMethodID, &order.ShippingMethod, &shippingDetail, &billingDetail,
+		&order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if order.ShippingAddressDetail, err = parseAddressSnapshot(shippingDetail); err != nil {
+		return nil, err
+	}
+	if order.BillingAddressDetail, err = parseAddressSnapshot(billingDetail); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CreateTx 在交易中創建訂單及其商品
+func (r *OrderRepository) CreateTx(tx *sql.Tx, order *Order) error {
+	query := `
+		INSERT INTO orders (order_number, group_id, customer_id, merchant_id, status, total_amount, shipping_fee,
+		                    discount_amount, payment_method, payment_status, shipping_address,
+		                    billing_address, notes, shipping_method_id, shipping_method, shipping_address_detail,
+		                    billing_address_detail)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	shippingDetail, err := AddressSnapshotValue(order.ShippingAddressDetail)
+	if err != nil {
+		return err
+	}
+	billingDetail, err := AddressSnapshotValue(order.BillingAddressDetail)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, order.OrderNumber, order.GroupID, order.CustomerID, order.MerchantID, order.Status,
+		order.TotalAmount, order.ShippingFee, order.DiscountAmount, order.PaymentMethod,
+		order.PaymentStatus, order.ShippingAddress, order.BillingAddress, order.Notes,
+		order.ShippingMethodID, order.ShippingMethod, shippingDetail, billingDetail)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	order.ID = int(id)
+
+	itemQuery := `
+		INSERT INTO order_items (order_id, product_id, product_name, product_price, variant_id, variant_name,
+			variant_sku, quantity, total_price)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = order.ID
+		result, err := tx.Exec(itemQuery, item.OrderID, item.ProductID, item.ProductName,
+			item.ProductPrice, item.VariantID, item.VariantName, item.VariantSKU, item.Quantity, item.TotalPrice)
+		if err != nil {
+			return err
+		}
+		itemID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		item.ID = int(itemID)
+	}
+
+	// 獲取創建時間
+	return tx.QueryRow(`SELECT created_at, updated_at FROM orders WHERE id = ?`, order.ID).
+		Scan(&order.CreatedAt, &order.UpdatedAt)
+}
+
+// GetByID 根據ID獲取訂單（含商品）
+func (r *OrderRepository) GetByID(id int) (*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = ?`
+
+	order, err := scanOrder(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := r.GetItems(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+
+	return order, nil
+}
+
+// GetByCustomerID 獲取客戶的訂單列表
+func (r *OrderRepository) GetByCustomerID(customerID, limit, offset int) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE customer_id = ?
+	          ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, customerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		items, err := r.GetItems(order.ID)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = items
+	}
+
+	return orders, nil
+}
+
+// CountByCustomerID 獲取客戶的訂單總數
+func (r *OrderRepository) CountByCustomerID(customerID int) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM orders WHERE customer_id = ?", customerID).Scan(&count)
+	return count, err
+}
+
+// GetByMerchantID 獲取包含商戶商品的訂單列表（只帶出該商戶的訂單商品）
+func (r *OrderRepository) GetByMerchantID(merchantID int, status string, limit, offset int) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders
+	          WHERE id IN (SELECT oi.order_id FROM order_items oi
+	                       JOIN products p ON p.id = oi.product_id WHERE p.merchant_id = ?)`
+	args := []interface{}{merchantID}
+
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+
+	query += " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		items, err := r.GetItemsForMerchant(order.ID, merchantID)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = items
+	}
+
+	return orders, nil
+}
+
+// CountByMerchantID 獲取包含商戶商品的訂單總數
+func (r *OrderRepository) CountByMerchantID(merchantID int, status string) (int, error) {
+	query := `SELECT COUNT(*) FROM orders
+	          WHERE id IN (SELECT oi.order_id FROM order_items oi
+	                       JOIN products p ON p.id = oi.product_id WHERE p.merchant_id = ?)`
+	args := []interface{}{merchantID}
+
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+
+	var count int
+	err := r.db.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
+
+// GetItemsForMerchant 獲取訂單中屬於指定商戶的商品
+func (r *OrderRepository) GetItemsForMerchant(orderID, merchantID int) ([]OrderItem, error) {
+	query := `SELECT oi.id, oi.order_id, oi.product_id, oi.product_name, oi.product_price, oi.variant_id,
+	          oi.variant_name, oi.variant_sku, oi.quantity, oi.total_price, oi.created_at
+	          FROM order_items oi JOIN products p ON p.id = oi.product_id
+	          WHERE oi.order_id = ? AND p.merchant_id = ? ORDER BY oi.id`
+
+	rows, err := r.db.Query(query, orderID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []OrderItem{}
+	for rows.Next() {
+		item := OrderItem{}
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName,
+			&item.ProductPrice, &item.VariantID, &item.VariantName, &item.VariantSKU,
+			&item.Quantity, &item.TotalPrice, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetItems 獲取訂單商品
+func (r *OrderRepository) GetItems(orderID int) ([]OrderItem, error) {
+	query := `SELECT id, order_id, product_id, product_name, product_price, variant_id, variant_name,
+	          variant_sku, quantity, total_price, created_at
+	          FROM order_items WHERE order_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []OrderItem{}
+	for rows.Next() {
+		item := OrderItem{}
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName,
+			&item.ProductPrice, &item.VariantID, &item.VariantName, &item.VariantSKU,
+			&item.Quantity, &item.TotalPrice, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// 錯誤定義
+var (
+	ErrOrderNotFound          = notFoundError("ORDER_NOT_FOUND", "訂單不存在")
+	ErrCartEmpty              = &DomainError{Code: "CART_EMPTY", Message: "購物車是空的"}
+	ErrInvalidOrderTransition = &DomainError{Code: "INVALID_STATUS_TRANSITION", Message: "不允許的訂單狀態變更"}
+	ErrOrderStatusConflict    = conflictError("STATUS_CONFLICT", "訂單狀態已被變更，請重新整理後再試")
+	ErrPriceChanged           = conflictError("PRICE_CHANGED", "商品價格已變動，請重新確認購物車")
+)
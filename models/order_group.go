@@ -0,0 +1,140 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OrderGroup 訂單群組，一次結帳對應一筆付款，並依商戶拆分為多張子訂單
+type OrderGroup struct {
+	ID                    int       `json:"id" db:"id"`
+	GroupNumber           string    `json:"group_number" db:"group_number"`
+	CustomerID            int       `json:"customer_id" db:"customer_id"`
+	TotalAmount           float64   `json:"total_amount" db:"total_amount"`
+	ShippingFee           float64   `json:"shipping_fee" db:"shipping_fee"`
+	DiscountAmount        float64   `json:"discount_amount" db:"discount_amount"`
+	PaymentMethod         *string   `json:"payment_method,omitempty" db:"payment_method"`
+	PaymentStatus         string    `json:"payment_status" db:"payment_status"`
+	ShippingAddress       string    `json:"shipping_address" db:"shipping_address"`
+	BillingAddress        *string   `json:"billing_address,omitempty" db:"billing_address"`
+	ShippingAddressDetail *Address  `json:"shipping_address_detail,omitempty" db:"shipping_address_detail"`
+	BillingAddressDetail  *Address  `json:"billing_address_detail,omitempty" db:"billing_address_detail"`
+	Notes                 *string   `json:"notes,omitempty" db:"notes"`
+	Orders                []*Order  `json:"orders"`
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateGroupTx 在交易中創建訂單群組（不含子訂單）
+func (r *OrderRepository) CreateGroupTx(tx *sql.Tx, group *OrderGroup) error {
+	query := `
+		INSERT INTO order_groups (group_number, customer_id, total_amount, shipping_fee, discount_amount,
+		                          payment_method, payment_status, shipping_address, billing_address, notes,
+		                          shipping_address_detail, billing_address_detail)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	shippingDetail, err := AddressSnapshotValue(group.ShippingAddressDetail)
+	if err != nil {
+		return err
+	}
+	billingDetail, err := AddressSnapshotValue(group.BillingAddressDetail)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, group.GroupNumber, group.CustomerID, group.TotalAmount, group.ShippingFee,
+		group.DiscountAmount, group.PaymentMethod, group.PaymentStatus, group.ShippingAddress,
+		group.BillingAddress, group.Notes, shippingDetail, billingDetail)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	group.ID = int(id)
+
+	return tx.QueryRow(`SELECT created_at, updated_at FROM order_groups WHERE id = ?`, group.ID).
+		Scan(&group.CreatedAt, &group.UpdatedAt)
+}
+
+// UpdateGroupTotalsTx 在交易中更新訂單群組金額
+func (r *OrderRepository) UpdateGroupTotalsTx(tx *sql.Tx, group *OrderGroup) error {
+	_, err := tx.Exec(`
+		UPDATE order_groups SET total_amount = ?, shipping_fee = ?, discount_amount = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, group.TotalAmount, group.ShippingFee, group.DiscountAmount, group.ID)
+	return err
+}
+
+// GetGroupByID 根據ID獲取訂單群組（含子訂單及商品）
+func (r *OrderRepository) GetGroupByID(id int) (*OrderGroup, error) {
+	group := &OrderGroup{}
+	query := `SELECT id, group_number, customer_id, total_amount, shipping_fee, discount_amount, payment_method,
+	          payment_status, shipping_address, billing_address, notes, shipping_address_detail,
+	          billing_address_detail, created_at, updated_at
+	          FROM order_groups WHERE id = ?`
+
+	var shippingDetail, billingDetail sql.NullString
+	err := r.db.QueryRow(query, id).Scan(
+		&group.ID, &group.GroupNumber, &group.CustomerID, &group.TotalAmount, &group.ShippingFee,
+		&group.DiscountAmount, &group.PaymentMethod, &group.PaymentStatus, &group.ShippingAddress,
+		&group.BillingAddress, &group.Notes, &shippingDetail, &billingDetail, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if group.ShippingAddressDetail, err = parseAddressSnapshot(shippingDetail); err != nil {
+		return nil, err
+	}
+	if group.BillingAddressDetail, err = parseAddressSnapshot(billingDetail); err != nil {
+		return nil, err
+	}
+
+	group.Orders, err = r.GetByGroupID(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// GetByGroupID 獲取訂單群組的子訂單
+func (r *OrderRepository) GetByGroupID(groupID int) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE group_id = ? ORDER BY id`
+
+	rows, err := r.db.Query(query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		items, err := r.GetItems(order.ID)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = items
+	}
+
+	return orders, nil
+}
+
+// CountOpenInGroupTx 在交易中計算群組內尚未取消或退款的子訂單數
+func (r *OrderRepository) CountOpenInGroupTx(tx *sql.Tx, groupID int) (int, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM orders WHERE group_id = ? AND status NOT IN (?, ?)`,
+		groupID, OrderStatusCancelled, OrderStatusRefunded).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 操作者類型
+const (
+	OrderActorCustomer = "customer"
+	OrderActorMerchant = "merchant"
+	OrderActorAdmin    = "admin"
+	OrderActorSystem   = "system"
+)
+
+// OrderTransition 訂單狀態轉換規則
+type OrderTransition struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Actors []string `json:"actors"`
+}
+
+// orderTransitions 訂單狀態轉換表，未列出的轉換一律拒絕
+//
+//	pending → paid → shipped → delivered → completed
+//	pending → cancelled
+//	paid / delivered → refunded
+//
+// pending → paid 只能由系統在驗證過的金流回調中執行
+// → refunded 須先經金流退款成功（PaymentService.RefundMerchantOrder），不可直接變更狀態
+var orderTransitions = []OrderTransition{
+	{From: OrderStatusPending, To: OrderStatusPaid, Actors: []string{OrderActorSystem}},
+	{From: OrderStatusPending, To: OrderStatusCancelled, Actors: []string{OrderActorCustomer, OrderActorMerchant, OrderActorAdmin, OrderActorSystem}},
+	{From: OrderStatusPaid, To: OrderStatusShipped, Actors: []string{OrderActorMerchant, OrderActorAdmin}},
+	{From: OrderStatusPaid, To: OrderStatusRefunded, Actors: []string{OrderActorMerchant, OrderActorAdmin}},
+	{From: OrderStatusShipped, To: OrderStatusDelivered, Actors: []string{OrderActorMerchant, OrderActorAdmin, OrderActorSystem}},
+	{From: OrderStatusDelivered, To: OrderStatusCompleted, Actors: []string{OrderActorCustomer, OrderActorAdmin, OrderActorSystem}},
+	{From: OrderStatusDelivered, To: OrderStatusRefunded, Actors: []string{OrderActorMerchant, OrderActorAdmin}},
+}
+
+// FindOrderTransition 查找狀態轉換規則
+func FindOrderTransition(from, to string) (*OrderTransition, bool) {
+	for i := range orderTransitions {
+		if orderTransitions[i].From == from && orderTransitions[i].To == to {
+			return &orderTransitions[i], true
+		}
+	}
+	return nil, false
+}
+
+// CanTransitionOrder 檢查操作者是否可將訂單從 from 轉為 to
+func CanTransitionOrder(from, to, actorType string) bool {
+	transition, ok := FindOrderTransition(from, to)
+	if !ok {
+		return false
+	}
+	for _, actor := range transition.Actors {
+		if actor == actorType {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOrderStatuses 獲取操作者在目前狀態下可轉換的狀態
+func NextOrderStatuses(from, actorType string) []string {
+	statuses := []string{}
+	for _, transition := range orderTransitions {
+		if transition.From == from && CanTransitionOrder(from, transition.To, actorType) {
+			statuses = append(statuses, transition.To)
+		}
+	}
+	return statuses
+}
+
+// IsValidOrderStatus 檢查是否為已定義的訂單狀態
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered,
+		OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// OrderStatusHistory 訂單狀態變更紀錄
+type OrderStatusHistory struct {
+	ID         int       `json:"id" db:"id"`
+	OrderID    int       `json:"order_id" db:"order_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	ActorType  string    `json:"actor_type" db:"actor_type"`
+	ActorID    int       `json:"actor_id" db:"actor_id"`
+	Note       *string   `json:"note,omitempty" db:"note"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// UpdateOrderStatusRequest 更新訂單狀態請求
+type UpdateOrderStatusRequest struct {
+	Status string  `json:"status" binding:"required"`
+	Note   *string `json:"note,omitempty"`
+}
+
+// CancelOrderRequest 取消訂單請求
+type CancelOrderRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+// UpdateStatusTx 在交易中更新訂單狀態，只有目前狀態為 from 時才會更新
+func (r *OrderRepository) UpdateStatusTx(tx *sql.Tx, orderID int, from, to, paymentStatus string) error {
+	result, err := tx.Exec(`
+		UPDATE orders SET status = ?, payment_status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`, to, paymentStatus, orderID, from)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrOrderStatusConflict
+	}
+
+	return nil
+}
+
+// AddStatusHistoryTx 在交易中寫入狀態變更紀錄
+func (r *OrderRepository) AddStatusHistoryTx(tx *sql.Tx, history *OrderStatusHistory) error {
+	result, err := tx.Exec(`
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor_type, actor_id, note)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		history.OrderID, history.FromStatus, history.ToStatus, history.ActorType, history.ActorID, history.Note)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	history.ID = int(id)
+
+	return nil
+}
+
+// GetStatusHistory 獲取訂單狀態變更紀錄
+func (r *OrderRepository) GetStatusHistory(orderID int) ([]OrderStatusHistory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, order_id, from_status, to_status, actor_type, actor_id, note, created_at
+		FROM order_status_history WHERE order_id = ? ORDER BY created_at, id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := []OrderStatusHistory{}
+	for rows.Next() {
+		history := OrderStatusHistory{}
+		err := rows.Scan(&history.ID, &history.OrderID, &history.FromStatus, &history.ToStatus,
+			&history.ActorType, &history.ActorID, &history.Note, &history.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+
+	return histories, rows.Err()
+}
+
+// RestockItemsTx 在交易中將訂單商品的庫存與銷售數還原，商品因此恢復可購買時為收藏者排入補貨通知
+func (r *OrderRepository) RestockItemsTx(tx *sql.Tx, orderID int) error {
+	watch, err := watchRestockQuery(tx, `SELECT DISTINCT product_id FROM order_items WHERE order_id = ?`, orderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products SET
+			stock = stock + (SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+			                 WHERE oi.order_id = ? AND oi.product_id = products.id),
+			sales_count = MAX(0, sales_count - (SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+			                                     WHERE oi.order_id = ? AND oi.product_id = products.id)),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (SELECT product_id FROM order_items WHERE order_id = ?)`, orderID, orderID, orderID)
+	if err != nil {
+		return err
+	}
+
+	// 有規格的訂單商品同時歸還規格庫存
+	_, err = tx.Exec(`
+		UPDATE product_variants SET
+			stock = stock + (SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+			                 WHERE oi.order_id = ? AND oi.variant_id = product_variants.id),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (SELECT variant_id FROM order_items WHERE order_id = ? AND variant_id IS NOT NULL)`,
+		orderID, orderID)
+	if err != nil {
+		return err
+	}
+
+	return watch.NotifyTx(tx)
+}
@@ -0,0 +1,73 @@
+package models
+
+import "testing"
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		actor string
+		want  bool
+	}{
+		{"系統確認付款", OrderStatusPending, OrderStatusPaid, OrderActorSystem, true},
+		{"客戶不可自行標記已付款", OrderStatusPending, OrderStatusPaid, OrderActorCustomer, false},
+		{"商戶不可自行標記已付款", OrderStatusPending, OrderStatusPaid, OrderActorMerchant, false},
+		{"客戶取消待付款訂單", OrderStatusPending, OrderStatusCancelled, OrderActorCustomer, true},
+		{"系統取消逾期訂單", OrderStatusPending, OrderStatusCancelled, OrderActorSystem, true},
+		{"已付款訂單不可取消", OrderStatusPaid, OrderStatusCancelled, OrderActorCustomer, false},
+		{"商戶出貨", OrderStatusPaid, OrderStatusShipped, OrderActorMerchant, true},
+		{"客戶不可出貨", OrderStatusPaid, OrderStatusShipped, OrderActorCustomer, false},
+		{"未付款不可出貨", OrderStatusPending, OrderStatusShipped, OrderActorMerchant, false},
+		{"商戶退款已付款訂單", OrderStatusPaid, OrderStatusRefunded, OrderActorMerchant, true},
+		{"客戶不可直接退款", OrderStatusPaid, OrderStatusRefunded, OrderActorCustomer, false},
+		{"系統確認送達", OrderStatusShipped, OrderStatusDelivered, OrderActorSystem, true},
+		{"客戶不可標記送達", OrderStatusShipped, OrderStatusDelivered, OrderActorCustomer, false},
+		{"客戶完成訂單", OrderStatusDelivered, OrderStatusCompleted, OrderActorCustomer, true},
+		{"商戶不可完成訂單", OrderStatusDelivered, OrderStatusCompleted, OrderActorMerchant, false},
+		{"管理員退款已送達訂單", OrderStatusDelivered, OrderStatusRefunded, OrderActorAdmin, true},
+		{"已出貨不可退款", OrderStatusShipped, OrderStatusRefunded, OrderActorAdmin, false},
+		{"已完成為終態", OrderStatusCompleted, OrderStatusRefunded, OrderActorAdmin, false},
+		{"已取消為終態", OrderStatusCancelled, OrderStatusPending, OrderActorAdmin, false},
+		{"不可跳過出貨", OrderStatusPaid, OrderStatusDelivered, OrderActorAdmin, false},
+		{"相同狀態不算轉換", OrderStatusPaid, OrderStatusPaid, OrderActorAdmin, false},
+		{"未知狀態", "unknown", OrderStatusPaid, OrderActorSystem, false},
+		{"未知操作者", OrderStatusPaid, OrderStatusShipped, "guest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransitionOrder(tt.from, tt.to, tt.actor); got != tt.want {
+				t.Errorf("CanTransitionOrder(%q, %q, %q) = %v, want %v", tt.from, tt.to, tt.actor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOrderStatuses(t *testing.T) {
+	tests := []struct {
+		from  string
+		actor string
+		want  []string
+	}{
+		{OrderStatusPending, OrderActorCustomer, []string{OrderStatusCancelled}},
+		{OrderStatusPending, OrderActorSystem, []string{OrderStatusPaid, OrderStatusCancelled}},
+		{OrderStatusPaid, OrderActorMerchant, []string{OrderStatusShipped, OrderStatusRefunded}},
+		{OrderStatusDelivered, OrderActorCustomer, []string{OrderStatusCompleted}},
+		{OrderStatusCompleted, OrderActorAdmin, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := NextOrderStatuses(tt.from, tt.actor)
+		if len(got) != len(tt.want) {
+			t.Errorf("NextOrderStatuses(%q, %q) = %v, want %v", tt.from, tt.actor, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("NextOrderStatuses(%q, %q) = %v, want %v", tt.from, tt.actor, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PaymentStatusVoided 付款已被同一訂單群組較新的付款取代（僅用於付款紀錄），之後收到的付款成功回調會自動退款
+const PaymentStatusVoided = "voided"
+
+// Payment 付款紀錄，一筆付款對應一個訂單群組
+type Payment struct {
+	ID             int        `json:"id" db:"id"`
+	PaymentNumber  string     `json:"payment_number" db:"payment_number"`
+	GroupID        int        `json:"group_id" db:"group_id"`
+	CustomerID     int        `json:"customer_id" db:"customer_id"`
+	Provider       string     `json:"provider" db:"provider"`
+	Amount         float64    `json:"amount" db:"amount"`
+	Currency       string     `json:"currency" db:"currency"`
+	Status         string     `json:"status" db:"status"`
+	RefundedAmount float64    `json:"refunded_amount" db:"refunded_amount"` // 已退款金額，可多次部分退款
+	ProviderRef    *string    `json:"provider_ref,omitempty" db:"provider_ref"`
+	PaidAt         *time.Time `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PaymentCallback 已處理的金流回調
+type PaymentCallback struct {
+	ID            int       `json:"id" db:"id"`
+	Provider      string    `json:"provider" db:"provider"`
+	EventID       string    `json:"event_id" db:"event_id"`
+	PaymentNumber string    `json:"payment_number" db:"payment_number"`
+	Status        string    `json:"status" db:"status"`
+	Amount        float64   `json:"amount" db:"amount"`
+	Payload       string    `json:"payload" db:"payload"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// 金流退款結果
+const (
+	PaymentRefundPending   = "pending" // 已預留退款額度，等待金流商回應
+	PaymentRefundSucceeded = "succeeded"
+	PaymentRefundFailed    = "failed"
+)
+
+// RefundReferencePayment 重複扣款自動退款的退款來源類型，reference_id 為付款ID
+const RefundReferencePayment = "payment"
+
+// PaymentRefund 金流退款紀錄，成功與失敗皆保留
+type PaymentRefund struct {
+	ID             int       `json:"id" db:"id"`
+	PaymentID      int       `json:"payment_id" db:"payment_id"`
+	Amount         float64   `json:"amount" db:"amount"`
+	Status         string    `json:"status" db:"status"`
+	ProviderRef    *string   `json:"provider_ref,omitempty" db:"provider_ref"`
+	Reason         *string   `json:"reason,omitempty" db:"reason"`
+	Message        *string   `json:"message,omitempty" db:"message"`
+	ReferenceType  *string   `json:"reference_type,omitempty" db:"reference_type"`
+	ReferenceID    *int      `json:"reference_id,omitempty" db:"reference_id"`
+	IdempotencyKey *string   `json:"idempotency_key,omitempty" db:"idempotency_key"` // 同一鍵只能有一筆處理中或已成功的退款
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreatePaymentRequest 建立付款請求
+type CreatePaymentRequest struct {
+	GroupID int `json:"group_id" binding:"required"`
+}
+
+// PaymentRepository 付款數據庫操作
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+// NewPaymentRepository 創建付款倉庫
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+const paymentColumns = `id, payment_number, group_id, customer_id, provider, amount, currency, status,
+	refunded_amount, provider_ref, paid_at, created_at, updated_at`
+
+// scanPayment 掃描一筆付款資料
+func scanPayment(scanner rowScanner) (*Payment, error) {
+	payment := &Payment{}
+	err := scanner.Scan(&payment.ID, &payment.PaymentNumber, &payment.GroupID, &payment.CustomerID,
+		&payment.Provider, &payment.Amount, &payment.Currency, &payment.Status, &payment.RefundedAmount, &payment.ProviderRef,
+		&payment.PaidAt, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// CreateTx 在交易中創建付款紀錄
+func (r *PaymentRepository) CreateTx(tx *sql.Tx, payment *Payment) error {
+	result, err := tx.Exec(`
+		INSERT INTO payments (payment_number, group_id, customer_id, provider, amount, currency, status, provider_ref)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		payment.PaymentNumber, payment.GroupID, payment.CustomerID, payment.Provider, payment.Amount,
+		payment.Currency, payment.Status, payment.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	payment.ID = int(id)
+
+	return tx.QueryRow(`SELECT created_at, updated_at FROM payments WHERE id = ?`, payment.ID).
+		Scan(&payment.CreatedAt, &payment.UpdatedAt)
+}
+
+// VoidPendingByGroupTx 在交易中將訂單群組所有待付款的付款作廢，回傳作廢筆數
+func (r *PaymentRepository) VoidPendingByGroupTx(tx *sql.Tx, groupID int) (int, error) {
+	result, err := tx.Exec(`UPDATE payments SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE group_id = ? AND status = ?`,
+		PaymentStatusVoided, groupID, PaymentStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
+
+// GetByIDTx 在交易中根據ID獲取付款紀錄
+func (r *PaymentRepository) GetByIDTx(tx *sql.Tx, id int) (*Payment, error) {
+	return scanPayment(tx.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id = ?`, id))
+}
+
+// GetByNumber 根據付款編號獲取付款紀錄
+func (r *PaymentRepository) GetByNumber(paymentNumber string) (*Payment, error) {
+	return scanPayment(r.db.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE payment_number = ?`, paymentNumber))
+}
+
+// GetByNumberTx 在交易中根據付款編號獲取付款紀錄
+func (r *PaymentRepository) GetByNumberTx(tx *sql.Tx, paymentNumber string) (*Payment, error) {
+	return scanPayment(tx.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE payment_number = ?`, paymentNumber))
+}
+
+// GetByGroupID 獲取訂單群組的付款紀錄
+func (r *PaymentRepository) GetByGroupID(groupID int) ([]*Payment, error) {
+	rows, err := r.db.Query(`SELECT `+paymentColumns+` FROM payments WHERE group_id = ? ORDER BY id DESC`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []*Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// UpdateProviderRef 更新金流商交易編號
+func (r *PaymentRepository) UpdateProviderRef(id int, providerRef string) error {
+	_, err := r.db.Exec(`UPDATE payments SET provider_ref = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		providerRef, id)
+	return err
+}
+
+// UpdateStatusTx 在交易中更新付款狀態
+func (r *PaymentRepository) UpdateStatusTx(tx *sql.Tx, id int, status string, providerRef *string) error {
+	query := `UPDATE payments SET status = ?, provider_ref = COALESCE(?, provider_ref), updated_at = CURRENT_TIMESTAMP`
+	if status == PaymentStatusPaid {
+		query += `, paid_at = CURRENT_TIMESTAMP`
+	}
+	query += ` WHERE id = ?`
+
+	_, err := tx.Exec(query, status, providerRef, id)
+	return err
+}
+
+// UpdateGroupPaymentStatusTx 在交易中更新訂單群組的付款狀態
+func (r *PaymentRepository) UpdateGroupPaymentStatusTx(tx *sql.Tx, groupID int, status string) error {
+	_, err := tx.Exec(`UPDATE order_groups SET payment_status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		status, groupID)
+	return err
+}
+
+// RecordCallbackTx 在交易中記錄金流回調，回調已處理過時回傳 false
+func (r *PaymentRepository) RecordCallbackTx(tx *sql.Tx, callback *PaymentCallback) (bool, error) {
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO payment_callbacks (provider, event_id, payment_number, status, amount, payload)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		callback.Provider, callback.EventID, callback.PaymentNumber, callback.Status, callback.Amount, callback.Payload)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetPaidByGroupIDTx 在交易中獲取訂單群組最早一筆已付款的付款紀錄
+func (r *PaymentRepository) GetPaidByGroupIDTx(tx *sql.Tx, groupID int) (*Payment, error) {
+	return scanPayment(tx.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE group_id = ? AND status = ? ORDER BY id LIMIT 1`,
+		groupID, PaymentStatusPaid))
+}
+
+// ReserveRefundTx 在交易中預留退款額度，只有累計退款金額不超過付款金額時才會累加
+func (r *PaymentRepository) ReserveRefundTx(tx *sql.Tx, paymentID int, amount float64) error {
+	result, err := tx.Exec(`
+		UPDATE payments SET refunded_amount = COALESCE(refunded_amount, 0) + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ? AND COALESCE(refunded_amount, 0) + ? <= amount + 0.005`,
+		amount, paymentID, PaymentStatusPaid, amount)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRefundExceedsPayment
+	}
+	return nil
+}
+
+// CreateRefundTx 在交易中寫入處理中的金流退款紀錄，需先以 ReserveRefundTx 預留退款額度
+func (r *PaymentRepository) CreateRefundTx(tx *sql.Tx, refund *PaymentRefund) error {
+	result, err := tx.Exec(`
+		INSERT INTO payment_refunds (payment_id, amount, status, provider_ref, reason, message, reference_type, reference_id, idempotency_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		refund.PaymentID, refund.Amount, refund.Status, refund.ProviderRef, refund.Reason, refund.Message,
+		refund.ReferenceType, refund.ReferenceID, refund.IdempotencyKey)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	refund.ID = int(id)
+
+	return tx.QueryRow(`SELECT created_at FROM payment_refunds WHERE id = ?`, refund.ID).Scan(&refund.CreatedAt)
+}
+
+// FinishRefundTx 在交易中記錄金流商的退款結果，只有處理中的退款才會更新
+// 退款成功且已全額退款時付款轉為 refunded；退款失敗時釋放預留的退款額度
+func (r *PaymentRepository) FinishRefundTx(tx *sql.Tx, refund *PaymentRefund) error {
+	result, err := tx.Exec(`
+		UPDATE payment_refunds SET status = ?, provider_ref = ?, message = ?
+		WHERE id = ? AND status = ?`,
+		refund.Status, refund.ProviderRef, refund.Message, refund.ID, PaymentRefundPending)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRefundInProgress
+	}
+
+	if refund.Status == PaymentRefundSucceeded {
+		_, err = tx.Exec(`
+			UPDATE payments SET status = CASE WHEN COALESCE(refunded_amount, 0) >= amount - 0.005 THEN ? ELSE status END,
+			       updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`, PaymentStatusRefunded, refund.PaymentID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE payments SET refunded_amount = MAX(0, COALESCE(refunded_amount, 0) - ?), updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`, refund.Amount, refund.PaymentID)
+	}
+	return err
+}
+
+// refundColumns 金流退款查詢欄位，順序需與 scanPaymentRefund 一致
+const refundColumns = `id, payment_id, amount, status, provider_ref, reason, message, reference_type, reference_id,
+	idempotency_key, created_at`
+
+// scanPaymentRefund 掃描一筆金流退款資料
+func scanPaymentRefund(scanner rowScanner) (*PaymentRefund, error) {
+	refund := &PaymentRefund{}
+	err := scanner.Scan(&refund.ID, &refund.PaymentID, &refund.Amount, &refund.Status, &refund.ProviderRef,
+		&refund.Reason, &refund.Message, &refund.ReferenceType, &refund.ReferenceID, &refund.IdempotencyKey, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// GetActiveRefundByKeyTx 在交易中以冪等鍵獲取處理中或已成功的退款，沒有時回傳 nil
+func (r *PaymentRepository) GetActiveRefundByKeyTx(tx *sql.Tx, key string) (*PaymentRefund, error) {
+	refund, err := scanPaymentRefund(tx.QueryRow(`SELECT `+refundColumns+` FROM payment_refunds
+		WHERE idempotency_key = ? AND status != ?`, key, PaymentRefundFailed))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return refund, err
+}
+
+// GetRefundsByReference 獲取指定來源（例如退貨申請）的退款紀錄
+func (r *PaymentRepository) GetRefundsByReference(referenceType string, referenceID int) ([]*PaymentRefund, error) {
+	rows, err := r.db.Query(`SELECT `+refundColumns+` FROM payment_refunds
+		WHERE reference_type = ? AND reference_id = ? ORDER BY id`, referenceType, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []*PaymentRefund{}
+	for rows.Next() {
+		refund, err := scanPaymentRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+// 錯誤定義
+var (
+	ErrPaymentNotFound       = notFoundError("PAYMENT_NOT_FOUND", "付款紀錄不存在")
+	ErrPaymentAmountMismatch = &DomainError{Code: "PAYMENT_AMOUNT_MISMATCH", Message: "付款金額與訂單不符"}
+	ErrInvalidSignature      = &DomainError{Code: "INVALID_SIGNATURE", Message: "回調簽章驗證失敗"}
+	ErrOrderAlreadyPaid      = &DomainError{Code: "ORDER_ALREADY_PAID", Message: "訂單已付款"}
+	ErrRefundExceedsPayment  = &DomainError{Code: "REFUND_EXCEEDS_PAYMENT", Message: "退款金額超過付款剩餘可退金額"}
+	ErrRefundInProgress      = conflictError("REFUND_IN_PROGRESS", "退款正在處理中，請稍後再試")
+)
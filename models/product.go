@@ -12,11 +12,14 @@ type Product struct {
 	Description string    `json:"description" db:"description"`
 	Price       float64   `json:"price" db:"price"`
 	OriginalPrice *float64 `json:"original_price,omitempty" db:"original_price"`
-	Category    string    `json:"category" db:"category"`
+	Category    string    `json:"category" db:"category"` // 根分類名稱快照
+	CategoryID  *int      `json:"category_id,omitempty" db:"category_id"`
 	SubCategory *string   `json:"sub_category,omitempty" db:"sub_category"`
 	Brand       *string   `json:"brand,omitempty" db:"brand"`
 	SKU         *string   `json:"sku,omitempty" db:"sku"`
-	Stock       int       `json:"stock" db:"stock"`
+	Stock       int       `json:"stock" db:"stock"` // 現有庫存（on-hand）
+	ReservedStock  int    `json:"reserved_stock" db:"reserved_stock"` // 結帳中被保留的數量
+	AvailableStock int    `json:"available_stock" db:"-"`             // 可售數量 = 現有庫存 - 保留數量
 	ImageURL    *string   `json:"image_url,omitempty" db:"image_url"`
 	Images      *string   `json:"images,omitempty" db:"images"` // JSON 格式存儲多張圖片
 	Tags        *string   `json:"tags,omitempty" db:"tags"`     // JSON 格式存儲標籤
@@ -30,10 +33,42 @@ type Product struct {
 	ReviewCount int       `json:"review_count" db:"review_count"`
 	Weight      *float64  `json:"weight,omitempty" db:"weight"`
 	Dimensions  *string   `json:"dimensions,omitempty" db:"dimensions"` // JSON 格式存儲尺寸
+	Options     []ProductOption   `json:"options,omitempty" db:"-"`  // 規格選項軸，僅商品詳情載入
+	Variants    []*ProductVariant `json:"variants,omitempty" db:"-"` // 啟用中的規格，僅商品詳情載入
+	Breadcrumbs []CategoryBreadcrumb `json:"breadcrumbs,omitempty" db:"-"` // 分類路徑，僅商品詳情載入
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// productColumns 商品查詢欄位，順序需與 scanProduct 一致
+const productColumns = `id, name, description, price, original_price, category, category_id, sub_category, 
+	brand, sku, stock, reserved_stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
+	merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
+	created_at, updated_at`
+
+// scanProduct 掃描一筆商品資料
+func scanProduct(scanner rowScanner) (*Product, error) {
+	product := &Product{}
+	err := scanner.Scan(
+		&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
+		&product.Category, &product.CategoryID, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
+		&product.ReservedStock, &product.ImageURL, &product.Images, &product.Tags, &product.IsActive,
+		&product.IsFeatured, &product.IsOnSale, &product.MerchantID, &product.ViewCount,
+		&product.SalesCount, &product.Rating, &product.ReviewCount, &product.Weight,
+		&product.Dimensions, &product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	product.AvailableStock = product.Stock - product.ReservedStock
+	return product, nil
+}
+
+// dbExecutor 同時適用於 *sql.DB 與 *sql.Tx
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // ProductRepository 商品數據庫操作
 type ProductRepository struct {
 	db *sql.DB
@@ -46,15 +81,28 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 
 // Create 創建商品
 func (r *ProductRepository) Create(product *Product) error {
+	return r.create(r.db, product)
+}
+
+// CreateTx 在交易中創建商品
+func (r *ProductRepository) CreateTx(tx *sql.Tx, product *Product) error {
+	return r.create(tx, product)
+}
+
+func (r *ProductRepository) create(db dbExecutor, product *Product) error {
+	if err := resolveProductCategory(db, product); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO products (name, description, price, original_price, category, sub_category, 
+		INSERT INTO products (name, description, price, original_price, category, category_id, sub_category, 
 		                     brand, sku, stock, image_url, images, tags, is_active, is_featured, 
 		                     is_on_sale, merchant_id, view_count, sales_count, rating, review_count, 
 		                     weight, dimensions) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
-	result, err := r.db.Exec(query, product.Name, product.Description, product.Price,
-		product.OriginalPrice, product.Category, product.SubCategory, product.Brand,
+	result, err := db.Exec(query, product.Name, product.Description, product.Price,
+		product.OriginalPrice, product.Category, product.CategoryID, product.SubCategory, product.Brand,
 		product.SKU, product.Stock, product.ImageURL, product.Images, product.Tags,
 		product.IsActive, product.IsFeatured, product.IsOnSale, product.MerchantID,
 		product.ViewCount, product.SalesCount, product.Rating, product.ReviewCount,
@@ -71,27 +119,28 @@ func (r *ProductRepository) Create(product *Product) error {
 	
 	// 獲取創建時間
 	query = `SELECT created_at, updated_at FROM products WHERE id = ?`
-	err = r.db.QueryRow(query, product.ID).Scan(&product.CreatedAt, &product.UpdatedAt)
+	err = db.QueryRow(query, product.ID).Scan(&product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		return err
+	}
 	
-	return err
+	return indexProduct(db, product)
 }
 
 // GetByID 根據ID獲取商品
 func (r *ProductRepository) GetByID(id int) (*Product, error) {
-	product := &Product{}
-	query := `SELECT id, name, description, price, original_price, category, sub_category, 
-	          brand, sku, stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
-	          merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
-	          created_at, updated_at FROM products WHERE id = ?`
-	
-	err := r.db.QueryRow(query, id).Scan(
-		&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-		&product.Category, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
-		&product.ImageURL, &product.Images, &product.Tags, &product.IsActive, &product.IsFeatured,
-		&product.IsOnSale, &product.MerchantID, &product.ViewCount, &product.SalesCount,
-		&product.Rating, &product.ReviewCount, &product.Weight, &product.Dimensions,
-		&product.CreatedAt, &product.UpdatedAt)
+	return r.getByID(r.db, id)
+}
+
+// GetByIDTx 在交易中根據ID獲取商品
+func (r *ProductRepository) GetByIDTx(tx *sql.Tx, id int) (*Product, error) {
+	return r.getByID(tx, id)
+}
+
+func (r *ProductRepository) getByID(db dbExecutor, id int) (*Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = ?`
 	
+	product, err := scanProduct(db.QueryRow(query, id))
 	if err != nil {
 		return nil, err
 	}
@@ -101,10 +150,7 @@ func (r *ProductRepository) GetByID(id int) (*Product, error) {
 
 // GetAll 獲取所有商品
 func (r *ProductRepository) GetAll(limit, offset int) ([]*Product, error) {
-	query := `SELECT id, name, description, price, original_price, category, sub_category, 
-	          brand, sku, stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
-	          merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
-	          created_at, updated_at FROM products WHERE is_active = 1 
+	query := `SELECT ` + productColumns + ` FROM products WHERE is_active = 1 
 	          ORDER BY created_at DESC LIMIT ? OFFSET ?`
 	
 	rows, err := r.db.Query(query, limit, offset)
@@ -115,14 +161,7 @@ func (r *ProductRepository) GetAll(limit, offset int) ([]*Product, error) {
 	
 	var products []*Product
 	for rows.Next() {
-		product := &Product{}
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-			&product.Category, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
-			&product.ImageURL, &product.Images, &product.Tags, &product.IsActive, &product.IsFeatured,
-			&product.IsOnSale, &product.MerchantID, &product.ViewCount, &product.SalesCount,
-			&product.Rating, &product.ReviewCount, &product.Weight, &product.Dimensions,
-			&product.CreatedAt, &product.UpdatedAt)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -134,10 +173,7 @@ func (r *ProductRepository) GetAll(limit, offset int) ([]*Product, error) {
 
 // GetFeatured 獲取精選商品
 func (r *ProductRepository) GetFeatured(limit int) ([]*Product, error) {
-	query := `SELECT id, name, description, price, original_price, category, sub_category, 
-	          brand, sku, stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
-	          merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
-	          created_at, updated_at FROM products WHERE is_active = 1 AND is_featured = 1 
+	query := `SELECT ` + productColumns + ` FROM products WHERE is_active = 1 AND is_featured = 1 
 	          ORDER BY created_at DESC LIMIT ?`
 	
 	rows, err := r.db.Query(query, limit)
@@ -148,14 +184,7 @@ func (r *ProductRepository) GetFeatured(limit int) ([]*Product, error) {
 	
 	var products []*Product
 	for rows.Next() {
-		product := &Product{}
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-			&product.Category, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
-			&product.ImageURL, &product.Images, &product.Tags, &product.IsActive, &product.IsFeatured,
-			&product.IsOnSale, &product.MerchantID, &product.ViewCount, &product.SalesCount,
-			&product.Rating, &product.ReviewCount, &product.Weight, &product.Dimensions,
-			&product.CreatedAt, &product.UpdatedAt)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -165,15 +194,20 @@ func (r *ProductRepository) GetFeatured(limit int) ([]*Product, error) {
 	return products, nil
 }
 
-// GetByCategory 根據分類獲取商品
+// GetByCategory 根據分類名稱獲取商品，包含其子孫分類下的商品
 func (r *ProductRepository) GetByCategory(category string, limit, offset int) ([]*Product, error) {
-	query := `SELECT id, name, description, price, original_price, category, sub_category, 
-	          brand, sku, stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
-	          merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
-	          created_at, updated_at FROM products WHERE is_active = 1 AND category = ? 
+	var categoryID int
+	err := r.db.QueryRow(`SELECT id FROM product_categories WHERE name = ?`, category).Scan(&categoryID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	query := categoryDescendantsCTE + `
+	          SELECT ` + productColumns + ` FROM products WHERE is_active = 1 
+	          AND (category = ? OR category_id IN (SELECT id FROM category_tree)) 
 	          ORDER BY created_at DESC LIMIT ? OFFSET ?`
 	
-	rows, err := r.db.Query(query, category, limit, offset)
+	rows, err := r.db.Query(query, categoryID, category, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -181,14 +215,7 @@ func (r *ProductRepository) GetByCategory(category string, limit, offset int) ([
 	
 	var products []*Product
 	for rows.Next() {
-		product := &Product{}
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-			&product.Category, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
-			&product.ImageURL, &product.Images, &product.Tags, &product.IsActive, &product.IsFeatured,
-			&product.IsOnSale, &product.MerchantID, &product.ViewCount, &product.SalesCount,
-			&product.Rating, &product.ReviewCount, &product.Weight, &product.Dimensions,
-			&product.CreatedAt, &product.UpdatedAt)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -198,12 +225,30 @@ func (r *ProductRepository) GetByCategory(category string, limit, offset int) ([
 	return products, nil
 }
 
-// Search 搜索商品
+// Search 搜索商品，全文檢索索引已建立時依相關度排序，否則以關鍵字比對並依上架時間排序
 func (r *ProductRepository) Search(keyword string, limit, offset int) ([]*Product, error) {
-	query := `SELECT id, name, description, price, original_price, category, sub_category, 
-	          brand, sku, stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
-	          merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
-	          created_at, updated_at FROM products WHERE is_active = 1 AND 
+	searchIndex.RLock()
+	ready, tokenizer := searchIndex.ready, searchIndex.tokenizer
+	searchIndex.RUnlock()
+	if !ready {
+		return r.searchLike(keyword, limit, offset)
+	}
+
+	results, _, err := NewProductSearchRepository(r.db, tokenizer).Search(keyword, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*Product, 0, len(results))
+	for _, result := range results {
+		products = append(products, result.Product)
+	}
+	return products, nil
+}
+
+// searchLike 以 LIKE 比對名稱、描述、分類與品牌
+func (r *ProductRepository) searchLike(keyword string, limit, offset int) ([]*Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE is_active = 1 AND 
 	          (name LIKE ? OR description LIKE ? OR category LIKE ? OR brand LIKE ?) 
 	          ORDER BY created_at DESC LIMIT ? OFFSET ?`
 	
@@ -216,14 +261,7 @@ func (r *ProductRepository) Search(keyword string, limit, offset int) ([]*Produc
 	
 	var products []*Product
 	for rows.Next() {
-		product := &Product{}
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-			&product.Category, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
-			&product.ImageURL, &product.Images, &product.Tags, &product.IsActive, &product.IsFeatured,
-			&product.IsOnSale, &product.MerchantID, &product.ViewCount, &product.SalesCount,
-			&product.Rating, &product.ReviewCount, &product.Weight, &product.Dimensions,
-			&product.CreatedAt, &product.UpdatedAt)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -256,31 +294,187 @@ func (r *ProductRepository) GetCategories() ([]string, error) {
 	return categories, nil
 }
 
-// Update 更新商品
+// Update 更新商品，商品由缺貨或下架恢復可購買時會為收藏者排入補貨通知
 func (r *ProductRepository) Update(product *Product) error {
+	// 補貨通知需與商品更新同時成功或失敗
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.update(tx, product); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateTx 在交易中更新商品
+func (r *ProductRepository) UpdateTx(tx *sql.Tx, product *Product) error {
+	return r.update(tx, product)
+}
+
+func (r *ProductRepository) update(db dbExecutor, product *Product) error {
+	wasAvailable, err := productAvailable(db, product.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := resolveProductCategory(db, product); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE products SET name = ?, description = ?, price = ?, original_price = ?, 
-		                   category = ?, sub_category = ?, brand = ?, sku = ?, stock = ?, 
+		                   category = ?, category_id = ?, sub_category = ?, brand = ?, sku = ?, stock = ?, 
 		                   image_url = ?, images = ?, tags = ?, is_active = ?, is_featured = ?, 
-		                   is_on_sale = ?, view_count = ?, sales_count = ?, rating = ?, 
-		                   review_count = ?, weight = ?, dimensions = ?, updated_at = CURRENT_TIMESTAMP 
+		                   is_on_sale = ?, view_count = ?, sales_count = ?, 
+		                   weight = ?, dimensions = ?, updated_at = CURRENT_TIMESTAMP 
 		WHERE id = ?`
 	
-	_, err := r.db.Exec(query, product.Name, product.Description, product.Price,
-		product.OriginalPrice, product.Category, product.SubCategory, product.Brand,
+	// rating 與 review_count 由評價彙總維護（ReviewRepository.RecomputeProductRatingTx），不在此覆寫
+	_, err = db.Exec(query, product.Name, product.Description, product.Price,
+		product.OriginalPrice, product.Category, product.CategoryID, product.SubCategory, product.Brand,
 		product.SKU, product.Stock, product.ImageURL, product.Images, product.Tags,
 		product.IsActive, product.IsFeatured, product.IsOnSale, product.ViewCount,
-		product.SalesCount, product.Rating, product.ReviewCount, product.Weight,
+		product.SalesCount, product.Weight,
 		product.Dimensions, product.ID)
-	
+	if err != nil {
+		return err
+	}
+
+	if err := indexProduct(db, product); err != nil {
+		return err
+	}
+
+	return notifyIfRestocked(db, product.ID, wasAvailable)
+}
+
+// SetActive 只更新商品的上下架狀態，不覆寫其他欄位；重新上架且有庫存時會為收藏者排入補貨通知
+func (r *ProductRepository) SetActive(id int, isActive bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	wasAvailable, err := productAvailable(tx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`UPDATE products SET is_active = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, isActive, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	product, err := r.getByID(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := indexProduct(tx, product); err != nil {
+		return err
+	}
+	if err := notifyIfRestocked(tx, id, wasAvailable); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// notifyIfRestocked 商品由缺貨或下架變為可購買時，為收藏者排入補貨通知
+func notifyIfRestocked(db dbExecutor, productID int, wasAvailable bool) error {
+	if wasAvailable {
+		return nil
+	}
+
+	available, err := productAvailable(db, productID)
+	if err != nil || !available {
+		return err
+	}
+
+	_, err = enqueueBackInStock(db, productID)
 	return err
 }
 
+// RestockWatch 庫存變動前各商品是否可購買，變動完成後以 NotifyTx 為由不可購買變為可購買的商品排入補貨通知
+// 用於一次歸還多項商品庫存或保留數量的操作，例如取消訂單、釋放或過期的結帳保留
+type RestockWatch map[int]bool
+
+// watchRestock 記錄商品在庫存變動前是否可購買
+func watchRestock(db dbExecutor, productIDs ...int) (RestockWatch, error) {
+	watch := RestockWatch{}
+	for _, id := range productIDs {
+		if _, seen := watch[id]; seen {
+			continue
+		}
+		available, err := productAvailable(db, id)
+		if err != nil {
+			return nil, err
+		}
+		watch[id] = available
+	}
+	return watch, nil
+}
+
+// watchRestockQuery 記錄查詢結果中的商品在庫存變動前是否可購買，query 需回傳商品ID
+func watchRestockQuery(tx *sql.Tx, query string, args ...interface{}) (RestockWatch, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var productIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		productIDs = append(productIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return watchRestock(tx, productIDs...)
+}
+
+// NotifyTx 在交易中為由不可購買變為可購買的商品排入補貨通知
+func (w RestockWatch) NotifyTx(tx *sql.Tx) error {
+	for productID, wasAvailable := range w {
+		if err := notifyIfRestocked(tx, productID, wasAvailable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Delete 刪除商品
 func (r *ProductRepository) Delete(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM products WHERE id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+	if _, err := tx.Exec(query, id); err != nil {
+		return err
+	}
+
+	if err := unindexProduct(tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // IncrementViewCount 增加瀏覽次數
@@ -297,12 +491,63 @@ func (r *ProductRepository) IncrementSalesCount(id int, quantity int) error {
 	return err
 }
 
+// IncrementSalesCountTx 在交易中增加銷售次數並扣減現有庫存，庫存不足時返回 ErrInsufficientStock
+// reservedQuantity 為該客戶結帳時保留的數量，會一併從 reserved_stock 扣除
+func (r *ProductRepository) IncrementSalesCountTx(tx *sql.Tx, id int, quantity int, reservedQuantity int) error {
+	query := `UPDATE products SET sales_count = sales_count + ?, stock = stock - ?, 
+	          reserved_stock = MAX(0, reserved_stock - ?), updated_at = CURRENT_TIMESTAMP 
+	          WHERE id = ? AND stock - ? >= MAX(0, reserved_stock - ?)`
+	result, err := tx.Exec(query, quantity, quantity, reservedQuantity, id, quantity, reservedQuantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// DecrementSalesCountTx 在交易中扣回銷售次數（退貨回補庫存時使用），不會低於 0
+func (r *ProductRepository) DecrementSalesCountTx(tx *sql.Tx, id int, quantity int) error {
+	_, err := tx.Exec(`UPDATE products SET sales_count = MAX(0, sales_count - ?), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, quantity, id)
+	return err
+}
+
+// AdjustStockTx 在交易中增減現有庫存，調整後可售數量為負時返回 ErrInsufficientStock
+func (r *ProductRepository) AdjustStockTx(tx *sql.Tx, id int, delta int) error {
+	wasAvailable, err := productAvailable(tx, id)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE products SET stock = stock + ?, updated_at = CURRENT_TIMESTAMP 
+	          WHERE id = ? AND stock + ? >= reserved_stock`
+	result, err := tx.Exec(query, delta, id, delta)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	return notifyIfRestocked(tx, id, wasAvailable)
+}
+
 // GetByMerchantID 根據商戶ID獲取商品列表
 func (r *ProductRepository) GetByMerchantID(merchantID, limit, offset int, status, search string) ([]*Product, error) {
-	query := `SELECT id, name, description, price, original_price, category, sub_category, 
-	          brand, sku, stock, image_url, images, tags, is_active, is_featured, is_on_sale, 
-	          merchant_id, view_count, sales_count, rating, review_count, weight, dimensions, 
-	          created_at, updated_at FROM products WHERE merchant_id = ?`
+	query := `SELECT ` + productColumns + ` FROM products WHERE merchant_id = ?`
 	
 	args := []interface{}{merchantID}
 	
@@ -333,14 +578,7 @@ func (r *ProductRepository) GetByMerchantID(merchantID, limit, offset int, statu
 	
 	var products []*Product
 	for rows.Next() {
-		product := &Product{}
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Price, &product.OriginalPrice,
-			&product.Category, &product.SubCategory, &product.Brand, &product.SKU, &product.Stock,
-			&product.ImageURL, &product.Images, &product.Tags, &product.IsActive, &product.IsFeatured,
-			&product.IsOnSale, &product.MerchantID, &product.ViewCount, &product.SalesCount,
-			&product.Rating, &product.ReviewCount, &product.Weight, &product.Dimensions,
-			&product.CreatedAt, &product.UpdatedAt)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -410,5 +648,15 @@ func (r *ProductRepository) GetMerchantStats(merchantID int) (map[string]interfa
 	}
 	stats["total_sales"] = totalSales
 	
+	// 總現有庫存與結帳保留中的數量
+	var totalStock, reservedStock int
+	err = r.db.QueryRow("SELECT COALESCE(SUM(stock), 0), COALESCE(SUM(reserved_stock), 0) FROM products WHERE merchant_id = ?", merchantID).Scan(&totalStock, &reservedStock)
+	if err != nil {
+		return nil, err
+	}
+	stats["total_stock"] = totalStock
+	stats["reserved_stock"] = reservedStock
+	stats["available_stock"] = totalStock - reservedStock
+	
 	return stats, nil
 }
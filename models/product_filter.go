@@ -0,0 +1,398 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 商品列表排序方式
+const (
+	ProductSortRelevance = "relevance" // 有關鍵字時的預設排序
+	ProductSortNewest    = "newest"
+	ProductSortPriceAsc  = "price_asc"
+	ProductSortPriceDesc = "price_desc"
+	ProductSortRating    = "rating"
+	ProductSortPopular   = "popular"
+)
+
+// productSortOrders 排序方式對應的 ORDER BY 子句
+var productSortOrders = map[string]string{
+	ProductSortNewest:    "p.created_at DESC, p.id DESC",
+	ProductSortPriceAsc:  "p.price ASC, p.id DESC",
+	ProductSortPriceDesc: "p.price DESC, p.id DESC",
+	ProductSortRating:    "p.rating DESC, p.review_count DESC, p.id DESC",
+	ProductSortPopular:   "p.sales_count DESC, p.id DESC",
+}
+
+// IsValidProductSort 是否為支援的排序方式
+func IsValidProductSort(sort string) bool {
+	_, ok := productSortOrders[sort]
+	return ok || sort == ProductSortRelevance
+}
+
+// 篩選面向名稱，計算某面向的數量時不套用該面向本身的篩選
+const (
+	facetCategory = "category"
+	facetBrand    = "brand"
+	facetPrice    = "price"
+	facetRating   = "rating"
+)
+
+// ProductFilter 商品列表篩選條件，各條件之間為 AND；同一面向的多個品牌為 OR，多個標籤為 AND
+type ProductFilter struct {
+	Keyword    string
+	CategoryID int    // 包含子孫分類
+	Category   string // 分類名稱，僅在 CategoryID 為 0 時依 products.category 比對
+	Brands     []string
+	MinPrice   *float64
+	MaxPrice   *float64
+	MinRating  *float64
+	OnSale     bool
+	InStock    bool
+	Tags       []string
+	Sort       string
+	Limit      int
+	Offset     int
+}
+
+// FacetCount 篩選面向的選項與符合商品數
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// CategoryFacet 分類面向，數量包含子孫分類的商品
+type CategoryFacet struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// PriceFacet 價格區間面向，Max 為 nil 表示無上限
+type PriceFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int      `json:"count"`
+}
+
+// RatingFacet 評分面向，為評分不低於 MinRating 的商品數
+type RatingFacet struct {
+	MinRating float64 `json:"min_rating"`
+	Count     int     `json:"count"`
+}
+
+// ProductFacets 商品列表的篩選面向統計
+type ProductFacets struct {
+	Categories  []CategoryFacet `json:"categories"`
+	Brands      []FacetCount    `json:"brands"`
+	PriceRanges []PriceFacet    `json:"price_ranges"`
+	Ratings     []RatingFacet   `json:"ratings"`
+	OnSale      int             `json:"on_sale"`
+	InStock     int             `json:"in_stock"`
+}
+
+// priceFacetBounds 價格區間的下限，最後一個區間無上限
+var priceFacetBounds = []float64{0, 500, 1000, 5000, 20000}
+
+// ratingFacetBounds 評分面向的門檻
+var ratingFacetBounds = []float64{4, 3, 2, 1}
+
+// productFilterQuery 由篩選條件組成的查詢來源與條件
+type productFilterQuery struct {
+	from      string
+	joinArgs  []interface{}
+	where     []string
+	whereArgs []interface{}
+	ranked    bool // 來源包含全文檢索相關度 m.rank
+}
+
+func (q *productFilterQuery) sql() string {
+	return q.from + " WHERE " + strings.Join(q.where, " AND ")
+}
+
+func (q *productFilterQuery) args(extra ...interface{}) []interface{} {
+	args := append([]interface{}{}, q.joinArgs...)
+	args = append(args, q.whereArgs...)
+	return append(args, extra...)
+}
+
+func (q *productFilterQuery) add(condition string, args ...interface{}) {
+	q.where = append(q.where, condition)
+	q.whereArgs = append(q.whereArgs, args...)
+}
+
+// buildFilterQuery 組成篩選查詢，exclude 指定的面向不套用其篩選（用於計算該面向的數量）
+func buildFilterQuery(filter *ProductFilter, exclude string) *productFilterQuery {
+	q := &productFilterQuery{from: " FROM products p"}
+	q.add("p.is_active = 1")
+
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		searchIndex.RLock()
+		ready, tokenizer := searchIndex.ready, searchIndex.tokenizer
+		searchIndex.RUnlock()
+
+		match := ""
+		if ready {
+			match = (&ProductSearchRepository{tokenizer: tokenizer}).matchQuery(keyword)
+		}
+		if match != "" {
+			q.from += ` JOIN (SELECT rowid AS id, bm25(products_fts, 10.0, 1.0, 5.0, 3.0) AS rank
+				FROM products_fts WHERE products_fts MATCH ?) m ON m.id = p.id`
+			q.joinArgs = append(q.joinArgs, match)
+			q.ranked = true
+		} else {
+			searchTerm := "%" + keyword + "%"
+			q.add("(p.name LIKE ? OR p.description LIKE ? OR p.category LIKE ? OR p.brand LIKE ?)",
+				searchTerm, searchTerm, searchTerm, searchTerm)
+		}
+	}
+
+	if exclude != facetCategory {
+		if filter.CategoryID > 0 {
+			q.add("p.category_id IN ("+categoryDescendantsCTE+" SELECT id FROM category_tree)", filter.CategoryID)
+		} else if filter.Category != "" {
+			q.add("p.category = ?", filter.Category)
+		}
+	}
+
+	if exclude != facetBrand && len(filter.Brands) > 0 {
+		q.add("p.brand IN (?"+strings.Repeat(", ?", len(filter.Brands)-1)+")", stringArgs(filter.Brands)...)
+	}
+
+	if exclude != facetPrice {
+		if filter.MinPrice != nil {
+			q.add("p.price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			q.add("p.price <= ?", *filter.MaxPrice)
+		}
+	}
+
+	if exclude != facetRating && filter.MinRating != nil {
+		q.add("p.rating >= ?", *filter.MinRating)
+	}
+
+	if filter.OnSale {
+		q.add("p.is_on_sale = 1")
+	}
+	if filter.InStock {
+		q.add("p.stock - p.reserved_stock > 0")
+	}
+
+	for _, tag := range filter.Tags {
+		q.add(`EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(p.tags) THEN p.tags ELSE '[]' END) t
+			WHERE t.value = ?)`, tag)
+	}
+
+	return q
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// Filter 依篩選條件與排序獲取上架商品，回傳當頁商品與符合總數
+func (r *ProductRepository) Filter(filter *ProductFilter) ([]*Product, int, error) {
+	q := buildFilterQuery(filter, "")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*)`+q.sql(), q.args()...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := productSortOrders[filter.Sort]
+	if !ok {
+		order = productSortOrders[ProductSortNewest]
+		if q.ranked {
+			order = "m.rank ASC, p.sales_count DESC"
+		}
+	}
+
+	query := `SELECT ` + prefixedProductColumns + q.sql() + ` ORDER BY ` + order + ` LIMIT ? OFFSET ?`
+	rows, err := r.db.Query(query, q.args(filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+
+	return products, total, rows.Err()
+}
+
+// Facets 統計篩選面向的符合商品數，每個面向以「其餘條件」計算，方便切換同面向的選項
+// 分類面向列出目前分類的子分類（未選分類時為根分類），數量包含子孫分類
+func (r *ProductRepository) Facets(filter *ProductFilter, categories []*ProductCategory) (*ProductFacets, error) {
+	facets := &ProductFacets{
+		Categories:  []CategoryFacet{},
+		Brands:      []FacetCount{},
+		PriceRanges: []PriceFacet{},
+		Ratings:     []RatingFacet{},
+	}
+
+	if err := r.categoryFacets(filter, categories, facets); err != nil {
+		return nil, err
+	}
+	if err := r.brandFacets(filter, facets); err != nil {
+		return nil, err
+	}
+	if err := r.priceFacets(filter, facets); err != nil {
+		return nil, err
+	}
+	if err := r.ratingFacets(filter, facets); err != nil {
+		return nil, err
+	}
+
+	q := buildFilterQuery(filter, "")
+	err := r.db.QueryRow(`SELECT COALESCE(SUM(p.is_on_sale = 1), 0), COALESCE(SUM(p.stock - p.reserved_stock > 0), 0)`+q.sql(),
+		q.args()...).Scan(&facets.OnSale, &facets.InStock)
+	if err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+func (r *ProductRepository) categoryFacets(filter *ProductFilter, categories []*ProductCategory, facets *ProductFacets) error {
+	q := buildFilterQuery(filter, facetCategory)
+	rows, err := r.db.Query(`SELECT p.category_id, COUNT(*)`+q.sql()+` AND p.category_id IS NOT NULL
+		GROUP BY p.category_id`, q.args()...)
+	if err != nil {
+		return err
+	}
+	direct := map[int]int{}
+	for rows.Next() {
+		var id, count int
+		if err := rows.Scan(&id, &count); err != nil {
+			rows.Close()
+			return err
+		}
+		direct[id] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 將各分類的商品數累加到所有祖先分類
+	parents := make(map[int]*int, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	subtree := map[int]int{}
+	for id, count := range direct {
+		for current, depth := id, 0; depth < 32; depth++ {
+			subtree[current] += count
+			parent, ok := parents[current]
+			if !ok || parent == nil {
+				break
+			}
+			current = *parent
+		}
+	}
+
+	for _, category := range categories {
+		isChild := (filter.CategoryID == 0 && category.ParentID == nil) ||
+			(filter.CategoryID > 0 && category.ParentID != nil && *category.ParentID == filter.CategoryID)
+		if isChild && subtree[category.ID] > 0 {
+			facets.Categories = append(facets.Categories, CategoryFacet{
+				ID:    category.ID,
+				Name:  category.Name,
+				Count: subtree[category.ID],
+			})
+		}
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) brandFacets(filter *ProductFilter, facets *ProductFacets) error {
+	q := buildFilterQuery(filter, facetBrand)
+	rows, err := r.db.Query(`SELECT p.brand, COUNT(*)`+q.sql()+` AND p.brand IS NOT NULL AND p.brand != ''
+		GROUP BY p.brand ORDER BY COUNT(*) DESC, p.brand`, q.args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet FacetCount
+		if err := rows.Scan(&facet.Value, &facet.Count); err != nil {
+			return err
+		}
+		facets.Brands = append(facets.Brands, facet)
+	}
+
+	return rows.Err()
+}
+
+func (r *ProductRepository) priceFacets(filter *ProductFilter, facets *ProductFacets) error {
+	q := buildFilterQuery(filter, facetPrice)
+
+	columns := make([]string, len(priceFacetBounds))
+	for i, min := range priceFacetBounds {
+		if i+1 < len(priceFacetBounds) {
+			columns[i] = fmt.Sprintf("COALESCE(SUM(p.price >= %g AND p.price < %g), 0)", min, priceFacetBounds[i+1])
+		} else {
+			columns[i] = fmt.Sprintf("COALESCE(SUM(p.price >= %g), 0)", min)
+		}
+	}
+
+	counts := make([]int, len(priceFacetBounds))
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := r.db.QueryRow(`SELECT `+strings.Join(columns, ", ")+q.sql(), q.args()...).Scan(dest...); err != nil {
+		return err
+	}
+
+	for i, min := range priceFacetBounds {
+		if counts[i] == 0 {
+			continue
+		}
+		facet := PriceFacet{Min: min, Count: counts[i]}
+		if i+1 < len(priceFacetBounds) {
+			max := priceFacetBounds[i+1]
+			facet.Max = &max
+		}
+		facets.PriceRanges = append(facets.PriceRanges, facet)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) ratingFacets(filter *ProductFilter, facets *ProductFacets) error {
+	q := buildFilterQuery(filter, facetRating)
+
+	columns := make([]string, len(ratingFacetBounds))
+	for i, min := range ratingFacetBounds {
+		columns[i] = fmt.Sprintf("COALESCE(SUM(p.rating >= %g), 0)", min)
+	}
+
+	counts := make([]int, len(ratingFacetBounds))
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := r.db.QueryRow(`SELECT `+strings.Join(columns, ", ")+q.sql(), q.args()...).Scan(dest...); err != nil {
+		return err
+	}
+
+	for i, min := range ratingFacetBounds {
+		facets.Ratings = append(facets.Ratings, RatingFacet{MinRating: min, Count: counts[i]})
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ProductImage 商戶上傳的商品圖片，Thumbnails 為尺寸名稱對應的縮圖網址
+type ProductImage struct {
+	ID          int               `json:"id" db:"id"`
+	ProductID   int               `json:"product_id" db:"product_id"`
+	MerchantID  int               `json:"merchant_id" db:"merchant_id"`
+	Storage     string            `json:"storage" db:"storage"`
+	StorageKey  string            `json:"-" db:"storage_key"`
+	URL         string            `json:"url" db:"url"`
+	Thumbnails  map[string]string `json:"thumbnails" db:"thumbnails"`
+	ContentType string            `json:"content_type" db:"content_type"`
+	Width       int               `json:"width" db:"width"`
+	Height      int               `json:"height" db:"height"`
+	SizeBytes   int               `json:"size_bytes" db:"size_bytes"`
+	Position    int               `json:"position" db:"position"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+}
+
+// ProductImageRepository 商品圖片數據庫操作
+type ProductImageRepository struct {
+	db *sql.DB
+}
+
+// NewProductImageRepository 創建商品圖片倉庫
+func NewProductImageRepository(db *sql.DB) *ProductImageRepository {
+	return &ProductImageRepository{db: db}
+}
+
+const productImageColumns = `id, product_id, merchant_id, storage, storage_key, url, thumbnails,
+	content_type, width, height, size_bytes, position, created_at`
+
+func scanProductImage(scanner rowScanner) (*ProductImage, error) {
+	image := &ProductImage{}
+	var thumbnails string
+	err := scanner.Scan(&image.ID, &image.ProductID, &image.MerchantID, &image.Storage, &image.StorageKey,
+		&image.URL, &thumbnails, &image.ContentType, &image.Width, &image.Height, &image.SizeBytes,
+		&image.Position, &image.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(thumbnails), &image.Thumbnails); err != nil {
+		image.Thumbnails = map[string]string{}
+	}
+	return image, nil
+}
+
+// GetByProductID 依排列順序獲取商品的所有上傳圖片
+func (r *ProductImageRepository) GetByProductID(productID int) ([]*ProductImage, error) {
+	rows, err := r.db.Query(`SELECT `+productImageColumns+` FROM product_images
+		WHERE product_id = ? ORDER BY position, id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []*ProductImage{}
+	for rows.Next() {
+		image, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+// GetByID 獲取商品的單張圖片
+func (r *ProductImageRepository) GetByID(productID, imageID int) (*ProductImage, error) {
+	image, err := scanProductImage(r.db.QueryRow(`SELECT `+productImageColumns+` FROM product_images
+		WHERE id = ? AND product_id = ?`, imageID, productID))
+	if err == sql.ErrNoRows {
+		return nil, ErrProductImageNotFound
+	}
+	return image, err
+}
+
+// CountByProductID 商品已上傳的圖片數
+func (r *ProductImageRepository) CountByProductID(productID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM product_images WHERE product_id = ?`, productID).Scan(&count)
+	return count, err
+}
+
+// CreateTx 在交易中新增圖片並排在最後，再同步商品的圖片欄位
+func (r *ProductImageRepository) CreateTx(tx *sql.Tx, image *ProductImage) error {
+	thumbnails, err := json.Marshal(image.Thumbnails)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`SELECT COALESCE(MAX(position), -1) + 1 FROM product_images WHERE product_id = ?`,
+		image.ProductID).Scan(&image.Position)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO product_images (product_id, merchant_id, storage, storage_key, url, thumbnails,
+		                            content_type, width, height, size_bytes, position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		image.ProductID, image.MerchantID, image.Storage, image.StorageKey, image.URL, string(thumbnails),
+		image.ContentType, image.Width, image.Height, image.SizeBytes, image.Position)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	image.ID = int(id)
+	image.CreatedAt = time.Now()
+
+	return syncProductImagesTx(tx, image.ProductID, nil)
+}
+
+// DeleteTx 在交易中刪除圖片，再同步商品的圖片欄位
+func (r *ProductImageRepository) DeleteTx(tx *sql.Tx, image *ProductImage) error {
+	result, err := tx.Exec(`DELETE FROM product_images WHERE id = ? AND product_id = ?`, image.ID, image.ProductID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrProductImageNotFound
+	}
+
+	return syncProductImagesTx(tx, image.ProductID, image)
+}
+
+// syncProductImagesTx 將 products.images 更新為上傳圖片的網址陣列
+// 商戶在商品資料中填寫的外部圖片網址會保留在前，上傳圖片依排列順序接在後面
+// 商品尚未設定主圖，或主圖是剛刪除的圖片時，主圖改為第一張上傳圖片的中尺寸縮圖
+func syncProductImagesTx(tx *sql.Tx, productID int, removed *ProductImage) error {
+	rows, err := tx.Query(`SELECT url, thumbnails FROM product_images WHERE product_id = ? ORDER BY position, id`, productID)
+	if err != nil {
+		return err
+	}
+	uploaded := []string{}
+	primary := ""
+	for rows.Next() {
+		var url, thumbnails string
+		if err := rows.Scan(&url, &thumbnails); err != nil {
+			rows.Close()
+			return err
+		}
+		uploaded = append(uploaded, url)
+		if primary == "" {
+			primary = url
+			var sizes map[string]string
+			if json.Unmarshal([]byte(thumbnails), &sizes) == nil && sizes[ProductImageSizeMedium] != "" {
+				primary = sizes[ProductImageSizeMedium]
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var imageURL, existing sql.NullString
+	if err := tx.QueryRow(`SELECT image_url, images FROM products WHERE id = ?`, productID).Scan(&imageURL, &existing); err != nil {
+		return err
+	}
+
+	isUpload := map[string]bool{}
+	for _, url := range uploaded {
+		isUpload[url] = true
+	}
+	if removed != nil {
+		isUpload[removed.URL] = true
+	}
+
+	urls := []string{}
+	var current []string
+	if existing.Valid && json.Unmarshal([]byte(existing.String), &current) == nil {
+		for _, url := range current {
+			if url != "" && !isUpload[url] {
+				urls = append(urls, url)
+			}
+		}
+	}
+	urls = append(urls, uploaded...)
+
+	images, err := json.Marshal(urls)
+	if err != nil {
+		return err
+	}
+
+	replace := !imageURL.Valid || imageURL.String == ""
+	if removed != nil && imageURL.Valid {
+		replace = replace || imageURL.String == removed.URL
+		for _, url := range removed.Thumbnails {
+			replace = replace || imageURL.String == url
+		}
+	}
+
+	if replace {
+		var newPrimary interface{}
+		if primary != "" {
+			newPrimary = primary
+		}
+		_, err = tx.Exec(`UPDATE products SET images = ?, image_url = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			string(images), newPrimary, productID)
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE products SET images = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, string(images), productID)
+	return err
+}
+
+// 商品圖片縮圖尺寸名稱
+const (
+	ProductImageSizeSmall  = "small"
+	ProductImageSizeMedium = "medium"
+	ProductImageSizeLarge  = "large"
+)
+
+var (
+	ErrProductImageNotFound = notFoundError("PRODUCT_IMAGE_NOT_FOUND", "商品圖片不存在")
+	ErrTooManyProductImages = &DomainError{Code: "TOO_MANY_IMAGES", Message: "商品圖片數量已達上限"}
+	ErrInvalidProductImage  = &DomainError{Code: "INVALID_IMAGE", Message: "不支援的圖片格式，僅接受 JPEG、PNG 與 GIF"}
+)
@@ -0,0 +1,101 @@
+package models
+
+import "database/sql"
+
+// ProductTransferColumns 商品匯入匯出的欄位，匯出檔可直接修改後再匯入
+var ProductTransferColumns = []string{
+	"sku", "name", "description", "price", "original_price", "category", "sub_category", "brand",
+	"stock", "image_url", "images", "tags", "is_active", "is_featured", "weight", "dimensions",
+}
+
+// ProductImportRowError 匯入檔案中單一列的驗證錯誤，Row 為資料列編號（CSV 不含標題列，從 1 開始）
+type ProductImportRowError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductImportRowResult 單一列的處理結果，Action 為 create 或 update
+type ProductImportRowResult struct {
+	Row       int    `json:"row"`
+	SKU       string `json:"sku"`
+	Action    string `json:"action"`
+	ProductID int    `json:"product_id,omitempty"` // 試算時新增的商品沒有ID
+}
+
+// ProductImportReport 匯入報告，有任何列錯誤時整批不寫入
+type ProductImportReport struct {
+	DryRun  bool                      `json:"dry_run"`
+	Applied bool                      `json:"applied"`
+	Total   int                       `json:"total"`
+	Created int                       `json:"created"`
+	Updated int                       `json:"updated"`
+	Failed  int                       `json:"failed"`
+	Errors  []*ProductImportRowError  `json:"errors"`
+	Results []*ProductImportRowResult `json:"results"`
+}
+
+// 匯入動作
+const (
+	ProductImportCreate = "create"
+	ProductImportUpdate = "update"
+)
+
+// GetByMerchantSKUTx 在交易中依商戶與 SKU 獲取商品，不存在時回傳 nil
+// 同一商戶有多個商品使用相同 SKU 時回傳 ErrAmbiguousSKU
+func (r *ProductRepository) GetByMerchantSKUTx(tx *sql.Tx, merchantID int, sku string) (*Product, error) {
+	rows, err := tx.Query(`SELECT `+productColumns+` FROM products
+		WHERE merchant_id = ? AND sku = ? ORDER BY id LIMIT 2`, merchantID, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(products) {
+	case 0:
+		return nil, nil
+	case 1:
+		return products[0], nil
+	default:
+		return nil, ErrAmbiguousSKU
+	}
+}
+
+// EachByMerchant 逐筆讀取商戶的所有商品（依ID排序），用於串流匯出而不需一次載入整個目錄
+func (r *ProductRepository) EachByMerchant(merchantID int, fn func(*Product) error) error {
+	rows, err := r.db.Query(`SELECT `+productColumns+` FROM products WHERE merchant_id = ? ORDER BY id`, merchantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+var (
+	ErrAmbiguousSKU      = &DomainError{Code: "AMBIGUOUS_SKU", Message: "同一 SKU 對應多個商品，請先修正重複的 SKU"}
+	ErrInvalidImportFile = &DomainError{Code: "INVALID_IMPORT_FILE", Message: "匯入檔案格式錯誤"}
+)
@@ -0,0 +1,508 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 全文檢索分詞方式
+const (
+	// SearchTokenizerCJK 以 unicode61 分詞，並在寫入與查詢前將中日韓文字逐字切開，適合中文商品
+	SearchTokenizerCJK = "cjk"
+	// SearchTokenizerUnicode61 FTS5 預設分詞，以空白與標點切詞，連續的中文會被視為一個詞
+	SearchTokenizerUnicode61 = "unicode61"
+	// SearchTokenizerTrigram 以三字元切詞，可做子字串比對，查詢詞需至少三個字
+	SearchTokenizerTrigram = "trigram"
+)
+
+// 摘要中標示命中詞的分隔字元，跳脫 HTML 後再換成 <mark> 標籤
+const (
+	highlightOpen  = "\x01"
+	highlightClose = "\x02"
+)
+
+// cjk 模式下由原文擷取描述摘要的字數，以及第一個命中詞前保留的字數
+const (
+	snippetRunes  = 64
+	snippetBefore = 16
+)
+
+// searchIndex 目前使用中的全文檢索設定，由 EnsureIndex 設定；尚未建立索引時商品異動不寫入索引
+var searchIndex struct {
+	sync.RWMutex
+	ready     bool
+	tokenizer string
+}
+
+// ProductSearchResult 全文檢索結果
+type ProductSearchResult struct {
+	*Product
+	Score     float64         `json:"score"` // 相關度，越大越相關
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+// SearchHighlight 命中詞以 <mark> 標示的商品名稱與描述摘要，其餘文字已跳脫 HTML
+type SearchHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProductSearchRepository 商品全文檢索數據庫操作（SQLite FTS5）
+type ProductSearchRepository struct {
+	db        *sql.DB
+	tokenizer string
+}
+
+// NewProductSearchRepository 創建商品全文檢索倉庫，未知的分詞方式視為 cjk
+func NewProductSearchRepository(db *sql.DB, tokenizer string) *ProductSearchRepository {
+	switch tokenizer {
+	case SearchTokenizerUnicode61, SearchTokenizerTrigram:
+	default:
+		tokenizer = SearchTokenizerCJK
+	}
+	return &ProductSearchRepository{db: db, tokenizer: tokenizer}
+}
+
+// Tokenizer 回傳使用中的分詞方式
+func (r *ProductSearchRepository) Tokenizer() string {
+	return r.tokenizer
+}
+
+// ftsTokenize 分詞方式對應的 FTS5 tokenize 參數
+func (r *ProductSearchRepository) ftsTokenize() string {
+	if r.tokenizer == SearchTokenizerTrigram {
+		return "trigram"
+	}
+	return "unicode61 remove_diacritics 2"
+}
+
+// EnsureIndex 建立全文檢索索引，分詞方式變更或索引與商品數不一致時重建，回傳是否重建
+func (r *ProductSearchRepository) EnsureIndex() (bool, error) {
+	var existing string
+	err := r.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'products_fts'`).Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	// 分詞方式記錄在索引表的欄位註記中，cjk 與 unicode61 共用 FTS5 分詞但寫入內容不同
+	marker := "tokenizer_" + r.tokenizer
+	rebuild := false
+	if existing == "" || !strings.Contains(existing, marker) {
+		if _, err := r.db.Exec(`DROP TABLE IF EXISTS products_fts`); err != nil {
+			return false, err
+		}
+		_, err := r.db.Exec(fmt.Sprintf(`CREATE VIRTUAL TABLE products_fts USING fts5(
+			name, description, brand, tags, %s UNINDEXED, tokenize = '%s')`, marker, r.ftsTokenize()))
+		if err != nil {
+			return false, err
+		}
+		rebuild = true
+	} else {
+		var products, indexed int
+		err := r.db.QueryRow(`SELECT (SELECT COUNT(*) FROM products), (SELECT COUNT(*) FROM products_fts)`).
+			Scan(&products, &indexed)
+		if err != nil {
+			return false, err
+		}
+		rebuild = products != indexed
+	}
+
+	searchIndex.Lock()
+	searchIndex.tokenizer = r.tokenizer
+	searchIndex.ready = true
+	searchIndex.Unlock()
+
+	if rebuild {
+		return true, r.Rebuild()
+	}
+	return false, nil
+}
+
+// Rebuild 清空並重新寫入所有商品的全文檢索索引
+func (r *ProductSearchRepository) Rebuild() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM products_fts`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, name, description, brand, tags FROM products`)
+	if err != nil {
+		return err
+	}
+	var products []*Product
+	for rows.Next() {
+		product := &Product{}
+		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Brand, &product.Tags); err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, product)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if err := insertSearchDocument(tx, r.tokenizer, product); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Search 以全文檢索搜尋上架商品，依相關度排序並回傳命中摘要與符合總數
+// 名稱的權重最高，其次為品牌、標籤與描述；索引未建立或無法組成全文檢索查詢時改以關鍵字比對
+func (r *ProductSearchRepository) Search(keyword string, limit, offset int) ([]*ProductSearchResult, int, error) {
+	searchIndex.RLock()
+	ready := searchIndex.ready
+	searchIndex.RUnlock()
+
+	match := r.matchQuery(keyword)
+	if !ready || match == "" {
+		return r.searchLike(keyword, limit, offset)
+	}
+
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM products_fts f JOIN products p ON p.id = f.rowid
+		WHERE products_fts MATCH ? AND p.is_active = 1`, match).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	descriptionExpr := `snippet(products_fts, 1, ?, ?, '…', 16)`
+	if r.tokenizer == SearchTokenizerCJK {
+		// 索引內容是切詞後的文字，取整欄的命中位置，再對應回原文擷取摘要
+		descriptionExpr = `highlight(products_fts, 1, ?, ?)`
+	}
+	query := `SELECT ` + prefixedProductColumns + `,
+		bm25(products_fts, 10.0, 1.0, 5.0, 3.0),
+		highlight(products_fts, 0, ?, ?),
+		` + descriptionExpr + `
+		FROM products_fts f JOIN products p ON p.id = f.rowid
+		WHERE products_fts MATCH ? AND p.is_active = 1
+		ORDER BY bm25(products_fts, 10.0, 1.0, 5.0, 3.0), p.sales_count DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, highlightOpen, highlightClose, highlightOpen, highlightClose, match, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := []*ProductSearchResult{}
+	for rows.Next() {
+		var rank float64
+		var name, description string
+		product, err := scanProduct(scannerWithExtra{rows, []interface{}{&rank, &name, &description}})
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, &ProductSearchResult{
+			Product: product,
+			Score:   -rank,
+			Highlight: SearchHighlight{
+				Name:        r.renderHighlight(product.Name, name, false),
+				Description: r.renderHighlight(product.Description, description, true),
+			},
+		})
+	}
+
+	return results, total, rows.Err()
+}
+
+// searchLike 以 LIKE 比對名稱、描述、分類與品牌，用於索引未建立或 trigram 下不足三個字的查詢
+func (r *ProductSearchRepository) searchLike(keyword string, limit, offset int) ([]*ProductSearchResult, int, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return []*ProductSearchResult{}, 0, nil
+	}
+
+	products, err := NewProductRepository(r.db).searchLike(keyword, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	searchTerm := "%" + keyword + "%"
+	err = r.db.QueryRow(`SELECT COUNT(*) FROM products WHERE is_active = 1 AND
+		(name LIKE ? OR description LIKE ? OR category LIKE ? OR brand LIKE ?)`,
+		searchTerm, searchTerm, searchTerm, searchTerm).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*ProductSearchResult, 0, len(products))
+	for _, product := range products {
+		results = append(results, &ProductSearchResult{
+			Product: product,
+			Highlight: SearchHighlight{
+				Name:        html.EscapeString(product.Name),
+				Description: html.EscapeString(product.Description),
+			},
+		})
+	}
+	return results, total, nil
+}
+
+// matchQuery 將使用者輸入轉為 FTS5 查詢語法，每個詞以片語比對並以 AND 串接
+// 無法組成有效查詢（例如 trigram 下不足三個字）時回傳空字串
+func (r *ProductSearchRepository) matchQuery(keyword string) string {
+	terms := []string{}
+	for _, term := range strings.Fields(keyword) {
+		term = strings.ReplaceAll(term, `"`, "")
+		if term == "" {
+			continue
+		}
+
+		switch r.tokenizer {
+		case SearchTokenizerTrigram:
+			if len([]rune(term)) < 3 {
+				continue
+			}
+			terms = append(terms, `"`+term+`"`)
+		case SearchTokenizerCJK:
+			segmented := segmentCJK(term)
+			if containsCJK(term) {
+				terms = append(terms, `"`+segmented+`"`)
+			} else {
+				terms = append(terms, `"`+segmented+`"*`)
+			}
+		default:
+			terms = append(terms, `"`+term+`"*`)
+		}
+	}
+
+	return strings.Join(terms, " AND ")
+}
+
+// renderHighlight 跳脫 HTML 並將命中詞標示為 <mark>，marked 為全文檢索標示命中詞後的索引內容
+// cjk 模式下索引內容是切詞後的文字，命中位置對應回原文，摘要也由原文擷取，保留原文的空白與換行
+func (r *ProductSearchRepository) renderHighlight(original, marked string, snippet bool) string {
+	text := marked
+	if r.tokenizer == SearchTokenizerCJK {
+		text = markOriginal(original, marked)
+		if snippet {
+			text = snippetAround(text)
+		}
+	}
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, highlightClose+highlightOpen, "")
+	text = strings.ReplaceAll(text, highlightOpen, "<mark>")
+	return strings.ReplaceAll(text, highlightClose, "</mark>")
+}
+
+// scannerWithExtra 在商品欄位後接著掃描額外欄位
+type scannerWithExtra struct {
+	scanner rowScanner
+	extra   []interface{}
+}
+
+func (s scannerWithExtra) Scan(dest ...interface{}) error {
+	return s.scanner.Scan(append(dest, s.extra...)...)
+}
+
+// prefixedProductColumns 以 p. 為前綴的商品查詢欄位，順序與 productColumns 相同
+var prefixedProductColumns = func() string {
+	columns := strings.Split(productColumns, ",")
+	for i, column := range columns {
+		columns[i] = "p." + strings.TrimSpace(column)
+	}
+	return strings.Join(columns, ", ")
+}()
+
+// indexProduct 將商品寫入全文檢索索引（已存在時取代），索引尚未建立時略過
+func indexProduct(db dbExecutor, product *Product) error {
+	searchIndex.RLock()
+	ready, tokenizer := searchIndex.ready, searchIndex.tokenizer
+	searchIndex.RUnlock()
+	if !ready {
+		return nil
+	}
+
+	if err := unindexProduct(db, product.ID); err != nil {
+		return err
+	}
+	return insertSearchDocument(db, tokenizer, product)
+}
+
+// unindexProduct 將商品自全文檢索索引移除，索引尚未建立時略過
+func unindexProduct(db dbExecutor, productID int) error {
+	searchIndex.RLock()
+	ready := searchIndex.ready
+	searchIndex.RUnlock()
+	if !ready {
+		return nil
+	}
+
+	_, err := db.Exec(`DELETE FROM products_fts WHERE rowid = ?`, productID)
+	return err
+}
+
+// insertSearchDocument 寫入一筆商品的索引內容，cjk 模式下先逐字切開中日韓文字
+func insertSearchDocument(db dbExecutor, tokenizer string, product *Product) error {
+	name, description := product.Name, product.Description
+	brand := ""
+	if product.Brand != nil {
+		brand = *product.Brand
+	}
+	tags := searchTags(product.Tags)
+
+	if tokenizer == SearchTokenizerCJK {
+		name, description = segmentCJK(name), segmentCJK(description)
+		brand, tags = segmentCJK(brand), segmentCJK(tags)
+	}
+
+	_, err := db.Exec(`INSERT INTO products_fts (rowid, name, description, brand, tags) VALUES (?, ?, ?, ?, ?)`,
+		product.ID, name, description, brand, tags)
+	return err
+}
+
+// searchTags 將 JSON 陣列格式的標籤轉為以空白分隔的文字，非 JSON 時原樣使用
+func searchTags(tags *string) string {
+	if tags == nil || *tags == "" {
+		return ""
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(*tags), &list); err != nil {
+		return *tags
+	}
+	return strings.Join(list, " ")
+}
+
+// isCJK 是否為中日韓文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// containsCJK 字串是否包含中日韓文字
+func containsCJK(s string) bool {
+	for _, r := range s {
+		if isCJK(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentCJK 在每個中日韓文字前後加入空白，使 unicode61 分詞以單字為詞，連續空白合併為一個
+// 只用於寫入索引與組成查詢，顯示的命中標示與摘要由原文產生
+func segmentCJK(s string) string {
+	segmented, _ := segmentCJKPositions(s)
+	return segmented
+}
+
+// segmentCJKPositions 同 segmentCJK，並回傳結果中每個字元在原文的字元位置，切詞加入或合併後的空白為 -1
+func segmentCJKPositions(s string) (string, []int) {
+	var b strings.Builder
+	positions := []int{}
+	pendingSpace, prevCJK := false, false
+	for i, r := range []rune(s) {
+		if unicode.IsSpace(r) {
+			pendingSpace = true
+			continue
+		}
+		cjk := isCJK(r)
+		if len(positions) > 0 && (pendingSpace || cjk || prevCJK) {
+			b.WriteRune(' ')
+			positions = append(positions, -1)
+		}
+		b.WriteRune(r)
+		positions = append(positions, i)
+		pendingSpace, prevCJK = false, cjk
+	}
+	return b.String(), positions
+}
+
+// markOriginal 將切詞後文字上的命中標記對應回原文的相同字元
+func markOriginal(original, marked string) string {
+	_, positions := segmentCJKPositions(original)
+	opens, closes := map[int]bool{}, map[int]bool{}
+	k := 0 // 目前在切詞後文字中的位置
+	for _, r := range marked {
+		switch string(r) {
+		case highlightOpen:
+			for j := k; j < len(positions); j++ {
+				if positions[j] >= 0 {
+					opens[positions[j]] = true
+					break
+				}
+			}
+		case highlightClose:
+			for j := min(k, len(positions)) - 1; j >= 0; j-- {
+				if positions[j] >= 0 {
+					closes[positions[j]] = true
+					break
+				}
+			}
+		default:
+			k++
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(original) {
+		if opens[i] {
+			b.WriteString(highlightOpen)
+		}
+		b.WriteRune(r)
+		if closes[i] {
+			b.WriteString(highlightClose)
+		}
+	}
+	return b.String()
+}
+
+// snippetAround 由已標示命中詞的文字擷取摘要：從第一個命中詞前 snippetBefore 個字起取 snippetRunes 個字，截斷處加上 …
+func snippetAround(text string) string {
+	runes := []rune(text)
+	isMarker := func(r rune) bool {
+		return string(r) == highlightOpen || string(r) == highlightClose
+	}
+
+	start := 0
+	for i, r := range runes {
+		if string(r) == highlightOpen {
+			for count := 0; i > 0 && count < snippetBefore; {
+				i--
+				if !isMarker(runes[i]) {
+					count++
+				}
+			}
+			start = i
+			break
+		}
+	}
+	end, count := start, 0
+	for end < len(runes) && count < snippetRunes {
+		if !isMarker(runes[end]) {
+			count++
+		}
+		end++
+	}
+
+	snippet := string(runes[start:end])
+	// 截斷在命中詞中間時補上結束標記
+	if strings.Count(snippet, highlightOpen) > strings.Count(snippet, highlightClose) {
+		snippet += highlightClose
+	}
+	snippet = strings.TrimSpace(snippet)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegmentCJK(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"中文逐字切開", "無線耳機", "無 線 耳 機"},
+		{"中英混合", "Sony無線耳機WH-1000", "Sony 無 線 耳 機 WH-1000"},
+		{"換行與連續空白合併", "藍牙\n\n耳機  降噪", "藍 牙 耳 機 降 噪"},
+		{"前後空白移除", "  USB 充電線 ", "USB 充 電 線"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := segmentCJK(tt.in); got != tt.want {
+				t.Errorf("segmentCJK(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHighlightCJK(t *testing.T) {
+	r := NewProductSearchRepository(nil, SearchTokenizerCJK)
+	// mark 模擬全文檢索在切詞後的索引內容上標示命中詞
+	mark := func(original string, terms ...string) string {
+		marked := segmentCJK(original)
+		for _, term := range terms {
+			marked = strings.ReplaceAll(marked, term, highlightOpen+term+highlightClose)
+		}
+		return marked
+	}
+
+	tests := []struct {
+		name     string
+		original string
+		terms    []string
+		snippet  bool
+		want     string
+	}{
+		{
+			name:     "連續中文命中合併標示",
+			original: "無線耳機",
+			terms:    []string{"耳", "機"},
+			want:     "無線<mark>耳機</mark>",
+		},
+		{
+			name:     "保留原文的換行與空白",
+			original: "藍牙耳機\n\n續航  30 小時",
+			terms:    []string{"續", "航"},
+			want:     "藍牙耳機\n\n<mark>續航</mark>  30 小時",
+		},
+		{
+			name:     "原文中文之間的空白不被移除",
+			original: "紅茶 綠茶",
+			terms:    []string{"綠"},
+			want:     "紅茶 <mark>綠</mark>茶",
+		},
+		{
+			name:     "英文命中並跳脫 HTML",
+			original: "<b>Sony</b> 耳機",
+			terms:    []string{"Sony"},
+			want:     "&lt;b&gt;<mark>Sony</mark>&lt;/b&gt; 耳機",
+		},
+		{
+			name:     "摘要由原文擷取並保留換行",
+			original: strings.Repeat("商品說明文字", 10) + "\n特色：主動降噪\n" + strings.Repeat("其他規格", 20),
+			terms:    []string{"降", "噪"},
+			snippet:  true,
+			want:     "…說明文字商品說明文字\n特色：主動<mark>降噪</mark>\n" + strings.Repeat("其他規格", 11) + "其…",
+		},
+		{
+			name:     "沒有命中時摘要取開頭",
+			original: "短描述\n第二行",
+			snippet:  true,
+			want:     "短描述\n第二行",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.renderHighlight(tt.original, mark(tt.original, tt.terms...), tt.snippet)
+			if got != tt.want {
+				t.Errorf("renderHighlight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
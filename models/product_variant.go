@@ -0,0 +1,369 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ProductOption 商品規格的選項軸，例如 尺寸: S/M/L
+type ProductOption struct {
+	ID        int      `json:"id" db:"id"`
+	ProductID int      `json:"product_id" db:"product_id"`
+	Name      string   `json:"name" db:"name"`
+	Values    []string `json:"values" db:"option_values"`
+	Position  int      `json:"position" db:"position"`
+}
+
+// ProductVariant 商品規格，每個規格有獨立的 SKU、價格與庫存
+// 有規格的商品，products.stock 與 reserved_stock 維持為所有啟用規格的加總
+type ProductVariant struct {
+	ID             int               `json:"id" db:"id"`
+	ProductID      int               `json:"product_id" db:"product_id"`
+	SKU            string            `json:"sku" db:"sku"`
+	Options        map[string]string `json:"options" db:"options"`
+	Name           string            `json:"name" db:"-"`                // 依選項軸順序組成，例如「黑 / M」
+	Price          *float64          `json:"price,omitempty" db:"price"` // 未設定時沿用商品價格
+	UnitPrice      float64           `json:"unit_price" db:"-"`          // 實際售價
+	Stock          int               `json:"stock" db:"stock"`
+	ReservedStock  int               `json:"reserved_stock" db:"reserved_stock"`
+	AvailableStock int               `json:"available_stock" db:"-"`
+	IsActive       bool              `json:"is_active" db:"is_active"`
+	Position       int               `json:"position" db:"position"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ProductVariantsRequest 設定商品規格請求，會取代商品現有的選項軸與規格
+// 依 SKU 比對既有規格，未列出的既有規格會停用
+type ProductVariantsRequest struct {
+	Options  []ProductOptionInput  `json:"options"`
+	Variants []ProductVariantInput `json:"variants"`
+}
+
+// ProductOptionInput 選項軸內容
+type ProductOptionInput struct {
+	Name   string   `json:"name" binding:"required"`
+	Values []string `json:"values" binding:"required"`
+}
+
+// ProductVariantInput 規格內容
+type ProductVariantInput struct {
+	SKU      string            `json:"sku" binding:"required"`
+	Options  map[string]string `json:"options" binding:"required"`
+	Price    *float64          `json:"price,omitempty"`
+	Stock    int               `json:"stock"`
+	IsActive *bool             `json:"is_active,omitempty"` // 未指定時為啟用
+}
+
+// ProductVariantRepository 商品規格數據庫操作
+type ProductVariantRepository struct {
+	db *sql.DB
+}
+
+// NewProductVariantRepository 創建商品規格倉庫
+func NewProductVariantRepository(db *sql.DB) *ProductVariantRepository {
+	return &ProductVariantRepository{db: db}
+}
+
+// variantOptionSeparator 組成 option_key 的分隔字元，選項值不可包含
+const variantOptionSeparator = "|"
+
+// productVariantColumns 規格查詢欄位，順序需與 scanProductVariant 一致
+const productVariantColumns = `v.id, v.product_id, v.sku, v.options, v.option_key, v.price,
+	COALESCE(v.price, p.price), v.stock, v.reserved_stock, v.is_active, v.position, v.created_at, v.updated_at`
+
+// scanProductVariant 掃描一筆規格資料
+func scanProductVariant(scanner rowScanner) (*ProductVariant, error) {
+	variant := &ProductVariant{}
+	var options, optionKey string
+	err := scanner.Scan(&variant.ID, &variant.ProductID, &variant.SKU, &options, &optionKey,
+		&variant.Price, &variant.UnitPrice, &variant.Stock, &variant.ReservedStock, &variant.IsActive,
+		&variant.Position, &variant.CreatedAt, &variant.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(options), &variant.Options); err != nil {
+		return nil, err
+	}
+	variant.Name = VariantName(optionKey)
+	variant.AvailableStock = variant.Stock - variant.ReservedStock
+	return variant, nil
+}
+
+// VariantOptionKey 依選項軸順序組成規格的唯一鍵
+func VariantOptionKey(options []ProductOption, values map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		parts = append(parts, values[option.Name])
+	}
+	return strings.Join(parts, variantOptionSeparator)
+}
+
+// VariantName 將規格唯一鍵轉為顯示名稱
+func VariantName(optionKey string) string {
+	return strings.ReplaceAll(optionKey, variantOptionSeparator, " / ")
+}
+
+// GetOptions 獲取商品的選項軸
+func (r *ProductVariantRepository) GetOptions(productID int) ([]ProductOption, error) {
+	return getProductOptions(r.db, productID)
+}
+
+// GetOptionsTx 在交易中獲取商品的選項軸
+func (r *ProductVariantRepository) GetOptionsTx(tx *sql.Tx, productID int) ([]ProductOption, error) {
+	return getProductOptions(tx, productID)
+}
+
+func getProductOptions(db dbQuerier, productID int) ([]ProductOption, error) {
+	rows, err := db.Query(`SELECT id, product_id, name, option_values, position FROM product_options
+		WHERE product_id = ? ORDER BY position, id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := []ProductOption{}
+	for rows.Next() {
+		option := ProductOption{}
+		var values string
+		if err := rows.Scan(&option.ID, &option.ProductID, &option.Name, &values, &option.Position); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(values), &option.Values); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+
+	return options, rows.Err()
+}
+
+// GetByProductID 獲取商品的規格，activeOnly 為 true 時只回傳啟用中的規格
+func (r *ProductVariantRepository) GetByProductID(productID int, activeOnly bool) ([]*ProductVariant, error) {
+	return getProductVariants(r.db, productID, activeOnly)
+}
+
+// GetByProductIDTx 在交易中獲取商品的規格
+func (r *ProductVariantRepository) GetByProductIDTx(tx *sql.Tx, productID int, activeOnly bool) ([]*ProductVariant, error) {
+	return getProductVariants(tx, productID, activeOnly)
+}
+
+func getProductVariants(db dbQuerier, productID int, activeOnly bool) ([]*ProductVariant, error) {
+	query := `SELECT ` + productVariantColumns + ` FROM product_variants v
+		JOIN products p ON p.id = v.product_id WHERE v.product_id = ?`
+	if activeOnly {
+		query += ` AND v.is_active = 1`
+	}
+	query += ` ORDER BY v.position, v.id`
+
+	rows, err := db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []*ProductVariant{}
+	for rows.Next() {
+		variant, err := scanProductVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants, rows.Err()
+}
+
+// GetByID 根據ID獲取規格
+func (r *ProductVariantRepository) GetByID(id int) (*ProductVariant, error) {
+	return getProductVariant(r.db, id)
+}
+
+// GetByIDTx 在交易中根據ID獲取規格
+func (r *ProductVariantRepository) GetByIDTx(tx *sql.Tx, id int) (*ProductVariant, error) {
+	return getProductVariant(tx, id)
+}
+
+func getProductVariant(db dbExecutor, id int) (*ProductVariant, error) {
+	return scanProductVariant(db.QueryRow(`SELECT `+productVariantColumns+` FROM product_variants v
+		JOIN products p ON p.id = v.product_id WHERE v.id = ?`, id))
+}
+
+// HasActiveVariants 商品是否有啟用中的規格
+func (r *ProductVariantRepository) HasActiveVariants(productID int) (bool, error) {
+	return productHasVariants(r.db, productID)
+}
+
+// HasActiveVariantsTx 在交易中檢查商品是否有啟用中的規格
+func (r *ProductVariantRepository) HasActiveVariantsTx(tx *sql.Tx, productID int) (bool, error) {
+	return productHasVariants(tx, productID)
+}
+
+func productHasVariants(db dbExecutor, productID int) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM product_variants WHERE product_id = ? AND is_active = 1`,
+		productID).Scan(&count)
+	return count > 0, err
+}
+
+// ReplaceOptionsTx 在交易中以新的選項軸取代商品現有的選項軸
+func (r *ProductVariantRepository) ReplaceOptionsTx(tx *sql.Tx, productID int, options []ProductOption) error {
+	if _, err := tx.Exec(`DELETE FROM product_options WHERE product_id = ?`, productID); err != nil {
+		return err
+	}
+
+	for i := range options {
+		values, err := json.Marshal(options[i].Values)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(`INSERT INTO product_options (product_id, name, option_values, position)
+			VALUES (?, ?, ?, ?)`, productID, options[i].Name, string(values), i)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		options[i].ID = int(id)
+		options[i].ProductID = productID
+		options[i].Position = i
+	}
+
+	return nil
+}
+
+// UpsertTx 在交易中依 SKU 新增或更新規格，SKU 已被其他商品使用時返回 ErrDuplicateVariantSKU
+// 保留數量不受影響
+func (r *ProductVariantRepository) UpsertTx(tx *sql.Tx, variant *ProductVariant, optionKey string) error {
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO product_variants (product_id, sku, options, option_key, price, stock, is_active, position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(sku) DO UPDATE SET
+			options = excluded.options, option_key = excluded.option_key, price = excluded.price,
+			stock = excluded.stock, is_active = excluded.is_active, position = excluded.position,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE product_variants.product_id = excluded.product_id`,
+		variant.ProductID, variant.SKU, string(options), optionKey, variant.Price, variant.Stock,
+		variant.IsActive, variant.Position)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDuplicateVariantSKU
+	}
+
+	return nil
+}
+
+// DeactivateAllTx 在交易中停用商品所有規格
+func (r *ProductVariantRepository) DeactivateAllTx(tx *sql.Tx, productID int) error {
+	_, err := tx.Exec(`UPDATE product_variants SET is_active = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = ? AND is_active = 1`, productID)
+	return err
+}
+
+// SyncProductStockTx 在交易中將商品的現有庫存與保留數量重設為啟用規格的加總，商品因此恢復可購買時為收藏者排入補貨通知
+// 商品沒有啟用中的規格時不變動
+func (r *ProductVariantRepository) SyncProductStockTx(tx *sql.Tx, productID int) error {
+	watch, err := watchRestock(tx, productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products SET
+			stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = ? AND is_active = 1),
+			reserved_stock = (SELECT COALESCE(SUM(reserved_stock), 0) FROM product_variants WHERE product_id = ? AND is_active = 1),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND EXISTS (SELECT 1 FROM product_variants WHERE product_id = ? AND is_active = 1)`,
+		productID, productID, productID, productID)
+	if err != nil {
+		return err
+	}
+
+	return watch.NotifyTx(tx)
+}
+
+// AdjustStockTx 在交易中增減規格的現有庫存，調整後低於保留數量時返回 ErrInsufficientStock
+// 商品層級的庫存需由呼叫端一併調整
+func (r *ProductVariantRepository) AdjustStockTx(tx *sql.Tx, id, delta int) error {
+	result, err := tx.Exec(`
+		UPDATE product_variants SET stock = stock + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND stock + ? >= reserved_stock`, delta, id, delta)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// DeductStockTx 在交易中扣減規格庫存，並釋放該規格已保留的數量
+func (r *ProductVariantRepository) DeductStockTx(tx *sql.Tx, id, quantity, reservedQuantity int) error {
+	result, err := tx.Exec(`
+		UPDATE product_variants SET stock = stock - ?, reserved_stock = MAX(0, reserved_stock - ?),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND is_active = 1 AND stock - ? >= MAX(0, reserved_stock - ?)`,
+		quantity, reservedQuantity, id, quantity, reservedQuantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// LoadVariants 載入商品的選項軸與啟用中的規格
+func (r *ProductRepository) LoadVariants(product *Product) error {
+	options, err := getProductOptions(r.db, product.ID)
+	if err != nil {
+		return err
+	}
+	variants, err := getProductVariants(r.db, product.ID, true)
+	if err != nil {
+		return err
+	}
+
+	if len(variants) > 0 {
+		product.Options = options
+		product.Variants = variants
+	}
+	return nil
+}
+
+var (
+	ErrVariantNotFound     = &CartError{Code: "VARIANT_NOT_FOUND", Message: "商品規格不存在"}
+	ErrVariantRequired     = &CartError{Code: "VARIANT_REQUIRED", Message: "請選擇商品規格"}
+	ErrVariantNotAllowed   = &CartError{Code: "VARIANT_NOT_ALLOWED", Message: "此商品沒有規格"}
+	ErrInvalidVariants     = &DomainError{Code: "INVALID_VARIANTS", Message: "商品規格設定錯誤"}
+	ErrDuplicateVariantSKU = conflictError("DUPLICATE_VARIANT_SKU", "規格 SKU 已被使用")
+)
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// 折扣類型
+const (
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+)
+
+// Promotion 促銷活動，有代碼的為優惠券，需由客戶套用；無代碼的符合條件即自動套用
+type Promotion struct {
+	ID               int        `json:"id" db:"id"`
+	Code             *string    `json:"code,omitempty" db:"code"`
+	Name             string     `json:"name" db:"name"`
+	Description      *string    `json:"description,omitempty" db:"description"`
+	MerchantID       *int       `json:"merchant_id,omitempty" db:"merchant_id"` // nil 表示全站適用
+	DiscountType     string     `json:"discount_type" db:"discount_type"`
+	DiscountValue    float64    `json:"discount_value" db:"discount_value"`
+	MaxDiscount      *float64   `json:"max_discount,omitempty" db:"max_discount"`
+	MinSpend         float64    `json:"min_spend" db:"min_spend"`
+	UsageLimit       int        `json:"usage_limit" db:"usage_limit"`
+	PerCustomerLimit int        `json:"per_customer_limit" db:"per_customer_limit"`
+	UsedCount        int        `json:"used_count" db:"used_count"`
+	StartsAt         *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt           *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	IsActive         bool       `json:"is_active" db:"is_active"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsCoupon 是否為需輸入代碼的優惠券
+func (p *Promotion) IsCoupon() bool {
+	return p.Code != nil && *p.Code != ""
+}
+
+// InWindow 檢查時間是否在有效期間內
+func (p *Promotion) InWindow(now time.Time) bool {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Discount 計算對 amount 的折扣金額，不會超過 amount
+func (p *Promotion) Discount(amount float64) float64 {
+	var discount float64
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		discount = amount * p.DiscountValue / 100
+		if p.MaxDiscount != nil && *p.MaxDiscount > 0 && discount > *p.MaxDiscount {
+			discount = *p.MaxDiscount
+		}
+	case DiscountTypeFixed:
+		discount = p.DiscountValue
+	}
+	if discount > amount {
+		discount = amount
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	return discount
+}
+
+// PromotionRequest 建立或更新促銷請求
+type PromotionRequest struct {
+	Code             *string    `json:"code,omitempty"`
+	Name             string     `json:"name" binding:"required"`
+	Description      *string    `json:"description,omitempty"`
+	MerchantID       *int       `json:"merchant_id,omitempty"` // 僅管理員可指定，商戶建立時固定為自己
+	DiscountType     string     `json:"discount_type" binding:"required"`
+	DiscountValue    float64    `json:"discount_value" binding:"required"`
+	MaxDiscount      *float64   `json:"max_discount,omitempty"`
+	MinSpend         float64    `json:"min_spend"`
+	UsageLimit       int        `json:"usage_limit"`
+	PerCustomerLimit int        `json:"per_customer_limit"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	IsActive         *bool      `json:"is_active,omitempty"`
+}
+
+// ApplyCouponRequest 套用優惠券請求
+type ApplyCouponRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// AppliedPromotion 購物車或訂單套用的促銷
+type AppliedPromotion struct {
+	PromotionID    int     `json:"promotion_id"`
+	Code           *string `json:"code,omitempty"`
+	Name           string  `json:"name"`
+	MerchantID     *int    `json:"merchant_id,omitempty"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
+
+// NormalizeCouponCode 統一優惠券代碼格式（去除空白並轉大寫）
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PromotionRepository 促銷數據庫操作
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+// NewPromotionRepository 創建促銷倉庫
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+// promotionColumns 促銷查詢欄位，順序需與 scanPromotion 一致
+const promotionColumns = `id, code, name, description, merchant_id, discount_type, discount_value,
+	max_discount, min_spend, usage_limit, per_customer_limit, used_count, starts_at, ends_at,
+	is_active, created_at, updated_at`
+
+// scanPromotion 掃描一筆促銷資料
+func scanPromotion(scanner rowScanner) (*Promotion, error) {
+	promotion := &Promotion{}
+	err := scanner.Scan(&promotion.ID, &promotion.Code, &promotion.Name, &promotion.Description,
+		&promotion.MerchantID, &promotion.DiscountType, &promotion.DiscountValue, &promotion.MaxDiscount,
+		&promotion.MinSpend, &promotion.UsageLimit, &promotion.PerCustomerLimit, &promotion.UsedCount,
+		&promotion.StartsAt, &promotion.EndsAt, &promotion.IsActive, &promotion.CreatedAt, &promotion.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// scanPromotions 掃描多筆促銷資料
+func scanPromotions(rows *sql.Rows) ([]*Promotion, error) {
+	defer rows.Close()
+
+	promotions := []*Promotion{}
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+// Create 創建促銷
+func (r *PromotionRepository) Create(promotion *Promotion) error {
+	result, err := r.db.Exec(`
+		INSERT INTO promotions (code, name, description, merchant_id, discount_type, discount_value,
+		                        max_discount, min_spend, usage_limit, per_customer_limit, starts_at,
+		                        ends_at, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		promotion.Code, promotion.Name, promotion.Description, promotion.MerchantID, promotion.DiscountType,
+		promotion.DiscountValue, promotion.MaxDiscount, promotion.MinSpend, promotion.UsageLimit,
+		promotion.PerCustomerLimit, promotion.StartsAt, promotion.EndsAt, promotion.IsActive)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	promotion.ID = int(id)
+
+	return r.db.QueryRow(`SELECT created_at, updated_at FROM promotions WHERE id = ?`, promotion.ID).
+		Scan(&promotion.CreatedAt, &promotion.UpdatedAt)
+}
+
+// Update 更新促銷（不含已使用次數）
+func (r *PromotionRepository) Update(promotion *Promotion) error {
+	_, err := r.db.Exec(`
+		UPDATE promotions SET code = ?, name = ?, description = ?, merchant_id = ?, discount_type = ?,
+		                      discount_value = ?, max_discount = ?, min_spend = ?, usage_limit = ?,
+		                      per_customer_limit = ?, starts_at = ?, ends_at = ?, is_active = ?,
+		                      updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		promotion.Code, promotion.Name, promotion.Description, promotion.MerchantID, promotion.DiscountType,
+		promotion.DiscountValue, promotion.MaxDiscount, promotion.MinSpend, promotion.UsageLimit,
+		promotion.PerCustomerLimit, promotion.StartsAt, promotion.EndsAt, promotion.IsActive, promotion.ID)
+	return err
+}
+
+// GetByID 根據ID獲取促銷
+func (r *PromotionRepository) GetByID(id int) (*Promotion, error) {
+	return scanPromotion(r.db.QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE id = ?`, id))
+}
+
+// GetByCode 根據優惠券代碼獲取促銷
+func (r *PromotionRepository) GetByCode(code string) (*Promotion, error) {
+	return scanPromotion(r.db.QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE code = ?`, code))
+}
+
+// GetAll 獲取所有促銷
+func (r *PromotionRepository) GetAll() ([]*Promotion, error) {
+	rows, err := r.db.Query(`SELECT ` + promotionColumns + ` FROM promotions ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanPromotions(rows)
+}
+
+// GetByMerchantID 獲取商戶的促銷
+func (r *PromotionRepository) GetByMerchantID(merchantID int) ([]*Promotion, error) {
+	rows, err := r.db.Query(`SELECT `+promotionColumns+` FROM promotions WHERE merchant_id = ? ORDER BY id DESC`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPromotions(rows)
+}
+
+// GetAutomatic 獲取啟用中的自動促銷（全站與指定商戶），有效期間由呼叫端判斷
+func (r *PromotionRepository) GetAutomatic(merchantIDs []int) ([]*Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions
+		WHERE is_active = 1 AND (code IS NULL OR code = '') AND (merchant_id IS NULL`
+	args := []interface{}{}
+	if len(merchantIDs) > 0 {
+		query += ` OR merchant_id IN (?` + strings.Repeat(", ?", len(merchantIDs)-1) + `)`
+		for _, merchantID := range merchantIDs {
+			args = append(args, merchantID)
+		}
+	}
+	query += `) ORDER BY id`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanPromotions(rows)
+}
+
+// CountCustomerRedemptions 計算客戶已使用某促銷的次數
+func (r *PromotionRepository) CountCustomerRedemptions(promotionID, customerID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = ? AND customer_id = ?`,
+		promotionID, customerID).Scan(&count)
+	return count, err
+}
+
+// RedeemTx 在交易中記錄促銷使用並累加使用次數，超過總次數或每人次數上限時返回 ErrPromotionLimitReached
+func (r *PromotionRepository) RedeemTx(tx *sql.Tx, promotion *Promotion, customerID, groupID int, discount float64) error {
+	if promotion.PerCustomerLimit > 0 {
+		var count int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = ? AND customer_id = ?`,
+			promotion.ID, customerID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count >= promotion.PerCustomerLimit {
+			return ErrPromotionLimitReached
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE promotions SET used_count = used_count + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND is_active = 1 AND (usage_limit = 0 OR used_count < usage_limit)`, promotion.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPromotionLimitReached
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO promotion_redemptions (promotion_id, customer_id, group_id, discount_amount)
+		VALUES (?, ?, ?, ?)`, promotion.ID, customerID, groupID, discount)
+	return err
+}
+
+// RevertRedemptionsTx 在交易中撤銷結帳群組對商戶促銷的使用紀錄並扣回使用次數
+// includeSiteWide 為 true 時（群組內已無有效訂單）一併撤銷全站促銷
+func (r *PromotionRepository) RevertRedemptionsTx(tx *sql.Tx, groupID, merchantID int, includeSiteWide bool) error {
+	scope := `SELECT id FROM promotions WHERE merchant_id = ? OR (? AND merchant_id IS NULL)`
+
+	_, err := tx.Exec(`
+		UPDATE promotions SET used_count = MAX(used_count - (
+			SELECT COUNT(*) FROM promotion_redemptions WHERE group_id = ? AND promotion_id = promotions.id
+		), 0), updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (`+scope+`)
+		AND id IN (SELECT promotion_id FROM promotion_redemptions WHERE group_id = ?)`,
+		groupID, merchantID, includeSiteWide, groupID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM promotion_redemptions WHERE group_id = ? AND promotion_id IN (`+scope+`)`,
+		groupID, merchantID, includeSiteWide)
+	return err
+}
+
+// GetCartCoupon 獲取客戶購物車套用的優惠券代碼，未套用時回傳空字串
+func (r *PromotionRepository) GetCartCoupon(customerID int) (string, error) {
+	var code string
+	err := r.db.QueryRow(`SELECT code FROM cart_coupons WHERE customer_id = ?`, customerID).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return code, err
+}
+
+// SetCartCoupon 設定客戶購物車套用的優惠券
+func (r *PromotionRepository) SetCartCoupon(customerID int, code string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO cart_coupons (customer_id, code) VALUES (?, ?)
+		ON CONFLICT(customer_id) DO UPDATE SET code = excluded.code, created_at = CURRENT_TIMESTAMP`,
+		customerID, code)
+	return err
+}
+
+// RemoveCartCoupon 移除客戶購物車套用的優惠券
+func (r *PromotionRepository) RemoveCartCoupon(customerID int) error {
+	_, err := r.db.Exec(`DELETE FROM cart_coupons WHERE customer_id = ?`, customerID)
+	return err
+}
+
+// RemoveCartCouponTx 在交易中移除客戶購物車套用的優惠券
+func (r *PromotionRepository) RemoveCartCouponTx(tx *sql.Tx, customerID int) error {
+	_, err := tx.Exec(`DELETE FROM cart_coupons WHERE customer_id = ?`, customerID)
+	return err
+}
+
+var (
+	ErrPromotionNotFound     = notFoundError("PROMOTION_NOT_FOUND", "促銷活動不存在")
+	ErrCouponNotFound        = &DomainError{Code: "COUPON_NOT_FOUND", Message: "優惠券代碼無效"}
+	ErrCouponNotApplicable   = &DomainError{Code: "COUPON_NOT_APPLICABLE", Message: "優惠券不適用於目前購物車"}
+	ErrPromotionLimitReached = &DomainError{Code: "PROMOTION_LIMIT_REACHED", Message: "優惠已達使用次數上限"}
+	ErrDuplicateCouponCode   = conflictError("DUPLICATE_COUPON_CODE", "優惠券代碼已存在")
+)
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// 推薦訊號權重：購買 > 放入購物車 > 瀏覽
+const (
+	recommendationPurchaseWeight = 3.0
+	recommendationCartWeight     = 2.0
+	recommendationViewWeight     = 1.0
+)
+
+// RecommendationStats 一次批次重算的結果
+type RecommendationStats struct {
+	Relations       int `json:"relations"`
+	Recommendations int `json:"recommendations"`
+}
+
+// RecommendationRepository 商品推薦數據訪問層
+type RecommendationRepository struct {
+	db *sql.DB
+}
+
+// NewRecommendationRepository 創建商品推薦倉庫
+func NewRecommendationRepository(db *sql.DB) *RecommendationRepository {
+	return &RecommendationRepository{db: db}
+}
+
+// RecordView 記錄登入顧客瀏覽商品，同一商品只累加次數並更新最後瀏覽時間
+func (r *RecommendationRepository) RecordView(customerID, productID int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO product_views (customer_id, product_id, view_count, last_viewed_at)
+		VALUES (?, ?, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT(customer_id, product_id) DO UPDATE SET
+			view_count = product_views.view_count + 1,
+			last_viewed_at = CURRENT_TIMESTAMP`, customerID, productID)
+	return err
+}
+
+// recommendationSignalsCTE 各顧客的購買（未取消的訂單）、購物車與近期瀏覽商品，參數為瀏覽期間（例如 "-90 days"）
+const recommendationSignalsCTE = `
+	WITH purchases AS (
+		SELECT DISTINCT o.customer_id, oi.product_id
+		FROM order_items oi JOIN orders o ON o.id = oi.order_id
+		WHERE o.status NOT IN ('` + OrderStatusCancelled + `', '` + OrderStatusRefunded + `')
+	),
+	carts AS (
+		SELECT DISTINCT customer_id, product_id FROM shopping_cart
+	),
+	views AS (
+		SELECT customer_id, product_id FROM product_views WHERE last_viewed_at >= datetime('now', ?)
+	)`
+
+// Recompute 依共同購買、共同加入購物車與共同瀏覽重算相關商品，再展開為每位顧客的個人化推薦
+// 兩張表在同一交易內整表替換，讀取端不會看到計算到一半的結果
+func (r *RecommendationRepository) Recompute(viewWindowDays, maxItems int) (*RecommendationStats, error) {
+	window := fmt.Sprintf("-%d days", viewWindowDays)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_relations`); err != nil {
+		return nil, err
+	}
+
+	// 同一位顧客的兩個不同商品構成一組配對，數量為同時具有該訊號的顧客數
+	result, err := tx.Exec(`
+		INSERT INTO product_relations (product_id, related_product_id, co_purchase_count, co_cart_count, co_view_count, score, computed_at)
+		`+recommendationSignalsCTE+`,
+		pairs AS (
+			SELECT a.product_id, b.product_id AS related_product_id, 1 AS purchased, 0 AS carted, 0 AS viewed
+			FROM purchases a JOIN purchases b ON b.customer_id = a.customer_id AND b.product_id != a.product_id
+			UNION ALL
+			SELECT a.product_id, b.product_id, 0, 1, 0
+			FROM carts a JOIN carts b ON b.customer_id = a.customer_id AND b.product_id != a.product_id
+			UNION ALL
+			SELECT a.product_id, b.product_id, 0, 0, 1
+			FROM views a JOIN views b ON b.customer_id = a.customer_id AND b.product_id != a.product_id
+		),
+		scored AS (
+			SELECT product_id, related_product_id,
+			       SUM(purchased) AS co_purchase, SUM(carted) AS co_cart, SUM(viewed) AS co_view,
+			       SUM(purchased) * ? + SUM(carted) * ? + SUM(viewed) * ? AS score
+			FROM pairs GROUP BY product_id, related_product_id
+		),
+		ranked AS (
+			SELECT s.*, ROW_NUMBER() OVER (PARTITION BY s.product_id ORDER BY s.score DESC, s.related_product_id) AS rn
+			FROM scored s JOIN products p ON p.id = s.related_product_id
+			WHERE p.is_active = 1
+		)
+		SELECT product_id, related_product_id, co_purchase, co_cart, co_view, score, CURRENT_TIMESTAMP
+		FROM ranked WHERE rn <= ?`,
+		window, recommendationPurchaseWeight, recommendationCartWeight, recommendationViewWeight, maxItems)
+	if err != nil {
+		return nil, err
+	}
+	relations, _ := result.RowsAffected()
+
+	if _, err := tx.Exec(`DELETE FROM customer_recommendations`); err != nil {
+		return nil, err
+	}
+
+	// 以顧客互動過的商品為種子，加總其相關商品分數；已購買或已在購物車的商品不再推薦
+	result, err = tx.Exec(`
+		INSERT INTO customer_recommendations (customer_id, product_id, score, computed_at)
+		`+recommendationSignalsCTE+`,
+		seeds AS (
+			SELECT customer_id, product_id, MAX(weight) AS weight FROM (
+				SELECT customer_id, product_id, ? AS weight FROM purchases
+				UNION ALL SELECT customer_id, product_id, ? FROM carts
+				UNION ALL SELECT customer_id, product_id, ? FROM views
+			) GROUP BY customer_id, product_id
+		),
+		candidates AS (
+			SELECT s.customer_id, r.related_product_id AS product_id, SUM(s.weight * r.score) AS score
+			FROM seeds s JOIN product_relations r ON r.product_id = s.product_id
+			WHERE NOT EXISTS (SELECT 1 FROM purchases pu WHERE pu.customer_id = s.customer_id AND pu.product_id = r.related_product_id)
+			  AND NOT EXISTS (SELECT 1 FROM carts c WHERE c.customer_id = s.customer_id AND c.product_id = r.related_product_id)
+			GROUP BY s.customer_id, r.related_product_id
+		),
+		ranked AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY customer_id ORDER BY score DESC, product_id) AS rn
+			FROM candidates
+		)
+		SELECT customer_id, product_id, score, CURRENT_TIMESTAMP FROM ranked WHERE rn <= ?`,
+		window, recommendationPurchaseWeight, recommendationCartWeight, recommendationViewWeight, maxItems)
+	if err != nil {
+		return nil, err
+	}
+	recommendations, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &RecommendationStats{
+		Relations:       int(relations),
+		Recommendations: int(recommendations),
+	}, nil
+}
+
+// GetRelated 獲取商品的相關商品（「買了這個的顧客也買了」），依分數排序
+func (r *RecommendationRepository) GetRelated(productID, limit int) ([]*Product, error) {
+	return r.queryProducts(`
+		SELECT `+prefixedProductColumns+`
+		FROM product_relations r JOIN products p ON p.id = r.related_product_id
+		WHERE r.product_id = ? AND p.is_active = 1
+		ORDER BY r.score DESC, p.sales_count DESC LIMIT ?`, productID, limit)
+}
+
+// GetForCustomer 獲取顧客的個人化推薦
+func (r *RecommendationRepository) GetForCustomer(customerID, limit int) ([]*Product, error) {
+	return r.queryProducts(`
+		SELECT `+prefixedProductColumns+`
+		FROM customer_recommendations cr JOIN products p ON p.id = cr.product_id
+		WHERE cr.customer_id = ? AND p.is_active = 1
+		ORDER BY cr.score DESC, p.sales_count DESC LIMIT ?`, customerID, limit)
+}
+
+// GetSimilar 獲取同分類（含子孫分類）的熱銷商品，作為尚無相關商品時的備援
+func (r *RecommendationRepository) GetSimilar(product *Product, excludeIDs []int, limit int) ([]*Product, error) {
+	query := `SELECT ` + prefixedProductColumns + ` FROM products p WHERE p.is_active = 1 AND p.id != ?`
+	args := []interface{}{product.ID}
+	if product.CategoryID != nil {
+		query += ` AND p.category_id IN (` + categoryDescendantsCTE + ` SELECT id FROM category_tree)`
+		args = append(args, *product.CategoryID)
+	} else {
+		query += ` AND p.category = ?`
+		args = append(args, product.Category)
+	}
+	query, args = excludeProducts(query, args, excludeIDs)
+	query += ` ORDER BY p.sales_count DESC, p.rating DESC, p.view_count DESC LIMIT ?`
+
+	return r.queryProducts(query, append(args, limit)...)
+}
+
+// GetPopular 獲取熱銷商品，指定顧客時略過其已購買與購物車中的商品
+func (r *RecommendationRepository) GetPopular(customerID int, excludeIDs []int, limit int) ([]*Product, error) {
+	query := `SELECT ` + prefixedProductColumns + ` FROM products p WHERE p.is_active = 1`
+	args := []interface{}{}
+	if customerID > 0 {
+		query += `
+			AND p.id NOT IN (SELECT oi.product_id FROM order_items oi JOIN orders o ON o.id = oi.order_id
+			                 WHERE o.customer_id = ? AND o.status != '` + OrderStatusCancelled + `')
+			AND p.id NOT IN (SELECT product_id FROM shopping_cart WHERE customer_id = ?)`
+		args = append(args, customerID, customerID)
+	}
+	query, args = excludeProducts(query, args, excludeIDs)
+	query += ` ORDER BY p.sales_count DESC, p.view_count DESC, p.rating DESC LIMIT ?`
+
+	return r.queryProducts(query, append(args, limit)...)
+}
+
+func excludeProducts(query string, args []interface{}, ids []int) (string, []interface{}) {
+	for _, id := range ids {
+		query += ` AND p.id != ?`
+		args = append(args, id)
+	}
+	return query, args
+}
+
+func (r *RecommendationRepository) queryProducts(query string, args ...interface{}) ([]*Product, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
@@ -0,0 +1,292 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 庫存保留狀態
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusConverted = "converted"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+// StockReservation 結帳時的庫存保留
+type StockReservation struct {
+	ID         int       `json:"id" db:"id"`
+	CustomerID int       `json:"customer_id" db:"customer_id"`
+	ProductID  int       `json:"product_id" db:"product_id"`
+	VariantID  int       `json:"variant_id,omitempty" db:"variant_id"` // 0 表示商品沒有規格
+	Quantity   int       `json:"quantity" db:"quantity"`
+	Status     string    `json:"status" db:"status"`
+	GroupID    *int      `json:"group_id,omitempty" db:"group_id"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// StockKey 庫存保留的對象：商品或商品的某個規格
+type StockKey struct {
+	ProductID int
+	VariantID int
+}
+
+// ReservationRepository 庫存保留數據庫操作
+type ReservationRepository struct {
+	db *sql.DB
+}
+
+// NewReservationRepository 創建庫存保留倉庫
+func NewReservationRepository(db *sql.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// ReserveTx 在交易中保留庫存，可售數量不足時返回 ErrInsufficientStock
+// 有規格時同時保留規格與商品層級的數量
+func (r *ReservationRepository) ReserveTx(tx *sql.Tx, reservation *StockReservation) error {
+	if reservation.VariantID > 0 {
+		result, err := tx.Exec(`
+			UPDATE product_variants SET reserved_stock = reserved_stock + ?
+			WHERE id = ? AND product_id = ? AND is_active = 1 AND stock - reserved_stock >= ?`,
+			reservation.Quantity, reservation.VariantID, reservation.ProductID, reservation.Quantity)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE products SET reserved_stock = reserved_stock + ?
+		WHERE id = ? AND is_active = 1 AND stock - reserved_stock >= ?`,
+		reservation.Quantity, reservation.ProductID, reservation.Quantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO stock_reservations (customer_id, product_id, variant_id, quantity, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		reservation.CustomerID, reservation.ProductID, reservation.VariantID, reservation.Quantity, ReservationStatusActive,
+		reservation.ExpiresAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	reservation.ID = int(id)
+	reservation.Status = ReservationStatusActive
+	reservation.CreatedAt = time.Now()
+	reservation.UpdatedAt = reservation.CreatedAt
+
+	return nil
+}
+
+// GetActiveByCustomer 獲取客戶仍有效的庫存保留，已過期但尚未由排程釋放的保留不列入
+func (r *ReservationRepository) GetActiveByCustomer(customerID int) ([]StockReservation, error) {
+	rows, err := r.db.Query(`
+		SELECT id, customer_id, product_id, variant_id, quantity, status, group_id, expires_at, created_at, updated_at
+		FROM stock_reservations WHERE customer_id = ? AND status = ? AND expires_at > ? ORDER BY id`,
+		customerID, ReservationStatusActive, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []StockReservation{}
+	for rows.Next() {
+		reservation := StockReservation{}
+		err := rows.Scan(&reservation.ID, &reservation.CustomerID, &reservation.ProductID,
+			&reservation.VariantID, &reservation.Quantity, &reservation.Status, &reservation.GroupID, &reservation.ExpiresAt,
+			&reservation.CreatedAt, &reservation.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, rows.Err()
+}
+
+// GetActiveQuantity 獲取客戶對某商品（或商品規格）仍有效的保留數量
+func (r *ReservationRepository) GetActiveQuantity(customerID int, key StockKey) (int, error) {
+	var quantity int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations
+		WHERE customer_id = ? AND product_id = ? AND variant_id = ? AND status = ? AND expires_at > ?`,
+		customerID, key.ProductID, key.VariantID, ReservationStatusActive, time.Now().UTC()).Scan(&quantity)
+	return quantity, err
+}
+
+// GetActiveQuantitiesTx 在交易中獲取客戶各商品（或商品規格）仍有效的保留數量
+// now 與 ConvertCustomerTx 使用同一時間點；已過期的保留即使尚未由排程釋放也不列入
+func (r *ReservationRepository) GetActiveQuantitiesTx(tx *sql.Tx, customerID int, now time.Time) (map[StockKey]int, error) {
+	rows, err := tx.Query(`
+		SELECT product_id, variant_id, SUM(quantity) FROM stock_reservations
+		WHERE customer_id = ? AND status = ? AND expires_at > ? GROUP BY product_id, variant_id`,
+		customerID, ReservationStatusActive, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := make(map[StockKey]int)
+	for rows.Next() {
+		var key StockKey
+		var quantity int
+		if err := rows.Scan(&key.ProductID, &key.VariantID, &quantity); err != nil {
+			return nil, err
+		}
+		quantities[key] = quantity
+	}
+
+	return quantities, rows.Err()
+}
+
+// ConvertCustomerTx 在交易中將客戶的有效保留標記為已轉為訂單
+// 保留數量由呼叫端在扣減庫存時一併從 reserved_stock 扣除；已過期的保留留給排程釋放並歸還 reserved_stock
+func (r *ReservationRepository) ConvertCustomerTx(tx *sql.Tx, customerID, groupID int, now time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE stock_reservations SET status = ?, group_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = ? AND status = ? AND expires_at > ?`,
+		ReservationStatusConverted, groupID, customerID, ReservationStatusActive, now.UTC())
+	return err
+}
+
+// ReturnReservedTx 在交易中歸還商品（與規格）的保留數量，商品因此恢復可購買時為收藏者排入補貨通知
+func (r *ReservationRepository) ReturnReservedTx(tx *sql.Tx, key StockKey, quantity int) error {
+	watch, err := watchRestock(tx, key.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if key.VariantID > 0 {
+		_, err := tx.Exec(`UPDATE product_variants SET reserved_stock = MAX(0, reserved_stock - ?) WHERE id = ?`,
+			quantity, key.VariantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE products SET reserved_stock = MAX(0, reserved_stock - ?) WHERE id = ?`,
+		quantity, key.ProductID)
+	if err != nil {
+		return err
+	}
+
+	return watch.NotifyTx(tx)
+}
+
+// ReleaseCustomerTx 在交易中釋放客戶所有有效保留並歸還 reserved_stock
+// 回傳釋放前各商品是否可購買，呼叫端完成同一交易的其他庫存變動（例如重新保留）後以 NotifyTx 排入補貨通知，
+// 避免商品釋放後又立即被重新保留時仍通知收藏者
+func (r *ReservationRepository) ReleaseCustomerTx(tx *sql.Tx, customerID int) (RestockWatch, error) {
+	watch, err := watchRestockQuery(tx, `SELECT DISTINCT product_id FROM stock_reservations WHERE customer_id = ? AND status = ?`,
+		customerID, ReservationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products SET reserved_stock = MAX(0, reserved_stock - (
+			SELECT COALESCE(SUM(sr.quantity), 0) FROM stock_reservations sr
+			WHERE sr.customer_id = ? AND sr.status = ? AND sr.product_id = products.id))
+		WHERE id IN (SELECT product_id FROM stock_reservations WHERE customer_id = ? AND status = ?)`,
+		customerID, ReservationStatusActive, customerID, ReservationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE product_variants SET reserved_stock = MAX(0, reserved_stock - (
+			SELECT COALESCE(SUM(sr.quantity), 0) FROM stock_reservations sr
+			WHERE sr.customer_id = ? AND sr.status = ? AND sr.variant_id = product_variants.id))
+		WHERE id IN (SELECT variant_id FROM stock_reservations WHERE customer_id = ? AND status = ?)`,
+		customerID, ReservationStatusActive, customerID, ReservationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE stock_reservations SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = ? AND status = ?`,
+		ReservationStatusReleased, customerID, ReservationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch, nil
+}
+
+// ExpireDue 將已過期的保留標記為過期並歸還 reserved_stock（商品恢復可購買時排入補貨通知），回傳處理筆數
+func (r *ReservationRepository) ExpireDue(now time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, product_id, variant_id, quantity FROM stock_reservations
+		WHERE status = ? AND expires_at <= ?`, ReservationStatusActive, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	type dueReservation struct {
+		id       int
+		key      StockKey
+		quantity int
+	}
+	var due []dueReservation
+	for rows.Next() {
+		var item dueReservation
+		if err := rows.Scan(&item.id, &item.key.ProductID, &item.key.VariantID, &item.quantity); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, item := range due {
+		result, err := tx.Exec(`
+			UPDATE stock_reservations SET status = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND status = ?`, ReservationStatusExpired, item.id, ReservationStatusActive)
+		if err != nil {
+			return 0, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			continue
+		}
+
+		if err := r.ReturnReservedTx(tx, item.key, item.quantity); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(due), nil
+}
@@ -0,0 +1,544 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 退貨申請狀態
+//
+//	requested → approved → refunded
+//	requested → rejected / cancelled
+//
+// approved 表示已核准但金流退款尚未成功（退款中或退款失敗待重試）
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRefunded  = "refunded"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusCancelled = "cancelled"
+)
+
+// 退貨退款狀態
+const (
+	ReturnRefundPending   = "pending"
+	ReturnRefundSucceeded = "succeeded"
+	ReturnRefundFailed    = "failed"
+)
+
+// 退貨原因
+const (
+	ReturnReasonDamaged        = "damaged"          // 商品破損
+	ReturnReasonDefective      = "defective"        // 功能瑕疵
+	ReturnReasonWrongItem      = "wrong_item"       // 寄錯商品或規格
+	ReturnReasonNotAsDescribed = "not_as_described" // 與商品描述不符
+	ReturnReasonChangedMind    = "changed_mind"     // 七天鑑賞期內不想要
+	ReturnReasonOther          = "other"
+)
+
+// 退貨稽核動作
+const (
+	ReturnActionRequested       = "requested"
+	ReturnActionPhotoAdded      = "photo_added"
+	ReturnActionApproved        = "approved"
+	ReturnActionRejected        = "rejected"
+	ReturnActionCancelled       = "cancelled"
+	ReturnActionRestocked       = "restocked"
+	ReturnActionRefundSucceeded = "refund_succeeded"
+	ReturnActionRefundFailed    = "refund_failed"
+)
+
+// MaxReturnPhotosPerItem 每個退貨商品可上傳的照片數量上限
+const MaxReturnPhotosPerItem = 5
+
+// IsValidReturnReason 檢查是否為已定義的退貨原因
+func IsValidReturnReason(reason string) bool {
+	switch reason {
+	case ReturnReasonDamaged, ReturnReasonDefective, ReturnReasonWrongItem, ReturnReasonNotAsDescribed,
+		ReturnReasonChangedMind, ReturnReasonOther:
+		return true
+	}
+	return false
+}
+
+// IsValidReturnStatus 檢查是否為已定義的退貨申請狀態
+func IsValidReturnStatus(status string) bool {
+	switch status {
+	case ReturnStatusRequested, ReturnStatusApproved, ReturnStatusRefunded, ReturnStatusRejected, ReturnStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// ReturnRequest 退貨申請，一筆申請對應一張（單一商戶的）訂單
+type ReturnRequest struct {
+	ID             int              `json:"id" db:"id"`
+	ReturnNumber   string           `json:"return_number" db:"return_number"`
+	OrderID        int              `json:"order_id" db:"order_id"`
+	OrderNumber    string           `json:"order_number" db:"order_number"`
+	CustomerID     int              `json:"customer_id" db:"customer_id"`
+	MerchantID     int              `json:"merchant_id" db:"merchant_id"`
+	Status         string           `json:"status" db:"status"`
+	RefundAmount   float64          `json:"refund_amount" db:"refund_amount"` // 不含運費，已依訂單折扣比例分攤
+	RefundStatus   *string          `json:"refund_status,omitempty" db:"refund_status"`
+	RefundRef      *string          `json:"refund_ref,omitempty" db:"refund_ref"`
+	Restock        bool             `json:"restock" db:"restock"`
+	CustomerNote   *string          `json:"customer_note,omitempty" db:"customer_note"`
+	ResolutionNote *string          `json:"resolution_note,omitempty" db:"resolution_note"`
+	ResolvedByType *string          `json:"resolved_by_type,omitempty" db:"resolved_by_type"`
+	ResolvedByID   *int             `json:"resolved_by_id,omitempty" db:"resolved_by_id"`
+	ResolvedAt     *time.Time       `json:"resolved_at,omitempty" db:"resolved_at"`
+	RefundedAt     *time.Time       `json:"refunded_at,omitempty" db:"refunded_at"`
+	Items          []*ReturnItem    `json:"items,omitempty"`
+	Events         []ReturnEvent    `json:"events,omitempty"`
+	Refunds        []*PaymentRefund `json:"refunds,omitempty"` // 金流退款紀錄，僅管理員檢視時帶出
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// ReturnItem 退貨商品（商品名稱為訂單快照）
+type ReturnItem struct {
+	ID           int           `json:"id" db:"id"`
+	ReturnID     int           `json:"return_id" db:"return_id"`
+	OrderItemID  int           `json:"order_item_id" db:"order_item_id"`
+	ProductID    int           `json:"product_id" db:"product_id"`
+	VariantID    *int          `json:"variant_id,omitempty" db:"variant_id"`
+	ProductName  string        `json:"product_name" db:"product_name"`
+	Quantity     int           `json:"quantity" db:"quantity"`
+	Reason       string        `json:"reason" db:"reason"`
+	ReasonDetail *string       `json:"reason_detail,omitempty" db:"reason_detail"`
+	RefundAmount float64       `json:"refund_amount" db:"refund_amount"`
+	Photos       []ReturnPhoto `json:"photos"`
+}
+
+// ReturnPhoto 退貨商品照片
+type ReturnPhoto struct {
+	ID           int       `json:"id" db:"id"`
+	ReturnItemID int       `json:"return_item_id" db:"return_item_id"`
+	Storage      string    `json:"storage" db:"storage"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	URL          string    `json:"url" db:"url"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	SizeBytes    int       `json:"size_bytes" db:"size_bytes"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReturnEvent 退貨稽核紀錄
+type ReturnEvent struct {
+	ID         int       `json:"id" db:"id"`
+	ReturnID   int       `json:"return_id" db:"return_id"`
+	Action     string    `json:"action" db:"action"`
+	FromStatus *string   `json:"from_status,omitempty" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	ActorType  string    `json:"actor_type" db:"actor_type"`
+	ActorID    int       `json:"actor_id" db:"actor_id"`
+	Note       *string   `json:"note,omitempty" db:"note"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateReturnRequest 申請退貨請求
+type CreateReturnRequest struct {
+	OrderID int                 `json:"order_id" binding:"required"`
+	Items   []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+	Note    *string             `json:"note,omitempty"`
+}
+
+// ReturnItemRequest 申請退貨的訂單商品
+type ReturnItemRequest struct {
+	OrderItemID  int     `json:"order_item_id" binding:"required"`
+	Quantity     int     `json:"quantity" binding:"required,min=1"`
+	Reason       string  `json:"reason" binding:"required"` // damaged, defective, wrong_item, not_as_described, changed_mind, other
+	ReasonDetail *string `json:"reason_detail,omitempty"`
+}
+
+// ReturnDecisionRequest 核准或拒絕退貨請求
+type ReturnDecisionRequest struct {
+	Note    *string `json:"note,omitempty"`    // 拒絕時必填
+	Restock *bool   `json:"restock,omitempty"` // 核准時是否回補庫存，預設為 true；商品毀損無法再販售時設為 false
+}
+
+// ReturnFilter 退貨申請查詢條件
+type ReturnFilter struct {
+	CustomerID *int
+	MerchantID *int
+	Status     string
+}
+
+// ReturnRepository 退貨申請數據庫操作
+type ReturnRepository struct {
+	db *sql.DB
+}
+
+// NewReturnRepository 創建退貨申請倉庫
+func NewReturnRepository(db *sql.DB) *ReturnRepository {
+	return &ReturnRepository{db: db}
+}
+
+// returnColumns 退貨申請查詢欄位，順序需與 scanReturn 一致
+const returnColumns = `r.id, r.return_number, r.order_id, o.order_number, r.customer_id, r.merchant_id, r.status,
+	r.refund_amount, r.refund_status, r.refund_ref, r.restock, r.customer_note, r.resolution_note, r.resolved_by_type,
+	r.resolved_by_id, r.resolved_at, r.refunded_at, r.created_at, r.updated_at`
+
+const returnFrom = ` FROM return_requests r JOIN orders o ON o.id = r.order_id`
+
+// scanReturn 掃描一筆退貨申請資料
+func scanReturn(scanner rowScanner) (*ReturnRequest, error) {
+	ret := &ReturnRequest{}
+	err := scanner.Scan(&ret.ID, &ret.ReturnNumber, &ret.OrderID, &ret.OrderNumber, &ret.CustomerID, &ret.MerchantID,
+		&ret.Status, &ret.RefundAmount, &ret.RefundStatus, &ret.RefundRef, &ret.Restock, &ret.CustomerNote,
+		&ret.ResolutionNote, &ret.ResolvedByType, &ret.ResolvedByID, &ret.ResolvedAt, &ret.RefundedAt,
+		&ret.CreatedAt, &ret.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// CreateTx 在交易中創建退貨申請與退貨商品
+func (r *ReturnRepository) CreateTx(tx *sql.Tx, ret *ReturnRequest) error {
+	result, err := tx.Exec(`
+		INSERT INTO return_requests (return_number, order_id, customer_id, merchant_id, status, refund_amount, customer_note)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		ret.ReturnNumber, ret.OrderID, ret.CustomerID, ret.MerchantID, ret.Status, ret.RefundAmount, ret.CustomerNote)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	ret.ID = int(id)
+
+	for _, item := range ret.Items {
+		item.ReturnID = ret.ID
+		result, err := tx.Exec(`
+			INSERT INTO return_items (return_id, order_item_id, product_id, variant_id, product_name, quantity,
+			                          reason, reason_detail, refund_amount)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			item.ReturnID, item.OrderItemID, item.ProductID, item.VariantID, item.ProductName, item.Quantity,
+			item.Reason, item.ReasonDetail, item.RefundAmount)
+		if err != nil {
+			return err
+		}
+		itemID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		item.ID = int(itemID)
+	}
+
+	return nil
+}
+
+// GetByID 根據ID獲取退貨申請
+func (r *ReturnRepository) GetByID(id int) (*ReturnRequest, error) {
+	return scanReturn(r.db.QueryRow(`SELECT `+returnColumns+returnFrom+` WHERE r.id = ?`, id))
+}
+
+// returnWhere 依查詢條件組合 WHERE 子句
+func returnWhere(filter ReturnFilter) (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.CustomerID != nil {
+		conditions = append(conditions, "r.customer_id = ?")
+		args = append(args, *filter.CustomerID)
+	}
+	if filter.MerchantID != nil {
+		conditions = append(conditions, "r.merchant_id = ?")
+		args = append(args, *filter.MerchantID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "r.status = ?")
+		args = append(args, filter.Status)
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// List 依條件獲取退貨申請列表（不含商品與稽核紀錄），最新的在前
+func (r *ReturnRepository) List(filter ReturnFilter, limit, offset int) ([]*ReturnRequest, error) {
+	where, args := returnWhere(filter)
+	rows, err := r.db.Query(`SELECT `+returnColumns+returnFrom+where+` ORDER BY r.id DESC LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []*ReturnRequest{}
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+	return returns, rows.Err()
+}
+
+// Count 依條件計算退貨申請數量
+func (r *ReturnRepository) Count(filter ReturnFilter) (int, error) {
+	where, args := returnWhere(filter)
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*)`+returnFrom+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetItems 獲取退貨商品與照片
+func (r *ReturnRepository) GetItems(returnID int) ([]*ReturnItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, return_id, order_item_id, product_id, variant_id, product_name, quantity, reason, reason_detail,
+		       refund_amount
+		FROM return_items WHERE return_id = ? ORDER BY id`, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*ReturnItem{}
+	byID := map[int]*ReturnItem{}
+	for rows.Next() {
+		item := &ReturnItem{Photos: []ReturnPhoto{}}
+		err := rows.Scan(&item.ID, &item.ReturnID, &item.OrderItemID, &item.ProductID, &item.VariantID,
+			&item.ProductName, &item.Quantity, &item.Reason, &item.ReasonDetail, &item.RefundAmount)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		byID[item.ID] = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	photoRows, err := r.db.Query(`
+		SELECT p.id, p.return_item_id, p.storage, p.storage_key, p.url, p.content_type, p.size_bytes, p.created_at
+		FROM return_photos p JOIN return_items i ON i.id = p.return_item_id
+		WHERE i.return_id = ? ORDER BY p.id`, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer photoRows.Close()
+
+	for photoRows.Next() {
+		var photo ReturnPhoto
+		err := photoRows.Scan(&photo.ID, &photo.ReturnItemID, &photo.Storage, &photo.StorageKey, &photo.URL,
+			&photo.ContentType, &photo.SizeBytes, &photo.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if item, ok := byID[photo.ReturnItemID]; ok {
+			item.Photos = append(item.Photos, photo)
+		}
+	}
+	return items, photoRows.Err()
+}
+
+// GetItemsTx 在交易中獲取退貨商品（不含照片），供回補庫存使用
+func (r *ReturnRepository) GetItemsTx(tx *sql.Tx, returnID int) ([]*ReturnItem, error) {
+	rows, err := tx.Query(`
+		SELECT id, return_id, order_item_id, product_id, variant_id, product_name, quantity, reason, reason_detail,
+		       refund_amount
+		FROM return_items WHERE return_id = ? ORDER BY id`, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*ReturnItem{}
+	for rows.Next() {
+		item := &ReturnItem{}
+		err := rows.Scan(&item.ID, &item.ReturnID, &item.OrderItemID, &item.ProductID, &item.VariantID,
+			&item.ProductName, &item.Quantity, &item.Reason, &item.ReasonDetail, &item.RefundAmount)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ReturnedQuantitiesTx 在交易中計算訂單各商品已申請退貨的數量（不含已拒絕或已取消的申請）
+func (r *ReturnRepository) ReturnedQuantitiesTx(tx *sql.Tx, orderID int) (map[int]int, error) {
+	rows, err := tx.Query(`
+		SELECT i.order_item_id, SUM(i.quantity)
+		FROM return_items i JOIN return_requests r ON r.id = i.return_id
+		WHERE r.order_id = ? AND r.status NOT IN (?, ?)
+		GROUP BY i.order_item_id`, orderID, ReturnStatusRejected, ReturnStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := map[int]int{}
+	for rows.Next() {
+		var orderItemID, quantity int
+		if err := rows.Scan(&orderItemID, &quantity); err != nil {
+			return nil, err
+		}
+		quantities[orderItemID] = quantity
+	}
+	return quantities, rows.Err()
+}
+
+// OrderRefundSummary 統計訂單經退貨已退款的金額，以及仍在處理中（待審核或已核准未退款）的退貨申請數
+func (r *ReturnRepository) OrderRefundSummary(orderID int) (float64, int, error) {
+	var refunded float64
+	var open int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN status = ? THEN refund_amount ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status IN (?, ?) THEN 1 ELSE 0 END), 0)
+		FROM return_requests WHERE order_id = ?`,
+		ReturnStatusRefunded, ReturnStatusRequested, ReturnStatusApproved, orderID).Scan(&refunded, &open)
+	return refunded, open, err
+}
+
+// DeliveredAt 獲取訂單最近一次轉為已送達的時間，尚未送達時回傳 nil
+func (r *ReturnRepository) DeliveredAt(orderID int) (*time.Time, error) {
+	var deliveredAt time.Time
+	err := r.db.QueryRow(`
+		SELECT created_at FROM order_status_history WHERE order_id = ? AND to_status = ?
+		ORDER BY id DESC LIMIT 1`, orderID, OrderStatusDelivered).Scan(&deliveredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deliveredAt, nil
+}
+
+// UpdateStatusTx 在交易中變更退貨申請狀態，只有目前狀態為 from 時才會更新
+func (r *ReturnRepository) UpdateStatusTx(tx *sql.Tx, id int, from, to string) error {
+	result, err := tx.Exec(`UPDATE return_requests SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?`,
+		to, id, from)
+	return expectReturnUpdated(result, err)
+}
+
+// ResolveTx 在交易中記錄核准或拒絕結果，只有待審核的申請才會更新
+func (r *ReturnRepository) ResolveTx(tx *sql.Tx, ret *ReturnRequest) error {
+	result, err := tx.Exec(`
+		UPDATE return_requests SET status = ?, refund_status = ?, restock = ?, resolution_note = ?,
+		                           resolved_by_type = ?, resolved_by_id = ?, resolved_at = CURRENT_TIMESTAMP,
+		                           updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`,
+		ret.Status, ret.RefundStatus, ret.Restock, ret.ResolutionNote, ret.ResolvedByType, ret.ResolvedByID,
+		ret.ID, ReturnStatusRequested)
+	return expectReturnUpdated(result, err)
+}
+
+// ClaimRefundRetry 將退款失敗、或退款中超過 pendingTimeout 仍未記錄結果的已核准申請改回退款中，只有一個請求能取得重試權
+// 核准後的退款處理中斷（錯誤提前返回或程序結束）時申請會停在退款中，逾時後即可重試
+func (r *ReturnRepository) ClaimRefundRetry(id int, pendingTimeout time.Duration) error {
+	result, err := r.db.Exec(`
+		UPDATE return_requests SET refund_status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?
+		  AND (refund_status = ? OR (refund_status = ? AND updated_at <= datetime('now', ?)))`,
+		ReturnRefundPending, id, ReturnStatusApproved, ReturnRefundFailed,
+		ReturnRefundPending, fmt.Sprintf("-%d seconds", int(pendingTimeout.Seconds())))
+	return expectReturnUpdated(result, err)
+}
+
+// UpdateRefundTx 在交易中記錄退款結果，退款成功時申請轉為 refunded
+// 只有已核准且退款中（已取得退款權）的申請才會更新
+func (r *ReturnRepository) UpdateRefundTx(tx *sql.Tx, id int, refundStatus string, refundRef *string) error {
+	status := ReturnStatusApproved
+	refundedAt := "NULL"
+	if refundStatus == ReturnRefundSucceeded {
+		status = ReturnStatusRefunded
+		refundedAt = "CURRENT_TIMESTAMP"
+	}
+	result, err := tx.Exec(`
+		UPDATE return_requests SET status = ?, refund_status = ?, refund_ref = COALESCE(?, refund_ref),
+		                           refunded_at = `+refundedAt+`, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ? AND refund_status = ?`,
+		status, refundStatus, refundRef, id, ReturnStatusApproved, ReturnRefundPending)
+	return expectReturnUpdated(result, err)
+}
+
+// expectReturnUpdated 檢查條件式更新是否有更新到資料，沒有時表示狀態已被其他操作變更
+func expectReturnUpdated(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrReturnStatusConflict
+	}
+	return nil
+}
+
+// AddEventTx 在交易中寫入退貨稽核紀錄
+func (r *ReturnRepository) AddEventTx(tx *sql.Tx, event *ReturnEvent) error {
+	result, err := tx.Exec(`
+		INSERT INTO return_events (return_id, action, from_status, to_status, actor_type, actor_id, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.ReturnID, event.Action, event.FromStatus, event.ToStatus, event.ActorType, event.ActorID, event.Note)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	event.ID = int(id)
+	return nil
+}
+
+// GetEvents 獲取退貨稽核紀錄，依時間先後排序
+func (r *ReturnRepository) GetEvents(returnID int) ([]ReturnEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, return_id, action, from_status, to_status, actor_type, actor_id, note, created_at
+		FROM return_events WHERE return_id = ? ORDER BY id`, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []ReturnEvent{}
+	for rows.Next() {
+		var event ReturnEvent
+		err := rows.Scan(&event.ID, &event.ReturnID, &event.Action, &event.FromStatus, &event.ToStatus,
+			&event.ActorType, &event.ActorID, &event.Note, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// CreatePhotoTx 在交易中寫入退貨商品照片
+func (r *ReturnRepository) CreatePhotoTx(tx *sql.Tx, photo *ReturnPhoto) error {
+	result, err := tx.Exec(`
+		INSERT INTO return_photos (return_item_id, storage, storage_key, url, content_type, size_bytes)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		photo.ReturnItemID, photo.Storage, photo.StorageKey, photo.URL, photo.ContentType, photo.SizeBytes)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	photo.ID = int(id)
+
+	return tx.QueryRow(`SELECT created_at FROM return_photos WHERE id = ?`, photo.ID).Scan(&photo.CreatedAt)
+}
+
+var (
+	ErrReturnNotFound       = notFoundError("RETURN_NOT_FOUND", "退貨申請不存在")
+	ErrReturnItemNotFound   = notFoundError("RETURN_ITEM_NOT_FOUND", "退貨商品不存在")
+	ErrReturnStatusConflict = conflictError("RETURN_STATUS_CONFLICT", "退貨申請狀態已變更，請重新整理後再試")
+	ErrReturnNotEligible    = &DomainError{Code: "RETURN_NOT_ELIGIBLE", Message: "訂單尚未送達或已超過退貨期限"}
+	ErrReturnQuantity       = &DomainError{Code: "RETURN_QUANTITY_EXCEEDED", Message: "退貨數量超過可退數量"}
+	ErrTooManyReturnPhotos  = &DomainError{Code: "TOO_MANY_RETURN_PHOTOS", Message: "每個退貨商品最多上傳 5 張照片"}
+)
@@ -0,0 +1,296 @@
+package models
+
+import (
+	"database/sql"
+	"math"
+	"time"
+)
+
+// 評價狀態
+const (
+	ReviewStatusPublished = "published"
+	ReviewStatusHidden    = "hidden"
+)
+
+// ProductReview 商品評價
+// 只有已收貨（delivered/completed）的買家可評價，商品的 rating 與 review_count 只計算已發佈的評價
+type ProductReview struct {
+	ID                int        `json:"id" db:"id"`
+	ProductID         int        `json:"product_id" db:"product_id"`
+	ProductName       string     `json:"product_name" db:"product_name"`
+	CustomerID        int        `json:"customer_id" db:"customer_id"`
+	CustomerName      string     `json:"customer_name" db:"customer_name"`
+	OrderID           *int       `json:"order_id,omitempty" db:"order_id"`
+	Rating            int        `json:"rating" db:"rating"`
+	Title             *string    `json:"title,omitempty" db:"title"`
+	Content           *string    `json:"content,omitempty" db:"content"`
+	IsVerified        bool       `json:"is_verified" db:"is_verified"`
+	Status            string     `json:"status" db:"status"`
+	MerchantReply     *string    `json:"merchant_reply,omitempty" db:"merchant_reply"`
+	MerchantRepliedAt *time.Time `json:"merchant_replied_at,omitempty" db:"merchant_replied_at"`
+	ModerationNote    *string    `json:"moderation_note,omitempty" db:"moderation_note"`
+	ModeratedBy       *int       `json:"moderated_by,omitempty" db:"moderated_by"`
+	ModeratedAt       *time.Time `json:"moderated_at,omitempty" db:"moderated_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ReviewRequest 新增或修改評價請求
+type ReviewRequest struct {
+	Rating  int     `json:"rating" binding:"required"`
+	Title   *string `json:"title"`
+	Content string  `json:"content"`
+}
+
+// ReviewReplyRequest 商戶回覆評價請求
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" binding:"required"`
+}
+
+// ReviewModerationRequest 管理員審核評價請求
+type ReviewModerationRequest struct {
+	Status string `json:"status" binding:"required"` // published, hidden
+	Note   string `json:"note"`
+}
+
+// ReviewSummary 商品評價統計
+type ReviewSummary struct {
+	Rating       float64     `json:"rating"`
+	ReviewCount  int         `json:"review_count"`
+	Distribution map[int]int `json:"distribution"` // 各星等的評價數
+}
+
+// ReviewRepository 商品評價數據庫操作
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+// NewReviewRepository 創建評價倉庫
+func NewReviewRepository(db *sql.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+// reviewColumns 評價查詢欄位，順序需與 scanReview 一致，需搭配 reviewFrom 使用
+const reviewColumns = `r.id, r.product_id, p.name, r.customer_id, COALESCE(c.name, ''), r.order_id,
+	r.rating, r.title, r.content, r.is_verified, COALESCE(r.status, 'published'), r.merchant_reply,
+	r.merchant_replied_at, r.moderation_note, r.moderated_by, r.moderated_at, r.created_at, r.updated_at`
+
+// reviewFrom 評價查詢來源
+const reviewFrom = ` FROM product_reviews r
+	JOIN products p ON p.id = r.product_id
+	LEFT JOIN customers c ON c.id = r.customer_id`
+
+// scanReview 掃描一筆評價資料
+func scanReview(scanner rowScanner) (*ProductReview, error) {
+	review := &ProductReview{}
+	err := scanner.Scan(&review.ID, &review.ProductID, &review.ProductName, &review.CustomerID,
+		&review.CustomerName, &review.OrderID, &review.Rating, &review.Title, &review.Content,
+		&review.IsVerified, &review.Status, &review.MerchantReply, &review.MerchantRepliedAt,
+		&review.ModerationNote, &review.ModeratedBy, &review.ModeratedAt, &review.CreatedAt,
+		&review.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// queryReviews 查詢多筆評價
+func queryReviews(db dbQuerier, query string, args ...interface{}) ([]*ProductReview, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*ProductReview{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// FindEligibleOrderID 找出顧客已收貨且含該商品的最新訂單，沒有時返回 sql.ErrNoRows
+func (r *ReviewRepository) FindEligibleOrderID(customerID, productID int) (int, error) {
+	var orderID int
+	err := r.db.QueryRow(`
+		SELECT o.id FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.customer_id = ? AND oi.product_id = ? AND o.status IN (?, ?)
+		ORDER BY o.id DESC LIMIT 1`,
+		customerID, productID, OrderStatusDelivered, OrderStatusCompleted).Scan(&orderID)
+	return orderID, err
+}
+
+// CreateTx 在交易中新增評價
+func (r *ReviewRepository) CreateTx(tx *sql.Tx, review *ProductReview) error {
+	result, err := tx.Exec(`
+		INSERT INTO product_reviews (product_id, customer_id, order_id, rating, title, content, is_verified, status)
+		VALUES (?, ?, ?, ?, ?, ?, 1, ?)`,
+		review.ProductID, review.CustomerID, review.OrderID, review.Rating, review.Title, review.Content,
+		ReviewStatusPublished)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	review.ID = int(id)
+
+	return nil
+}
+
+// GetByID 根據ID獲取評價
+func (r *ReviewRepository) GetByID(id int) (*ProductReview, error) {
+	return r.getByID(r.db, id)
+}
+
+// GetByIDTx 在交易中根據ID獲取評價
+func (r *ReviewRepository) GetByIDTx(tx *sql.Tx, id int) (*ProductReview, error) {
+	return r.getByID(tx, id)
+}
+
+func (r *ReviewRepository) getByID(db dbExecutor, id int) (*ProductReview, error) {
+	return scanReview(db.QueryRow(`SELECT `+reviewColumns+reviewFrom+` WHERE r.id = ?`, id))
+}
+
+// ExistsForCustomer 顧客是否已評價過該商品
+func (r *ReviewRepository) ExistsForCustomer(productID, customerID int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM product_reviews WHERE product_id = ? AND customer_id = ?`,
+		productID, customerID).Scan(&count)
+	return count > 0, err
+}
+
+// GetPublishedByProductID 獲取商品已發佈的評價
+func (r *ReviewRepository) GetPublishedByProductID(productID, limit, offset int) ([]*ProductReview, error) {
+	return queryReviews(r.db, `SELECT `+reviewColumns+reviewFrom+`
+		WHERE r.product_id = ? AND COALESCE(r.status, 'published') = ?
+		ORDER BY r.created_at DESC, r.id DESC LIMIT ? OFFSET ?`,
+		productID, ReviewStatusPublished, limit, offset)
+}
+
+// GetSummary 統計商品已發佈評價的平均星等與各星等數量
+func (r *ReviewRepository) GetSummary(productID int) (*ReviewSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT rating, COUNT(*) FROM product_reviews
+		WHERE product_id = ? AND COALESCE(status, 'published') = ?
+		GROUP BY rating`, productID, ReviewStatusPublished)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &ReviewSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	total := 0
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		summary.Distribution[rating] = count
+		summary.ReviewCount += count
+		total += rating * count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if summary.ReviewCount > 0 {
+		summary.Rating = math.Round(float64(total)/float64(summary.ReviewCount)*100) / 100
+	}
+	return summary, nil
+}
+
+// GetByMerchantID 獲取商戶商品的評價（含已隱藏）
+func (r *ReviewRepository) GetByMerchantID(merchantID, productID, limit, offset int) ([]*ProductReview, int, error) {
+	where := ` WHERE p.merchant_id = ?`
+	args := []interface{}{merchantID}
+	if productID > 0 {
+		where += ` AND r.product_id = ?`
+		args = append(args, productID)
+	}
+
+	return r.queryPage(where, args, limit, offset)
+}
+
+// GetAll 管理員獲取評價，可依狀態篩選
+func (r *ReviewRepository) GetAll(status string, limit, offset int) ([]*ProductReview, int, error) {
+	where := ` WHERE 1 = 1`
+	args := []interface{}{}
+	if status != "" {
+		where += ` AND COALESCE(r.status, 'published') = ?`
+		args = append(args, status)
+	}
+
+	return r.queryPage(where, args, limit, offset)
+}
+
+// queryPage 分頁查詢評價並回傳總數
+func (r *ReviewRepository) queryPage(where string, args []interface{}, limit, offset int) ([]*ProductReview, int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*)`+reviewFrom+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	reviews, err := queryReviews(r.db, `SELECT `+reviewColumns+reviewFrom+where+`
+		ORDER BY r.created_at DESC, r.id DESC LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+// UpdateContentTx 在交易中更新評價星等與內容
+func (r *ReviewRepository) UpdateContentTx(tx *sql.Tx, review *ProductReview) error {
+	_, err := tx.Exec(`
+		UPDATE product_reviews SET rating = ?, title = ?, content = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, review.Rating, review.Title, review.Content, review.ID)
+	return err
+}
+
+// SetReply 設定商戶回覆
+func (r *ReviewRepository) SetReply(id int, reply string) error {
+	_, err := r.db.Exec(`
+		UPDATE product_reviews SET merchant_reply = ?, merchant_replied_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, reply, id)
+	return err
+}
+
+// ModerateTx 在交易中設定評價審核狀態
+func (r *ReviewRepository) ModerateTx(tx *sql.Tx, id int, status string, note *string, adminID int) error {
+	_, err := tx.Exec(`
+		UPDATE product_reviews SET status = ?, moderation_note = ?, moderated_by = ?,
+			moderated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, status, note, adminID, id)
+	return err
+}
+
+// RecomputeProductRatingTx 在交易中依已發佈的評價重新計算商品的 rating 與 review_count
+func (r *ReviewRepository) RecomputeProductRatingTx(tx *sql.Tx, productID int) error {
+	_, err := tx.Exec(`
+		UPDATE products SET
+			rating = COALESCE((SELECT ROUND(AVG(rating), 2) FROM product_reviews
+				WHERE product_id = ? AND COALESCE(status, 'published') = ?), 0),
+			review_count = (SELECT COUNT(*) FROM product_reviews
+				WHERE product_id = ? AND COALESCE(status, 'published') = ?)
+		WHERE id = ?`,
+		productID, ReviewStatusPublished, productID, ReviewStatusPublished, productID)
+	return err
+}
+
+var (
+	ErrReviewNotFound    = notFoundError("REVIEW_NOT_FOUND", "評價不存在")
+	ErrReviewExists      = conflictError("REVIEW_EXISTS", "您已評價過此商品，請修改原有評價")
+	ErrReviewNotEligible = forbiddenError("REVIEW_NOT_ELIGIBLE", "僅限已收到商品的買家評價")
+	ErrInvalidReview     = &DomainError{Code: "INVALID_REVIEW", Message: "評價星等需為 1 到 5"}
+)
@@ -0,0 +1,314 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 特價活動狀態
+const (
+	SaleStatusScheduled = "scheduled"
+	SaleStatusActive    = "active"
+	SaleStatusEnded     = "ended"
+	SaleStatusCancelled = "cancelled"
+)
+
+// SaleCampaign 限時特價活動
+// 開始時將商品價格改為特價並標記 is_on_sale，結束或售完時還原為活動開始前的價格
+// 特價只作用於商品價格，有規格的商品（以規格價格結帳）不能建立活動
+type SaleCampaign struct {
+	ID                   int       `json:"id" db:"id"`
+	MerchantID           int       `json:"merchant_id" db:"merchant_id"`
+	ProductID            int       `json:"product_id" db:"product_id"`
+	Name                 string    `json:"name" db:"name"`
+	SalePrice            float64   `json:"sale_price" db:"sale_price"`
+	QuantityCap          int       `json:"quantity_cap" db:"quantity_cap"`
+	SoldQuantity         int       `json:"sold_quantity" db:"sold_quantity"`
+	StartsAt             time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt               time.Time `json:"ends_at" db:"ends_at"`
+	Status               string    `json:"status" db:"status"`
+	RegularPrice         *float64  `json:"regular_price,omitempty" db:"regular_price"`
+	RegularOriginalPrice *float64  `json:"regular_original_price,omitempty" db:"regular_original_price"`
+	RegularOnSale        bool      `json:"regular_on_sale" db:"regular_on_sale"`
+	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SoldOut 是否已達特價數量上限
+func (c *SaleCampaign) SoldOut() bool {
+	return c.QuantityCap > 0 && c.SoldQuantity >= c.QuantityCap
+}
+
+// CreateSaleCampaignRequest 建立特價活動請求
+type CreateSaleCampaignRequest struct {
+	ProductID   int       `json:"product_id" binding:"required"`
+	Name        string    `json:"name" binding:"required"`
+	SalePrice   float64   `json:"sale_price" binding:"required"`
+	QuantityCap int       `json:"quantity_cap"`
+	StartsAt    time.Time `json:"starts_at" binding:"required"`
+	EndsAt      time.Time `json:"ends_at" binding:"required"`
+}
+
+// SaleCampaignRepository 特價活動數據庫操作
+type SaleCampaignRepository struct {
+	db *sql.DB
+}
+
+// NewSaleCampaignRepository 創建特價活動倉庫
+func NewSaleCampaignRepository(db *sql.DB) *SaleCampaignRepository {
+	return &SaleCampaignRepository{db: db}
+}
+
+// saleCampaignColumns 特價活動查詢欄位，順序需與 scanSaleCampaign 一致
+const saleCampaignColumns = `id, merchant_id, product_id, name, sale_price, quantity_cap, sold_quantity,
+	starts_at, ends_at, status, regular_price, regular_original_price, regular_on_sale,
+	created_at, updated_at`
+
+// scanSaleCampaign 掃描一筆特價活動資料
+func scanSaleCampaign(scanner rowScanner) (*SaleCampaign, error) {
+	campaign := &SaleCampaign{}
+	err := scanner.Scan(&campaign.ID, &campaign.MerchantID, &campaign.ProductID, &campaign.Name,
+		&campaign.SalePrice, &campaign.QuantityCap, &campaign.SoldQuantity, &campaign.StartsAt,
+		&campaign.EndsAt, &campaign.Status, &campaign.RegularPrice, &campaign.RegularOriginalPrice,
+		&campaign.RegularOnSale, &campaign.CreatedAt, &campaign.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// dbQuerier 同時適用於 *sql.DB 與 *sql.Tx 的查詢
+type dbQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// querySaleCampaigns 查詢多筆特價活動
+func querySaleCampaigns(db dbQuerier, query string, args ...interface{}) ([]*SaleCampaign, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := []*SaleCampaign{}
+	for rows.Next() {
+		campaign, err := scanSaleCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// Create 創建特價活動
+func (r *SaleCampaignRepository) Create(campaign *SaleCampaign) error {
+	result, err := r.db.Exec(`
+		INSERT INTO sale_campaigns (merchant_id, product_id, name, sale_price, quantity_cap, starts_at, ends_at, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		campaign.MerchantID, campaign.ProductID, campaign.Name, campaign.SalePrice, campaign.QuantityCap,
+		campaign.StartsAt.UTC(), campaign.EndsAt.UTC(), SaleStatusScheduled)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	created, err := r.GetByID(int(id))
+	if err != nil {
+		return err
+	}
+	*campaign = *created
+
+	return nil
+}
+
+// GetByID 根據ID獲取特價活動
+func (r *SaleCampaignRepository) GetByID(id int) (*SaleCampaign, error) {
+	return scanSaleCampaign(r.db.QueryRow(`SELECT `+saleCampaignColumns+` FROM sale_campaigns WHERE id = ?`, id))
+}
+
+// GetByMerchantID 獲取商戶的特價活動
+func (r *SaleCampaignRepository) GetByMerchantID(merchantID int, status string) ([]*SaleCampaign, error) {
+	query := `SELECT ` + saleCampaignColumns + ` FROM sale_campaigns WHERE merchant_id = ?`
+	args := []interface{}{merchantID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY starts_at DESC, id DESC`
+
+	return querySaleCampaigns(r.db, query, args...)
+}
+
+// GetOpen 獲取尚未結束的特價活動（已排程或進行中）
+func (r *SaleCampaignRepository) GetOpen() ([]*SaleCampaign, error) {
+	return querySaleCampaigns(r.db, `SELECT `+saleCampaignColumns+` FROM sale_campaigns
+		WHERE status IN (?, ?) ORDER BY starts_at, id`, SaleStatusScheduled, SaleStatusActive)
+}
+
+// GetOpenByProductID 獲取商品尚未結束的特價活動
+func (r *SaleCampaignRepository) GetOpenByProductID(productID int) ([]*SaleCampaign, error) {
+	return querySaleCampaigns(r.db, `SELECT `+saleCampaignColumns+` FROM sale_campaigns
+		WHERE product_id = ? AND status IN (?, ?) ORDER BY starts_at, id`,
+		productID, SaleStatusScheduled, SaleStatusActive)
+}
+
+// ActivateTx 在交易中開始特價：記錄商品目前價格後改為特價
+// 排程中的活動於開始時間套用；售完結束的活動在取消訂單釋出數量後也可重新套用
+func (r *SaleCampaignRepository) ActivateTx(tx *sql.Tx, campaign *SaleCampaign) error {
+	result, err := tx.Exec(`
+		UPDATE sale_campaigns SET status = ?,
+			regular_price = (SELECT price FROM products WHERE id = ?),
+			regular_original_price = (SELECT original_price FROM products WHERE id = ?),
+			regular_on_sale = (SELECT is_on_sale FROM products WHERE id = ?),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`,
+		SaleStatusActive, campaign.ProductID, campaign.ProductID, campaign.ProductID,
+		campaign.ID, campaign.Status)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrSaleCampaignConflict
+	}
+
+	// 原價欄位顯示活動前的售價，讓前台可顯示劃線價
+	_, err = tx.Exec(`
+		UPDATE products SET original_price = COALESCE(original_price, price), price = ?, is_on_sale = 1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, campaign.SalePrice, campaign.ProductID)
+	if err != nil {
+		return err
+	}
+
+	campaign.Status = SaleStatusActive
+	return nil
+}
+
+// EndTx 在交易中結束特價活動，進行中的活動會還原商品價格
+func (r *SaleCampaignRepository) EndTx(tx *sql.Tx, campaign *SaleCampaign, status string) error {
+	result, err := tx.Exec(`
+		UPDATE sale_campaigns SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`, status, campaign.ID, campaign.Status)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrSaleCampaignConflict
+	}
+
+	if campaign.Status == SaleStatusActive {
+		// 只有商品仍是活動特價時才還原，商戶在活動期間改過價格時保留商戶的價格、原價與特價標記
+		// 原價與特價標記也只在仍是活動套用的值時還原
+		_, err = tx.Exec(`
+			UPDATE products SET
+				price = COALESCE(c.regular_price, products.price),
+				original_price = CASE WHEN products.original_price IS COALESCE(c.regular_original_price, c.regular_price)
+					THEN c.regular_original_price ELSE products.original_price END,
+				is_on_sale = CASE WHEN products.is_on_sale = 1 THEN c.regular_on_sale ELSE products.is_on_sale END,
+				updated_at = CURRENT_TIMESTAMP
+			FROM sale_campaigns c
+			WHERE c.id = ? AND products.id = c.product_id AND products.price = c.sale_price`,
+			campaign.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	campaign.Status = status
+	return nil
+}
+
+// RecordSoldTx 在交易中累加商品進行中特價活動的售出數量，並記錄到訂單商品供取消時扣回
+// 超過數量上限時返回 ErrSaleSoldOut，沒有進行中的活動時回傳 nil
+func (r *SaleCampaignRepository) RecordSoldTx(tx *sql.Tx, orderItemID, productID, quantity int) (*SaleCampaign, error) {
+	campaign, err := scanSaleCampaign(tx.QueryRow(`SELECT `+saleCampaignColumns+` FROM sale_campaigns
+		WHERE product_id = ? AND status = ? ORDER BY id LIMIT 1`, productID, SaleStatusActive))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE sale_campaigns SET sold_quantity = sold_quantity + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND (quantity_cap = 0 OR sold_quantity + ? <= quantity_cap)`,
+		quantity, campaign.ID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rowsAffected == 0 {
+		return nil, ErrSaleSoldOut
+	}
+
+	_, err = tx.Exec(`UPDATE order_items SET sale_campaign_id = ? WHERE id = ?`, campaign.ID, orderItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.SoldQuantity += quantity
+	return campaign, nil
+}
+
+// RevertSoldTx 在交易中扣回訂單商品計入的特價售出數量，回傳受影響的活動
+func (r *SaleCampaignRepository) RevertSoldTx(tx *sql.Tx, orderID int) ([]*SaleCampaign, error) {
+	_, err := tx.Exec(`
+		UPDATE sale_campaigns SET
+			sold_quantity = MAX(0, sold_quantity - (SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+			                                        WHERE oi.order_id = ? AND oi.sale_campaign_id = sale_campaigns.id)),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (SELECT sale_campaign_id FROM order_items WHERE order_id = ? AND sale_campaign_id IS NOT NULL)`,
+		orderID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return querySaleCampaigns(tx, `SELECT `+saleCampaignColumns+` FROM sale_campaigns
+		WHERE id IN (SELECT sale_campaign_id FROM order_items WHERE order_id = ? AND sale_campaign_id IS NOT NULL)
+		ORDER BY id`, orderID)
+}
+
+// HasOpenByProductTx 在交易中檢查商品是否有尚未結束（已排程或進行中）的特價活動
+func (r *SaleCampaignRepository) HasOpenByProductTx(tx *sql.Tx, productID int) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM sale_campaigns WHERE product_id = ? AND status IN (?, ?))`,
+		productID, SaleStatusScheduled, SaleStatusActive).Scan(&exists)
+	return exists, err
+}
+
+// HasOtherOpenTx 在交易中檢查商品是否有其他尚未結束且與活動期間重疊的特價活動
+func (r *SaleCampaignRepository) HasOtherOpenTx(tx *sql.Tx, campaign *SaleCampaign) (bool, error) {
+	open, err := querySaleCampaigns(tx, `SELECT `+saleCampaignColumns+` FROM sale_campaigns
+		WHERE product_id = ? AND id != ? AND status IN (?, ?)`,
+		campaign.ProductID, campaign.ID, SaleStatusScheduled, SaleStatusActive)
+	if err != nil {
+		return false, err
+	}
+	for _, other := range open {
+		if campaign.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(campaign.EndsAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var (
+	ErrSaleCampaignNotFound = notFoundError("SALE_CAMPAIGN_NOT_FOUND", "特價活動不存在")
+	ErrSaleCampaignConflict = conflictError("SALE_CAMPAIGN_CONFLICT", "特價活動狀態已變更，請重新整理後再試")
+	ErrSaleCampaignOverlap  = conflictError("SALE_CAMPAIGN_OVERLAP", "此商品在該期間已有其他特價活動")
+	ErrSaleSoldOut          = &DomainError{Code: "SALE_SOLD_OUT", Message: "特價商品已售完"}
+	ErrSaleVariantProduct   = &DomainError{Code: "SALE_VARIANT_PRODUCT", Message: "有規格的商品不支援限時特價"}
+	ErrSaleCampaignOpen     = conflictError("SALE_CAMPAIGN_OPEN", "商品有尚未結束的特價活動，請先取消活動再設定規格")
+)
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// 運費計算方式
+const (
+	ShippingRateFlat       = "flat"       // 固定運費
+	ShippingRateWeight     = "weight"     // 依實際重量級距
+	ShippingRateVolumetric = "volumetric" // 依實際重量與材積重取較大者的級距
+)
+
+// ShippingTier 運費級距，重量不超過 MaxWeight（公斤）時收取 Fee
+type ShippingTier struct {
+	MaxWeight float64 `json:"max_weight"`
+	Fee       float64 `json:"fee"`
+}
+
+// ShippingMethod 運送方式
+type ShippingMethod struct {
+	ID                int            `json:"id" db:"id"`
+	MerchantID        *int           `json:"merchant_id,omitempty" db:"merchant_id"` // nil 表示平台預設
+	Name              string         `json:"name" db:"name"`
+	Carrier           string         `json:"carrier" db:"carrier"`
+	RateType          string         `json:"rate_type" db:"rate_type"`
+	BaseFee           float64        `json:"base_fee" db:"base_fee"`
+	Tiers             []ShippingTier `json:"tiers" db:"tiers"`
+	VolumetricDivisor *float64       `json:"volumetric_divisor,omitempty" db:"volumetric_divisor"`
+	FreeThreshold     *float64       `json:"free_threshold,omitempty" db:"free_threshold"`
+	SortOrder         int            `json:"sort_order" db:"sort_order"`
+	IsActive          bool           `json:"is_active" db:"is_active"`
+	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Fee 依計費重量計算運費（未含免運），超過最後一個級距時回傳 false 表示不可使用
+func (m *ShippingMethod) Fee(weight float64) (float64, bool) {
+	if m.RateType == ShippingRateFlat {
+		return m.BaseFee, true
+	}
+	for _, tier := range m.Tiers {
+		if weight <= tier.MaxWeight {
+			return m.BaseFee + tier.Fee, true
+		}
+	}
+	return 0, false
+}
+
+// MaxWeight 可寄送的最大計費重量，固定運費時為 0 表示不限
+func (m *ShippingMethod) MaxWeight() float64 {
+	if m.RateType == ShippingRateFlat || len(m.Tiers) == 0 {
+		return 0
+	}
+	return m.Tiers[len(m.Tiers)-1].MaxWeight
+}
+
+// ShippingMethodRequest 建立或更新運送方式請求
+type ShippingMethodRequest struct {
+	MerchantID        *int           `json:"merchant_id,omitempty"` // 僅管理員可指定，商戶建立時固定為自己
+	Name              string         `json:"name" binding:"required"`
+	Carrier           string         `json:"carrier" binding:"required"`
+	RateType          string         `json:"rate_type" binding:"required"`
+	BaseFee           float64        `json:"base_fee"`
+	Tiers             []ShippingTier `json:"tiers,omitempty"`
+	VolumetricDivisor *float64       `json:"volumetric_divisor,omitempty"`
+	FreeThreshold     *float64       `json:"free_threshold,omitempty"`
+	SortOrder         int            `json:"sort_order"`
+	IsActive          *bool          `json:"is_active,omitempty"`
+
+	MerchantIDSet bool `json:"-"` // 請求是否帶有 merchant_id 欄位（含 null），管理員更新時未帶則保留原歸屬
+}
+
+// UnmarshalJSON 解析請求並記錄是否帶有 merchant_id 欄位，以區分未提供與指定為 null（平台預設）
+func (r *ShippingMethodRequest) UnmarshalJSON(data []byte) error {
+	type plain ShippingMethodRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	_, r.MerchantIDSet = fields["merchant_id"]
+	return nil
+}
+
+// SelectShippingRequest 選擇商戶運送方式請求
+type SelectShippingRequest struct {
+	MerchantID       int `json:"merchant_id" binding:"required"`
+	ShippingMethodID int `json:"shipping_method_id" binding:"required"`
+}
+
+// ShippingOption 購物車中某商戶可選的運送方式與運費
+type ShippingOption struct {
+	ShippingMethodID int      `json:"shipping_method_id"`
+	Name             string   `json:"name"`
+	Carrier          string   `json:"carrier"`
+	RateType         string   `json:"rate_type"`
+	Fee              float64  `json:"fee"`
+	OriginalFee      float64  `json:"original_fee"` // 免運前的運費
+	FreeShipping     bool     `json:"free_shipping"`
+	FreeThreshold    *float64 `json:"free_threshold,omitempty"`
+	Available        bool     `json:"available"`
+	Reason           string   `json:"reason,omitempty"` // 不可使用的原因
+}
+
+// ShippingRepository 運送方式數據庫操作
+type ShippingRepository struct {
+	db *sql.DB
+}
+
+// NewShippingRepository 創建運送方式倉庫
+func NewShippingRepository(db *sql.DB) *ShippingRepository {
+	return &ShippingRepository{db: db}
+}
+
+// shippingMethodColumns 運送方式查詢欄位，順序需與 scanShippingMethod 一致
+const shippingMethodColumns = `id, merchant_id, name, carrier, rate_type, base_fee, tiers, volumetric_divisor,
+	free_threshold, sort_order, is_active, created_at, updated_at`
+
+// scanShippingMethod 掃描一筆運送方式資料
+func scanShippingMethod(scanner rowScanner) (*ShippingMethod, error) {
+	method := &ShippingMethod{}
+	var tiers sql.NullString
+	err := scanner.Scan(&method.ID, &method.MerchantID, &method.Name, &method.Carrier, &method.RateType,
+		&method.BaseFee, &tiers, &method.VolumetricDivisor, &method.FreeThreshold, &method.SortOrder,
+		&method.IsActive, &method.CreatedAt, &method.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	method.Tiers = []ShippingTier{}
+	if tiers.Valid && tiers.String != "" {
+		if err := json.Unmarshal([]byte(tiers.String), &method.Tiers); err != nil {
+			return nil, err
+		}
+	}
+	return method, nil
+}
+
+// scanShippingMethods 掃描多筆運送方式資料
+func scanShippingMethods(rows *sql.Rows) ([]*ShippingMethod, error) {
+	defer rows.Close()
+
+	methods := []*ShippingMethod{}
+	for rows.Next() {
+		method, err := scanShippingMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	return methods, rows.Err()
+}
+
+// Create 創建運送方式
+func (r *ShippingRepository) Create(method *ShippingMethod) error {
+	tiers, err := json.Marshal(method.Tiers)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO shipping_methods (merchant_id, name, carrier, rate_type, base_fee, tiers, volumetric_divisor,
+		                              free_threshold, sort_order, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		method.MerchantID, method.Name, method.Carrier, method.RateType, method.BaseFee, string(tiers),
+		method.VolumetricDivisor, method.FreeThreshold, method.SortOrder, method.IsActive)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	method.ID = int(id)
+
+	return r.db.QueryRow(`SELECT created_at, updated_at FROM shipping_methods WHERE id = ?`, method.ID).
+		Scan(&method.CreatedAt, &method.UpdatedAt)
+}
+
+// Update 更新運送方式
+func (r *ShippingRepository) Update(method *ShippingMethod) error {
+	tiers, err := json.Marshal(method.Tiers)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE shipping_methods SET merchant_id = ?, name = ?, carrier = ?, rate_type = ?, base_fee = ?, tiers = ?,
+		                            volumetric_divisor = ?, free_threshold = ?, sort_order = ?, is_active = ?,
+		                            updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		method.MerchantID, method.Name, method.Carrier, method.RateType, method.BaseFee, string(tiers),
+		method.VolumetricDivisor, method.FreeThreshold, method.SortOrder, method.IsActive, method.ID)
+	return err
+}
+
+// GetByID 根據ID獲取運送方式
+func (r *ShippingRepository) GetByID(id int) (*ShippingMethod, error) {
+	return scanShippingMethod(r.db.QueryRow(`SELECT `+shippingMethodColumns+` FROM shipping_methods WHERE id = ?`, id))
+}
+
+// GetAll 獲取所有運送方式，平台預設在前
+func (r *ShippingRepository) GetAll() ([]*ShippingMethod, error) {
+	rows, err := r.db.Query(`SELECT ` + shippingMethodColumns + ` FROM shipping_methods
+		ORDER BY merchant_id IS NOT NULL, merchant_id, sort_order, id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanShippingMethods(rows)
+}
+
+// GetByMerchantID 獲取商戶自己的運送方式
+func (r *ShippingRepository) GetByMerchantID(merchantID int) ([]*ShippingMethod, error) {
+	rows, err := r.db.Query(`SELECT `+shippingMethodColumns+` FROM shipping_methods
+		WHERE merchant_id = ? ORDER BY sort_order, id`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	return scanShippingMethods(rows)
+}
+
+// GetActive 獲取啟用中的平台預設與指定商戶的運送方式
+func (r *ShippingRepository) GetActive(merchantIDs []int) ([]*ShippingMethod, error) {
+	query := `SELECT ` + shippingMethodColumns + ` FROM shipping_methods WHERE is_active = 1 AND (merchant_id IS NULL`
+	args := []interface{}{}
+	if len(merchantIDs) > 0 {
+		query += ` OR merchant_id IN (?` + strings.Repeat(", ?", len(merchantIDs)-1) + `)`
+		for _, merchantID := range merchantIDs {
+			args = append(args, merchantID)
+		}
+	}
+	query += `) ORDER BY sort_order, id`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanShippingMethods(rows)
+}
+
+// GetCartSelections 獲取客戶購物車各商戶選擇的運送方式（merchant_id => shipping_method_id）
+func (r *ShippingRepository) GetCartSelections(customerID int) (map[int]int, error) {
+	rows, err := r.db.Query(`SELECT merchant_id, shipping_method_id FROM cart_shipping_selections WHERE customer_id = ?`,
+		customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	selections := make(map[int]int)
+	for rows.Next() {
+		var merchantID, methodID int
+		if err := rows.Scan(&merchantID, &methodID); err != nil {
+			return nil, err
+		}
+		selections[merchantID] = methodID
+	}
+	return selections, rows.Err()
+}
+
+// SetCartSelection 設定客戶購物車某商戶的運送方式
+func (r *ShippingRepository) SetCartSelection(customerID, merchantID, methodID int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO cart_shipping_selections (customer_id, merchant_id, shipping_method_id) VALUES (?, ?, ?)
+		ON CONFLICT(customer_id, merchant_id) DO UPDATE SET shipping_method_id = excluded.shipping_method_id,
+		                                                    created_at = CURRENT_TIMESTAMP`,
+		customerID, merchantID, methodID)
+	return err
+}
+
+// ClearCartSelectionsTx 在交易中清除客戶購物車的運送方式選擇
+func (r *ShippingRepository) ClearCartSelectionsTx(tx *sql.Tx, customerID int) error {
+	_, err := tx.Exec(`DELETE FROM cart_shipping_selections WHERE customer_id = ?`, customerID)
+	return err
+}
+
+var (
+	ErrShippingMethodNotFound    = notFoundError("SHIPPING_METHOD_NOT_FOUND", "運送方式不存在")
+	ErrShippingMethodUnavailable = &DomainError{Code: "SHIPPING_METHOD_UNAVAILABLE", Message: "此運送方式不適用於目前購物車"}
+	ErrNoShippingMethod          = &DomainError{Code: "NO_SHIPPING_METHOD", Message: "沒有可用的運送方式"}
+)
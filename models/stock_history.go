@@ -0,0 +1,215 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// K 棒週期
+const (
+	StockInterval1m = "1m"
+	StockInterval5m = "5m"
+	StockInterval1d = "1d"
+)
+
+// StockTick 盤中逐筆行情，成交量與成交金額為當日累計值
+type StockTick struct {
+	ID        int       `json:"id" db:"id"`
+	StockCode string    `json:"stock_code" db:"stock_code"`
+	Price     float64   `json:"price" db:"price"`
+	Volume    int64     `json:"volume" db:"volume"`
+	Amount    float64   `json:"amount" db:"amount"`
+	TradedAt  time.Time `json:"traded_at" db:"traded_at"`
+}
+
+// StockBar OHLCV K 棒，分 K 的成交量與成交金額為週期內增量，日 K 為當日累計值
+type StockBar struct {
+	StockCode   string    `json:"-" db:"stock_code"`
+	Interval    string    `json:"-" db:"interval"`
+	BucketStart time.Time `json:"time" db:"bucket_start"`
+	Open        float64   `json:"open" db:"open_price"`
+	High        float64   `json:"high" db:"high_price"`
+	Low         float64   `json:"low" db:"low_price"`
+	Close       float64   `json:"close" db:"close_price"`
+	Volume      int64     `json:"volume" db:"volume"`
+	Amount      float64   `json:"amount" db:"amount"`
+}
+
+// StockHistory 股價歷史查詢結果
+type StockHistory struct {
+	StockCode string     `json:"stock_code"`
+	Interval  string     `json:"interval"`
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Bars      []StockBar `json:"bars"`
+}
+
+// StockHistoryCompaction 一次保留期限清理的結果
+type StockHistoryCompaction struct {
+	TicksDeleted          int64 `json:"ticks_deleted"`
+	MinuteBarsDeleted     int64 `json:"minute_bars_deleted"`
+	FiveMinuteBarsDeleted int64 `json:"five_minute_bars_deleted"`
+}
+
+// IsValidStockInterval 檢查 K 棒週期是否支援
+func IsValidStockInterval(interval string) bool {
+	switch interval {
+	case StockInterval1m, StockInterval5m, StockInterval1d:
+		return true
+	}
+	return false
+}
+
+// StockHistoryRepository 股價歷史數據訪問層
+// 時間一律以 UTC 寫入，DATETIME 欄位以字串比較時才會依時間排序
+type StockHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewStockHistoryRepository 創建股價歷史倉庫
+func NewStockHistoryRepository(db *sql.DB) *StockHistoryRepository {
+	return &StockHistoryRepository{db: db}
+}
+
+// LatestTick 獲取股票最近一筆逐筆行情，沒有資料時回傳 nil
+func (r *StockHistoryRepository) LatestTick(code string) (*StockTick, error) {
+	var tick StockTick
+	err := r.db.QueryRow(`
+		SELECT id, stock_code, price, volume, amount, traded_at
+		FROM stock_ticks WHERE stock_code = ? ORDER BY traded_at DESC, id DESC LIMIT 1`, code).
+		Scan(&tick.ID, &tick.StockCode, &tick.Price, &tick.Volume, &tick.Amount, &tick.TradedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tick, nil
+}
+
+// LatestBarBefore 獲取指定時間之前最近的一根 K 棒，沒有資料時回傳 nil
+func (r *StockHistoryRepository) LatestBarBefore(code, interval string, before time.Time) (*StockBar, error) {
+	bar, err := scanStockBar(r.db.QueryRow(`
+		SELECT stock_code, interval, bucket_start, open_price, high_price, low_price, close_price, volume, amount
+		FROM stock_bars WHERE stock_code = ? AND interval = ? AND bucket_start < ?
+		ORDER BY bucket_start DESC LIMIT 1`,
+		code, interval, before.UTC()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return bar, err
+}
+
+// CreateTickTx 寫入逐筆行情
+func (r *StockHistoryRepository) CreateTickTx(tx *sql.Tx, tick *StockTick) error {
+	result, err := tx.Exec(`
+		INSERT INTO stock_ticks (stock_code, price, volume, amount, traded_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		tick.StockCode, tick.Price, tick.Volume, tick.Amount, tick.TradedAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	tick.ID = int(id)
+	return nil
+}
+
+// AccumulateBarTx 將一筆行情併入分 K：首筆決定開盤價，之後更新最高、最低、收盤並累加成交量與成交金額
+func (r *StockHistoryRepository) AccumulateBarTx(tx *sql.Tx, bar *StockBar) error {
+	_, err := tx.Exec(`
+		INSERT INTO stock_bars (stock_code, interval, bucket_start, open_price, high_price, low_price, close_price, volume, amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(stock_code, interval, bucket_start) DO UPDATE SET
+			high_price = MAX(stock_bars.high_price, excluded.high_price),
+			low_price = MIN(stock_bars.low_price, excluded.low_price),
+			close_price = excluded.close_price,
+			volume = stock_bars.volume + excluded.volume,
+			amount = stock_bars.amount + excluded.amount,
+			updated_at = CURRENT_TIMESTAMP`,
+		bar.StockCode, bar.Interval, bar.BucketStart.UTC(), bar.Open, bar.High, bar.Low, bar.Close, bar.Volume, bar.Amount)
+	return err
+}
+
+// UpsertBarTx 以交易所提供的當日開高低收與累計量覆寫 K 棒（用於日 K）
+func (r *StockHistoryRepository) UpsertBarTx(tx *sql.Tx, bar *StockBar) error {
+	_, err := tx.Exec(`
+		INSERT INTO stock_bars (stock_code, interval, bucket_start, open_price, high_price, low_price, close_price, volume, amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(stock_code, interval, bucket_start) DO UPDATE SET
+			open_price = excluded.open_price,
+			high_price = excluded.high_price,
+			low_price = excluded.low_price,
+			close_price = excluded.close_price,
+			volume = excluded.volume,
+			amount = excluded.amount,
+			updated_at = CURRENT_TIMESTAMP`,
+		bar.StockCode, bar.Interval, bar.BucketStart.UTC(), bar.Open, bar.High, bar.Low, bar.Close, bar.Volume, bar.Amount)
+	return err
+}
+
+// GetBars 依時間順序獲取區間 [from, to) 內的 K 棒，最多 limit 根（保留最新的部分）
+func (r *StockHistoryRepository) GetBars(code, interval string, from, to time.Time, limit int) ([]StockBar, error) {
+	rows, err := r.db.Query(`
+		SELECT stock_code, interval, bucket_start, open_price, high_price, low_price, close_price, volume, amount
+		FROM stock_bars
+		WHERE stock_code = ? AND interval = ? AND bucket_start >= ? AND bucket_start < ?
+		ORDER BY bucket_start DESC LIMIT ?`,
+		code, interval, from.UTC(), to.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bars := []StockBar{}
+	for rows.Next() {
+		bar, err := scanStockBar(rows)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, *bar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 以倒序取最新的 limit 根後再轉回時間順序
+	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
+		bars[i], bars[j] = bars[j], bars[i]
+	}
+	return bars, nil
+}
+
+// DeleteTicksBefore 刪除指定時間之前的逐筆行情
+func (r *StockHistoryRepository) DeleteTicksBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM stock_ticks WHERE traded_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteBarsBefore 刪除指定週期在指定時間之前的 K 棒
+func (r *StockHistoryRepository) DeleteBarsBefore(interval string, cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM stock_bars WHERE interval = ? AND bucket_start < ?`, interval, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanStockBar(row rowScanner) (*StockBar, error) {
+	var bar StockBar
+	err := row.Scan(&bar.StockCode, &bar.Interval, &bar.BucketStart, &bar.Open, &bar.High, &bar.Low,
+		&bar.Close, &bar.Volume, &bar.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return &bar, nil
+}
+
+var (
+	ErrStockNotFound = notFoundError("STOCK_NOT_FOUND", "股票不存在")
+)
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 補貨通知狀態
+const (
+	NotificationStatusPending   = "pending"
+	NotificationStatusDelivered = "delivered"
+
+	NotificationTypeBackInStock = "back_in_stock"
+)
+
+// WishlistItem 收藏清單項目
+type WishlistItem struct {
+	ID              int       `json:"id" db:"id"`
+	CustomerID      int       `json:"customer_id" db:"customer_id"`
+	ProductID       int       `json:"product_id" db:"product_id"`
+	NotifyOnRestock bool      `json:"notify_on_restock" db:"notify_on_restock"`
+	Available       bool      `json:"available" db:"-"` // 商品上架中且有可售庫存
+	Product         *Product  `json:"product,omitempty"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WishlistNotification 收藏商品的補貨通知
+type WishlistNotification struct {
+	ID          int        `json:"id" db:"id"`
+	CustomerID  int        `json:"customer_id" db:"customer_id"`
+	ProductID   int        `json:"product_id" db:"product_id"`
+	Type        string     `json:"type" db:"type"`
+	Message     string     `json:"message" db:"message"`
+	Status      string     `json:"status" db:"status"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	ReadAt      *time.Time `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AddWishlistRequest 加入收藏請求
+type AddWishlistRequest struct {
+	ProductID       int   `json:"product_id" binding:"required"`
+	NotifyOnRestock *bool `json:"notify_on_restock"` // 未指定時預設通知
+}
+
+// WishlistRepository 收藏清單數據庫操作
+type WishlistRepository struct {
+	db *sql.DB
+}
+
+// NewWishlistRepository 創建收藏清單倉庫
+func NewWishlistRepository(db *sql.DB) *WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+// GetByCustomerID 獲取顧客的收藏清單（含已下架商品，以便顧客等待補貨）
+func (r *WishlistRepository) GetByCustomerID(customerID int) ([]*WishlistItem, error) {
+	query := `
+		SELECT w.id, w.customer_id, w.product_id, w.notify_on_restock, w.created_at, w.updated_at,
+		       p.name, p.description, p.price, p.original_price, p.category,
+		       p.sub_category, p.brand, p.sku, p.stock, p.reserved_stock, p.image_url, p.images,
+		       p.tags, p.is_active, p.is_featured, p.is_on_sale, p.merchant_id,
+		       p.view_count, p.sales_count, p.rating, p.review_count, p.weight,
+		       p.dimensions, p.created_at, p.updated_at
+		FROM wishlists w
+		JOIN products p ON w.product_id = p.id
+		WHERE w.customer_id = ?
+		ORDER BY w.created_at DESC, w.id DESC`
+
+	rows, err := r.db.Query(query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*WishlistItem{}
+	for rows.Next() {
+		item := &WishlistItem{}
+		product := &Product{}
+
+		err := rows.Scan(
+			&item.ID, &item.CustomerID, &item.ProductID, &item.NotifyOnRestock, &item.CreatedAt, &item.UpdatedAt,
+			&product.Name, &product.Description, &product.Price, &product.OriginalPrice,
+			&product.Category, &product.SubCategory, &product.Brand, &product.SKU,
+			&product.Stock, &product.ReservedStock, &product.ImageURL, &product.Images, &product.Tags,
+			&product.IsActive, &product.IsFeatured, &product.IsOnSale, &product.MerchantID,
+			&product.ViewCount, &product.SalesCount, &product.Rating, &product.ReviewCount,
+			&product.Weight, &product.Dimensions, &product.CreatedAt, &product.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		product.ID = item.ProductID
+		product.AvailableStock = product.Stock - product.ReservedStock
+		item.Product = product
+		item.Available = product.IsActive && product.AvailableStock > 0
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Upsert 加入收藏，已收藏時更新通知設定
+func (r *WishlistRepository) Upsert(customerID, productID int, notifyOnRestock bool) error {
+	_, err := r.db.Exec(`
+		INSERT INTO wishlists (customer_id, product_id, notify_on_restock) VALUES (?, ?, ?)
+		ON CONFLICT(customer_id, product_id) DO UPDATE SET
+			notify_on_restock = excluded.notify_on_restock, updated_at = CURRENT_TIMESTAMP`,
+		customerID, productID, notifyOnRestock)
+	return err
+}
+
+// Remove 移除收藏
+func (r *WishlistRepository) Remove(customerID, productID int) error {
+	result, err := r.db.Exec(`DELETE FROM wishlists WHERE customer_id = ? AND product_id = ?`, customerID, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrWishlistItemNotFound
+	}
+
+	return nil
+}
+
+// GetNotifications 獲取顧客的補貨通知
+func (r *WishlistRepository) GetNotifications(customerID int, unreadOnly bool, limit int) ([]*WishlistNotification, error) {
+	query := `SELECT id, customer_id, product_id, type, message, status, delivered_at, read_at, created_at
+		FROM wishlist_notifications WHERE customer_id = ?`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*WishlistNotification{}
+	for rows.Next() {
+		notification := &WishlistNotification{}
+		err := rows.Scan(&notification.ID, &notification.CustomerID, &notification.ProductID,
+			&notification.Type, &notification.Message, &notification.Status, &notification.DeliveredAt,
+			&notification.ReadAt, &notification.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// CountUnreadNotifications 統計顧客未讀的補貨通知
+func (r *WishlistRepository) CountUnreadNotifications(customerID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM wishlist_notifications WHERE customer_id = ? AND read_at IS NULL`,
+		customerID).Scan(&count)
+	return count, err
+}
+
+// MarkDelivered 將顧客待送出的通知標記為已送出（站內通知於顧客讀取列表時送達）
+func (r *WishlistRepository) MarkDelivered(customerID int) error {
+	_, err := r.db.Exec(`
+		UPDATE wishlist_notifications SET status = ?, delivered_at = CURRENT_TIMESTAMP
+		WHERE customer_id = ? AND status = ?`,
+		NotificationStatusDelivered, customerID, NotificationStatusPending)
+	return err
+}
+
+// MarkRead 將通知標記為已讀
+func (r *WishlistRepository) MarkRead(customerID, notificationID int) error {
+	result, err := r.db.Exec(`
+		UPDATE wishlist_notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = ? AND customer_id = ?`, notificationID, customerID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// productAvailable 商品是否上架中且有可售庫存
+func productAvailable(db dbExecutor, productID int) (bool, error) {
+	var available bool
+	err := db.QueryRow(`SELECT is_active = 1 AND stock - reserved_stock > 0 FROM products WHERE id = ?`,
+		productID).Scan(&available)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return available, err
+}
+
+// enqueueBackInStock 為開啟通知的收藏者排入補貨通知，已有未讀的同商品通知時不重複排入
+func enqueueBackInStock(db dbExecutor, productID int) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO wishlist_notifications (customer_id, product_id, type, message, status)
+		SELECT w.customer_id, w.product_id, ?, '您收藏的「' || p.name || '」已可購買', ?
+		FROM wishlists w
+		JOIN products p ON p.id = w.product_id
+		WHERE w.product_id = ? AND w.notify_on_restock = 1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM wishlist_notifications n
+		      WHERE n.customer_id = w.customer_id AND n.product_id = w.product_id AND n.read_at IS NULL
+		  )`,
+		NotificationTypeBackInStock, NotificationStatusPending, productID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+var (
+	ErrWishlistItemNotFound = notFoundError("WISHLIST_ITEM_NOT_FOUND", "收藏清單中沒有此商品")
+	ErrNotificationNotFound = notFoundError("NOTIFICATION_NOT_FOUND", "通知不存在")
+)
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"go-simple-app/controllers"
+	"go-simple-app/middleware"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAddressRoutes 設置客戶通訊錄路由
+func SetupAddressRoutes(router *gin.Engine, addressService *services.AddressService, unifiedAuthService *services.UnifiedAuthService) {
+	// 創建通訊錄控制器
+	addressController := controllers.NewAddressController(addressService)
+
+	// 縣市與鄉鎮市區（公開）
+	router.GET("/api/addresses/regions", addressController.GetRegions)
+
+	// 通訊錄API路由組（需要客戶端認證）
+	addressAPI := router.Group("/api/addresses")
+	addressAPI.Use(middleware.UnifiedAuthMiddleware(unifiedAuthService))
+	addressAPI.Use(middleware.CustomerMiddleware())
+	{
+		// 獲取通訊錄
+		addressAPI.GET("", addressController.GetAddresses)
+
+		// 新增、更新與刪除地址
+		addressAPI.POST("", addressController.CreateAddress)
+		addressAPI.PUT("/:id", addressController.UpdateAddress)
+		addressAPI.DELETE("/:id", addressController.DeleteAddress)
+
+		// 設定預設收件或帳單地址
+		addressAPI.PUT("/:id/default", addressController.SetDefaultAddress)
+	}
+}
@@ -30,6 +30,13 @@ func SetupCartRoutes(router *gin.Engine, cartService *services.CartService, unif
 		// 清空購物車
 		cartAPI.DELETE("", cartController.ClearCart)
 		
+		// 優惠券
+		cartAPI.POST("/coupon", cartController.ApplyCoupon)
+		cartAPI.DELETE("/coupon", cartController.RemoveCoupon)
+		
+		// 運送方式
+		cartAPI.PUT("/shipping", cartController.SelectShipping)
+		
 		// 購物車項目管理
 		cartItems := cartAPI.Group("/items")
 		{
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"go-simple-app/controllers"
+	"go-simple-app/middleware"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOrderRoutes 設置訂單路由
+func SetupOrderRoutes(router *gin.Engine, orderService *services.OrderService, inventoryService *services.InventoryService, unifiedAuthService *services.UnifiedAuthService) {
+	// 創建訂單控制器
+	orderController := controllers.NewOrderController(orderService, inventoryService)
+
+	// 訂單API路由組（需要客戶端認證）
+	orderAPI := router.Group("/api/orders")
+	orderAPI.Use(middleware.UnifiedAuthMiddleware(unifiedAuthService))
+	orderAPI.Use(middleware.CustomerMiddleware())
+	{
+		// 結帳下單
+		orderAPI.POST("", orderController.PlaceOrder)
+
+		// 獲取訂單列表
+		orderAPI.GET("", orderController.GetOrders)
+
+		// 結帳庫存保留
+		orderAPI.POST("/checkout", orderController.StartCheckout)
+		orderAPI.GET("/checkout", orderController.GetCheckout)
+		orderAPI.DELETE("/checkout", orderController.CancelCheckout)
+
+		// 獲取訂單群組（一次結帳的所有子訂單）
+		orderAPI.GET("/groups/:id", orderController.GetOrderGroup)
+
+		// 獲取訂單詳情
+		orderAPI.GET("/:id", orderController.GetOrder)
+
+		// 取消訂單
+		orderAPI.POST("/:id/cancel", orderController.CancelOrder)
+
+		// 確認收貨
+		orderAPI.POST("/:id/complete", orderController.CompleteOrder)
+	}
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"errors"
+
+	"go-simple-app/controllers"
+	"go-simple-app/logger"
+	"go-simple-app/middleware"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPaymentRoutes 設置付款路由
+// mockSimulate 為開發用的模擬付款開關，只能搭配模擬金流使用，Release 模式下拒絕啟動
+func SetupPaymentRoutes(router *gin.Engine, paymentService *services.PaymentService, unifiedAuthService *services.UnifiedAuthService, mockSimulate bool) {
+	if mockSimulate {
+		if gin.Mode() == gin.ReleaseMode {
+			logger.Fatal("金流配置錯誤", errors.New("Release 模式下不得啟用 PAYMENT_MOCK_SIMULATE"))
+		}
+		if paymentService.Provider().Name() != "mock" {
+			logger.Fatal("金流配置錯誤", errors.New("PAYMENT_MOCK_SIMULATE 只能搭配模擬金流使用"))
+		}
+	}
+
+	// 創建付款控制器
+	paymentController := controllers.NewPaymentController(paymentService)
+
+	// 金流回調（金流商呼叫，以簽章驗證，不需登入）
+	router.POST("/api/payments/callback/:provider", paymentController.HandleCallback)
+
+	// 付款API路由組（需要客戶端認證）
+	paymentAPI := router.Group("/api/payments")
+	paymentAPI.Use(middleware.UnifiedAuthMiddleware(unifiedAuthService))
+	paymentAPI.Use(middleware.CustomerMiddleware())
+	{
+		// 建立付款
+		paymentAPI.POST("", paymentController.CreatePayment)
+
+		// 獲取付款狀態
+		paymentAPI.GET("/:number", paymentController.GetPayment)
+
+		// 向金流商查詢交易結果
+		paymentAPI.POST("/:number/confirm", paymentController.ConfirmPayment)
+
+		// 模擬付款（僅限開發環境明確啟用）
+		if mockSimulate {
+			paymentAPI.POST("/mock/:number/simulate", paymentController.SimulateMockPayment)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"go-simple-app/controllers"
+	"go-simple-app/middleware"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReturnRoutes 設置客戶退貨申請路由（商戶審核與管理員稽核路由位於 routes.go 的商戶與管理員API群組）
+func SetupReturnRoutes(router *gin.Engine, returnController *controllers.ReturnController, unifiedAuthService *services.UnifiedAuthService) {
+	// 退貨API路由組（需要客戶端認證）
+	returnAPI := router.Group("/api/returns")
+	returnAPI.Use(middleware.UnifiedAuthMiddleware(unifiedAuthService))
+	returnAPI.Use(middleware.CustomerMiddleware())
+	{
+		// 獲取退貨申請
+		returnAPI.GET("", returnController.GetReturns)
+		returnAPI.GET("/:id", returnController.GetReturn)
+
+		// 申請與取消退貨
+		returnAPI.POST("", returnController.CreateReturn)
+		returnAPI.POST("/:id/cancel", returnController.CancelReturn)
+
+		// 上傳退貨商品照片
+		returnAPI.POST("/:id/items/:itemId/photos", returnController.UploadReturnPhotos)
+	}
+}
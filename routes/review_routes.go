@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"go-simple-app/controllers"
+	"go-simple-app/middleware"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReviewRoutes 設置商品評價路由（商戶回覆與管理員審核路由位於各自的 API 群組）
+func SetupReviewRoutes(router *gin.Engine, reviewController *controllers.ReviewController, unifiedAuthService *services.UnifiedAuthService) {
+	// 商品評價列表（公開）
+	router.GET("/api/products/:id/reviews", reviewController.GetProductReviews)
+
+	// 顧客評價API路由組（需要客戶端認證）
+	reviewAPI := router.Group("/api")
+	reviewAPI.Use(middleware.UnifiedAuthMiddleware(unifiedAuthService))
+	reviewAPI.Use(middleware.CustomerMiddleware())
+	{
+		// 評價已收到的商品
+		reviewAPI.POST("/products/:id/reviews", reviewController.CreateReview)
+
+		// 修改自己的評價
+		reviewAPI.PUT("/reviews/:id", reviewController.UpdateReview)
+	}
+}
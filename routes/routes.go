@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"go-simple-app/config"
 	"go-simple-app/controllers"
 	"go-simple-app/database"
 	"go-simple-app/logger"
@@ -23,6 +24,7 @@ func SetupRoutes(
 	chatController *controllers.ChatController,
 	oauthController *controllers.OAuthController,
 	versionService *services.VersionService,
+	cfg *config.Config,
 ) *gin.Engine {
 	r := gin.Default()
 
@@ -102,13 +104,72 @@ func SetupRoutes(
 
 	// 初始化商城控制器
 	productRepo := models.NewProductRepository(database.DB)
-	mallController := controllers.NewMallController(productRepo)
-	merchantProductController := controllers.NewMerchantProductController(productRepo)
-	imageController := controllers.NewImageController()
-	imageProxyController := controllers.NewImageProxyController()
+	
+	// 初始化商品全文檢索並於必要時重建索引
+	searchService := services.NewSearchService(database.DB, cfg.Search)
+	if err := searchService.EnsureIndex(); err != nil {
+		logger.Error("建立商品全文檢索索引失敗", err)
+	}
+	mallService := services.NewMallService(database.DB)
+	
+	// 初始化商品推薦並啟動批次重算排程
+	recommendationService := services.NewRecommendationService(database.DB, cfg.Recommendation)
+	recommendationService.StartScheduler()
+	mallController := controllers.NewMallController(productRepo, searchService, mallService)
+	
+	// 初始化庫存服務並啟動過期保留釋放排程
+	inventoryService := services.NewInventoryService(database.DB, cfg.Inventory)
+	if err := inventoryService.RecordOpeningBalances(); err != nil {
+		logger.Error("補登期初庫存失敗", err)
+	}
+	inventoryService.StartSweeper()
+	
+	// 初始化商戶商品與庫存控制器
+	merchantProductController := controllers.NewMerchantProductController(productRepo, inventoryService)
+	merchantInventoryController := controllers.NewMerchantInventoryController(inventoryService)
+	storage := services.NewObjectStorage(cfg.Storage)
+	if localStorage, ok := storage.(*services.LocalStorage); ok {
+		r.Static(cfg.Storage.PublicBaseURL, localStorage.Dir())
+	}
+	merchantProductImageController := controllers.NewMerchantProductImageController(services.NewProductImageService(database.DB, cfg.Storage, storage))
+	merchantProductImportController := controllers.NewMerchantProductImportController(services.NewProductImportService(database.DB, inventoryService))
+	imageController := controllers.NewImageController(services.NewPlaceholderService(cfg.Placeholder))
+	imageProxyController := controllers.NewImageProxyController(services.NewImageProxyService(cfg.ImageProxy))
+	
+	// 初始化運費計算服務和控制器
+	shippingService := services.NewShippingService(database.DB, cfg.Shipping)
+	shippingController := controllers.NewShippingController(shippingService)
 	
 	// 初始化購物車服務和控制器
-	cartService := services.NewCartService(database.DB)
+	cartService := services.NewCartService(database.DB, shippingService)
+	
+	// 初始化限時特價服務並啟動排程
+	saleService := services.NewSaleService(database.DB, cfg.Sale, cartService)
+	saleService.StartScheduler()
+	merchantSaleController := controllers.NewMerchantSaleController(saleService)
+	
+	// 初始化訂單服務
+	orderService := services.NewOrderService(database.DB, shippingService)
+	
+	// 初始化促銷服務和控制器
+	promotionController := controllers.NewPromotionController(services.NewPromotionService(database.DB))
+	
+	// 初始化商品評價服務和控制器
+	reviewController := controllers.NewReviewController(services.NewReviewService(database.DB))
+	
+	// 初始化商品分類服務和控制器
+	categoryController := controllers.NewCategoryController(services.NewCategoryService(database.DB))
+	
+	// 初始化付款服務與商戶訂單控制器（商戶退款需經金流）
+	paymentProvider, err := services.NewPaymentProvider(cfg.Payment)
+	if err != nil {
+		logger.Fatal("金流配置錯誤", err)
+	}
+	paymentService := services.NewPaymentService(database.DB, cfg.Payment, paymentProvider, orderService)
+	merchantOrderController := controllers.NewMerchantOrderController(orderService, paymentService)
+	
+	// 初始化退貨退款服務和控制器
+	returnController := controllers.NewReturnController(services.NewReturnService(database.DB, cfg.Returns, inventoryService, paymentService, storage))
 	
 	// 初始化版本控制器
 	versionController := controllers.NewVersionController(versionService)
@@ -180,11 +241,15 @@ func SetupRoutes(
 	{
 		// 商品相關API
 		api.GET("/categories", mallController.GetCategories)
+		api.GET("/categories/tree", categoryController.GetCategoryTree)
+		api.GET("/categories/:id/products", categoryController.GetCategoryProducts)
 		api.GET("/products/featured", mallController.GetFeaturedProducts)
 		api.GET("/products", mallController.GetProducts)
 		api.GET("/products/category/:category", mallController.GetProductsByCategory)
 		api.GET("/products/search", mallController.SearchProducts)
-		api.GET("/products/:id", mallController.GetProduct)
+		api.GET("/products/:id", middleware.OptionalUnifiedAuthMiddleware(unifiedAuthService), mallController.GetProduct)
+		api.GET("/products/:id/related", mallController.GetRelatedProducts)
+		api.GET("/recommendations", middleware.OptionalUnifiedAuthMiddleware(unifiedAuthService), mallController.GetRecommendations)
 		
 		// 圖片相關API
 		api.GET("/image/product", imageController.GenerateProductImage)
@@ -195,17 +260,25 @@ func SetupRoutes(
 
 	// 股票API路由
 	stockRepo := models.NewStockRepository(database.DB)
-	stockService := services.NewStockService(stockRepo)
+	stockHistoryService := services.NewStockHistoryService(database.DB, cfg.StockHistory)
+	candleService := services.NewCandleService(database.DB)
+	indicatorService := services.NewIndicatorService(candleService)
+	stockService := services.NewStockService(stockRepo, stockHistoryService, candleService, indicatorService)
+	chatController.SetIndicatorService(indicatorService)
 	stockController := controllers.NewStockController(stockService)
 	
 	// 啟動股票價格自動更新（每5秒，僅交易時間）
 	stockService.StartAutoUpdate()
+	stockHistoryService.StartCompactor()
 	
 	stockAPI := r.Group("/api/stock")
 	{
 		// 股票列表和搜尋
 		stockAPI.GET("/stocks", stockController.GetStocks)
 		stockAPI.GET("/stocks/:code", stockController.GetStock)
+		stockAPI.GET("/stocks/:code/history", stockController.GetStockHistory)
+		stockAPI.GET("/stocks/:code/candles", stockController.GetStockCandles)
+		stockAPI.GET("/stocks/:code/indicators", stockController.GetStockIndicators)
 		stockAPI.GET("/search", stockController.SearchStocks)
 		stockAPI.GET("/category/:category", stockController.GetStocksByCategory)
 		
@@ -228,6 +301,24 @@ func SetupRoutes(
 	// 設置購物車路由
 	SetupCartRoutes(r, cartService, unifiedAuthService)
 
+	// 設置訂單路由
+	SetupOrderRoutes(r, orderService, inventoryService, unifiedAuthService)
+
+	// 設置付款路由
+	SetupPaymentRoutes(r, paymentService, unifiedAuthService, cfg.Payment.Mock.SimulateEnabled)
+
+	// 設置商品評價路由
+	SetupReviewRoutes(r, reviewController, unifiedAuthService)
+
+	// 設置收藏清單路由
+	SetupWishlistRoutes(r, services.NewWishlistService(database.DB), unifiedAuthService)
+
+	// 設置客戶通訊錄路由
+	SetupAddressRoutes(r, services.NewAddressService(database.DB), unifiedAuthService)
+
+	// 設置退貨退款路由
+	SetupReturnRoutes(r, returnController, unifiedAuthService)
+
 	// 商城頁面路由（已移至Vue.js）
 	// {
 	//	// 商品詳情頁面
@@ -516,11 +607,55 @@ func SetupRoutes(
 		{
 			merchantAPI.GET("/products", merchantProductController.GetMerchantProducts)
 			merchantAPI.GET("/products/stats", merchantProductController.GetMerchantProductStats)
+			merchantAPI.POST("/products/import", merchantProductImportController.ImportProducts)
+			merchantAPI.GET("/products/export", merchantProductImportController.ExportProducts)
 			merchantAPI.POST("/products", merchantProductController.CreateMerchantProduct)
 			merchantAPI.GET("/products/:id", merchantProductController.GetMerchantProduct)
 			merchantAPI.PUT("/products/:id", merchantProductController.UpdateMerchantProduct)
 			merchantAPI.PUT("/products/:id/toggle-status", merchantProductController.ToggleMerchantProductStatus)
 			merchantAPI.DELETE("/products/:id", merchantProductController.DeleteMerchantProduct)
+			merchantAPI.POST("/products/:id/stock-adjustments", merchantInventoryController.AdjustStock)
+			merchantAPI.GET("/products/:id/variants", merchantInventoryController.GetVariants)
+			merchantAPI.PUT("/products/:id/variants", merchantInventoryController.SetVariants)
+			merchantAPI.GET("/products/:id/images", merchantProductImageController.GetImages)
+			merchantAPI.POST("/products/:id/images", merchantProductImageController.UploadImages)
+			merchantAPI.DELETE("/products/:id/images/:imageId", merchantProductImageController.DeleteImage)
+
+			// 商戶庫存異動帳與對帳
+			merchantAPI.GET("/inventory/movements", merchantInventoryController.GetMovements)
+			merchantAPI.GET("/inventory/reconcile", merchantInventoryController.GetReconciliation)
+			merchantAPI.POST("/inventory/reconcile", merchantInventoryController.ApplyReconciliation)
+
+			// 商戶訂單管理
+			merchantAPI.GET("/orders", merchantOrderController.GetMerchantOrders)
+			merchantAPI.GET("/orders/:id", merchantOrderController.GetMerchantOrder)
+			merchantAPI.PUT("/orders/:id/status", merchantOrderController.UpdateMerchantOrderStatus)
+
+			// 商戶退貨審核
+			merchantAPI.GET("/returns", returnController.GetMerchantReturns)
+			merchantAPI.GET("/returns/:id", returnController.GetMerchantReturn)
+			merchantAPI.POST("/returns/:id/approve", returnController.ApproveMerchantReturn)
+			merchantAPI.POST("/returns/:id/reject", returnController.RejectMerchantReturn)
+			merchantAPI.POST("/returns/:id/refund", returnController.RetryMerchantRefund)
+
+			// 商戶促銷與優惠券
+			merchantAPI.GET("/promotions", promotionController.GetMerchantPromotions)
+			merchantAPI.POST("/promotions", promotionController.CreateMerchantPromotion)
+			merchantAPI.PUT("/promotions/:id", promotionController.UpdateMerchantPromotion)
+
+			// 運送方式管理
+			merchantAPI.GET("/shipping-methods", shippingController.GetMerchantShippingMethods)
+			merchantAPI.POST("/shipping-methods", shippingController.CreateMerchantShippingMethod)
+			merchantAPI.PUT("/shipping-methods/:id", shippingController.UpdateMerchantShippingMethod)
+
+			// 商戶限時特價
+			merchantAPI.GET("/sales", merchantSaleController.GetCampaigns)
+			merchantAPI.POST("/sales", merchantSaleController.CreateCampaign)
+			merchantAPI.POST("/sales/:id/cancel", merchantSaleController.CancelCampaign)
+
+			// 商品評價回覆
+			merchantAPI.GET("/reviews", reviewController.GetMerchantReviews)
+			merchantAPI.PUT("/reviews/:id/reply", reviewController.ReplyReview)
 		}
 	}
 
@@ -593,6 +728,33 @@ func SetupRoutes(
 			// 聊天管理
 			adminAPI.GET("/chat/status", chatController.GetDatabaseStatus)
 			adminAPI.POST("/chat/cleanup", chatController.CleanupOldData)
+			
+			// 促銷管理（全站與各商戶）
+			adminAPI.GET("/promotions", promotionController.GetAdminPromotions)
+			adminAPI.POST("/promotions", promotionController.CreateAdminPromotion)
+			adminAPI.PUT("/promotions/:id", promotionController.UpdateAdminPromotion)
+
+			// 運送方式管理
+			adminAPI.GET("/shipping-methods", shippingController.GetAdminShippingMethods)
+			adminAPI.POST("/shipping-methods", shippingController.CreateAdminShippingMethod)
+			adminAPI.PUT("/shipping-methods/:id", shippingController.UpdateAdminShippingMethod)
+
+			// 退貨退款稽核與處理
+			adminAPI.GET("/returns", returnController.GetAdminReturns)
+			adminAPI.GET("/returns/:id", returnController.GetAdminReturn)
+			adminAPI.POST("/returns/:id/approve", returnController.ApproveAdminReturn)
+			adminAPI.POST("/returns/:id/reject", returnController.RejectAdminReturn)
+			adminAPI.POST("/returns/:id/refund", returnController.RetryAdminRefund)
+			
+			// 商品評價審核
+			adminAPI.GET("/reviews", reviewController.GetAdminReviews)
+			adminAPI.PUT("/reviews/:id/moderation", reviewController.ModerateReview)
+			
+			// 商品分類管理
+			adminAPI.GET("/categories", categoryController.GetAdminCategories)
+			adminAPI.POST("/categories", categoryController.CreateCategory)
+			adminAPI.PUT("/categories/:id", categoryController.UpdateCategory)
+			adminAPI.DELETE("/categories/:id", categoryController.DeleteCategory)
 		}
 	}
 
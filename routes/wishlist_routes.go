@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"go-simple-app/controllers"
+	"go-simple-app/middleware"
+	"go-simple-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWishlistRoutes 設置收藏清單路由
+func SetupWishlistRoutes(router *gin.Engine, wishlistService *services.WishlistService, unifiedAuthService *services.UnifiedAuthService) {
+	// 創建收藏清單控制器
+	wishlistController := controllers.NewWishlistController(wishlistService)
+
+	// 收藏清單API路由組（需要客戶端認證）
+	wishlistAPI := router.Group("/api/wishlist")
+	wishlistAPI.Use(middleware.UnifiedAuthMiddleware(unifiedAuthService))
+	wishlistAPI.Use(middleware.CustomerMiddleware())
+	{
+		// 獲取收藏清單
+		wishlistAPI.GET("", wishlistController.GetWishlist)
+
+		// 加入收藏或更新通知設定
+		wishlistAPI.POST("", wishlistController.AddToWishlist)
+
+		// 移除收藏
+		wishlistAPI.DELETE("/:productId", wishlistController.RemoveFromWishlist)
+
+		// 補貨通知
+		wishlistAPI.GET("/notifications", wishlistController.GetNotifications)
+		wishlistAPI.PUT("/notifications/:id/read", wishlistController.MarkNotificationRead)
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go-simple-app/models"
+)
+
+var (
+	mobilePhonePattern   = regexp.MustCompile(`^09\d{8}$`)
+	landlinePhonePattern = regexp.MustCompile(`^0[2-8]\d{7,8}$`)
+	postalCodePattern    = regexp.MustCompile(`^\d{3}(\d{2,3})?$`)
+	phoneSeparators      = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", "#", "")
+)
+
+// AddressService 客戶通訊錄業務邏輯服務
+// 每位客戶各有一筆預設收件地址與預設帳單地址，新增第一筆或刪除預設地址時自動補上
+type AddressService struct {
+	db          *sql.DB
+	addressRepo *models.AddressRepository
+}
+
+// NewAddressService 創建客戶通訊錄服務
+func NewAddressService(db *sql.DB) *AddressService {
+	return &AddressService{
+		db:          db,
+		addressRepo: models.NewAddressRepository(db),
+	}
+}
+
+// Regions 臺灣縣市、鄉鎮市區與郵遞區號，供前端下拉選單使用
+func (s *AddressService) Regions() []models.TaiwanCity {
+	return models.TaiwanCities
+}
+
+// ListAddresses 獲取客戶的所有地址
+func (s *AddressService) ListAddresses(customerID int) ([]*models.CustomerAddress, error) {
+	return s.addressRepo.GetByCustomerID(customerID)
+}
+
+// GetAddress 獲取客戶的指定地址
+func (s *AddressService) GetAddress(customerID, id int) (*models.CustomerAddress, error) {
+	address, err := s.addressRepo.GetByID(customerID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrAddressNotFound
+		}
+		return nil, err
+	}
+	return address, nil
+}
+
+// CreateAddress 新增地址，客戶的第一筆地址同時成為預設收件與帳單地址
+func (s *AddressService) CreateAddress(customerID int, req *models.AddressRequest) (*models.CustomerAddress, error) {
+	count, err := s.addressRepo.CountByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= models.MaxCustomerAddresses {
+		return nil, models.ErrAddressLimitExceeded
+	}
+
+	address := &models.CustomerAddress{CustomerID: customerID}
+	if err := applyAddressRequest(address, req); err != nil {
+		return nil, err
+	}
+
+	err = s.withTx(customerID, address, func(tx *sql.Tx) error {
+		return s.addressRepo.CreateTx(tx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAddress(customerID, address.ID)
+}
+
+// UpdateAddress 更新地址，預設用途只能在此設為預設，取消預設需將其他地址設為預設
+func (s *AddressService) UpdateAddress(customerID, id int, req *models.AddressRequest) (*models.CustomerAddress, error) {
+	address, err := s.GetAddress(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	isDefaultShipping, isDefaultBilling := address.IsDefaultShipping, address.IsDefaultBilling
+	if err := applyAddressRequest(address, req); err != nil {
+		return nil, err
+	}
+	address.IsDefaultShipping = address.IsDefaultShipping || isDefaultShipping
+	address.IsDefaultBilling = address.IsDefaultBilling || isDefaultBilling
+
+	err = s.withTx(customerID, address, func(tx *sql.Tx) error {
+		return s.addressRepo.UpdateTx(tx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAddress(customerID, id)
+}
+
+// SetDefault 將地址設為預設收件或帳單地址
+func (s *AddressService) SetDefault(customerID, id int, usage string) (*models.CustomerAddress, error) {
+	if usage != models.AddressUsageShipping && usage != models.AddressUsageBilling {
+		return nil, &models.DomainError{Code: "INVALID_ADDRESS_USAGE", Message: "無效的地址用途"}
+	}
+
+	address, err := s.GetAddress(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if usage == models.AddressUsageShipping {
+		address.IsDefaultShipping = true
+	} else {
+		address.IsDefaultBilling = true
+	}
+
+	err = s.withTx(customerID, address, func(tx *sql.Tx) error {
+		return s.addressRepo.UpdateTx(tx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAddress(customerID, id)
+}
+
+// DeleteAddress 刪除地址，刪除的是預設地址時改以最近新增的地址作為預設
+func (s *AddressService) DeleteAddress(customerID, id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.addressRepo.DeleteTx(tx, customerID, id); err != nil {
+		return err
+	}
+	if err := s.addressRepo.EnsureDefaultsTx(tx, customerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResolveForOrder 決定下單使用的地址：指定 addressID 時使用通訊錄地址，否則使用輸入的文字地址，都沒有時使用預設地址
+// 回傳完整地址文字與通訊錄地址快照（使用文字地址時快照為 nil）；沒有任何地址時文字為空字串
+func (s *AddressService) ResolveForOrder(customerID int, addressID *int, text string, usage string) (string, *models.Address, error) {
+	var address *models.CustomerAddress
+	var err error
+	switch {
+	case addressID != nil:
+		address, err = s.GetAddress(customerID, *addressID)
+		if err != nil {
+			return "", nil, err
+		}
+	case strings.TrimSpace(text) != "":
+		return strings.TrimSpace(text), nil, nil
+	default:
+		address, err = s.addressRepo.GetDefault(customerID, usage)
+		if err == sql.ErrNoRows {
+			return "", nil, nil
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	snapshot := address.Address
+	return snapshot.FullAddress(), &snapshot, nil
+}
+
+// withTx 在交易中寫入地址，並維持每種用途只有一筆預設地址
+func (s *AddressService) withTx(customerID int, address *models.CustomerAddress, write func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := write(tx); err != nil {
+		return err
+	}
+	if address.IsDefaultShipping {
+		if err := s.addressRepo.ClearDefaultTx(tx, customerID, models.AddressUsageShipping, address.ID); err != nil {
+			return err
+		}
+	}
+	if address.IsDefaultBilling {
+		if err := s.addressRepo.ClearDefaultTx(tx, customerID, models.AddressUsageBilling, address.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.addressRepo.EnsureDefaultsTx(tx, customerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyAddressRequest 驗證並正規化地址欄位：縣市與鄉鎮市區需存在，郵遞區號需與鄉鎮市區相符
+func applyAddressRequest(address *models.CustomerAddress, req *models.AddressRequest) error {
+	recipient := strings.TrimSpace(req.RecipientName)
+	if recipient == "" || utf8.RuneCountInString(recipient) > 50 {
+		return &models.DomainError{Code: "INVALID_ADDRESS", Message: "收件人姓名不能為空且不可超過 50 字"}
+	}
+
+	phone, ok := normalizeTaiwanPhone(req.Phone)
+	if !ok {
+		return &models.DomainError{Code: "INVALID_ADDRESS", Message: "電話格式錯誤，請輸入手機（09 開頭 10 碼）或含區碼的市話"}
+	}
+
+	city := models.NormalizeTaiwanPlaceName(req.City)
+	districtName := models.NormalizeTaiwanPlaceName(req.District)
+	district, ok := models.FindTaiwanDistrict(city, districtName)
+	if !ok {
+		return &models.DomainError{Code: "INVALID_ADDRESS", Message: "查無「" + city + districtName + "」，請確認縣市與鄉鎮市區"}
+	}
+
+	postalCode := strings.NewReplacer(" ", "", "-", "").Replace(req.PostalCode)
+	if postalCode == "" {
+		postalCode = district.PostalCode
+	}
+	if !postalCodePattern.MatchString(postalCode) {
+		return &models.DomainError{Code: "INVALID_ADDRESS", Message: "郵遞區號需為 3、5 或 6 碼數字"}
+	}
+	if !strings.HasPrefix(postalCode, district.PostalCode) {
+		return &models.DomainError{Code: "INVALID_ADDRESS", Message: "郵遞區號與" + city + districtName + "（" + district.PostalCode + "）不符"}
+	}
+
+	// 街道欄位常連同縣市與鄉鎮市區一起填寫，去除重複的部分（「台」與「臺」的 UTF-8 長度相同）
+	street := strings.TrimSpace(req.Street)
+	if strings.HasPrefix(models.NormalizeTaiwanPlaceName(street), city+districtName) {
+		street = strings.TrimSpace(street[len(city+districtName):])
+	}
+	if street == "" || utf8.RuneCountInString(street) > 200 {
+		return &models.DomainError{Code: "INVALID_ADDRESS", Message: "街道地址不能為空且不可超過 200 字"}
+	}
+
+	address.Label = nil
+	if req.Label != nil {
+		if label := strings.TrimSpace(*req.Label); label != "" {
+			address.Label = &label
+		}
+	}
+	address.RecipientName = recipient
+	address.Phone = phone
+	address.PostalCode = postalCode
+	address.City = city
+	address.District = districtName
+	address.Street = street
+	address.IsDefaultShipping = req.IsDefaultShipping
+	address.IsDefaultBilling = req.IsDefaultBilling
+
+	return nil
+}
+
+// normalizeTaiwanPhone 去除分隔符號並將 +886 改為 0，回傳是否為有效的手機或市話號碼
+func normalizeTaiwanPhone(phone string) (string, bool) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+	if strings.HasPrefix(phone, "+886") {
+		phone = "0" + strings.TrimPrefix(strings.TrimPrefix(phone, "+886"), "0")
+	}
+	return phone, mobilePhonePattern.MatchString(phone) || landlinePhonePattern.MatchString(phone)
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"database/sql"
+	"math"
+	"sync"
+	"time"
+
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// tradingSession 一段連續交易時段（台灣時間，自零時起算的分鐘數）
+type tradingSession struct {
+	open  int
+	close int
+}
+
+// twseSessions 台股一般交易時段 9:00–13:30，中間沒有午休
+// 有午休的市場可設定多個時段，分 K 以各時段開盤對齊且不會跨越時段
+var twseSessions = []tradingSession{
+	{open: 9 * 60, close: 13*60 + 30},
+}
+
+// maxCandles 單次查詢最多回傳的 K 線數，也是每個快取序列保留的已收盤 K 線上限
+const maxCandles = 5000
+
+// candleResolutionSpec 各週期的彙總來源與預設查詢區間
+type candleResolutionSpec struct {
+	source      string        // 彙總來源的 K 棒週期
+	duration    time.Duration // 分 K 的週期長度，日 K 以上為 0
+	sourceRatio int           // 每根 K 線最多涵蓋的來源 K 棒數，用於估算讀取筆數
+	defaultSpan time.Duration // 未指定 from 時往前查詢的區間
+}
+
+// candleResolutions 分 K 由盤中逐筆行情寫入的 1 分 K、5 分 K 彙總，日 K 以上由日 K 彙總
+// 15 分與 60 分 K 採用保留較久的 5 分 K，查詢較長期間時不受 1 分 K 保留天數限制
+var candleResolutions = map[string]candleResolutionSpec{
+	models.CandleResolution1m:    {models.StockInterval1m, time.Minute, 1, 24 * time.Hour},
+	models.CandleResolution5m:    {models.StockInterval5m, 5 * time.Minute, 1, 5 * 24 * time.Hour},
+	models.CandleResolution15m:   {models.StockInterval5m, 15 * time.Minute, 3, 10 * 24 * time.Hour},
+	models.CandleResolution60m:   {models.StockInterval5m, time.Hour, 12, 30 * 24 * time.Hour},
+	models.CandleResolutionDay:   {models.StockInterval1d, 0, 1, 365 * 24 * time.Hour},
+	models.CandleResolutionWeek:  {models.StockInterval1d, 0, 7, 3 * 365 * 24 * time.Hour},
+	models.CandleResolutionMonth: {models.StockInterval1d, 0, 31, 10 * 365 * 24 * time.Hour},
+}
+
+// candleEntry K 線與其所屬的週期區間 [start, end)
+type candleEntry struct {
+	start  time.Time
+	end    time.Time
+	candle models.Candle
+}
+
+// candleSeries 單一股票單一週期的快取：已收盤的 K 線只計算一次，形成中的 K 線隨行情更新重算
+type candleSeries struct {
+	loadedFrom time.Time
+	completed  []candleEntry
+	forming    *candleEntry
+}
+
+type candleCacheKey struct {
+	code       string
+	resolution string
+}
+
+// CandleService K 線彙總服務
+// 分 K 依交易時段對齊，不跨越時段與交易日，收盤那一分鐘的成交（收盤集合競價）併入最後一根；
+// 沒有成交的時段與休市日不補空 K 線，週 K、月 K 的時間取該期間第一個交易日
+type CandleService struct {
+	historyRepo *models.StockHistoryRepository
+	sessions    []tradingSession
+	mu          sync.Mutex
+	cache       map[candleCacheKey]*candleSeries
+}
+
+// NewCandleService 創建 K 線服務
+func NewCandleService(db *sql.DB) *CandleService {
+	return &CandleService{
+		historyRepo: models.NewStockHistoryRepository(db),
+		sessions:    twseSessions,
+		cache:       make(map[candleCacheKey]*candleSeries),
+	}
+}
+
+// GetCandles 查詢股票 K 線，from 與 to 的格式同股價歷史查詢，最後一根未收盤的 K 線 complete 為 false
+func (s *CandleService) GetCandles(code, resolution, from, to string) (*models.CandleSeries, error) {
+	if resolution == "" {
+		resolution = models.CandleResolutionDay
+	}
+	res := models.NormalizeCandleResolution(resolution)
+	if res == "" {
+		return nil, &models.DomainError{Code: "INVALID_CANDLE_QUERY", Message: "resolution 只支援 1m、5m、15m、60m、day、week、month"}
+	}
+	spec := candleResolutions[res]
+
+	now := time.Now()
+	end := now
+	if to != "" {
+		parsed, err := parseStockHistoryTime(to, true)
+		if err != nil {
+			return nil, &models.DomainError{Code: "INVALID_CANDLE_QUERY", Message: "to 格式錯誤，請使用 RFC3339 或 YYYY-MM-DD"}
+		}
+		end = parsed
+	}
+
+	start := end.Add(-spec.defaultSpan)
+	if from != "" {
+		parsed, err := parseStockHistoryTime(from, false)
+		if err != nil {
+			return nil, &models.DomainError{Code: "INVALID_CANDLE_QUERY", Message: "from 格式錯誤，請使用 RFC3339 或 YYYY-MM-DD"}
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		return nil, &models.DomainError{Code: "INVALID_CANDLE_QUERY", Message: "from 必須早於 to"}
+	}
+
+	loadFrom := start
+	if bucketStart, _, ok := s.bucket(res, start); ok {
+		loadFrom = bucketStart
+	}
+
+	candles := []models.Candle{}
+	add := func(entry candleEntry, complete bool) {
+		if entry.start.Before(end) && entry.end.After(start) {
+			candle := entry.candle
+			candle.Time = candle.Time.In(taipeiLocation)
+			candle.Complete = complete
+			candles = append(candles, candle)
+		}
+	}
+
+	s.mu.Lock()
+	key := candleCacheKey{code: code, resolution: res}
+	series := s.cache[key]
+	if series == nil || loadFrom.Before(series.loadedFrom) {
+		entries, truncated, err := s.aggregate(code, res, loadFrom, now)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+		if truncated {
+			// 查詢起點太早，無法完整快取到現在：只彙總查詢區間，不寫入快取
+			s.mu.Unlock()
+			if entries, _, err = s.aggregate(code, res, loadFrom, end); err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				add(entry, !entry.end.After(now))
+			}
+			return s.candleResult(code, res, start, end, candles), nil
+		}
+
+		series = &candleSeries{loadedFrom: loadFrom}
+		for i := range entries {
+			if entries[i].end.After(now) {
+				series.forming = &entries[i]
+			} else {
+				series.completed = append(series.completed, entries[i])
+			}
+		}
+		s.cache[key] = series
+	}
+	s.promoteLocked(series, now)
+
+	for _, entry := range series.completed {
+		add(entry, true)
+	}
+	if series.forming != nil {
+		add(*series.forming, false)
+	}
+	s.mu.Unlock()
+
+	return s.candleResult(code, res, start, end, candles), nil
+}
+
+// candleResult 組成查詢結果，超過上限時保留最新的部分
+func (s *CandleService) candleResult(code, resolution string, start, end time.Time, candles []models.Candle) *models.CandleSeries {
+	if len(candles) > maxCandles {
+		candles = candles[len(candles)-maxCandles:]
+	}
+	return &models.CandleSeries{
+		StockCode:  code,
+		Resolution: resolution,
+		From:       start.In(taipeiLocation),
+		To:         end.In(taipeiLocation),
+		Candles:    candles,
+	}
+}
+
+// Refresh 行情更新後重算該股票各快取週期中形成中的 K 線，已收盤的 K 線不再重新計算
+// 行情進入新週期時，原本形成中的 K 線移入已收盤的快取
+func (s *CandleService) Refresh(code string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for res := range candleResolutions {
+		series := s.cache[candleCacheKey{code: code, resolution: res}]
+		if series == nil {
+			continue
+		}
+		start, end, ok := s.bucket(res, at)
+		if !ok {
+			continue
+		}
+		if n := len(series.completed); n > 0 && !series.completed[n-1].start.Before(start) {
+			continue
+		}
+		if series.forming != nil {
+			if start.Before(series.forming.start) {
+				continue
+			}
+			if series.forming.start.Before(start) {
+				series.completed = append(series.completed, *series.forming)
+				series.forming = nil
+			}
+		}
+
+		entries, _, err := s.aggregate(code, res, start, end)
+		if err != nil {
+			logger.Error("重算形成中K線失敗", err, logrus.Fields{
+				"stock_code": code,
+				"resolution": res,
+			})
+			continue
+		}
+		if len(entries) > 0 {
+			series.forming = &entries[len(entries)-1]
+		}
+		s.trimLocked(series)
+	}
+}
+
+// promoteLocked 形成中的 K 線週期已結束時移入已收盤的快取
+func (s *CandleService) promoteLocked(series *candleSeries, now time.Time) {
+	if series.forming != nil && !series.forming.end.After(now) {
+		series.completed = append(series.completed, *series.forming)
+		series.forming = nil
+	}
+	s.trimLocked(series)
+}
+
+// trimLocked 已收盤的 K 線超過上限時捨棄最舊的部分，並將快取起點移到第一根保留的 K 線
+func (s *CandleService) trimLocked(series *candleSeries) {
+	if len(series.completed) > maxCandles {
+		series.completed = series.completed[len(series.completed)-maxCandles:]
+		series.loadedFrom = series.completed[0].start
+	}
+}
+
+// aggregate 將區間 [from, to) 內的來源 K 棒彙總為指定週期的 K 線
+// 來源筆數達上限時第一根 K 線可能只涵蓋部分資料，會被捨棄並回傳 truncated
+func (s *CandleService) aggregate(code, resolution string, from, to time.Time) ([]candleEntry, bool, error) {
+	spec := candleResolutions[resolution]
+	limit := maxCandles * spec.sourceRatio
+	bars, err := s.historyRepo.GetBars(code, spec.source, from, to, limit)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entries []candleEntry
+	for _, bar := range bars {
+		start, end, ok := s.bucket(resolution, bar.BucketStart)
+		if !ok {
+			continue
+		}
+
+		if n := len(entries); n > 0 && entries[n-1].start.Equal(start) {
+			candle := &entries[n-1].candle
+			candle.High = math.Max(candle.High, bar.High)
+			candle.Low = math.Min(candle.Low, bar.Low)
+			candle.Close = bar.Close
+			candle.Volume += bar.Volume
+			candle.Amount += bar.Amount
+			continue
+		}
+
+		candleTime := start
+		if resolution == models.CandleResolutionWeek || resolution == models.CandleResolutionMonth {
+			candleTime = bar.BucketStart
+		}
+		entries = append(entries, candleEntry{
+			start: start,
+			end:   end,
+			candle: models.Candle{
+				Time:   candleTime,
+				Open:   bar.Open,
+				High:   bar.High,
+				Low:    bar.Low,
+				Close:  bar.Close,
+				Volume: bar.Volume,
+				Amount: bar.Amount,
+			},
+		})
+	}
+
+	truncated := len(bars) == limit
+	if truncated && len(entries) > 0 {
+		entries = entries[1:]
+	}
+	return entries, truncated, nil
+}
+
+// bucket 計算時間所屬的週期區間 [start, end)，不在任何交易時段內的分 K 時間回傳 false
+func (s *CandleService) bucket(resolution string, t time.Time) (time.Time, time.Time, bool) {
+	day := tradingDay(t)
+
+	switch resolution {
+	case models.CandleResolutionDay:
+		return day, day.AddDate(0, 0, 1), true
+	case models.CandleResolutionWeek:
+		weekStart := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return weekStart, weekStart.AddDate(0, 0, 7), true
+	case models.CandleResolutionMonth:
+		monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, taipeiLocation)
+		return monthStart, monthStart.AddDate(0, 1, 0), true
+	}
+
+	duration := candleResolutions[resolution].duration
+	for _, session := range s.sessions {
+		sessionOpen := day.Add(time.Duration(session.open) * time.Minute)
+		sessionClose := day.Add(time.Duration(session.close) * time.Minute)
+		sessionEnd := sessionClose.Add(time.Minute) // 含收盤那一分鐘
+		if t.Before(sessionOpen) || !t.Before(sessionEnd) {
+			continue
+		}
+
+		// 收盤那一分鐘的成交併入時段內最後一根 K 線
+		elapsed := t.Sub(sessionOpen)
+		if length := sessionClose.Sub(sessionOpen); elapsed >= length {
+			elapsed = length - 1
+		}
+		start := sessionOpen.Add(elapsed / duration * duration)
+		end := start.Add(duration)
+		if !end.Before(sessionClose) {
+			end = sessionEnd
+		}
+		return start, end, true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
@@ -2,25 +2,34 @@ package services
 
 import (
 	"database/sql"
+	"strconv"
 	"go-simple-app/models"
 )
 
 // CartService 購物車業務邏輯服務
 type CartService struct {
-	cartRepo    *models.CartRepository
-	productRepo *models.ProductRepository
+	cartRepo        *models.CartRepository
+	productRepo     *models.ProductRepository
+	variantRepo     *models.ProductVariantRepository
+	reservationRepo *models.ReservationRepository
+	promotions      *PromotionService
+	shipping        *ShippingService
 }
 
 // NewCartService 創建購物車服務
-func NewCartService(db *sql.DB) *CartService {
+func NewCartService(db *sql.DB, shipping *ShippingService) *CartService {
 	return &CartService{
-		cartRepo:    models.NewCartRepository(db),
-		productRepo: models.NewProductRepository(db),
+		cartRepo:        models.NewCartRepository(db),
+		productRepo:     models.NewProductRepository(db),
+		variantRepo:     models.NewProductVariantRepository(db),
+		reservationRepo: models.NewReservationRepository(db),
+		promotions:      NewPromotionService(db),
+		shipping:        shipping,
 	}
 }
 
-// AddToCart 添加商品到購物車
-func (s *CartService) AddToCart(customerID, productID, quantity int) error {
+// AddToCart 添加商品到購物車，有規格的商品必須指定 variantID
+func (s *CartService) AddToCart(customerID, productID, variantID, quantity int) error {
 	// 參數驗證
 	if customerID <= 0 {
 		return &models.CartError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
@@ -46,17 +55,21 @@ func (s *CartService) AddToCart(customerID, productID, quantity int) error {
 		return &models.CartError{Code: "PRODUCT_NOT_AVAILABLE", Message: "商品已下架"}
 	}
 
-	// 檢查庫存
-	if product.Stock < quantity {
+	// 檢查規格與庫存
+	available, err := s.availableStock(product, variantID)
+	if err != nil {
+		return err
+	}
+	if available < quantity {
 		return &models.CartError{Code: "INSUFFICIENT_STOCK", Message: "庫存不足"}
 	}
 
 	// 添加到購物車
-	return s.cartRepo.AddItemToCart(customerID, productID, quantity)
+	return s.cartRepo.AddItemToCart(customerID, productID, variantID, quantity)
 }
 
 // UpdateCartItem 更新購物車商品數量
-func (s *CartService) UpdateCartItem(customerID, productID, quantity int) error {
+func (s *CartService) UpdateCartItem(customerID, productID, variantID, quantity int) error {
 	// 參數驗證
 	if customerID <= 0 {
 		return &models.CartError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
@@ -70,7 +83,7 @@ func (s *CartService) UpdateCartItem(customerID, productID, quantity int) error
 
 	// 如果數量為0，移除商品
 	if quantity == 0 {
-		return s.RemoveFromCart(customerID, productID)
+		return s.RemoveFromCart(customerID, productID, variantID)
 	}
 
 	// 檢查商品是否仍然可用
@@ -86,17 +99,56 @@ func (s *CartService) UpdateCartItem(customerID, productID, quantity int) error
 		return &models.CartError{Code: "PRODUCT_NOT_AVAILABLE", Message: "商品已下架"}
 	}
 
-	// 檢查庫存
-	if product.Stock < quantity {
+	// 檢查規格與庫存
+	available, err := s.availableStock(product, variantID)
+	if err != nil {
+		return err
+	}
+	if available < quantity {
 		return &models.CartError{Code: "INSUFFICIENT_STOCK", Message: "庫存不足"}
 	}
 
 	// 更新購物車項目
-	return s.cartRepo.UpdateCartItem(customerID, productID, quantity)
+	return s.cartRepo.UpdateCartItem(customerID, productID, variantID, quantity)
+}
+
+// availableStock 驗證規格選擇並回傳可售數量
+// 有啟用中規格的商品必須指定規格，沒有規格的商品不可指定
+func (s *CartService) availableStock(product *models.Product, variantID int) (int, error) {
+	if variantID <= 0 {
+		hasVariants, err := s.variantRepo.HasActiveVariants(product.ID)
+		if err != nil {
+			return 0, err
+		}
+		if hasVariants {
+			return 0, models.ErrVariantRequired
+		}
+		return product.AvailableStock, nil
+	}
+
+	variant, err := s.variantRepo.GetByID(variantID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if variant == nil || variant.ProductID != product.ID {
+		hasVariants, err := s.variantRepo.HasActiveVariants(product.ID)
+		if err != nil {
+			return 0, err
+		}
+		if !hasVariants {
+			return 0, models.ErrVariantNotAllowed
+		}
+		return 0, models.ErrVariantNotFound
+	}
+	if !variant.IsActive {
+		return 0, &models.CartError{Code: "PRODUCT_NOT_AVAILABLE", Message: "此規格已停售"}
+	}
+
+	return variant.AvailableStock, nil
 }
 
 // RemoveFromCart 從購物車移除商品
-func (s *CartService) RemoveFromCart(customerID, productID int) error {
+func (s *CartService) RemoveFromCart(customerID, productID, variantID int) error {
 	// 參數驗證
 	if customerID <= 0 {
 		return &models.CartError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
@@ -105,7 +157,7 @@ func (s *CartService) RemoveFromCart(customerID, productID int) error {
 		return &models.CartError{Code: "INVALID_PRODUCT_ID", Message: "無效的商品ID"}
 	}
 
-	return s.cartRepo.RemoveItemFromCart(customerID, productID)
+	return s.cartRepo.RemoveItemFromCart(customerID, productID, variantID)
 }
 
 // GetCart 獲取購物車
@@ -155,12 +207,8 @@ func (s *CartService) CalculateCartTotal(cart *models.Cart) float64 {
 func (s *CartService) calculateCartTotal(cart *models.Cart) float64 {
 	total := 0.0
 	for _, item := range cart.Items {
-		// 使用商品的最新價格
-		if item.Product != nil {
-			total += item.Product.Price * float64(item.Quantity)
-		} else {
-			total += item.Price * float64(item.Quantity)
-		}
+		// 使用商品（或規格）的最新價格
+		total += item.UnitPrice() * float64(item.Quantity)
 	}
 	cart.TotalPrice = total
 	return total
@@ -184,21 +232,47 @@ func (s *CartService) ValidateCartItems(cart *models.Cart) []string {
 			continue
 		}
 
-		// 檢查庫存
-		if product.Stock < item.Quantity {
-			errors = append(errors, "商品 "+product.Name+" 庫存不足，當前庫存："+string(rune(product.Stock)))
+		// 有規格的商品以規格的狀態、庫存與價格為準
+		name := product.Name
+		available := product.AvailableStock
+		price := product.Price
+		if item.VariantID > 0 {
+			variant, err := s.variantRepo.GetByID(item.VariantID)
+			if err != nil || variant.ProductID != product.ID {
+				errors = append(errors, "商品 "+product.Name+" 的規格已不存在")
+				continue
+			}
+			name = product.Name + "（" + variant.Name + "）"
+			if !variant.IsActive {
+				errors = append(errors, "商品 "+name+" 已停售")
+				continue
+			}
+			available = variant.AvailableStock
+			price = variant.UnitPrice
+		} else if hasVariants, err := s.variantRepo.HasActiveVariants(product.ID); err == nil && hasVariants {
+			errors = append(errors, "商品 "+product.Name+" 請選擇規格")
+			continue
+		}
+
+		// 檢查庫存（客戶自己結帳中保留的數量也算可用）
+		key := models.StockKey{ProductID: item.ProductID, VariantID: item.VariantID}
+		if reserved, err := s.reservationRepo.GetActiveQuantity(cart.CustomerID, key); err == nil {
+			available += reserved
+		}
+		if available < item.Quantity {
+			errors = append(errors, "商品 "+name+" 庫存不足，當前庫存："+strconv.Itoa(available))
 		}
 
 		// 檢查價格是否變更
-		if product.Price != item.Price {
-			errors = append(errors, "商品 "+product.Name+" 價格已變更")
+		if price != item.Price {
+			errors = append(errors, "商品 "+name+" 價格已變更")
 		}
 	}
 
 	return errors
 }
 
-// SyncCartWithProductPrices 同步購物車商品價格
+// SyncCartWithProductPrices 同步購物車商品價格（含特價活動開始或結束造成的變動）
 func (s *CartService) SyncCartWithProductPrices(cart *models.Cart) error {
 	for i, item := range cart.Items {
 		product, err := s.productRepo.GetByID(item.ProductID)
@@ -206,10 +280,33 @@ func (s *CartService) SyncCartWithProductPrices(cart *models.Cart) error {
 			continue // 跳過不存在的商品
 		}
 
+		// 有規格時以規格售價為準（未設定規格價格時沿用商品價格）
+		price := product.Price
+		if item.VariantID > 0 {
+			variant, err := s.variantRepo.GetByID(item.VariantID)
+			if err != nil {
+				continue // 跳過不存在的規格
+			}
+			price = variant.UnitPrice
+			if cart.Items[i].Variant != nil {
+				cart.Items[i].Variant.Price = variant.Price
+				cart.Items[i].Variant.UnitPrice = variant.UnitPrice
+			}
+		}
+
+		// 價格有變動時寫回購物車
+		if item.Price != price {
+			if err := s.cartRepo.UpdateItemPrice(cart.CustomerID, item.ProductID, item.VariantID, price); err != nil {
+				return err
+			}
+		}
+
 		// 更新價格
-		cart.Items[i].Price = product.Price
+		cart.Items[i].Price = price
 		if cart.Items[i].Product != nil {
 			cart.Items[i].Product.Price = product.Price
+			cart.Items[i].Product.OriginalPrice = product.OriginalPrice
+			cart.Items[i].Product.IsOnSale = product.IsOnSale
 		}
 	}
 
@@ -218,6 +315,26 @@ func (s *CartService) SyncCartWithProductPrices(cart *models.Cart) error {
 	return nil
 }
 
+// SyncProductInCarts 將商品的最新價格同步到所有含該商品的購物車，回傳同步的購物車數
+func (s *CartService) SyncProductInCarts(productID int) (int, error) {
+	customerIDs, err := s.cartRepo.GetCustomerIDsByProductID(productID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, customerID := range customerIDs {
+		cart, err := s.cartRepo.GetCartByCustomerID(customerID)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.SyncCartWithProductPrices(cart); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(customerIDs), nil
+}
+
 // GetCartSummary 獲取購物車摘要
 func (s *CartService) GetCartSummary(customerID int) (map[string]interface{}, error) {
 	cart, err := s.GetCart(customerID)
@@ -231,9 +348,28 @@ func (s *CartService) GetCartSummary(customerID int) (map[string]interface{}, er
 	// 同步價格
 	s.SyncCartWithProductPrices(cart)
 
+	// 套用促銷與優惠券
+	pricing, err := s.promotions.PriceCart(customerID, cart.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	// 計算各商戶運費
+	shipping, err := s.shipping.QuoteCart(customerID, cart.Items, pricing, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	summary := map[string]interface{}{
 		"item_count":        cart.ItemCount,
 		"total_price":       cart.TotalPrice,
+		"discount_amount":   pricing.DiscountAmount,
+		"shipping_fee":      shipping.ShippingFee,
+		"final_price":       roundAmount(pricing.Total + shipping.ShippingFee),
+		"promotions":        pricing.Promotions,
+		"coupon_code":       pricing.CouponCode,
+		"coupon_error":      pricing.CouponError,
+		"shipping":          shipping.Merchants,
 		"items":             cart.Items,
 		"validation_errors": validationErrors,
 		"has_errors":        len(validationErrors) > 0,
@@ -241,3 +377,27 @@ func (s *CartService) GetCartSummary(customerID int) (map[string]interface{}, er
 
 	return summary, nil
 }
+
+// ApplyCoupon 套用優惠券到購物車
+func (s *CartService) ApplyCoupon(customerID int, code string) error {
+	if customerID <= 0 {
+		return &models.CartError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
+	}
+	return s.promotions.ApplyCoupon(customerID, code)
+}
+
+// SelectShipping 選擇購物車中某商戶的運送方式
+func (s *CartService) SelectShipping(customerID, merchantID, methodID int) error {
+	if customerID <= 0 {
+		return &models.CartError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
+	}
+	return s.shipping.SelectMethod(customerID, merchantID, methodID)
+}
+
+// RemoveCoupon 移除購物車套用的優惠券
+func (s *CartService) RemoveCoupon(customerID int) error {
+	if customerID <= 0 {
+		return &models.CartError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
+	}
+	return s.promotions.RemoveCoupon(customerID)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+
+	"go-simple-app/models"
+)
+
+// CategoryService 商品分類業務邏輯服務
+type CategoryService struct {
+	categoryRepo *models.CategoryRepository
+	productRepo  *models.ProductRepository
+}
+
+// NewCategoryService 創建商品分類服務
+func NewCategoryService(db *sql.DB) *CategoryService {
+	return &CategoryService{
+		categoryRepo: models.NewCategoryRepository(db),
+		productRepo:  models.NewProductRepository(db),
+	}
+}
+
+// GetTree 獲取分類樹，activeOnly 為 true 時略過停用的分類及其子樹
+func (s *CategoryService) GetTree(activeOnly bool) ([]*models.ProductCategory, error) {
+	categories, err := s.categoryRepo.GetAll(activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildCategoryTree(categories), nil
+}
+
+// GetCategoryProducts 獲取分類（含子孫分類）下的商品與分類的麵包屑路徑
+func (s *CategoryService) GetCategoryProducts(categoryID, limit, offset int) ([]*models.Product, []*models.ProductCategory, error) {
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !category.IsActive {
+		return nil, nil, models.ErrCategoryNotFound
+	}
+
+	breadcrumbs, err := s.categoryRepo.GetAncestors(categoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products, err := s.productRepo.GetByCategoryID(categoryID, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return products, breadcrumbs, nil
+}
+
+// CreateCategory 新增分類
+func (s *CategoryService) CreateCategory(req *models.CategoryRequest) (*models.ProductCategory, error) {
+	category, err := s.buildCategory(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryRepo.Create(category); err != nil {
+		return nil, mapCategoryError(err)
+	}
+
+	return s.categoryRepo.GetByID(category.ID)
+}
+
+// UpdateCategory 修改分類，移動分類時不可移到自身或其子分類之下
+func (s *CategoryService) UpdateCategory(categoryID int, req *models.CategoryRequest) (*models.ProductCategory, error) {
+	if _, err := s.categoryRepo.GetByID(categoryID); err != nil {
+		return nil, err
+	}
+
+	category, err := s.buildCategory(req)
+	if err != nil {
+		return nil, err
+	}
+	category.ID = categoryID
+
+	if category.ParentID != nil {
+		cyclic, err := s.categoryRepo.IsDescendant(categoryID, *category.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if cyclic {
+			return nil, models.ErrCategoryCycle
+		}
+	}
+
+	if err := s.categoryRepo.Update(category); err != nil {
+		return nil, mapCategoryError(err)
+	}
+
+	return s.categoryRepo.GetByID(categoryID)
+}
+
+// DeleteCategory 刪除分類，仍有子分類或商品時拒絕
+func (s *CategoryService) DeleteCategory(categoryID int) error {
+	return s.categoryRepo.Delete(categoryID)
+}
+
+// buildCategory 驗證請求並轉為分類，指定的父分類必須存在
+func (s *CategoryService) buildCategory(req *models.CategoryRequest) (*models.ProductCategory, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return nil, &models.DomainError{Code: "INVALID_CATEGORY", Message: "分類名稱不可為空且不可超過100個字"}
+	}
+
+	if req.ParentID != nil {
+		if _, err := s.categoryRepo.GetByID(*req.ParentID); err != nil {
+			if err == models.ErrCategoryNotFound {
+				return nil, &models.DomainError{Code: "INVALID_CATEGORY", Message: "父分類不存在"}
+			}
+			return nil, err
+		}
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return &models.ProductCategory{
+		Name:        name,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		ImageURL:    req.ImageURL,
+		SortOrder:   req.SortOrder,
+		IsActive:    isActive,
+	}, nil
+}
+
+// mapCategoryError 將分類名稱重複的唯一索引錯誤轉為業務錯誤
+func mapCategoryError(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return models.ErrDuplicateCategory
+	}
+	return err
+}
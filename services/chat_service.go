@@ -17,8 +17,9 @@ import (
 )
 
 type ChatService struct {
-	collection *mongo.Collection
-	aiManager  *AIManager
+	collection       *mongo.Collection
+	aiManager        *AIManager
+	indicatorService *IndicatorService
 }
 
 // NewChatService 创建聊天服务实例
@@ -50,6 +51,11 @@ func (s *ChatService) SetAIManager(aiManager *AIManager) {
 	s.aiManager = aiManager
 }
 
+// SetIndicatorService 設置技術指標服務，股票問答時以實際計算的指標提供給AI
+func (s *ChatService) SetIndicatorService(indicatorService *IndicatorService) {
+	s.indicatorService = indicatorService
+}
+
 // CreateConversation 创建新对话
 func (s *ChatService) CreateConversation(userID int, title string) (*models.CreateConversationResponse, error) {
 	if s.collection == nil {
@@ -330,6 +336,7 @@ func (s *ChatService) GenerateAIResponse(message, conversationID string, stockCo
 	var enhancedContext map[string]interface{}
 	if stockContext != nil {
 		enhancedContext = s.buildQuestionSpecificContext(stockContext, message)
+		s.attachTechnicalIndicators(enhancedContext)
 	} else {
 		enhancedContext = s.buildEnhancedStockContext(stockContext)
 	}
@@ -343,6 +350,25 @@ func (s *ChatService) GenerateAIResponse(message, conversationID string, stockCo
 	return s.getSimulatedAIResponse(message), nil
 }
 
+// attachTechnicalIndicators 以歷史日K計算的技術指標加入股票上下文，不採用前端傳入的指標數值
+func (s *ChatService) attachTechnicalIndicators(enhanced map[string]interface{}) {
+	delete(enhanced, "technical_indicators")
+	
+	code, _, _, _, _ := extractStockInfo(enhanced)
+	if s.indicatorService == nil || code == "" {
+		return
+	}
+	
+	result, err := s.indicatorService.GetIndicators(code, models.CandleResolutionDay, 0)
+	if err != nil {
+		log.Printf("計算技術指標失敗 (%s): %v", code, err)
+		return
+	}
+	if result.Latest != nil {
+		enhanced["technical_indicators"] = result
+	}
+}
+
 // buildEnhancedStockContext 構建增強的股票上下文
 func (s *ChatService) buildEnhancedStockContext(stockContext map[string]interface{}) map[string]interface{} {
 	if stockContext == nil {
@@ -225,6 +225,12 @@ func (s *GroqService) buildEnhancedPrompt(message string, stockContext map[strin
 	// 構建專門的提示詞
 	prompt := fmt.Sprintf("你是專業股票分析師。分析股票：%s\n\n", stockInfo)
 	
+	// 伺服器實際計算的技術指標，避免模型自行編造數值
+	if indicatorText := describeTechnicalIndicators(stockContext); indicatorText != "" {
+		prompt += indicatorText
+		prompt += "技術面分析請以上述實際數值為準，不要自行假設指標數值。\n\n"
+	}
+	
 	if hasInstructions {
 		shouldQuery, _ := queryInstructions["should_query_history"].(bool)
 		questionType, _ := queryInstructions["question_type"].(string)
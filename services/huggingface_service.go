@@ -71,6 +71,9 @@ func (s *HuggingFaceService) GenerateResponse(ctx context.Context, message, conv
 		
 		if stockInfo != "" {
 			content = fmt.Sprintf("股票: %s\n問題: %s", stockInfo, message)
+			if indicatorText := describeTechnicalIndicators(stockContext); indicatorText != "" {
+				content = fmt.Sprintf("股票: %s\n%s問題: %s", stockInfo, indicatorText, message)
+			}
 		}
 	}
 
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImageCacheEntry 磁碟快取中的一張圖片
+// ETag 與 LastModified 是來源網站回傳的值，向來源重新驗證時使用；Digest 為圖片內容雜湊，作為對用戶端的 ETag
+type ImageCacheEntry struct {
+	Key          string    `json:"key"`
+	URL          string    `json:"url"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Digest       string    `json:"digest"`
+	Size         int64     `json:"size"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ImageCache 以磁碟保存代理圖片的 LRU 快取
+// 每張圖片存成 <key>.img 與 <key>.json 兩個檔案，圖片檔的修改時間記錄最後使用時間，重啟後依此還原淘汰順序
+type ImageCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List // 最前面是最近使用的項目
+	entries map[string]*list.Element
+	size    int64
+}
+
+// NewImageCache 創建磁碟快取並載入目錄中既有的圖片
+func NewImageCache(dir string, maxBytes int64) (*ImageCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	cache := &ImageCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+	}
+	if err := cache.load(); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// load 讀取既有的快取項目，缺少圖片檔或內容損毀的項目與寫到一半的暫存檔直接刪除
+func (c *ImageCache) load() error {
+	tmps, _ := filepath.Glob(filepath.Join(c.dir, ".tmp-*"))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+
+	metas, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	type loaded struct {
+		entry    *ImageCacheEntry
+		lastUsed time.Time
+	}
+	items := []loaded{}
+	for _, meta := range metas {
+		key := strings.TrimSuffix(filepath.Base(meta), ".json")
+		entry := &ImageCacheEntry{}
+		data, err := os.ReadFile(meta)
+		if err == nil {
+			err = json.Unmarshal(data, entry)
+		}
+		info, statErr := os.Stat(c.imagePath(key))
+		if err != nil || statErr != nil || entry.Key != key || info.Size() != entry.Size {
+			c.removeFiles(key)
+			continue
+		}
+		items = append(items, loaded{entry: entry, lastUsed: info.ModTime()})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].lastUsed.After(items[j].lastUsed)
+	})
+	for _, item := range items {
+		c.entries[item.entry.Key] = c.lru.PushBack(item.entry)
+		c.size += item.entry.Size
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evictLocked()
+	return nil
+}
+
+// Get 查詢快取項目並標記為最近使用
+func (c *ImageCache) Get(key string) (*ImageCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	now := time.Now()
+	os.Chtimes(c.imagePath(key), now, now)
+
+	entry := *element.Value.(*ImageCacheEntry)
+	return &entry, true
+}
+
+// Read 讀取快取的圖片內容，檔案已被外部刪除時一併移除項目
+func (c *ImageCache) Read(entry *ImageCacheEntry) ([]byte, error) {
+	data, err := os.ReadFile(c.imagePath(entry.Key))
+	if err != nil {
+		c.Remove(entry.Key)
+		return nil, err
+	}
+	return data, nil
+}
+
+// Put 寫入或取代快取項目，超過容量時淘汰最久未使用的圖片；單張大於容量上限的圖片不快取
+func (c *ImageCache) Put(entry *ImageCacheEntry, data []byte) error {
+	entry.Size = int64(len(data))
+	if entry.Size > c.maxBytes {
+		return nil
+	}
+
+	if err := writeFileAtomic(c.imagePath(entry.Key), data); err != nil {
+		return err
+	}
+	if err := c.writeMeta(entry); err != nil {
+		c.removeFiles(entry.Key)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored := *entry
+	if element, ok := c.entries[entry.Key]; ok {
+		c.size -= element.Value.(*ImageCacheEntry).Size
+		element.Value = &stored
+		c.lru.MoveToFront(element)
+	} else {
+		c.entries[entry.Key] = c.lru.PushFront(&stored)
+	}
+	c.size += stored.Size
+	c.evictLocked()
+	return nil
+}
+
+// Revalidated 來源確認圖片未變更（304）後更新新鮮期與驗證標頭
+func (c *ImageCache) Revalidated(entry *ImageCacheEntry) error {
+	c.mu.Lock()
+	element, ok := c.entries[entry.Key]
+	if ok {
+		stored := *entry
+		element.Value = &stored
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return c.writeMeta(entry)
+}
+
+// Remove 移除快取項目
+func (c *ImageCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.size -= element.Value.(*ImageCacheEntry).Size
+		c.lru.Remove(element)
+		delete(c.entries, key)
+	}
+	c.removeFiles(key)
+}
+
+// evictLocked 從最久未使用的項目開始淘汰，直到總大小不超過上限
+func (c *ImageCache) evictLocked() {
+	for c.size > c.maxBytes {
+		element := c.lru.Back()
+		if element == nil {
+			return
+		}
+		entry := element.Value.(*ImageCacheEntry)
+		c.lru.Remove(element)
+		delete(c.entries, entry.Key)
+		c.size -= entry.Size
+		c.removeFiles(entry.Key)
+	}
+}
+
+func (c *ImageCache) writeMeta(entry *ImageCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.metaPath(entry.Key), data)
+}
+
+func (c *ImageCache) removeFiles(key string) {
+	os.Remove(c.imagePath(key))
+	os.Remove(c.metaPath(key))
+}
+
+func (c *ImageCache) imagePath(key string) string {
+	return filepath.Join(c.dir, key+".img")
+}
+
+func (c *ImageCache) metaPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// writeFileAtomic 先寫入暫存檔再改名，避免讀取到寫一半的檔案
+func writeFileAtomic(target string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
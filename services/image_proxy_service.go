@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxImageProxyRedirects = 5
+	maxImageProxyFreshness = 7 * 24 * time.Hour
+)
+
+// proxyImageTypes 允許代理的圖片格式（依內容判斷），不含可夾帶腳本的 SVG
+var proxyImageTypes = map[string]bool{
+	"image/jpeg":               true,
+	"image/png":                true,
+	"image/gif":                true,
+	"image/webp":               true,
+	"image/bmp":                true,
+	"image/x-icon":             true,
+	"image/vnd.microsoft.icon": true,
+}
+
+// blockedPrefixes 除了私有、本機與鏈路本地位址外，不允許連線的保留網段
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // 電信級 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 可能轉到內網 IPv4
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// ImageProxyError 圖片代理錯誤，Status 為回應用戶端的 HTTP 狀態碼
+type ImageProxyError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *ImageProxyError) Error() string {
+	return e.Message
+}
+
+// ProxiedImage 代理取得的圖片
+type ProxiedImage struct {
+	Entry *ImageCacheEntry
+	Data  []byte
+	Cache string // 快取狀態：HIT、MISS、REVALIDATED、STALE
+}
+
+// ModTime 圖片的最後修改時間，來源未提供時以取得時間代替
+func (p *ProxiedImage) ModTime() time.Time {
+	if modTime, err := http.ParseTime(p.Entry.LastModified); err == nil {
+		return modTime
+	}
+	return p.Entry.FetchedAt
+}
+
+// ImageProxyService 外部圖片代理服務
+// 只代理白名單網域的圖片，連線時檢查解析後的 IP，避免透過 DNS 或轉址存取內網（SSRF）
+type ImageProxyService struct {
+	allowedHosts []string
+	allowPrivate bool
+	maxBytes     int64
+	ttl          time.Duration
+	client       *http.Client
+	cache        *ImageCache
+}
+
+// NewImageProxyService 創建圖片代理服務，磁碟快取無法建立時不使用快取
+func NewImageProxyService(cfg config.ImageProxyConfig) *ImageProxyService {
+	s := &ImageProxyService{
+		allowPrivate: cfg.AllowPrivate,
+		maxBytes:     int64(cfg.MaxImageMB) << 20,
+		ttl:          time.Duration(cfg.CacheTTLMinutes) * time.Minute,
+	}
+	for _, host := range cfg.AllowedHosts {
+		s.allowedHosts = append(s.allowedHosts, strings.ToLower(strings.TrimPrefix(host, ".")))
+	}
+	if s.maxBytes <= 0 {
+		s.maxBytes = 10 << 20
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: s.checkDialAddress,
+	}
+	s.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // 不經過環境變數設定的代理，否則連線檢查會落在代理伺服器上
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          20,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxImageProxyRedirects {
+				return ErrImageTooManyRedirects
+			}
+			return s.validateURL(req.URL)
+		},
+	}
+
+	if cfg.CacheMaxMB > 0 {
+		cache, err := NewImageCache(cfg.CacheDir, int64(cfg.CacheMaxMB)<<20)
+		if err != nil {
+			logger.Error("建立圖片代理快取失敗，將不使用快取", err, logrus.Fields{"dir": cfg.CacheDir})
+		} else {
+			s.cache = cache
+		}
+	}
+
+	return s
+}
+
+// Fetch 取得外部圖片
+// 快取仍在新鮮期內直接回傳；過期時以 If-None-Match / If-Modified-Since 向來源重新驗證，來源無法連線時回傳舊的快取
+func (s *ImageProxyService) Fetch(ctx context.Context, rawURL string) (*ProxiedImage, error) {
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, ErrInvalidImageURL
+	}
+	if err := s.validateURL(target); err != nil {
+		return nil, err
+	}
+	key := sha256Hex([]byte(target.String()))
+
+	var cached *ImageCacheEntry
+	if s.cache != nil {
+		if entry, ok := s.cache.Get(key); ok {
+			if time.Now().Before(entry.ExpiresAt) {
+				if data, err := s.cache.Read(entry); err == nil {
+					return &ProxiedImage{Entry: entry, Data: data, Cache: "HIT"}, nil
+				}
+			} else {
+				cached = entry
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidImageURL
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Accept", "image/webp,image/png,image/jpeg,image/*;q=0.8")
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		var proxyErr *ImageProxyError
+		if errors.As(err, &proxyErr) {
+			return nil, proxyErr
+		}
+		if stale := s.staleImage(cached); stale != nil {
+			logger.Error("重新驗證代理圖片失敗，回傳舊的快取", err, logrus.Fields{"url": target.String()})
+			return stale, nil
+		}
+		return nil, &ImageProxyError{Status: http.StatusBadGateway, Code: "IMAGE_FETCH_FAILED", Message: "無法獲取圖片: " + err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		if data, err := s.cache.Read(cached); err == nil {
+			if etag := resp.Header.Get("ETag"); etag != "" {
+				cached.ETag = etag
+			}
+			if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+				cached.LastModified = lastModified
+			}
+			cached.ExpiresAt = s.expiresAt(resp.Header)
+			if err := s.cache.Revalidated(cached); err != nil {
+				logger.Error("更新代理圖片快取失敗", err, logrus.Fields{"url": target.String()})
+			}
+			return &ProxiedImage{Entry: cached, Data: data, Cache: "REVALIDATED"}, nil
+		}
+		return nil, &ImageProxyError{Status: http.StatusBadGateway, Code: "IMAGE_FETCH_FAILED", Message: "圖片快取已失效，請重試"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		if stale := s.staleImage(cached); stale != nil && resp.StatusCode >= 500 {
+			return stale, nil
+		}
+		return nil, &ImageProxyError{Status: http.StatusBadGateway, Code: "IMAGE_FETCH_FAILED", Message: "圖片服務返回錯誤狀態: " + resp.Status}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, ErrNotAnImage
+	}
+	if resp.ContentLength > s.maxBytes {
+		return nil, s.tooLarge()
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.maxBytes+1))
+	if err != nil {
+		return nil, &ImageProxyError{Status: http.StatusBadGateway, Code: "IMAGE_FETCH_FAILED", Message: "圖片傳輸失敗: " + err.Error()}
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, s.tooLarge()
+	}
+
+	// 來源宣稱的 Content-Type 不可信，以實際內容判斷格式
+	contentType := http.DetectContentType(data)
+	if !proxyImageTypes[contentType] {
+		return nil, ErrNotAnImage
+	}
+
+	now := time.Now()
+	entry := &ImageCacheEntry{
+		Key:          key,
+		URL:          target.String(),
+		ContentType:  contentType,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Digest:       sha256Hex(data)[:32],
+		Size:         int64(len(data)),
+		FetchedAt:    now,
+		ExpiresAt:    s.expiresAt(resp.Header),
+	}
+	if s.cache != nil && !hasCacheDirective(resp.Header, "no-store") {
+		if err := s.cache.Put(entry, data); err != nil {
+			logger.Error("寫入代理圖片快取失敗", err, logrus.Fields{"url": target.String()})
+		}
+	}
+
+	logger.Info("代理外部圖片", logrus.Fields{
+		"url":          target.String(),
+		"content_type": contentType,
+		"size":         len(data),
+	})
+	return &ProxiedImage{Entry: entry, Data: data, Cache: "MISS"}, nil
+}
+
+// validateURL 檢查網址的協定、連接埠與網域白名單，轉址目標也以此檢查
+func (s *ImageProxyService) validateURL(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return ErrInvalidImageURL
+	}
+	if target.User != nil || target.Hostname() == "" {
+		return ErrInvalidImageURL
+	}
+	if port := target.Port(); port != "" && port != "80" && port != "443" && !s.allowPrivate {
+		return ErrImageHostNotAllowed
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(target.Hostname(), "."))
+	for _, allowed := range s.allowedHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+	return ErrImageHostNotAllowed
+}
+
+// checkDialAddress 在 DNS 解析後、建立連線前檢查實際連線的 IP，避免白名單網域解析到內網位址
+func (s *ImageProxyService) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if s.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrImageAddressBlocked
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || isBlockedAddr(addr) {
+		return ErrImageAddressBlocked
+	}
+	return nil
+}
+
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// expiresAt 依來源的 Cache-Control 計算快取新鮮期，未提供時使用設定的預設值
+func (s *ImageProxyService) expiresAt(header http.Header) time.Time {
+	now := time.Now()
+	if hasCacheDirective(header, "no-cache") {
+		return now
+	}
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || strings.ToLower(name) != "max-age" {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return now.Add(min(time.Duration(seconds)*time.Second, maxImageProxyFreshness))
+		}
+	}
+	return now.Add(s.ttl)
+}
+
+func (s *ImageProxyService) staleImage(cached *ImageCacheEntry) *ProxiedImage {
+	if cached == nil {
+		return nil
+	}
+	data, err := s.cache.Read(cached)
+	if err != nil {
+		return nil
+	}
+	return &ProxiedImage{Entry: cached, Data: data, Cache: "STALE"}
+}
+
+func (s *ImageProxyService) tooLarge() error {
+	return &ImageProxyError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "IMAGE_TOO_LARGE",
+		Message: fmt.Sprintf("圖片不可超過 %dMB", s.maxBytes>>20),
+	}
+}
+
+func hasCacheDirective(header http.Header, name string) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), name) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrInvalidImageURL       = &ImageProxyError{Status: http.StatusBadRequest, Code: "INVALID_IMAGE_URL", Message: "無效的圖片URL"}
+	ErrImageHostNotAllowed   = &ImageProxyError{Status: http.StatusForbidden, Code: "IMAGE_HOST_NOT_ALLOWED", Message: "不允許代理此網域的圖片"}
+	ErrImageAddressBlocked   = &ImageProxyError{Status: http.StatusForbidden, Code: "IMAGE_ADDRESS_BLOCKED", Message: "圖片網址指向不允許存取的網路位址"}
+	ErrImageTooManyRedirects = &ImageProxyError{Status: http.StatusBadGateway, Code: "IMAGE_TOO_MANY_REDIRECTS", Message: "圖片網址轉址次數過多"}
+	ErrNotAnImage            = &ImageProxyError{Status: http.StatusUnsupportedMediaType, Code: "NOT_AN_IMAGE", Message: "來源內容不是支援的圖片格式"}
+)
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"go-simple-app/indicators"
+	"go-simple-app/models"
+)
+
+// IndicatorService 技術指標服務
+// 以 K 線服務彙總的歷史 K 線（預設查詢區間）計算 MA、EMA、RSI、MACD、KD 與布林通道
+type IndicatorService struct {
+	candles *CandleService
+}
+
+// NewIndicatorService 創建技術指標服務
+func NewIndicatorService(candles *CandleService) *IndicatorService {
+	return &IndicatorService{
+		candles: candles,
+	}
+}
+
+// GetIndicators 計算股票技術指標，limit 為回傳的歷史序列筆數，0 表示只回傳最新指標與訊號
+func (s *IndicatorService) GetIndicators(code, resolution string, limit int) (*models.StockIndicators, error) {
+	series, err := s.candles.GetCandles(code, resolution, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StockIndicators{
+		StockCode:  code,
+		Resolution: series.Resolution,
+		Signals:    []string{},
+	}
+
+	points := computeIndicatorPoints(series.Candles)
+	if n := len(points); n > 0 {
+		result.Latest = &points[n-1]
+		result.Signals = indicatorSignals(points)
+		if limit > 0 {
+			if limit < n {
+				points = points[n-limit:]
+			}
+			result.Series = points
+		}
+	}
+
+	return result, nil
+}
+
+// computeIndicatorPoints 依 K 線計算各期指標
+func computeIndicatorPoints(candles []models.Candle) []models.IndicatorPoint {
+	closes := make([]float64, len(candles))
+	highs := make([]float64, len(candles))
+	lows := make([]float64, len(candles))
+	for i, candle := range candles {
+		closes[i] = candle.Close
+		highs[i] = candle.High
+		lows[i] = candle.Low
+	}
+
+	ma5 := indicators.SMA(closes, 5)
+	ma10 := indicators.SMA(closes, 10)
+	ma20 := indicators.SMA(closes, 20)
+	ma60 := indicators.SMA(closes, 60)
+	ema12 := indicators.EMA(closes, 12)
+	ema26 := indicators.EMA(closes, 26)
+	rsi := indicators.RSI(closes, 14)
+	macd := indicators.MACD(closes, 12, 26, 9)
+	kd := indicators.KD(highs, lows, closes, 9, 3, 3)
+	boll := indicators.Bollinger(closes, 20, 2)
+
+	points := make([]models.IndicatorPoint, len(candles))
+	for i, candle := range candles {
+		points[i] = models.IndicatorPoint{
+			Time:          candle.Time,
+			Close:         candle.Close,
+			MA5:           indicatorValue(ma5[i]),
+			MA10:          indicatorValue(ma10[i]),
+			MA20:          indicatorValue(ma20[i]),
+			MA60:          indicatorValue(ma60[i]),
+			EMA12:         indicatorValue(ema12[i]),
+			EMA26:         indicatorValue(ema26[i]),
+			RSI14:         indicatorValue(rsi[i]),
+			MACDDIF:       indicatorValue(macd.DIF[i]),
+			MACDSignal:    indicatorValue(macd.Signal[i]),
+			MACDHistogram: indicatorValue(macd.Histogram[i]),
+			K:             indicatorValue(kd.K[i]),
+			D:             indicatorValue(kd.D[i]),
+			BollUpper:     indicatorValue(boll.Upper[i]),
+			BollMiddle:    indicatorValue(boll.Middle[i]),
+			BollLower:     indicatorValue(boll.Lower[i]),
+		}
+	}
+	return points
+}
+
+// indicatorValue 將指標值四捨五入到小數第二位，尚無法計算（NaN）時回傳 nil
+func indicatorValue(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	rounded := math.Round(v*100) / 100
+	return &rounded
+}
+
+// indicatorSignals 依最新兩根 K 線的指標判讀訊號
+func indicatorSignals(points []models.IndicatorPoint) []string {
+	signals := []string{}
+	latest := points[len(points)-1]
+	var prev *models.IndicatorPoint
+	if len(points) > 1 {
+		prev = &points[len(points)-2]
+	}
+
+	if latest.RSI14 != nil {
+		switch {
+		case *latest.RSI14 >= 70:
+			signals = append(signals, fmt.Sprintf("RSI(14) %.2f 進入超買區", *latest.RSI14))
+		case *latest.RSI14 <= 30:
+			signals = append(signals, fmt.Sprintf("RSI(14) %.2f 進入超賣區", *latest.RSI14))
+		}
+	}
+
+	if latest.K != nil && latest.D != nil {
+		switch {
+		case *latest.K >= 80 && *latest.D >= 80:
+			signals = append(signals, "KD 位於 80 以上高檔區")
+		case *latest.K <= 20 && *latest.D <= 20:
+			signals = append(signals, "KD 位於 20 以下低檔區")
+		}
+		if prev != nil && prev.K != nil && prev.D != nil {
+			if *prev.K <= *prev.D && *latest.K > *latest.D {
+				signals = append(signals, "KD 黃金交叉（K 值向上穿越 D 值）")
+			} else if *prev.K >= *prev.D && *latest.K < *latest.D {
+				signals = append(signals, "KD 死亡交叉（K 值向下跌破 D 值）")
+			}
+		}
+	}
+
+	if latest.MACDHistogram != nil && prev != nil && prev.MACDHistogram != nil {
+		if *prev.MACDHistogram <= 0 && *latest.MACDHistogram > 0 {
+			signals = append(signals, "MACD 柱狀體翻正（DIF 向上穿越訊號線）")
+		} else if *prev.MACDHistogram >= 0 && *latest.MACDHistogram < 0 {
+			signals = append(signals, "MACD 柱狀體翻負（DIF 向下跌破訊號線）")
+		}
+	}
+
+	if latest.MA5 != nil && latest.MA10 != nil && latest.MA20 != nil {
+		if *latest.MA5 > *latest.MA10 && *latest.MA10 > *latest.MA20 {
+			signals = append(signals, "均線多頭排列（MA5 > MA10 > MA20）")
+		} else if *latest.MA5 < *latest.MA10 && *latest.MA10 < *latest.MA20 {
+			signals = append(signals, "均線空頭排列（MA5 < MA10 < MA20）")
+		}
+	}
+	if latest.MA20 != nil {
+		if latest.Close >= *latest.MA20 {
+			signals = append(signals, "收盤價位於 MA20 之上")
+		} else {
+			signals = append(signals, "收盤價位於 MA20 之下")
+		}
+	}
+
+	if latest.BollUpper != nil && latest.BollLower != nil {
+		if latest.Close > *latest.BollUpper {
+			signals = append(signals, "收盤價突破布林通道上軌")
+		} else if latest.Close < *latest.BollLower {
+			signals = append(signals, "收盤價跌破布林通道下軌")
+		}
+	}
+
+	return signals
+}
+
+// describeTechnicalIndicators 將伺服器計算的技術指標轉為文字，供 AI 提示詞與模擬回覆使用，沒有指標時回傳空字串
+func describeTechnicalIndicators(stockContext map[string]interface{}) string {
+	result, ok := stockContext["technical_indicators"].(*models.StockIndicators)
+	if !ok || result == nil || result.Latest == nil {
+		return ""
+	}
+
+	p := result.Latest
+	text := fmt.Sprintf("**技術指標（依本站歷史日K計算，%s 收盤 %.2f）：**\n", p.Time.Format("2006-01-02"), p.Close)
+	text += fmt.Sprintf("• 移動平均線：MA5 %s、MA10 %s、MA20 %s、MA60 %s\n",
+		formatIndicator(p.MA5), formatIndicator(p.MA10), formatIndicator(p.MA20), formatIndicator(p.MA60))
+	text += fmt.Sprintf("• EMA：EMA12 %s、EMA26 %s\n", formatIndicator(p.EMA12), formatIndicator(p.EMA26))
+	text += fmt.Sprintf("• RSI(14)：%s\n", formatIndicator(p.RSI14))
+	text += fmt.Sprintf("• MACD(12,26,9)：DIF %s、訊號線 %s、柱狀體 %s\n",
+		formatIndicator(p.MACDDIF), formatIndicator(p.MACDSignal), formatIndicator(p.MACDHistogram))
+	text += fmt.Sprintf("• KD(9,3,3)：K %s、D %s\n", formatIndicator(p.K), formatIndicator(p.D))
+	text += fmt.Sprintf("• 布林通道(20,2)：上軌 %s、中軌 %s、下軌 %s\n",
+		formatIndicator(p.BollUpper), formatIndicator(p.BollMiddle), formatIndicator(p.BollLower))
+	for _, signal := range result.Signals {
+		text += fmt.Sprintf("• 訊號：%s\n", signal)
+	}
+	return text
+}
+
+// formatIndicator 格式化指標數值，資料不足時顯示說明
+func formatIndicator(v *float64) string {
+	if v == nil {
+		return "資料不足"
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
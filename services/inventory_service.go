@@ -0,0 +1,759 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// InventoryService 庫存業務邏輯服務
+// 結帳開始時為購物車商品保留庫存，保留在 TTL 內有效，逾時由背景排程釋放
+// 商戶端的庫存變動一律經由此服務，並寫入庫存異動帳
+type InventoryService struct {
+	db              *sql.DB
+	reservationRepo *models.ReservationRepository
+	movementRepo    *models.InventoryMovementRepository
+	productRepo     *models.ProductRepository
+	variantRepo     *models.ProductVariantRepository
+	saleRepo        *models.SaleCampaignRepository
+	cartRepo        *models.CartRepository
+	ttl             time.Duration
+	sweepInterval   time.Duration
+	ticker          *time.Ticker
+	stopChan        chan bool
+}
+
+// CheckoutReservation 結帳保留結果
+type CheckoutReservation struct {
+	Reservations []models.StockReservation `json:"reservations"`
+	ExpiresAt    time.Time                 `json:"expires_at"`
+}
+
+// NewInventoryService 創建庫存服務
+func NewInventoryService(db *sql.DB, cfg config.InventoryConfig) *InventoryService {
+	ttl := time.Duration(cfg.ReservationTTLMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+	sweepInterval := time.Duration(cfg.SweepIntervalSeconds) * time.Second
+	if sweepInterval <= 0 {
+		sweepInterval = time.Minute
+	}
+
+	return &InventoryService{
+		db:              db,
+		reservationRepo: models.NewReservationRepository(db),
+		movementRepo:    models.NewInventoryMovementRepository(db),
+		productRepo:     models.NewProductRepository(db),
+		variantRepo:     models.NewProductVariantRepository(db),
+		saleRepo:        models.NewSaleCampaignRepository(db),
+		cartRepo:        models.NewCartRepository(db),
+		ttl:             ttl,
+		sweepInterval:   sweepInterval,
+		stopChan:        make(chan bool),
+	}
+}
+
+// StartCheckout 開始結帳：釋放客戶先前的保留後，依目前購物車重新保留庫存
+func (s *InventoryService) StartCheckout(customerID int) (*CheckoutReservation, error) {
+	if customerID <= 0 {
+		return nil, &models.DomainError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
+	}
+
+	cart, err := s.cartRepo.GetCartByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, models.ErrCartEmpty
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 先釋放舊的保留再依購物車重新保留，補貨通知待重新保留後才判斷，只通知最後仍可購買的商品
+	released, err := s.reservationRepo.ReleaseCustomerTx(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.ttl).UTC().Truncate(time.Second)
+	result := &CheckoutReservation{
+		Reservations: []models.StockReservation{},
+		ExpiresAt:    expiresAt,
+	}
+
+	for _, item := range cart.Items {
+		reservation := models.StockReservation{
+			CustomerID: customerID,
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+			Quantity:   item.Quantity,
+			ExpiresAt:  expiresAt,
+		}
+		if err := s.reservationRepo.ReserveTx(tx, &reservation); err != nil {
+			if err == models.ErrInsufficientStock {
+				name := item.Product.Name
+				if item.Variant != nil {
+					name += "（" + item.Variant.Name + "）"
+				}
+				return nil, &models.DomainError{Code: "INSUFFICIENT_STOCK", Message: "商品 " + name + " 庫存不足"}
+			}
+			return nil, err
+		}
+		result.Reservations = append(result.Reservations, reservation)
+	}
+	if err := released.NotifyTx(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CancelCheckout 取消結帳並釋放客戶所有保留
+func (s *InventoryService) CancelCheckout(customerID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	released, err := s.reservationRepo.ReleaseCustomerTx(tx, customerID)
+	if err != nil {
+		return err
+	}
+	if err := released.NotifyTx(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCheckout 獲取客戶目前有效的保留
+func (s *InventoryService) GetCheckout(customerID int) (*CheckoutReservation, error) {
+	reservations, err := s.reservationRepo.GetActiveByCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckoutReservation{Reservations: reservations}
+	for _, reservation := range reservations {
+		if result.ExpiresAt.IsZero() || reservation.ExpiresAt.Before(result.ExpiresAt) {
+			result.ExpiresAt = reservation.ExpiresAt
+		}
+	}
+
+	return result, nil
+}
+
+// CreateMerchantProduct 創建商戶商品並記錄期初庫存
+func (s *InventoryService) CreateMerchantProduct(merchantID int, product *models.Product) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.createMerchantProductTx(tx, merchantID, product); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *InventoryService) createMerchantProductTx(tx *sql.Tx, merchantID int, product *models.Product) error {
+	product.MerchantID = merchantID
+
+	if err := s.productRepo.CreateTx(tx, product); err != nil {
+		return err
+	}
+
+	return s.movementRepo.RecordTx(tx, &models.InventoryMovement{
+		ProductID: product.ID,
+		Delta:     product.Stock,
+		Reason:    models.MovementReasonOpening,
+		ActorType: models.OrderActorMerchant,
+		ActorID:   merchantID,
+	})
+}
+
+// UpdateMerchantProduct 更新商戶商品，庫存有變動時寫入異動帳
+// 有規格的商品庫存由規格加總而來，此處不變更
+func (s *InventoryService) UpdateMerchantProduct(merchantID int, product *models.Product) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.updateMerchantProductTx(tx, merchantID, product); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *InventoryService) updateMerchantProductTx(tx *sql.Tx, merchantID int, product *models.Product) error {
+	existing, err := s.productRepo.GetByIDTx(tx, product.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrMerchantProductNotFound
+		}
+		return err
+	}
+	if existing.MerchantID != merchantID {
+		return models.ErrMerchantProductNotFound
+	}
+
+	hasVariants, err := s.variantRepo.HasActiveVariantsTx(tx, product.ID)
+	if err != nil {
+		return err
+	}
+	if hasVariants {
+		product.Stock = existing.Stock
+	}
+
+	// 與手動調整相同，庫存不可低於結帳中保留的數量
+	if product.Stock < 0 || product.Stock < existing.ReservedStock {
+		return models.ErrInvalidStockAdjustment.WithMessage(fmt.Sprintf("庫存不可低於結帳中保留的數量（%d）或為負數", existing.ReservedStock))
+	}
+
+	product.MerchantID = merchantID
+	if err := s.productRepo.UpdateTx(tx, product); err != nil {
+		return err
+	}
+
+	if delta := product.Stock - existing.Stock; delta != 0 {
+		return s.movementRepo.RecordTx(tx, &models.InventoryMovement{
+			ProductID: product.ID,
+			Delta:     delta,
+			Reason:    models.MovementReasonMerchantEdit,
+			ActorType: models.OrderActorMerchant,
+			ActorID:   merchantID,
+		})
+	}
+
+	return nil
+}
+
+// AdjustStock 商戶手動增減庫存（盤點、報廢等）
+// 有規格的商品需指定規格，規格與商品庫存一併調整，異動帳以商品為單位記錄
+func (s *InventoryService) AdjustStock(merchantID, productID, variantID, delta int, note *string) (*models.InventoryMovement, error) {
+	if delta == 0 {
+		return nil, &models.DomainError{Code: "INVALID_QUANTITY", Message: "調整數量不可為0"}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	product, err := s.productRepo.GetByIDTx(tx, productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrMerchantProductNotFound
+		}
+		return nil, err
+	}
+	if product.MerchantID != merchantID {
+		return nil, models.ErrMerchantProductNotFound
+	}
+
+	if err := s.adjustVariantStockTx(tx, productID, variantID, delta); err != nil {
+		return nil, err
+	}
+
+	if err := s.productRepo.AdjustStockTx(tx, productID, delta); err != nil {
+		if err == models.ErrInsufficientStock {
+			return nil, models.ErrInvalidStockAdjustment
+		}
+		return nil, err
+	}
+
+	movement := &models.InventoryMovement{
+		ProductID: productID,
+		Delta:     delta,
+		Reason:    models.MovementReasonManualAdjustment,
+		ActorType: models.OrderActorMerchant,
+		ActorID:   merchantID,
+		Note:      note,
+	}
+	if err := s.movementRepo.RecordTx(tx, movement); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+// RestockReturnTx 在呼叫端的交易中將核准退貨的商品放回庫存並扣回銷售數，以退貨申請為參照寫入異動帳
+// 商品或規格已刪除時略過該商品，回傳實際回補的件數
+func (s *InventoryService) RestockReturnTx(tx *sql.Tx, returnID int, items []*models.ReturnItem, actorType string, actorID int) (int, error) {
+	referenceType := models.MovementReferenceReturn
+	restocked := 0
+	for _, item := range items {
+		if _, err := s.productRepo.GetByIDTx(tx, item.ProductID); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+
+		if item.VariantID != nil {
+			// 規格已刪除時只回補商品層級的庫存
+			if err := s.variantRepo.AdjustStockTx(tx, *item.VariantID, item.Quantity); err != nil && err != models.ErrInsufficientStock {
+				return 0, err
+			}
+		}
+		if err := s.productRepo.AdjustStockTx(tx, item.ProductID, item.Quantity); err != nil {
+			return 0, err
+		}
+		// 與取消訂單回補庫存一致，扣回退貨商品的銷售數
+		if err := s.productRepo.DecrementSalesCountTx(tx, item.ProductID, item.Quantity); err != nil {
+			return 0, err
+		}
+
+		note := "退貨回補 " + item.ProductName
+		err := s.movementRepo.RecordTx(tx, &models.InventoryMovement{
+			ProductID:     item.ProductID,
+			Delta:         item.Quantity,
+			Reason:        models.MovementReasonReturnRestocked,
+			ActorType:     actorType,
+			ActorID:       actorID,
+			ReferenceType: &referenceType,
+			ReferenceID:   &returnID,
+			Note:          &note,
+		})
+		if err != nil {
+			return 0, err
+		}
+		restocked += item.Quantity
+	}
+	return restocked, nil
+}
+
+// adjustVariantStockTx 在交易中調整規格庫存，並檢查規格選擇是否符合商品
+func (s *InventoryService) adjustVariantStockTx(tx *sql.Tx, productID, variantID, delta int) error {
+	hasVariants, err := s.variantRepo.HasActiveVariantsTx(tx, productID)
+	if err != nil {
+		return err
+	}
+	if variantID <= 0 {
+		if hasVariants {
+			return models.ErrVariantRequired
+		}
+		return nil
+	}
+	if !hasVariants {
+		return models.ErrVariantNotAllowed
+	}
+
+	variant, err := s.variantRepo.GetByIDTx(tx, variantID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrVariantNotFound
+		}
+		return err
+	}
+	if variant.ProductID != productID || !variant.IsActive {
+		return models.ErrVariantNotFound
+	}
+
+	if err := s.variantRepo.AdjustStockTx(tx, variantID, delta); err != nil {
+		if err == models.ErrInsufficientStock {
+			return models.ErrInvalidStockAdjustment
+		}
+		return err
+	}
+	return nil
+}
+
+// GetProductVariants 獲取商戶商品的選項軸與所有規格（含已停用）
+func (s *InventoryService) GetProductVariants(merchantID, productID int) ([]models.ProductOption, []*models.ProductVariant, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, models.ErrMerchantProductNotFound
+		}
+		return nil, nil, err
+	}
+	if product.MerchantID != merchantID {
+		return nil, nil, models.ErrMerchantProductNotFound
+	}
+
+	options, err := s.variantRepo.GetOptions(productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	variants, err := s.variantRepo.GetByProductID(productID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return options, variants, nil
+}
+
+// SetProductVariants 以請求內容取代商戶商品的選項軸與規格
+// 既有規格依 SKU 比對後更新，未列出的規格停用；商品庫存重設為啟用規格的加總並寫入異動帳
+// 規格的庫存不可低於已保留數量，已保留的規格也不可停用
+func (s *InventoryService) SetProductVariants(merchantID, productID int, req *models.ProductVariantsRequest) ([]models.ProductOption, []*models.ProductVariant, error) {
+	options, err := validateVariantOptions(req.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	product, err := s.productRepo.GetByIDTx(tx, productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, models.ErrMerchantProductNotFound
+		}
+		return nil, nil, err
+	}
+	if product.MerchantID != merchantID {
+		return nil, nil, models.ErrMerchantProductNotFound
+	}
+	// 特價只作用於商品價格，有規格後會以規格價格結帳，因此特價活動結束前不能設定規格
+	if len(req.Variants) > 0 {
+		hasSale, err := s.saleRepo.HasOpenByProductTx(tx, productID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hasSale {
+			return nil, nil, models.ErrSaleCampaignOpen
+		}
+	}
+
+	existing, err := s.variantRepo.GetByProductIDTx(tx, productID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	reservedBySKU := make(map[string]int, len(existing))
+	for _, variant := range existing {
+		if variant.IsActive && variant.ReservedStock > 0 {
+			reservedBySKU[variant.SKU] = variant.ReservedStock
+		}
+	}
+
+	if err := s.variantRepo.ReplaceOptionsTx(tx, productID, options); err != nil {
+		return nil, nil, err
+	}
+
+	// 先停用全部規格，再依請求逐一更新或新增，未列出的規格即維持停用
+	if err := s.variantRepo.DeactivateAllTx(tx, productID); err != nil {
+		return nil, nil, err
+	}
+
+	activeKeys := make(map[string]bool, len(req.Variants))
+	seenSKUs := make(map[string]bool, len(req.Variants))
+	for i, input := range req.Variants {
+		variant, optionKey, err := buildVariant(options, productID, i, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		if seenSKUs[variant.SKU] {
+			return nil, nil, models.ErrDuplicateVariantSKU
+		}
+		seenSKUs[variant.SKU] = true
+		if variant.IsActive {
+			if activeKeys[optionKey] {
+				return nil, nil, models.ErrInvalidVariants.WithMessage("規格 " + models.VariantName(optionKey) + " 重複")
+			}
+			activeKeys[optionKey] = true
+		}
+
+		if reserved := reservedBySKU[variant.SKU]; reserved > 0 {
+			if !variant.IsActive || variant.Stock < reserved {
+				return nil, nil, models.ErrInvalidVariants.WithMessage("規格 " + variant.SKU + " 尚有顧客結帳中保留的數量")
+			}
+			delete(reservedBySKU, variant.SKU)
+		}
+
+		if err := s.variantRepo.UpsertTx(tx, variant, optionKey); err != nil {
+			if err == models.ErrDuplicateVariantSKU || strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return nil, nil, models.ErrDuplicateVariantSKU
+			}
+			return nil, nil, err
+		}
+	}
+
+	// 未列出的規格會停用，仍有保留數量時拒絕
+	for sku := range reservedBySKU {
+		return nil, nil, models.ErrInvalidVariants.WithMessage("規格 " + sku + " 尚有顧客結帳中保留的數量")
+	}
+
+	if err := s.variantRepo.SyncProductStockTx(tx, productID); err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := s.productRepo.GetByIDTx(tx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if delta := updated.Stock - product.Stock; delta != 0 {
+		err := s.movementRepo.RecordTx(tx, &models.InventoryMovement{
+			ProductID: productID,
+			Delta:     delta,
+			Reason:    models.MovementReasonMerchantEdit,
+			ActorType: models.OrderActorMerchant,
+			ActorID:   merchantID,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	variants, err := s.variantRepo.GetByProductIDTx(tx, productID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return options, variants, nil
+}
+
+// validateVariantOptions 驗證選項軸：名稱不可重複，每個選項軸至少一個值且值不可重複
+func validateVariantOptions(inputs []models.ProductOptionInput) ([]models.ProductOption, error) {
+	options := make([]models.ProductOption, 0, len(inputs))
+	names := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		name := strings.TrimSpace(input.Name)
+		if name == "" || names[name] {
+			return nil, models.ErrInvalidVariants.WithMessage("選項名稱不可為空或重複")
+		}
+		names[name] = true
+
+		values := make([]string, 0, len(input.Values))
+		seen := make(map[string]bool, len(input.Values))
+		for _, value := range input.Values {
+			value = strings.TrimSpace(value)
+			if value == "" || seen[value] || strings.Contains(value, "|") {
+				return nil, models.ErrInvalidVariants.WithMessage("選項 " + name + " 的值不可為空、重複或包含「|」")
+			}
+			seen[value] = true
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			return nil, models.ErrInvalidVariants.WithMessage("選項 " + name + " 至少需要一個值")
+		}
+
+		options = append(options, models.ProductOption{Name: name, Values: values})
+	}
+	return options, nil
+}
+
+// buildVariant 依選項軸驗證規格內容，回傳規格與其選項組合鍵
+func buildVariant(options []models.ProductOption, productID, position int, input models.ProductVariantInput) (*models.ProductVariant, string, error) {
+	sku := strings.TrimSpace(input.SKU)
+	if sku == "" {
+		return nil, "", models.ErrInvalidVariants.WithMessage("規格 SKU 不可為空")
+	}
+	if len(options) == 0 || len(input.Options) != len(options) {
+		return nil, "", models.ErrInvalidVariants.WithMessage("規格 " + sku + " 的選項需與選項軸一致")
+	}
+
+	values := make(map[string]string, len(options))
+	for _, option := range options {
+		value := strings.TrimSpace(input.Options[option.Name])
+		valid := false
+		for _, allowed := range option.Values {
+			if allowed == value {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, "", models.ErrInvalidVariants.WithMessage("規格 " + sku + " 的選項 " + option.Name + " 不在可選值中")
+		}
+		values[option.Name] = value
+	}
+
+	if input.Price != nil && *input.Price <= 0 {
+		return nil, "", models.ErrInvalidVariants.WithMessage("規格 " + sku + " 的價格必須大於0")
+	}
+	if input.Stock < 0 {
+		return nil, "", models.ErrInvalidVariants.WithMessage("規格 " + sku + " 的庫存不可為負數")
+	}
+
+	isActive := true
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+
+	return &models.ProductVariant{
+		ProductID: productID,
+		SKU:       sku,
+		Options:   values,
+		Price:     input.Price,
+		Stock:     input.Stock,
+		IsActive:  isActive,
+		Position:  position,
+	}, models.VariantOptionKey(options, values), nil
+}
+
+// GetMovements 獲取商戶的庫存異動帳，productID 為 0 時不限商品
+func (s *InventoryService) GetMovements(merchantID, productID, limit, offset int) ([]models.InventoryMovement, int, error) {
+	movements, err := s.movementRepo.GetByMerchantID(merchantID, productID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.movementRepo.CountByMerchantID(merchantID, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return movements, total, nil
+}
+
+// Reconcile 比對商戶商品的現有庫存與異動帳
+func (s *InventoryService) Reconcile(merchantID, productID int) ([]models.InventoryReconciliation, error) {
+	return s.movementRepo.Reconcile(merchantID, productID)
+}
+
+// ApplyReconciliation 以異動帳加總重設不一致商品的現有庫存，回傳重設後的對帳結果
+// 每筆重設都寫入對帳調整異動；任一商品重設後會低於保留數量時整批不套用
+func (s *InventoryService) ApplyReconciliation(merchantID, productID int) ([]models.InventoryReconciliation, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	results, err := s.movementRepo.ReconcileTx(tx, merchantID, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	updated := 0
+	for _, result := range results {
+		if result.Consistent {
+			continue
+		}
+
+		delta := result.LedgerStock - result.Stock
+		if err := s.productRepo.AdjustStockTx(tx, result.ProductID, delta); err != nil {
+			if err == models.ErrInsufficientStock {
+				return nil, 0, models.ErrInvalidStockAdjustment.WithMessage(fmt.Sprintf("商品「%s」依異動帳重設後庫存為 %d，低於保留數量或為負數", result.ProductName, result.LedgerStock))
+			}
+			return nil, 0, err
+		}
+
+		note := fmt.Sprintf("對帳重設：現有庫存 %d，異動帳加總 %d", result.Stock, result.LedgerStock)
+		err := s.movementRepo.RecordTx(tx, &models.InventoryMovement{
+			ProductID: result.ProductID,
+			Delta:     delta,
+			Reason:    models.MovementReasonReconciliation,
+			ActorType: models.OrderActorMerchant,
+			ActorID:   merchantID,
+			Note:      &note,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+
+	if updated > 0 {
+		logger.Info("已依庫存異動帳重設庫存", logrus.Fields{
+			"merchant_id": merchantID,
+			"product_id":  productID,
+			"updated":     updated,
+		})
+	}
+
+	results, err = s.movementRepo.Reconcile(merchantID, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, updated, nil
+}
+
+// RecordOpeningBalances 為尚未建帳的商品補登期初庫存
+func (s *InventoryService) RecordOpeningBalances() error {
+	recorded, err := s.movementRepo.RecordOpeningBalances()
+	if err != nil {
+		return err
+	}
+	if recorded > 0 {
+		logger.Info("已補登商品期初庫存", logrus.Fields{
+			"products": recorded,
+		})
+	}
+	return nil
+}
+
+// ReleaseExpired 釋放已過期的保留
+func (s *InventoryService) ReleaseExpired() (int, error) {
+	return s.reservationRepo.ExpireDue(time.Now())
+}
+
+// StartSweeper 啟動背景排程定期釋放過期保留
+func (s *InventoryService) StartSweeper() {
+	s.ticker = time.NewTicker(s.sweepInterval)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				released, err := s.ReleaseExpired()
+				if err != nil {
+					logger.Error("釋放過期庫存保留失敗", err)
+				} else if released > 0 {
+					logger.Info("已釋放過期庫存保留", logrus.Fields{
+						"released": released,
+					})
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	logger.Info("庫存保留釋放排程已啟動", logrus.Fields{
+		"ttl":            s.ttl.String(),
+		"sweep_interval": s.sweepInterval.String(),
+	})
+}
+
+// StopSweeper 停止背景排程
+func (s *InventoryService) StopSweeper() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	select {
+	case s.stopChan <- true:
+	default:
+	}
+}
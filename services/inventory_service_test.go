@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"go-simple-app/models"
+)
+
+// expireReservations 將客戶的保留改為已過期，模擬排程尚未執行的期間
+func (s *testShop) expireReservations(t *testing.T, customerID int) {
+	t.Helper()
+	s.exec(t, `UPDATE stock_reservations SET expires_at = ? WHERE customer_id = ? AND status = ?`,
+		time.Now().Add(-time.Minute).UTC(), customerID, models.ReservationStatusActive)
+}
+
+func TestCheckoutReservation(t *testing.T) {
+	tests := []struct {
+		name           string
+		expire         bool // 下單前保留已過期但排程尚未釋放
+		wantStock      int
+		wantReserved   int // 下單後、排程執行前的保留數量
+		wantConverted  int
+		wantSwept      int // 排程釋放的筆數
+		wantAfterSweep int
+	}{
+		{name: "保留有效時下單轉為扣減", wantStock: 3, wantReserved: 0, wantConverted: 1, wantSwept: 0, wantAfterSweep: 0},
+		{name: "保留過期後下單改扣可售庫存", expire: true, wantStock: 3, wantReserved: 2, wantConverted: 0, wantSwept: 1, wantAfterSweep: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			customerID := shop.customer(t)
+			productID := shop.product(t, shop.merchant(t), 100, 5)
+			shop.addToCart(t, customerID, productID, 2)
+
+			if _, err := shop.inventory.StartCheckout(customerID); err != nil {
+				t.Fatalf("StartCheckout: %v", err)
+			}
+			if _, reserved := shop.stock(t, productID); reserved != 2 {
+				t.Fatalf("reserved after StartCheckout = %d, want 2", reserved)
+			}
+			if tt.expire {
+				shop.expireReservations(t, customerID)
+			}
+
+			shop.checkout(t, customerID)
+			stock, reserved := shop.stock(t, productID)
+			if stock != tt.wantStock || reserved != tt.wantReserved {
+				t.Errorf("after checkout stock/reserved = %d/%d, want %d/%d", stock, reserved, tt.wantStock, tt.wantReserved)
+			}
+			var converted int
+			shop.scalar(t, &converted, `SELECT COUNT(*) FROM stock_reservations WHERE customer_id = ? AND status = ?`,
+				customerID, models.ReservationStatusConverted)
+			if converted != tt.wantConverted {
+				t.Errorf("converted reservations = %d, want %d", converted, tt.wantConverted)
+			}
+
+			swept, err := shop.inventory.ReleaseExpired()
+			if err != nil {
+				t.Fatalf("ReleaseExpired: %v", err)
+			}
+			if swept != tt.wantSwept {
+				t.Errorf("swept = %d, want %d", swept, tt.wantSwept)
+			}
+			if stock, reserved := shop.stock(t, productID); stock != tt.wantStock || reserved != tt.wantAfterSweep {
+				t.Errorf("after sweep stock/reserved = %d/%d, want %d/%d", stock, reserved, tt.wantStock, tt.wantAfterSweep)
+			}
+		})
+	}
+}
+
+func TestCheckoutReservationBlocksOtherCustomers(t *testing.T) {
+	shop := newTestShop(t)
+	productID := shop.product(t, shop.merchant(t), 100, 3)
+	holder := shop.customer(t)
+	other := shop.customer(t)
+	shop.addToCart(t, holder, productID, 2)
+	shop.addToCart(t, other, productID, 2)
+
+	if _, err := shop.inventory.StartCheckout(holder); err != nil {
+		t.Fatalf("StartCheckout(holder): %v", err)
+	}
+	_, err := shop.inventory.StartCheckout(other)
+	if domainErr, ok := err.(*models.DomainError); !ok || domainErr.Code != "INSUFFICIENT_STOCK" {
+		t.Fatalf("StartCheckout(other) error = %v, want INSUFFICIENT_STOCK", err)
+	}
+
+	// 保留過期並由排程釋放後，其他客戶可以保留
+	shop.expireReservations(t, holder)
+	if _, err := shop.inventory.ReleaseExpired(); err != nil {
+		t.Fatalf("ReleaseExpired: %v", err)
+	}
+	if _, err := shop.inventory.StartCheckout(other); err != nil {
+		t.Errorf("StartCheckout(other) after sweep: %v", err)
+	}
+}
+
+func TestGetCheckoutExcludesExpiredReservations(t *testing.T) {
+	shop := newTestShop(t)
+	customerID := shop.customer(t)
+	productID := shop.product(t, shop.merchant(t), 100, 5)
+	shop.addToCart(t, customerID, productID, 1)
+
+	if _, err := shop.inventory.StartCheckout(customerID); err != nil {
+		t.Fatalf("StartCheckout: %v", err)
+	}
+	shop.expireReservations(t, customerID)
+
+	checkout, err := shop.inventory.GetCheckout(customerID)
+	if err != nil {
+		t.Fatalf("GetCheckout: %v", err)
+	}
+	if len(checkout.Reservations) != 0 {
+		t.Errorf("reservations = %d, want expired ones excluded", len(checkout.Reservations))
+	}
+}
+
+func TestCancelCheckoutReleasesReservation(t *testing.T) {
+	shop := newTestShop(t)
+	customerID := shop.customer(t)
+	productID := shop.product(t, shop.merchant(t), 100, 5)
+	shop.addToCart(t, customerID, productID, 4)
+
+	if _, err := shop.inventory.StartCheckout(customerID); err != nil {
+		t.Fatalf("StartCheckout: %v", err)
+	}
+	if err := shop.inventory.CancelCheckout(customerID); err != nil {
+		t.Fatalf("CancelCheckout: %v", err)
+	}
+	if stock, reserved := shop.stock(t, productID); stock != 5 || reserved != 0 {
+		t.Errorf("stock/reserved = %d/%d, want 5/0", stock, reserved)
+	}
+}
@@ -6,12 +6,16 @@ import (
 )
 
 type MallService struct {
-	productRepo *models.ProductRepository
+	productRepo        *models.ProductRepository
+	categoryRepo       *models.CategoryRepository
+	recommendationRepo *models.RecommendationRepository
 }
 
 func NewMallService(db *sql.DB) *MallService {
 	return &MallService{
-		productRepo: models.NewProductRepository(db),
+		productRepo:        models.NewProductRepository(db),
+		categoryRepo:       models.NewCategoryRepository(db),
+		recommendationRepo: models.NewRecommendationRepository(db),
 	}
 }
 
@@ -54,27 +58,121 @@ func (s *MallService) GetProductStats() (map[string]interface{}, error) {
 	}, nil
 }
 
-// SearchProductsWithFilters 帶篩選條件的商品搜尋
-func (s *MallService) SearchProductsWithFilters(keyword string, category string, minPrice, maxPrice float64, limit, offset int) ([]*models.Product, error) {
-	// 這裡可以實現更複雜的搜尋邏輯
-	// 例如：價格範圍篩選、分類篩選、排序等
-	
-	if keyword != "" {
-		return s.productRepo.Search(keyword, limit, offset)
+// ProductListResult 商品列表篩選結果
+type ProductListResult struct {
+	Products []*models.Product     `json:"products"`
+	Total    int                   `json:"total"`
+	Facets   *models.ProductFacets `json:"facets"`
+	Limit    int                   `json:"limit"`
+	Offset   int                   `json:"offset"`
+}
+
+// SearchProductsWithFilters 帶篩選條件的商品搜尋，並附上各篩選面向的商品數
+func (s *MallService) SearchProductsWithFilters(filter *models.ProductFilter) (*ProductListResult, error) {
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, &models.DomainError{Code: "INVALID_FILTER", Message: "最低價格不可高於最高價格"}
 	}
-	
-	if category != "" {
-		return s.productRepo.GetByCategory(category, limit, offset)
+	if filter.Sort != "" && !models.IsValidProductSort(filter.Sort) {
+		return nil, &models.DomainError{Code: "INVALID_FILTER", Message: "不支援的排序方式"}
 	}
-	
-	return s.productRepo.GetAll(limit, offset)
+
+	categories, err := s.categoryRepo.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分類名稱對應到分類樹時改用分類ID，才會包含子孫分類
+	if filter.CategoryID == 0 && filter.Category != "" {
+		for _, category := range categories {
+			if category.Name == filter.Category {
+				filter.CategoryID = category.ID
+				break
+			}
+		}
+	}
+
+	products, total, err := s.productRepo.Filter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := s.productRepo.Facets(filter, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProductListResult{
+		Products: products,
+		Total:    total,
+		Facets:   facets,
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+	}, nil
 }
 
-// GetRecommendedProducts 獲取推薦商品
+// GetRecommendedProducts 獲取顧客的個人化推薦（批次計算結果），不足的部分以熱銷商品補齊
+// userID 為 0 時（未登入）只回傳熱銷商品
 func (s *MallService) GetRecommendedProducts(userID int, limit int) ([]*models.Product, error) {
-	// 這裡可以實現基於用戶行為的推薦算法
-	// 目前簡單返回精選商品
-	return s.productRepo.GetFeatured(limit)
+	products := []*models.Product{}
+	if userID > 0 {
+		recommended, err := s.recommendationRepo.GetForCustomer(userID, limit)
+		if err != nil {
+			return nil, err
+		}
+		products = recommended
+	}
+
+	if len(products) < limit {
+		popular, err := s.recommendationRepo.GetPopular(userID, productIDs(products), limit-len(products))
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, popular...)
+	}
+
+	return products, nil
+}
+
+// GetRelatedProducts 獲取「買了這個的顧客也買了」，不足的部分以同分類熱銷商品補齊
+func (s *MallService) GetRelatedProducts(productID int, limit int) ([]*models.Product, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrMerchantProductNotFound
+		}
+		return nil, err
+	}
+	if !product.IsActive {
+		return nil, models.ErrMerchantProductNotFound
+	}
+
+	products, err := s.recommendationRepo.GetRelated(product.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) < limit {
+		similar, err := s.recommendationRepo.GetSimilar(product, productIDs(products), limit-len(products))
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, similar...)
+	}
+
+	return products, nil
+}
+
+// RecordProductView 記錄顧客瀏覽商品，作為共同瀏覽的推薦訊號
+func (s *MallService) RecordProductView(customerID, productID int) error {
+	return s.recommendationRepo.RecordView(customerID, productID)
+}
+
+func productIDs(products []*models.Product) []int {
+	ids := make([]int, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	return ids
 }
 
 // GetProductCategories 獲取商品分類（包含子分類）
@@ -0,0 +1,653 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"go-simple-app/models"
+)
+
+// OrderService 訂單業務邏輯服務
+type OrderService struct {
+	db              *sql.DB
+	orderRepo       *models.OrderRepository
+	cartRepo        *models.CartRepository
+	productRepo     *models.ProductRepository
+	variantRepo     *models.ProductVariantRepository
+	reservationRepo *models.ReservationRepository
+	movementRepo    *models.InventoryMovementRepository
+	saleRepo        *models.SaleCampaignRepository
+	paymentRepo     *models.PaymentRepository
+	promotions      *PromotionService
+	shipping        *ShippingService
+	addresses       *AddressService
+}
+
+// NewOrderService 創建訂單服務
+func NewOrderService(db *sql.DB, shipping *ShippingService) *OrderService {
+	return &OrderService{
+		db:              db,
+		orderRepo:       models.NewOrderRepository(db),
+		cartRepo:        models.NewCartRepository(db),
+		productRepo:     models.NewProductRepository(db),
+		variantRepo:     models.NewProductVariantRepository(db),
+		reservationRepo: models.NewReservationRepository(db),
+		movementRepo:    models.NewInventoryMovementRepository(db),
+		saleRepo:        models.NewSaleCampaignRepository(db),
+		paymentRepo:     models.NewPaymentRepository(db),
+		promotions:      NewPromotionService(db),
+		shipping:        shipping,
+		addresses:       NewAddressService(db),
+	}
+}
+
+// PlaceOrder 將客戶購物車轉為訂單
+// 購物車依商品的商戶拆分為多張子訂單，並以一個訂單群組對應一次付款
+// 商品名稱與價格在下單時快照，扣減庫存、增加銷售數與清空購物車在同一個交易中完成
+// 客戶在結帳開始時保留的庫存會在此轉為實際扣減
+// 促銷折扣在此重新計算，套用的優惠券不可用時拒絕下單
+// 促銷、免運門檻與優惠券低消以讀取購物車時的售價計算，交易內重新讀取的售價與其不同時拒絕下單，避免兩種價格基準
+func (s *OrderService) PlaceOrder(customerID int, req *models.PlaceOrderRequest) (*models.OrderGroup, error) {
+	// 參數驗證
+	if customerID <= 0 {
+		return nil, &models.DomainError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
+	}
+	if req == nil {
+		return nil, &models.DomainError{Code: "INVALID_SHIPPING_ADDRESS", Message: "收件地址不能為空"}
+	}
+
+	// 收件與帳單地址：通訊錄地址、輸入的文字地址或預設地址
+	shippingAddress, shippingDetail, err := s.addresses.ResolveForOrder(customerID, req.ShippingAddressID,
+		req.ShippingAddress, models.AddressUsageShipping)
+	if err != nil {
+		return nil, err
+	}
+	if shippingAddress == "" {
+		return nil, &models.DomainError{Code: "INVALID_SHIPPING_ADDRESS", Message: "收件地址不能為空"}
+	}
+	billingText := ""
+	if req.BillingAddress != nil {
+		billingText = *req.BillingAddress
+	}
+	billingAddress, billingDetail, err := s.addresses.ResolveForOrder(customerID, req.BillingAddressID,
+		billingText, models.AddressUsageBilling)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := s.cartRepo.GetCartByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, models.ErrCartEmpty
+	}
+
+	// 依商戶分組，保留購物車中的先後順序
+	var merchantIDs []int
+	itemsByMerchant := make(map[int][]models.CartItem)
+	for _, item := range cart.Items {
+		merchantID := item.Product.MerchantID
+		if _, exists := itemsByMerchant[merchantID]; !exists {
+			merchantIDs = append(merchantIDs, merchantID)
+		}
+		itemsByMerchant[merchantID] = append(itemsByMerchant[merchantID], item)
+	}
+
+	pricing, err := s.promotions.PriceCart(customerID, cart.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := pricing.CheckCoupon(); err != nil {
+		return nil, err
+	}
+
+	shipping, err := s.shipping.QuoteCart(customerID, cart.Items, pricing, req.ShippingMethods)
+	if err != nil {
+		return nil, err
+	}
+	if err := shipping.Check(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	group := &models.OrderGroup{
+		GroupNumber:           generateOrderNumber(),
+		CustomerID:            customerID,
+		PaymentMethod:         req.PaymentMethod,
+		PaymentStatus:         models.PaymentStatusPending,
+		ShippingAddress:       shippingAddress,
+		Notes:                 req.Notes,
+		ShippingAddressDetail: shippingDetail,
+		BillingAddressDetail:  billingDetail,
+	}
+	if billingAddress != "" {
+		group.BillingAddress = &billingAddress
+	}
+	if err := s.orderRepo.CreateGroupTx(tx, group); err != nil {
+		return nil, fmt.Errorf("創建訂單群組失敗: %w", err)
+	}
+
+	// 保留是否有效以同一時間點判斷，避免讀取與轉換之間剛好過期的保留被重複歸還
+	now := time.Now()
+	reserved, err := s.reservationRepo.GetActiveQuantitiesTx(tx, customerID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, merchantID := range merchantIDs {
+		discount := pricing.MerchantDiscounts[merchantID]
+		order, err := s.createMerchantOrderTx(tx, group, merchantID, itemsByMerchant[merchantID], reserved, discount,
+			shipping.Merchant(merchantID), i+1)
+		if err != nil {
+			return nil, err
+		}
+		group.Orders = append(group.Orders, order)
+		group.ShippingFee += order.ShippingFee
+		group.DiscountAmount += order.DiscountAmount
+		group.TotalAmount += order.TotalAmount
+	}
+	group.ShippingFee = roundAmount(group.ShippingFee)
+	group.DiscountAmount = roundAmount(group.DiscountAmount)
+	group.TotalAmount = roundAmount(group.TotalAmount)
+
+	if err := s.orderRepo.UpdateGroupTotalsTx(tx, group); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotions.RedeemTx(tx, pricing, customerID, group.ID); err != nil {
+		return nil, err
+	}
+
+	// 已保留但最後未下單的商品（例如保留後從購物車移除），歸還保留數量
+	for key, quantity := range reserved {
+		if err := s.reservationRepo.ReturnReservedTx(tx, key, quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.reservationRepo.ConvertCustomerTx(tx, customerID, group.ID, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.ClearCartTx(tx, customerID); err != nil {
+		return nil, err
+	}
+
+	if err := s.shipping.ClearCartSelectionsTx(tx, customerID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// createMerchantOrderTx 在交易中為單一商戶建立子訂單並扣減庫存
+// reserved 為客戶各商品（規格）的保留數量，扣減庫存時一併釋放；discount 為分攤到此商戶的促銷折扣；shipping 為此商戶選擇的運送方式
+func (s *OrderService) createMerchantOrderTx(tx *sql.Tx, group *models.OrderGroup, merchantID int, cartItems []models.CartItem, reserved map[models.StockKey]int, discount float64, shipping *MerchantShipping, seq int) (*models.Order, error) {
+	order := &models.Order{
+		OrderNumber:           fmt.Sprintf("%s-%02d", group.GroupNumber, seq),
+		GroupID:               &group.ID,
+		CustomerID:            group.CustomerID,
+		MerchantID:            merchantID,
+		Status:                models.OrderStatusPending,
+		PaymentMethod:         group.PaymentMethod,
+		PaymentStatus:         models.PaymentStatusPending,
+		ShippingAddress:       group.ShippingAddress,
+		BillingAddress:        group.BillingAddress,
+		Notes:                 group.Notes,
+		ShippingAddressDetail: group.ShippingAddressDetail,
+		BillingAddressDetail:  group.BillingAddressDetail,
+	}
+	if shipping != nil {
+		order.ShippingFee = shipping.ShippingFee
+		order.ShippingMethodID = shipping.ShippingMethodID
+		order.ShippingMethod = &shipping.ShippingMethod
+	}
+
+	subtotal := 0.0
+	for _, item := range cartItems {
+		// 在交易內重新讀取商品，確保快照為最新價格
+		var name string
+		var price float64
+		var isActive bool
+		err := tx.QueryRow("SELECT name, price, is_active FROM products WHERE id = ?", item.ProductID).
+			Scan(&name, &price, &isActive)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, models.ErrMerchantProductNotFound
+			}
+			return nil, err
+		}
+		if !isActive {
+			return nil, &models.DomainError{Code: "PRODUCT_NOT_AVAILABLE", Message: "商品 " + name + " 已下架"}
+		}
+
+		orderItem := models.OrderItem{
+			ProductID:   item.ProductID,
+			ProductName: name,
+			Quantity:    item.Quantity,
+		}
+
+		// 有規格時快照規格名稱、SKU 與規格售價
+		if item.VariantID > 0 {
+			variant, err := s.variantRepo.GetByIDTx(tx, item.VariantID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return nil, &models.DomainError{Code: models.ErrVariantNotFound.Code, Message: "商品 " + name + " 的規格不存在"}
+				}
+				return nil, err
+			}
+			if variant.ProductID != item.ProductID || !variant.IsActive {
+				return nil, &models.DomainError{Code: "PRODUCT_NOT_AVAILABLE", Message: "商品 " + name + "（" + variant.Name + "）已停售"}
+			}
+			price = variant.UnitPrice
+			orderItem.VariantID = &variant.ID
+			orderItem.VariantName = &variant.Name
+			orderItem.VariantSKU = &variant.SKU
+		} else {
+			hasVariants, err := s.variantRepo.HasActiveVariantsTx(tx, item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			if hasVariants {
+				return nil, &models.DomainError{Code: models.ErrVariantRequired.Code, Message: "商品 " + name + " 請選擇規格"}
+			}
+		}
+
+		// 促銷與運費已依購物車讀取時的售價計算，期間價格有變動（商戶改價、特價開始或結束）時請客戶重新確認
+		if math.Abs(price-item.UnitPrice()) >= 0.005 {
+			return nil, models.ErrPriceChanged.WithMessage("商品 " + name + " 價格已變動，請重新確認購物車")
+		}
+
+		lineTotal := roundAmount(price * float64(item.Quantity))
+		orderItem.ProductPrice = price
+		orderItem.TotalPrice = lineTotal
+		order.Items = append(order.Items, orderItem)
+		subtotal += lineTotal
+	}
+	order.DiscountAmount = roundAmount(math.Min(discount, subtotal))
+	order.TotalAmount = roundAmount(subtotal + order.ShippingFee - order.DiscountAmount)
+
+	if err := s.orderRepo.CreateTx(tx, order); err != nil {
+		return nil, fmt.Errorf("創建訂單失敗: %w", err)
+	}
+
+	for _, item := range order.Items {
+		key := models.StockKey{ProductID: item.ProductID}
+		if item.VariantID != nil {
+			key.VariantID = *item.VariantID
+		}
+		reservedQuantity := reserved[key]
+		delete(reserved, key)
+		if err := s.productRepo.IncrementSalesCountTx(tx, item.ProductID, item.Quantity, reservedQuantity); err != nil {
+			if err == models.ErrInsufficientStock {
+				return nil, &models.DomainError{Code: "INSUFFICIENT_STOCK", Message: "商品 " + item.ProductName + " 庫存不足"}
+			}
+			return nil, err
+		}
+		if key.VariantID > 0 {
+			if err := s.variantRepo.DeductStockTx(tx, key.VariantID, item.Quantity, reservedQuantity); err != nil {
+				if err == models.ErrInsufficientStock {
+					return nil, &models.DomainError{Code: "INSUFFICIENT_STOCK", Message: "商品 " + item.ProductName + "（" + *item.VariantName + "）庫存不足"}
+				}
+				return nil, err
+			}
+		}
+
+		// 累計特價售出數量，達到上限時立即結束特價；規格商品以規格價格售出，不計入特價數量
+		if key.VariantID > 0 {
+			continue
+		}
+		campaign, err := s.saleRepo.RecordSoldTx(tx, item.ID, item.ProductID, item.Quantity)
+		if err != nil {
+			if err == models.ErrSaleSoldOut {
+				return nil, models.ErrSaleSoldOut.WithMessage("特價商品 " + item.ProductName + " 剩餘數量不足")
+			}
+			return nil, err
+		}
+		if campaign != nil && campaign.SoldOut() {
+			if err := s.saleRepo.EndTx(tx, campaign, models.SaleStatusEnded); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err := s.movementRepo.RecordOrderItemsTx(tx, order.ID, -1, models.MovementReasonOrderPlaced,
+		models.OrderActorCustomer, group.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 記錄訂單建立
+	err = s.orderRepo.AddStatusHistoryTx(tx, &models.OrderStatusHistory{
+		OrderID:   order.ID,
+		ToStatus:  order.Status,
+		ActorType: models.OrderActorCustomer,
+		ActorID:   group.CustomerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// QuoteShipping 依目前購物車與促銷計算各商戶可選的運送方式與運費，供結帳頁顯示
+func (s *OrderService) QuoteShipping(customerID int) (*ShippingQuote, error) {
+	cart, err := s.cartRepo.GetCartByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing, err := s.promotions.PriceCart(customerID, cart.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.shipping.QuoteCart(customerID, cart.Items, pricing, nil)
+}
+
+// GetCustomerOrderGroup 獲取客戶的訂單群組（一次結帳的所有子訂單）
+func (s *OrderService) GetCustomerOrderGroup(customerID, groupID int) (*models.OrderGroup, error) {
+	group, err := s.orderRepo.GetGroupByID(groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if group.CustomerID != customerID {
+		return nil, models.ErrOrderNotFound
+	}
+
+	return group, nil
+}
+
+// GetCustomerOrders 獲取客戶訂單列表
+func (s *OrderService) GetCustomerOrders(customerID, limit, offset int) ([]*models.Order, int, error) {
+	if customerID <= 0 {
+		return nil, 0, &models.DomainError{Code: "INVALID_CUSTOMER_ID", Message: "無效的客戶ID"}
+	}
+
+	orders, err := s.orderRepo.GetByCustomerID(customerID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.orderRepo.CountByCustomerID(customerID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+// GetCustomerOrder 獲取客戶的單一訂單
+func (s *OrderService) GetCustomerOrder(customerID, orderID int) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	// 只能查看自己的訂單
+	if order.CustomerID != customerID {
+		return nil, models.ErrOrderNotFound
+	}
+
+	order.History, err = s.orderRepo.GetStatusHistory(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CancelCustomerOrder 客戶取消自己的訂單
+func (s *OrderService) CancelCustomerOrder(customerID, orderID int, note *string) (*models.Order, error) {
+	if _, err := s.GetCustomerOrder(customerID, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.TransitionOrder(orderID, models.OrderStatusCancelled, models.OrderActorCustomer, customerID, note)
+}
+
+// CompleteCustomerOrder 客戶確認收貨並完成訂單
+func (s *OrderService) CompleteCustomerOrder(customerID, orderID int, note *string) (*models.Order, error) {
+	if _, err := s.GetCustomerOrder(customerID, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.TransitionOrder(orderID, models.OrderStatusCompleted, models.OrderActorCustomer, customerID, note)
+}
+
+// GetMerchantOrders 獲取包含商戶商品的訂單列表
+func (s *OrderService) GetMerchantOrders(merchantID int, status string, limit, offset int) ([]*models.Order, int, error) {
+	if status != "" && !models.IsValidOrderStatus(status) {
+		return nil, 0, &models.DomainError{Code: "INVALID_STATUS", Message: "無效的訂單狀態"}
+	}
+
+	orders, err := s.orderRepo.GetByMerchantID(merchantID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.orderRepo.CountByMerchantID(merchantID, status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+// GetMerchantOrder 獲取商戶的單一訂單，只帶出屬於該商戶的訂單商品
+func (s *OrderService) GetMerchantOrder(merchantID, orderID int) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	items, err := s.orderRepo.GetItemsForMerchant(orderID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	// 訂單中沒有該商戶的商品時視為不存在
+	if len(items) == 0 {
+		return nil, models.ErrOrderNotFound
+	}
+	order.Items = items
+
+	order.History, err = s.orderRepo.GetStatusHistory(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// UpdateMerchantOrderStatus 商戶更新訂單狀態
+// 退款需經金流退還款項，須改用 PaymentService.RefundMerchantOrder
+func (s *OrderService) UpdateMerchantOrderStatus(merchantID, orderID int, status string, note *string) (*models.Order, error) {
+	if status == models.OrderStatusRefunded {
+		return nil, models.ErrInvalidOrderTransition
+	}
+	if _, err := s.GetMerchantOrder(merchantID, orderID); err != nil {
+		return nil, err
+	}
+
+	order, err := s.TransitionOrder(orderID, status, models.OrderActorMerchant, merchantID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetMerchantOrder(merchantID, order.ID)
+}
+
+// TransitionOrder 依狀態轉換表變更訂單狀態並記錄操作者
+// 未出貨前取消或退款會在同一個交易中還原庫存
+func (s *OrderService) TransitionOrder(orderID int, to, actorType string, actorID int, note *string) (*models.Order, error) {
+	if !models.IsValidOrderStatus(to) {
+		return nil, &models.DomainError{Code: "INVALID_STATUS", Message: "無效的訂單狀態"}
+	}
+
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.TransitionOrderTx(tx, order, to, actorType, actorID, note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	order, err = s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.History, err = s.orderRepo.GetStatusHistory(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// TransitionOrderTx 在呼叫端的交易中變更訂單狀態，供付款回調等需要與其他寫入一起提交的流程使用
+func (s *OrderService) TransitionOrderTx(tx *sql.Tx, order *models.Order, to, actorType string, actorID int, note *string) error {
+	from := order.Status
+	if !models.CanTransitionOrder(from, to, actorType) {
+		return models.ErrInvalidOrderTransition
+	}
+
+	paymentStatus := order.PaymentStatus
+	switch to {
+	case models.OrderStatusPaid:
+		paymentStatus = models.PaymentStatusPaid
+	case models.OrderStatusRefunded:
+		paymentStatus = models.PaymentStatusRefunded
+	}
+
+	if err := s.orderRepo.UpdateStatusTx(tx, order.ID, from, to, paymentStatus); err != nil {
+		return err
+	}
+
+	// 商品尚未寄出時取消或退款，將庫存放回
+	if (from == models.OrderStatusPending || from == models.OrderStatusPaid) &&
+		(to == models.OrderStatusCancelled || to == models.OrderStatusRefunded) {
+		if err := s.orderRepo.RestockItemsTx(tx, order.ID); err != nil {
+			return err
+		}
+
+		reason := models.MovementReasonOrderCancelled
+		if to == models.OrderStatusRefunded {
+			reason = models.MovementReasonOrderRefunded
+		}
+		if err := s.movementRepo.RecordOrderItemsTx(tx, order.ID, 1, reason, actorType, actorID); err != nil {
+			return err
+		}
+		if err := s.revertSaleQuantityTx(tx, order.ID); err != nil {
+			return err
+		}
+	}
+
+	// 待付款的子訂單取消後，群組已建立的付款金額包含此訂單，作廢後該付款若仍收到付款成功回調會全額退款，客戶需重新付款
+	if from == models.OrderStatusPending && to == models.OrderStatusCancelled && order.GroupID != nil {
+		if _, err := s.paymentRepo.VoidPendingByGroupTx(tx, *order.GroupID); err != nil {
+			return err
+		}
+	}
+
+	// 取消或退款時撤銷促銷使用紀錄，恢復使用次數
+	if (to == models.OrderStatusCancelled || to == models.OrderStatusRefunded) && order.GroupID != nil {
+		open, err := s.orderRepo.CountOpenInGroupTx(tx, *order.GroupID)
+		if err != nil {
+			return err
+		}
+		if err := s.promotions.RevertRedemptionsTx(tx, *order.GroupID, order.MerchantID, open == 0); err != nil {
+			return err
+		}
+	}
+
+	err := s.orderRepo.AddStatusHistoryTx(tx, &models.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorType:  actorType,
+		ActorID:    actorID,
+		Note:       note,
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Status = to
+	order.PaymentStatus = paymentStatus
+	return nil
+}
+
+// revertSaleQuantityTx 扣回訂單計入的特價售出數量，因售完而提前結束的活動在期間內且仍有名額時重新套用特價
+func (s *OrderService) revertSaleQuantityTx(tx *sql.Tx, orderID int) error {
+	campaigns, err := s.saleRepo.RevertSoldTx(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, campaign := range campaigns {
+		if campaign.Status != models.SaleStatusEnded || campaign.SoldOut() || !now.Before(campaign.EndsAt) {
+			continue
+		}
+		// 售完後商戶可能已為同一商品另建活動，此時不再重新套用
+		overlap, err := s.saleRepo.HasOtherOpenTx(tx, campaign)
+		if err != nil {
+			return err
+		}
+		if overlap {
+			continue
+		}
+		if err := s.saleRepo.ActivateTx(tx, campaign); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateOrderNumber 產生訂單編號，例如 ORD202501021530451234
+func generateOrderNumber() string {
+	return fmt.Sprintf("ORD%s%04d", time.Now().Format("20060102150405"), rand.Intn(10000))
+}
+
+// roundAmount 金額四捨五入到小數點後兩位
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
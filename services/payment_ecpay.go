@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/models"
+)
+
+// ECPayProvider 綠界金流轉接器
+// 依綠界全方位金流 (AIO) 的參數與 CheckMacValue 規則實作，APIURL 可指向本地模擬伺服器
+type ECPayProvider struct {
+	config config.ECPayConfig
+	client *http.Client
+}
+
+// NewECPayProvider 創建綠界金流轉接器
+func NewECPayProvider(cfg config.ECPayConfig) *ECPayProvider {
+	return &ECPayProvider{
+		config: cfg,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name 金流服務商名稱
+func (p *ECPayProvider) Name() string {
+	return "ecpay"
+}
+
+// CreateIntent 產生 AIO 結帳表單參數，由前端以 POST 表單導向綠界付款頁
+func (p *ECPayProvider) CreateIntent(ctx context.Context, req *PaymentIntentRequest) (*PaymentIntent, error) {
+	itemName := strings.Join(req.ItemNames, "#")
+	if itemName == "" {
+		itemName = req.Description
+	}
+
+	params := map[string]string{
+		"MerchantID":        p.config.MerchantID,
+		"MerchantTradeNo":   req.PaymentNumber,
+		"MerchantTradeDate": time.Now().Format("2006/01/02 15:04:05"),
+		"PaymentType":       "aio",
+		"TotalAmount":       strconv.Itoa(ecpayAmount(req.Amount)),
+		"TradeDesc":         req.Description,
+		"ItemName":          itemName,
+		"ReturnURL":         req.CallbackURL,
+		"ClientBackURL":     req.ClientBackURL,
+		"ChoosePayment":     "ALL",
+		"EncryptType":       "1",
+	}
+	params["CheckMacValue"] = p.checkMacValue(params)
+
+	return &PaymentIntent{
+		Provider:   p.Name(),
+		PaymentURL: strings.TrimRight(p.config.APIURL, "/") + "/Cashier/AioCheckOut/V5",
+		Method:     http.MethodPost,
+		FormParams: params,
+	}, nil
+}
+
+// Confirm 呼叫 QueryTradeInfo 查詢交易結果
+func (p *ECPayProvider) Confirm(ctx context.Context, paymentNumber string) (*PaymentResult, error) {
+	params := map[string]string{
+		"MerchantID":      p.config.MerchantID,
+		"MerchantTradeNo": paymentNumber,
+		"TimeStamp":       strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	params["CheckMacValue"] = p.checkMacValue(params)
+
+	values, err := p.post(ctx, "/Cashier/QueryTradeInfo/V5", params)
+	if err != nil {
+		return nil, err
+	}
+	if !p.verifyValues(values) {
+		return nil, models.ErrInvalidSignature
+	}
+
+	amount, _ := strconv.ParseFloat(values.Get("TradeAmt"), 64)
+	status := models.PaymentStatusPending
+	switch values.Get("TradeStatus") {
+	case "1":
+		status = models.PaymentStatusPaid
+	case "10200095":
+		status = models.PaymentStatusFailed
+	}
+
+	return &PaymentResult{
+		PaymentNumber: values.Get("MerchantTradeNo"),
+		ProviderRef:   values.Get("TradeNo"),
+		Status:        status,
+		Amount:        amount,
+	}, nil
+}
+
+// Refund 呼叫信用卡請退款 (DoAction, Action=R)
+// DoAction 不支援冪等鍵，重複退款由 payment_refunds 的冪等鍵唯一索引防止
+func (p *ECPayProvider) Refund(ctx context.Context, req *PaymentRefundRequest) (*PaymentResult, error) {
+	params := map[string]string{
+		"MerchantID":      p.config.MerchantID,
+		"MerchantTradeNo": req.PaymentNumber,
+		"TradeNo":         req.ProviderRef,
+		"Action":          "R",
+		"TotalAmount":     strconv.Itoa(ecpayAmount(req.Amount)),
+	}
+	params["CheckMacValue"] = p.checkMacValue(params)
+
+	values, err := p.post(ctx, "/CreditDetail/DoAction", params)
+	if err != nil {
+		return nil, err
+	}
+	if values.Get("RtnCode") != "1" {
+		return nil, &PaymentError{Provider: p.Name(), Message: "退款失敗: " + values.Get("RtnMsg")}
+	}
+
+	return &PaymentResult{
+		PaymentNumber: req.PaymentNumber,
+		ProviderRef:   req.ProviderRef,
+		Status:        models.PaymentStatusRefunded,
+		Amount:        req.Amount,
+		Message:       values.Get("RtnMsg"),
+	}, nil
+}
+
+// VerifyCallback 驗證 ReturnURL 回調的 CheckMacValue
+func (p *ECPayProvider) VerifyCallback(header http.Header, body []byte) (*PaymentCallbackEvent, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, &PaymentError{Provider: p.Name(), Message: "回調格式錯誤: " + err.Error()}
+	}
+	if !p.verifyValues(values) {
+		return nil, models.ErrInvalidSignature
+	}
+
+	status := models.PaymentStatusFailed
+	if values.Get("RtnCode") == "1" {
+		status = models.PaymentStatusPaid
+	}
+	amount, _ := strconv.ParseFloat(values.Get("TradeAmt"), 64)
+
+	return &PaymentCallbackEvent{
+		// 綠界重送同一筆通知時內容相同，以交易編號與結果代碼作為事件ID
+		EventID:       values.Get("MerchantTradeNo") + ":" + values.Get("TradeNo") + ":" + values.Get("RtnCode"),
+		PaymentNumber: values.Get("MerchantTradeNo"),
+		ProviderRef:   values.Get("TradeNo"),
+		Status:        status,
+		Amount:        amount,
+		Payload:       string(body),
+	}, nil
+}
+
+// CallbackAck 綠界要求回應 1|OK，否則會重送通知
+func (p *ECPayProvider) CallbackAck() string {
+	return "1|OK"
+}
+
+// post 以表單送出請求並解析回應
+func (p *ECPayProvider) post(ctx context.Context, path string, params map[string]string) (url.Values, error) {
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(p.config.APIURL, "/")+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, &PaymentError{Provider: p.Name(), Message: "連線失敗: " + err.Error()}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &PaymentError{Provider: p.Name(), Message: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	}
+
+	return url.ParseQuery(string(body))
+}
+
+// verifyValues 驗證回應內容的 CheckMacValue
+func (p *ECPayProvider) verifyValues(values url.Values) bool {
+	received := values.Get("CheckMacValue")
+	if received == "" {
+		return false
+	}
+
+	params := make(map[string]string, len(values))
+	for key := range values {
+		if key != "CheckMacValue" {
+			params[key] = values.Get(key)
+		}
+	}
+
+	expected := p.checkMacValue(params)
+	return subtle.ConstantTimeCompare([]byte(strings.ToUpper(received)), []byte(expected)) == 1
+}
+
+// checkMacValue 計算綠界 CheckMacValue (SHA256)
+// 參數依名稱排序後前後加上 HashKey/HashIV，做 .NET 風格的 URL encode 並轉小寫後雜湊
+func (p *ECPayProvider) checkMacValue(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "CheckMacValue" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+
+	var builder strings.Builder
+	builder.WriteString("HashKey=" + p.config.HashKey)
+	for _, key := range keys {
+		builder.WriteString("&" + key + "=" + params[key])
+	}
+	builder.WriteString("&HashIV=" + p.config.HashIV)
+
+	encoded := strings.ToLower(url.QueryEscape(builder.String()))
+	// 綠界沿用 .NET 的編碼規則，以下字元不編碼；Go 不編碼的 ~ 在 .NET 會編碼
+	replacer := strings.NewReplacer(
+		"%2d", "-", "%5f", "_", "%2e", ".", "%21", "!",
+		"%2a", "*", "%28", "(", "%29", ")", "~", "%7e",
+	)
+	encoded = replacer.Replace(encoded)
+
+	sum := sha256.Sum256([]byte(encoded))
+	return strings.ToUpper(fmt.Sprintf("%x", sum))
+}
+
+// ecpayAmount 綠界金額只接受整數新台幣
+func ecpayAmount(amount float64) int {
+	return int(math.Round(amount))
+}
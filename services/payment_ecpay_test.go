@@ -0,0 +1,147 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"go-simple-app/config"
+)
+
+// ecpayExampleParams 綠界全方位金流技術文件「檢查碼機制」的範例參數（測試商店 3002607）
+func ecpayExampleParams() map[string]string {
+	return map[string]string{
+		"ChoosePayment":     "ALL",
+		"EncryptType":       "1",
+		"ItemName":          "Apple iphone 15",
+		"MerchantID":        "3002607",
+		"MerchantTradeDate": "2023/03/12 15:30:23",
+		"MerchantTradeNo":   "ecpay20230312153023",
+		"PaymentType":       "aio",
+		"ReturnURL":         "https://www.ecpay.com.tw/receive.php",
+		"TotalAmount":       "30000",
+		"TradeDesc":         "促銷方案",
+	}
+}
+
+const ecpayExampleCheckMacValue = "6C51C9E6888DE861FD62FB1DD17029FC742634498FD813DC43D4243B5685B840"
+
+func newTestECPayProvider() *ECPayProvider {
+	return NewECPayProvider(config.ECPayConfig{
+		MerchantID: "3002607",
+		HashKey:    "pwFHCqoQZGmho4w6",
+		HashIV:     "EkRm7iFT261dpevs",
+	})
+}
+
+func TestECPayCheckMacValue(t *testing.T) {
+	p := newTestECPayProvider()
+
+	tests := []struct {
+		name   string
+		modify func(params map[string]string)
+		want   string
+	}{
+		{"官方文件範例", func(map[string]string) {}, ecpayExampleCheckMacValue},
+		{"忽略既有的 CheckMacValue", func(params map[string]string) { params["CheckMacValue"] = "ANY" }, ecpayExampleCheckMacValue},
+		{"參數變動", func(params map[string]string) { params["TotalAmount"] = "30001" }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := ecpayExampleParams()
+			tt.modify(params)
+			got := p.checkMacValue(params)
+			if tt.want == "" {
+				if got == ecpayExampleCheckMacValue {
+					t.Errorf("checkMacValue() = %s, want a different value", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("checkMacValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestECPayCheckMacValueEncoding(t *testing.T) {
+	p := newTestECPayProvider()
+
+	// 依 .NET UrlEncode：英數與 -_.!*() 不編碼、空白轉為 +、其餘字元（含 ~ 與 '）以小寫百分比編碼
+	// 預期值以 hashkey%3dpwfhcqoqzgmho4w6%26itemname%3d%e6%b8%ac%e8%a9%a6-_.!*()+%2f%7e%27%26hashiv%3dekrm7ift261dpevs 計算
+	want := "A654DDEBBF8B43AA933A2E701526A9788ABFFDADFA03517B89D455D897ADE642"
+	if got := p.checkMacValue(map[string]string{"ItemName": "測試-_.!*() /~'"}); got != want {
+		t.Errorf("checkMacValue() = %s, want %s", got, want)
+	}
+}
+
+func TestECPayVerifyValues(t *testing.T) {
+	p := newTestECPayProvider()
+
+	values := func(mac string, overrides map[string]string) url.Values {
+		v := url.Values{}
+		for key, value := range ecpayExampleParams() {
+			v.Set(key, value)
+		}
+		for key, value := range overrides {
+			v.Set(key, value)
+		}
+		if mac != "" {
+			v.Set("CheckMacValue", mac)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name   string
+		values url.Values
+		want   bool
+	}{
+		{"正確檢查碼", values(ecpayExampleCheckMacValue, nil), true},
+		{"小寫檢查碼", values(strings.ToLower(ecpayExampleCheckMacValue), nil), true},
+		{"缺少檢查碼", values("", nil), false},
+		{"錯誤檢查碼", values(strings.Repeat("0", 64), nil), false},
+		{"竄改金額", values(ecpayExampleCheckMacValue, map[string]string{"TotalAmount": "1"}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.verifyValues(tt.values); got != tt.want {
+				t.Errorf("verifyValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPaymentProviderRequiresECPaySettings(t *testing.T) {
+	complete := config.ECPayConfig{
+		APIURL:     "https://payment.ecpay.com.tw",
+		MerchantID: "2000132",
+		HashKey:    "key",
+		HashIV:     "iv",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *config.ECPayConfig)
+		wantErr bool
+	}{
+		{"設定完整", func(*config.ECPayConfig) {}, false},
+		{"缺少 API 網址", func(cfg *config.ECPayConfig) { cfg.APIURL = "" }, true},
+		{"缺少商店代號", func(cfg *config.ECPayConfig) { cfg.MerchantID = "" }, true},
+		{"缺少 HashKey", func(cfg *config.ECPayConfig) { cfg.HashKey = "" }, true},
+		{"缺少 HashIV", func(cfg *config.ECPayConfig) { cfg.HashIV = "" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecpay := complete
+			tt.modify(&ecpay)
+			_, err := NewPaymentProvider(config.PaymentConfig{Provider: "ecpay", ECPay: ecpay})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPaymentProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
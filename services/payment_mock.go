@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/models"
+)
+
+// MockPaymentSignatureHeader 模擬金流回調的簽章標頭
+const MockPaymentSignatureHeader = "X-Mock-Signature"
+
+// MockPaymentProvider 完全離線的模擬金流，以 HMAC-SHA256 簽署回調
+type MockPaymentProvider struct {
+	secret   []byte
+	mu       sync.Mutex
+	payments map[string]*PaymentResult
+	refunds  map[string]*PaymentResult // 依冪等鍵記錄的退款結果
+}
+
+// mockCallbackPayload 模擬金流回調內容
+type mockCallbackPayload struct {
+	EventID       string  `json:"event_id"`
+	PaymentNumber string  `json:"payment_number"`
+	ProviderRef   string  `json:"provider_ref"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+}
+
+// NewMockPaymentProvider 創建模擬金流
+func NewMockPaymentProvider(cfg config.MockPaymentConfig) *MockPaymentProvider {
+	return &MockPaymentProvider{
+		secret:   []byte(cfg.Secret),
+		payments: make(map[string]*PaymentResult),
+		refunds:  make(map[string]*PaymentResult),
+	}
+}
+
+// Name 金流服務商名稱
+func (p *MockPaymentProvider) Name() string {
+	return "mock"
+}
+
+// CreateIntent 建立付款意圖
+func (p *MockPaymentProvider) CreateIntent(ctx context.Context, req *PaymentIntentRequest) (*PaymentIntent, error) {
+	providerRef := "MOCK" + req.PaymentNumber
+
+	p.mu.Lock()
+	p.payments[req.PaymentNumber] = &PaymentResult{
+		PaymentNumber: req.PaymentNumber,
+		ProviderRef:   providerRef,
+		Status:        models.PaymentStatusPending,
+		Amount:        req.Amount,
+	}
+	p.mu.Unlock()
+
+	return &PaymentIntent{
+		Provider:    p.Name(),
+		ProviderRef: providerRef,
+		PaymentURL:  "/api/payments/mock/" + req.PaymentNumber + "/simulate",
+		Method:      http.MethodPost,
+	}, nil
+}
+
+// Confirm 查詢模擬交易狀態
+func (p *MockPaymentProvider) Confirm(ctx context.Context, paymentNumber string) (*PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result, exists := p.payments[paymentNumber]
+	if !exists {
+		return nil, &PaymentError{Provider: p.Name(), Message: "交易不存在"}
+	}
+	copied := *result
+	return &copied, nil
+}
+
+// Refund 模擬退款，一律成功；同一冪等鍵重送時回傳第一次的結果
+func (p *MockPaymentProvider) Refund(ctx context.Context, req *PaymentRefundRequest) (*PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if result, exists := p.refunds[req.IdempotencyKey]; exists {
+			copied := *result
+			return &copied, nil
+		}
+	}
+
+	if result, exists := p.payments[req.PaymentNumber]; exists {
+		result.Status = models.PaymentStatusRefunded
+	}
+
+	result := &PaymentResult{
+		PaymentNumber: req.PaymentNumber,
+		ProviderRef:   req.ProviderRef,
+		Status:        models.PaymentStatusRefunded,
+		Amount:        req.Amount,
+	}
+	if req.IdempotencyKey != "" {
+		p.refunds[req.IdempotencyKey] = result
+	}
+
+	copied := *result
+	return &copied, nil
+}
+
+// VerifyCallback 驗證 HMAC 簽章並解析回調
+func (p *MockPaymentProvider) VerifyCallback(header http.Header, body []byte) (*PaymentCallbackEvent, error) {
+	signature, err := hex.DecodeString(header.Get(MockPaymentSignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.sign(body)) {
+		return nil, models.ErrInvalidSignature
+	}
+
+	var payload mockCallbackPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, &PaymentError{Provider: p.Name(), Message: "回調格式錯誤: " + err.Error()}
+	}
+
+	return &PaymentCallbackEvent{
+		EventID:       payload.EventID,
+		PaymentNumber: payload.PaymentNumber,
+		ProviderRef:   payload.ProviderRef,
+		Status:        payload.Status,
+		Amount:        payload.Amount,
+		Payload:       string(body),
+	}, nil
+}
+
+// CallbackAck 回調成功回應
+func (p *MockPaymentProvider) CallbackAck() string {
+	return "OK"
+}
+
+// BuildCallback 產生已簽章的回調，模擬金流商通知
+func (p *MockPaymentProvider) BuildCallback(payment *models.Payment, status string) (http.Header, []byte, error) {
+	if status != models.PaymentStatusPaid && status != models.PaymentStatusFailed {
+		return nil, nil, &PaymentError{Provider: p.Name(), Message: "無效的模擬付款狀態"}
+	}
+
+	providerRef := "MOCK" + payment.PaymentNumber
+	p.mu.Lock()
+	p.payments[payment.PaymentNumber] = &PaymentResult{
+		PaymentNumber: payment.PaymentNumber,
+		ProviderRef:   providerRef,
+		Status:        status,
+		Amount:        payment.Amount,
+	}
+	p.mu.Unlock()
+
+	body, err := json.Marshal(mockCallbackPayload{
+		EventID:       fmt.Sprintf("%s-%s-%d", payment.PaymentNumber, status, time.Now().UnixNano()),
+		PaymentNumber: payment.PaymentNumber,
+		ProviderRef:   providerRef,
+		Status:        status,
+		Amount:        payment.Amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(MockPaymentSignatureHeader, hex.EncodeToString(p.sign(body)))
+	return header, body, nil
+}
+
+// sign 計算 HMAC-SHA256 簽章
+func (p *MockPaymentProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
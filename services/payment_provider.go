@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go-simple-app/config"
+)
+
+// PaymentProvider 定義金流服務商的通用接口
+type PaymentProvider interface {
+	// Name 金流服務商名稱，對應 payments.provider 與回調路徑
+	Name() string
+
+	// CreateIntent 建立付款意圖，回傳導向付款頁所需資訊
+	CreateIntent(ctx context.Context, req *PaymentIntentRequest) (*PaymentIntent, error)
+
+	// Confirm 向金流商查詢/確認交易結果（不會直接變更訂單狀態）
+	Confirm(ctx context.Context, paymentNumber string) (*PaymentResult, error)
+
+	// Refund 退款
+	Refund(ctx context.Context, req *PaymentRefundRequest) (*PaymentResult, error)
+
+	// VerifyCallback 驗證回調簽章並解析回調內容
+	VerifyCallback(header http.Header, body []byte) (*PaymentCallbackEvent, error)
+
+	// CallbackAck 回調處理成功時回應給金流商的內容
+	CallbackAck() string
+}
+
+// PaymentIntentRequest 建立付款意圖請求
+type PaymentIntentRequest struct {
+	PaymentNumber string
+	Amount        float64
+	Description   string
+	ItemNames     []string
+	CallbackURL   string
+	ClientBackURL string
+}
+
+// PaymentIntent 付款意圖
+type PaymentIntent struct {
+	Provider    string            `json:"provider"`
+	ProviderRef string            `json:"provider_ref,omitempty"`
+	PaymentURL  string            `json:"payment_url"`
+	Method      string            `json:"method"` // GET 直接導向，POST 需以表單送出 FormParams
+	FormParams  map[string]string `json:"form_params,omitempty"`
+}
+
+// PaymentRefundRequest 退款請求
+type PaymentRefundRequest struct {
+	PaymentNumber  string
+	ProviderRef    string
+	Amount         float64
+	Reason         string
+	IdempotencyKey string // 退款來源的冪等鍵（例如 return:12），同一鍵重送時不得重複退款
+}
+
+// PaymentResult 交易查詢/退款結果
+type PaymentResult struct {
+	PaymentNumber string  `json:"payment_number"`
+	ProviderRef   string  `json:"provider_ref,omitempty"`
+	Status        string  `json:"status"` // pending, paid, failed, refunded
+	Amount        float64 `json:"amount"`
+	Message       string  `json:"message,omitempty"`
+}
+
+// PaymentCallbackEvent 已驗證的金流回調
+type PaymentCallbackEvent struct {
+	EventID       string
+	PaymentNumber string
+	ProviderRef   string
+	Status        string // paid 或 failed
+	Amount        float64
+	Payload       string
+}
+
+// PaymentError 金流錯誤
+type PaymentError struct {
+	Provider string `json:"provider"`
+	Message  string `json:"message"`
+}
+
+func (e *PaymentError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Provider, e.Message)
+}
+
+// NewPaymentProvider 根據配置建立金流服務商，金流服務商與各服務商的密鑰沒有預設值，未設定時回傳錯誤
+func NewPaymentProvider(cfg config.PaymentConfig) (PaymentProvider, error) {
+	switch cfg.Provider {
+	case "ecpay":
+		if cfg.ECPay.APIURL == "" || cfg.ECPay.MerchantID == "" || cfg.ECPay.HashKey == "" || cfg.ECPay.HashIV == "" {
+			return nil, fmt.Errorf("使用綠界金流時必須設定 ECPAY_API_URL、ECPAY_MERCHANT_ID、ECPAY_HASH_KEY 與 ECPAY_HASH_IV")
+		}
+		return NewECPayProvider(cfg.ECPay), nil
+	case "mock":
+		if cfg.Mock.Secret == "" {
+			return nil, fmt.Errorf("使用模擬金流時必須設定 PAYMENT_MOCK_SECRET")
+		}
+		return NewMockPaymentProvider(cfg.Mock), nil
+	case "":
+		return nil, fmt.Errorf("必須設定 PAYMENT_PROVIDER（mock 或 ecpay）")
+	default:
+		return nil, fmt.Errorf("不支援的金流服務商: %s", cfg.Provider)
+	}
+}
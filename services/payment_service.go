@@ -0,0 +1,516 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PaymentService 付款業務邏輯服務
+// 訂單只會在驗證過簽章的金流回調中轉為 paid，同一回調重送時不會重複處理
+type PaymentService struct {
+	db           *sql.DB
+	config       config.PaymentConfig
+	provider     PaymentProvider
+	paymentRepo  *models.PaymentRepository
+	orderRepo    *models.OrderRepository
+	returnRepo   *models.ReturnRepository
+	orderService *OrderService
+}
+
+// NewPaymentService 創建付款服務
+func NewPaymentService(db *sql.DB, cfg config.PaymentConfig, provider PaymentProvider, orderService *OrderService) *PaymentService {
+	return &PaymentService{
+		db:           db,
+		config:       cfg,
+		provider:     provider,
+		paymentRepo:  models.NewPaymentRepository(db),
+		orderRepo:    models.NewOrderRepository(db),
+		returnRepo:   models.NewReturnRepository(db),
+		orderService: orderService,
+	}
+}
+
+// Provider 目前使用的金流服務商
+func (s *PaymentService) Provider() PaymentProvider {
+	return s.provider
+}
+
+// CreatePayment 為客戶的訂單群組建立付款並回傳付款意圖
+func (s *PaymentService) CreatePayment(ctx context.Context, customerID, groupID int) (*models.Payment, *PaymentIntent, error) {
+	group, err := s.orderService.GetCustomerOrderGroup(customerID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if group.PaymentStatus == models.PaymentStatusPaid {
+		return nil, nil, models.ErrOrderAlreadyPaid
+	}
+
+	// 只對仍待付款的子訂單收款
+	amount := 0.0
+	var itemNames []string
+	for _, order := range group.Orders {
+		if order.Status != models.OrderStatusPending {
+			continue
+		}
+		amount += order.TotalAmount
+		for _, item := range order.Items {
+			itemNames = append(itemNames, fmt.Sprintf("%s x %d", item.ProductName, item.Quantity))
+		}
+	}
+	amount = roundAmount(amount)
+	if amount <= 0 {
+		return nil, nil, &models.DomainError{Code: "NOTHING_TO_PAY", Message: "沒有待付款的訂單"}
+	}
+
+	payment := &models.Payment{
+		PaymentNumber: generatePaymentNumber(),
+		GroupID:       group.ID,
+		CustomerID:    customerID,
+		Provider:      s.provider.Name(),
+		Amount:        amount,
+		Currency:      "TWD",
+		Status:        models.PaymentStatusPending,
+	}
+	// 同一群組只保留一筆待付款：先前未完成的付款作廢，之後若仍收到其付款成功回調會自動退款
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	voided, err := s.paymentRepo.VoidPendingByGroupTx(tx, group.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.paymentRepo.CreateTx(tx, payment); err != nil {
+		return nil, nil, fmt.Errorf("創建付款紀錄失敗: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	if voided > 0 {
+		logger.Info("作廢先前未完成的付款", logrus.Fields{
+			"group_id":       group.ID,
+			"voided":         voided,
+			"payment_number": payment.PaymentNumber,
+		})
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, &PaymentIntentRequest{
+		PaymentNumber: payment.PaymentNumber,
+		Amount:        payment.Amount,
+		Description:   "訂單 " + group.GroupNumber,
+		ItemNames:     itemNames,
+		CallbackURL:   strings.TrimRight(s.config.CallbackBaseURL, "/") + "/api/payments/callback/" + s.provider.Name(),
+		ClientBackURL: s.config.ClientBackURL,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if intent.ProviderRef != "" {
+		if err := s.paymentRepo.UpdateProviderRef(payment.ID, intent.ProviderRef); err != nil {
+			return nil, nil, err
+		}
+		payment.ProviderRef = &intent.ProviderRef
+	}
+
+	return payment, intent, nil
+}
+
+// GetCustomerPayment 獲取客戶的付款紀錄
+func (s *PaymentService) GetCustomerPayment(customerID int, paymentNumber string) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetByNumber(paymentNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if payment.CustomerID != customerID {
+		return nil, models.ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+// ConfirmPayment 向金流商查詢交易結果，僅供顯示，不會變更訂單狀態
+func (s *PaymentService) ConfirmPayment(ctx context.Context, customerID int, paymentNumber string) (*PaymentResult, error) {
+	if _, err := s.GetCustomerPayment(customerID, paymentNumber); err != nil {
+		return nil, err
+	}
+	return s.provider.Confirm(ctx, paymentNumber)
+}
+
+// HandleCallback 處理金流回調
+// 回傳 false 表示此回調先前已處理過（重送），呼叫端仍應回應成功
+// 已作廢、已失敗或群組已由其他付款付清的付款收到付款成功時，視為重複扣款並自動全額退款
+func (s *PaymentService) HandleCallback(ctx context.Context, providerName string, header http.Header, body []byte) (bool, error) {
+	if providerName != s.provider.Name() {
+		return false, &PaymentError{Provider: providerName, Message: "未啟用的金流服務商"}
+	}
+
+	event, err := s.provider.VerifyCallback(header, body)
+	if err != nil {
+		logger.Warn("金流回調驗證失敗", logrus.Fields{
+			"provider": providerName,
+			"error":    err.Error(),
+		})
+		return false, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	payment, err := s.paymentRepo.GetByNumberTx(tx, event.PaymentNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, models.ErrPaymentNotFound
+		}
+		return false, err
+	}
+
+	// 先寫入回調紀錄，重複的事件ID代表已處理過
+	recorded, err := s.paymentRepo.RecordCallbackTx(tx, &models.PaymentCallback{
+		Provider:      providerName,
+		EventID:       event.EventID,
+		PaymentNumber: event.PaymentNumber,
+		Status:        event.Status,
+		Amount:        event.Amount,
+		Payload:       event.Payload,
+	})
+	if err != nil {
+		return false, err
+	}
+	if !recorded {
+		return false, nil
+	}
+
+	duplicate := false
+	if event.Status == models.PaymentStatusPaid {
+		switch payment.Status {
+		case models.PaymentStatusVoided, models.PaymentStatusFailed:
+			duplicate = true
+		case models.PaymentStatusPending:
+			_, err := s.paymentRepo.GetPaidByGroupIDTx(tx, payment.GroupID)
+			if err != nil && err != sql.ErrNoRows {
+				return false, err
+			}
+			duplicate = err == nil
+		}
+	}
+
+	// 已是終態的付款不再變更，只保留回調紀錄
+	if payment.Status != models.PaymentStatusPending && !duplicate {
+		return true, tx.Commit()
+	}
+
+	var providerRef *string
+	if event.ProviderRef != "" {
+		providerRef = &event.ProviderRef
+	}
+
+	switch {
+	case duplicate:
+		// 記錄實際已扣款，提交後全額退款，不變更訂單狀態
+		if err := s.paymentRepo.UpdateStatusTx(tx, payment.ID, models.PaymentStatusPaid, providerRef); err != nil {
+			return false, err
+		}
+	case event.Status == models.PaymentStatusPaid:
+		if math.Abs(event.Amount-payment.Amount) >= 1 {
+			return false, models.ErrPaymentAmountMismatch
+		}
+		if err := s.markGroupPaidTx(tx, payment, providerRef); err != nil {
+			return false, err
+		}
+	default:
+		if err := s.paymentRepo.UpdateStatusTx(tx, payment.ID, models.PaymentStatusFailed, providerRef); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	logger.Info("金流回調處理完成", logrus.Fields{
+		"provider":       providerName,
+		"payment_number": payment.PaymentNumber,
+		"status":         event.Status,
+		"duplicate":      duplicate,
+	})
+
+	if duplicate {
+		s.refundDuplicatePayment(ctx, payment)
+	}
+
+	return true, nil
+}
+
+// refundDuplicatePayment 全額退還重複扣款的付款
+// 退款失敗時保留失敗的退款紀錄並記錄錯誤，需人工處理
+func (s *PaymentService) refundDuplicatePayment(ctx context.Context, payment *models.Payment) {
+	fields := logrus.Fields{
+		"payment_number": payment.PaymentNumber,
+		"group_id":       payment.GroupID,
+		"amount":         payment.Amount,
+	}
+
+	locate := func(tx *sql.Tx) (*models.Payment, error) {
+		return s.paymentRepo.GetByIDTx(tx, payment.ID)
+	}
+	_, err := s.refundPayment(ctx, locate, payment.Amount, "重複付款自動退款 "+payment.PaymentNumber,
+		models.RefundReferencePayment, payment.ID)
+	if err != nil {
+		logger.Error("重複付款自動退款失敗，需人工處理", err, fields)
+		return
+	}
+
+	logger.Warn("重複付款已自動退款", fields)
+}
+
+// markGroupPaidTx 將付款與訂單群組下待付款的子訂單標記為已付款
+func (s *PaymentService) markGroupPaidTx(tx *sql.Tx, payment *models.Payment, providerRef *string) error {
+	if err := s.paymentRepo.UpdateStatusTx(tx, payment.ID, models.PaymentStatusPaid, providerRef); err != nil {
+		return err
+	}
+	if err := s.paymentRepo.UpdateGroupPaymentStatusTx(tx, payment.GroupID, models.PaymentStatusPaid); err != nil {
+		return err
+	}
+
+	orders, err := s.orderRepo.GetByGroupID(payment.GroupID)
+	if err != nil {
+		return err
+	}
+
+	note := "付款完成 " + payment.PaymentNumber
+	for _, order := range orders {
+		if order.Status != models.OrderStatusPending {
+			continue
+		}
+		if err := s.orderService.TransitionOrderTx(tx, order, models.OrderStatusPaid, models.OrderActorSystem, 0, &note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SimulateMockPayment 透過模擬金流產生已簽章的回調並走正式的回調流程（僅限 mock）
+func (s *PaymentService) SimulateMockPayment(ctx context.Context, customerID int, paymentNumber, status string) error {
+	mock, ok := s.provider.(*MockPaymentProvider)
+	if !ok {
+		return &PaymentError{Provider: s.provider.Name(), Message: "僅模擬金流支援此操作"}
+	}
+
+	payment, err := s.GetCustomerPayment(customerID, paymentNumber)
+	if err != nil {
+		return err
+	}
+
+	header, body, err := mock.BuildCallback(payment, status)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.HandleCallback(ctx, mock.Name(), header, body)
+	return err
+}
+
+// RefundGroupPayment 對訂單群組已付款的付款發起（部分）退款，成功與失敗都會寫入退款紀錄
+// referenceType/referenceID 標示退款來源（例如退貨申請），供稽核查詢，並組成冪等鍵（例如 return:12）：
+// 同一來源已退款成功時直接回傳該紀錄，不會再向金流商退款；仍在處理中時回傳 ErrRefundInProgress
+func (s *PaymentService) RefundGroupPayment(ctx context.Context, groupID int, amount float64, reason, referenceType string, referenceID int) (*models.PaymentRefund, error) {
+	amount = roundAmount(amount)
+	if amount <= 0 {
+		return nil, models.ErrRefundExceedsPayment
+	}
+
+	locate := func(tx *sql.Tx) (*models.Payment, error) {
+		return s.paymentRepo.GetPaidByGroupIDTx(tx, groupID)
+	}
+	return s.refundPayment(ctx, locate, amount, reason, referenceType, referenceID)
+}
+
+// refundPayment 對 locate 在交易中取得的已付款付款發起退款，以 referenceType:referenceID 為冪等鍵
+func (s *PaymentService) refundPayment(ctx context.Context, locate func(*sql.Tx) (*models.Payment, error), amount float64, reason, referenceType string, referenceID int) (*models.PaymentRefund, error) {
+	key := fmt.Sprintf("%s:%d", referenceType, referenceID)
+	refund, payment, err := s.reserveRefund(locate, amount, reason, referenceType, referenceID, key)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status == models.PaymentRefundSucceeded {
+		return refund, nil
+	}
+
+	providerRef := ""
+	if payment.ProviderRef != nil {
+		providerRef = *payment.ProviderRef
+	}
+	result, refundErr := s.provider.Refund(ctx, &PaymentRefundRequest{
+		PaymentNumber:  payment.PaymentNumber,
+		ProviderRef:    providerRef,
+		Amount:         amount,
+		Reason:         reason,
+		IdempotencyKey: key,
+	})
+
+	refund.Status = models.PaymentRefundSucceeded
+	if refundErr != nil {
+		message := refundErr.Error()
+		refund.Status = models.PaymentRefundFailed
+		refund.Message = &message
+	} else {
+		if result.ProviderRef != "" {
+			refund.ProviderRef = &result.ProviderRef
+		}
+		if result.Message != "" {
+			refund.Message = &result.Message
+		}
+	}
+
+	// 寫入失敗時退款維持 pending 並保留預留額度，同一來源無法再次退款，需人工確認金流商結果
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.paymentRepo.FinishRefundTx(tx, refund); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if refundErr != nil {
+		logger.Error("金流退款失敗", refundErr, logrus.Fields{
+			"payment_number": payment.PaymentNumber,
+			"amount":         amount,
+			"reference_type": referenceType,
+			"reference_id":   referenceID,
+		})
+		return refund, refundErr
+	}
+
+	logger.Info("金流退款完成", logrus.Fields{
+		"payment_number": payment.PaymentNumber,
+		"amount":         amount,
+		"reference_type": referenceType,
+		"reference_id":   referenceID,
+	})
+	return refund, nil
+}
+
+// reserveRefund 在同一個交易中檢查冪等鍵、預留付款的退款額度並寫入處理中的退款紀錄
+// 同一冪等鍵已退款成功時回傳該紀錄（payment 為 nil）
+func (s *PaymentService) reserveRefund(locate func(*sql.Tx) (*models.Payment, error), amount float64, reason, referenceType string, referenceID int, key string) (*models.PaymentRefund, *models.Payment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	existing, err := s.paymentRepo.GetActiveRefundByKeyTx(tx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		if existing.Status == models.PaymentRefundSucceeded {
+			return existing, nil, nil
+		}
+		return nil, nil, models.ErrRefundInProgress
+	}
+
+	payment, err := locate(tx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, models.ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+	if err := s.paymentRepo.ReserveRefundTx(tx, payment.ID, amount); err != nil {
+		return nil, nil, err
+	}
+
+	refund := &models.PaymentRefund{
+		PaymentID:      payment.ID,
+		Amount:         amount,
+		Status:         models.PaymentRefundPending,
+		Reason:         &reason,
+		ReferenceType:  &referenceType,
+		ReferenceID:    &referenceID,
+		IdempotencyKey: &key,
+	}
+	if err := s.paymentRepo.CreateRefundTx(tx, refund); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return refund, payment, nil
+}
+
+// RefundMerchantOrder 商戶將訂單轉為已退款：先經金流退還訂單尚未退款的金額，金流退款成功後才變更訂單狀態
+// 已透過退貨退款的金額會扣除；仍有處理中的退貨申請時須先完成退貨流程
+func (s *PaymentService) RefundMerchantOrder(ctx context.Context, merchantID, orderID int, note *string) (*models.Order, error) {
+	order, err := s.orderService.GetMerchantOrder(merchantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanTransitionOrder(order.Status, models.OrderStatusRefunded, models.OrderActorMerchant) {
+		return nil, models.ErrInvalidOrderTransition
+	}
+	if order.GroupID == nil {
+		return nil, models.ErrPaymentNotFound
+	}
+
+	returned, open, err := s.returnRepo.OrderRefundSummary(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, models.ErrOrderStatusConflict.WithMessage("訂單仍有處理中的退貨申請，請先完成退貨流程")
+	}
+
+	// 以 order:<id> 為冪等鍵，先前已退款成功但訂單狀態未更新時不會重複向金流商退款
+	amount := roundAmount(order.TotalAmount - returned)
+	if amount > 0 {
+		_, err := s.RefundGroupPayment(ctx, *order.GroupID, amount, "訂單退款 "+order.OrderNumber,
+			models.MovementReferenceOrder, order.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.orderService.TransitionOrder(order.ID, models.OrderStatusRefunded, models.OrderActorMerchant, merchantID, note); err != nil {
+		return nil, err
+	}
+
+	return s.orderService.GetMerchantOrder(merchantID, order.ID)
+}
+
+// GetRefunds 獲取指定來源的金流退款紀錄
+func (s *PaymentService) GetRefunds(referenceType string, referenceID int) ([]*models.PaymentRefund, error) {
+	return s.paymentRepo.GetRefundsByReference(referenceType, referenceID)
+}
+
+// generatePaymentNumber 產生付款編號，例如 PAY250102153045123（綠界限制 20 碼英數字）
+func generatePaymentNumber() string {
+	return fmt.Sprintf("PAY%s%03d", time.Now().Format("060102150405"), rand.Intn(1000))
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"go-simple-app/models"
+)
+
+func TestPaymentCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		cancelFirst    bool   // 建立付款後、回調前取消第一張子訂單
+		wantStatus     string // 付款回調處理後的狀態
+		wantRefunded   bool   // 是否全額退款
+		wantSecondPaid bool   // 第二張子訂單是否轉為已付款
+	}{
+		{name: "全部子訂單待付款", wantStatus: models.PaymentStatusPaid, wantSecondPaid: true},
+		{name: "付款後取消部分子訂單", cancelFirst: true, wantStatus: models.PaymentStatusRefunded, wantRefunded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop(t)
+			customerID := shop.customer(t)
+			first := shop.product(t, shop.merchant(t), 300, 10)
+			second := shop.product(t, shop.merchant(t), 600, 10)
+			group := shop.placeOrder(t, customerID, first, 1, second, 1)
+			if len(group.Orders) != 2 {
+				t.Fatalf("sub-orders = %d, want 2", len(group.Orders))
+			}
+
+			payment, _, err := shop.payments.CreatePayment(ctx, customerID, group.ID)
+			if err != nil {
+				t.Fatalf("CreatePayment: %v", err)
+			}
+			if tt.cancelFirst {
+				if _, err := shop.orders.CancelCustomerOrder(customerID, group.Orders[0].ID, nil); err != nil {
+					t.Fatalf("CancelCustomerOrder: %v", err)
+				}
+			}
+
+			header, body, err := shop.provider.BuildCallback(payment, models.PaymentStatusPaid)
+			if err != nil {
+				t.Fatalf("BuildCallback: %v", err)
+			}
+			processed, err := shop.payments.HandleCallback(ctx, shop.provider.Name(), header, body)
+			if err != nil || !processed {
+				t.Fatalf("HandleCallback = %v, %v, want processed", processed, err)
+			}
+
+			// 重送同一回調不會再次處理
+			if processed, err := shop.payments.HandleCallback(ctx, shop.provider.Name(), header, body); err != nil || processed {
+				t.Errorf("redelivered HandleCallback = %v, %v, want already processed", processed, err)
+			}
+
+			stored, err := shop.payments.GetCustomerPayment(customerID, payment.PaymentNumber)
+			if err != nil {
+				t.Fatalf("GetCustomerPayment: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			wantRefund := 0.0
+			if tt.wantRefunded {
+				wantRefund = payment.Amount
+			}
+			if !amountEqual(stored.RefundedAmount, wantRefund) {
+				t.Errorf("refunded = %.2f, want %.2f", stored.RefundedAmount, wantRefund)
+			}
+			refunds, err := shop.payments.GetRefunds(models.RefundReferencePayment, payment.ID)
+			if err != nil {
+				t.Fatalf("GetRefunds: %v", err)
+			}
+			if tt.wantRefunded && (len(refunds) != 1 || refunds[0].Status != models.PaymentRefundSucceeded) {
+				t.Errorf("refunds = %+v, want one succeeded refund", refunds)
+			}
+
+			wantSecond := models.OrderStatusPending
+			if tt.wantSecondPaid {
+				wantSecond = models.OrderStatusPaid
+			}
+			if got := shop.orderStatus(t, group.Orders[1].ID); got != wantSecond {
+				t.Errorf("second order status = %s, want %s", got, wantSecond)
+			}
+		})
+	}
+}
+
+func TestCreatePaymentAfterCancellationChargesRemainingOrders(t *testing.T) {
+	ctx := context.Background()
+	shop := newTestShop(t)
+	customerID := shop.customer(t)
+	first := shop.product(t, shop.merchant(t), 300, 10)
+	second := shop.product(t, shop.merchant(t), 600, 10)
+	group := shop.placeOrder(t, customerID, first, 1, second, 1)
+
+	stale, _, err := shop.payments.CreatePayment(ctx, customerID, group.ID)
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	if _, err := shop.orders.CancelCustomerOrder(customerID, group.Orders[0].ID, nil); err != nil {
+		t.Fatalf("CancelCustomerOrder: %v", err)
+	}
+
+	stored, err := shop.payments.GetCustomerPayment(customerID, stale.PaymentNumber)
+	if err != nil {
+		t.Fatalf("GetCustomerPayment: %v", err)
+	}
+	if stored.Status != models.PaymentStatusVoided {
+		t.Errorf("stale payment status = %s, want %s", stored.Status, models.PaymentStatusVoided)
+	}
+
+	payment, _, err := shop.payments.CreatePayment(ctx, customerID, group.ID)
+	if err != nil {
+		t.Fatalf("CreatePayment after cancel: %v", err)
+	}
+	if !amountEqual(payment.Amount, group.Orders[1].TotalAmount) {
+		t.Errorf("amount = %.2f, want remaining order total %.2f", payment.Amount, group.Orders[1].TotalAmount)
+	}
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// 佔位圖片樣式
+const (
+	PlaceholderStyleProduct     = "product"
+	PlaceholderStylePlaceholder = "placeholder"
+)
+
+// 佔位圖片輸出格式
+const (
+	PlaceholderFormatPNG  = "png"
+	PlaceholderFormatWebP = "webp"
+	PlaceholderFormatSVG  = "svg"
+)
+
+const (
+	minPlaceholderSize     = 16
+	maxPlaceholderTextLen  = 60
+	minPlaceholderFontSize = 10
+)
+
+var placeholderContentTypes = map[string]string{
+	PlaceholderFormatPNG:  "image/png",
+	PlaceholderFormatWebP: "image/webp",
+	PlaceholderFormatSVG:  "image/svg+xml",
+}
+
+// 佔位圖片配色，與原本的 SVG 版本一致
+var (
+	placeholderBackground = color.NRGBA{0xf8, 0xf9, 0xfa, 0xff}
+	placeholderBorder     = color.NRGBA{0xde, 0xe2, 0xe6, 0xff}
+	placeholderPanel      = color.NRGBA{0xe9, 0xec, 0xef, 0xff}
+	placeholderPanelEdge  = color.NRGBA{0xad, 0xb5, 0xbd, 0xff}
+	placeholderTitle      = color.NRGBA{0x49, 0x50, 0x57, 0xff}
+	placeholderSubtitle   = color.NRGBA{0x6c, 0x75, 0x7d, 0xff}
+)
+
+// PlaceholderOptions 佔位圖片參數
+type PlaceholderOptions struct {
+	Style  string
+	Width  int
+	Height int
+	Text   string
+	Format string
+}
+
+// PlaceholderImage 產生的佔位圖片
+type PlaceholderImage struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
+
+// PlaceholderService 佔位圖片產生服務
+// 文字以載入的字型逐字繪製，缺字時依序改用下一個字型，最後以內建的 Go 字型顯示英數字；
+// 產生的圖片依參數保存在記憶體 LRU 快取
+type PlaceholderService struct {
+	fonts   []*opentype.Font
+	maxSize int
+	cache   *placeholderCache
+}
+
+// NewPlaceholderService 創建佔位圖片服務並載入字型
+func NewPlaceholderService(cfg config.PlaceholderConfig) *PlaceholderService {
+	s := &PlaceholderService{
+		maxSize: cfg.MaxSize,
+		cache:   newPlaceholderCache(cfg.CacheEntries),
+	}
+	if s.maxSize < minPlaceholderSize {
+		s.maxSize = 2000
+	}
+
+	for _, path := range cfg.FontPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		collection, err := opentype.ParseCollection(data)
+		if err != nil || collection.NumFonts() == 0 {
+			logger.Error("載入佔位圖片字型失敗", err, logrus.Fields{"path": path})
+			continue
+		}
+		f, err := collection.Font(0)
+		if err != nil {
+			logger.Error("載入佔位圖片字型失敗", err, logrus.Fields{"path": path})
+			continue
+		}
+		s.fonts = append(s.fonts, f)
+		logger.Info("已載入佔位圖片字型", logrus.Fields{"path": path})
+	}
+	if len(s.fonts) == 0 {
+		logger.Info("未找到可用的 CJK 字型，佔位圖片中的中文字將無法顯示，可用 PLACEHOLDER_FONT_PATHS 指定字型檔", logrus.Fields{})
+	}
+
+	// 內建的 Go 字型作為最後的備援，至少能顯示英數字
+	if fallback, err := opentype.Parse(goregular.TTF); err == nil {
+		s.fonts = append(s.fonts, fallback)
+	}
+
+	return s
+}
+
+// Render 產生佔位圖片，相同參數的結果直接取自快取
+func (s *PlaceholderService) Render(opts PlaceholderOptions) (*PlaceholderImage, error) {
+	opts, err := s.normalize(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s|%d|%d|%s|%s", opts.Style, opts.Width, opts.Height, opts.Format, opts.Text)
+	if cached, ok := s.cache.get(key); ok {
+		return cached, nil
+	}
+
+	var data []byte
+	switch opts.Format {
+	case PlaceholderFormatSVG:
+		data = renderPlaceholderSVG(opts)
+	default:
+		canvas := s.rasterize(opts)
+		if opts.Format == PlaceholderFormatWebP {
+			data, err = encodeWebPLossless(canvas)
+		} else {
+			var buf bytes.Buffer
+			encoder := png.Encoder{CompressionLevel: png.BestCompression}
+			err = encoder.Encode(&buf, canvas)
+			data = buf.Bytes()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &PlaceholderImage{
+		Data:        data,
+		ContentType: placeholderContentTypes[opts.Format],
+		ETag:        `"` + sha256Hex(data)[:32] + `"`,
+	}
+	s.cache.put(key, result)
+	return result, nil
+}
+
+// normalize 檢查格式、限制尺寸範圍，並移除文字中的控制字元
+func (s *PlaceholderService) normalize(opts PlaceholderOptions) (PlaceholderOptions, error) {
+	opts.Format = strings.ToLower(strings.TrimSpace(opts.Format))
+	if opts.Format == "" {
+		opts.Format = PlaceholderFormatPNG
+	}
+	if _, ok := placeholderContentTypes[opts.Format]; !ok {
+		return opts, ErrInvalidPlaceholderFormat
+	}
+	if opts.Style != PlaceholderStyleProduct {
+		opts.Style = PlaceholderStylePlaceholder
+	}
+
+	opts.Width = min(max(opts.Width, minPlaceholderSize), s.maxSize)
+	opts.Height = min(max(opts.Height, minPlaceholderSize), s.maxSize)
+
+	text := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return ' '
+		}
+		return r
+	}, opts.Text)
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxPlaceholderTextLen {
+		text = string(runes[:maxPlaceholderTextLen])
+	}
+	opts.Text = text
+
+	return opts, nil
+}
+
+// rasterize 繪製與 SVG 版本相同版面的點陣圖
+func (s *PlaceholderService) rasterize(opts PlaceholderOptions) *image.NRGBA {
+	w, h := opts.Width, opts.Height
+	canvas := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(placeholderBackground), image.Point{}, draw.Src)
+
+	if opts.Style == PlaceholderStyleProduct {
+		strokeRect(canvas, canvas.Bounds(), 2, placeholderBorder)
+		panel := image.Rect(10, 10, w-10, h-10)
+		draw.Draw(canvas, panel, image.NewUniform(placeholderPanel), image.Point{}, draw.Src)
+		strokeRect(canvas, panel, 1, placeholderPanelEdge)
+
+		s.drawText(canvas, opts.Text, float64(w)/20, float64(w-40), w/2, h/2-10, placeholderTitle)
+		s.drawText(canvas, fmt.Sprintf("%dx%d", w, h), float64(w)/25, float64(w-40), w/2, h/2+20, placeholderSubtitle)
+		return canvas
+	}
+
+	fillRoundedRect(canvas, image.Rect(w/4, h/4, w/4+w/2, h/4+h/2), 8, placeholderPanel)
+	s.drawText(canvas, opts.Text, float64(w)/15, float64(w/2-16), w/2, h/2, placeholderSubtitle)
+	return canvas
+}
+
+// drawText 以 (cx, cy) 為中心繪製一行文字；太寬時先縮小字級，縮到下限仍放不下則截斷並加上刪節號
+func (s *PlaceholderService) drawText(dst draw.Image, text string, size, maxWidth float64, cx, cy int, col color.Color) {
+	if text == "" || maxWidth <= 0 {
+		return
+	}
+	size = math.Max(size, minPlaceholderFontSize)
+
+	faces := s.newFaces(size)
+	width := measureText(faces, text)
+	limit := fixed.Int26_6(maxWidth * 64)
+	if width > limit {
+		size = math.Max(size*float64(limit)/float64(width), minPlaceholderFontSize)
+		closeFaces(faces)
+		faces = s.newFaces(size)
+		width = measureText(faces, text)
+	}
+	if width > limit {
+		runes := []rune(text)
+		for len(runes) > 0 {
+			runes = runes[:len(runes)-1]
+			text = strings.TrimSpace(string(runes)) + "…"
+			if width = measureText(faces, text); width <= limit {
+				break
+			}
+		}
+	}
+	defer closeFaces(faces)
+
+	// 以第一個有字的字型的上下緣讓文字垂直置中
+	metrics := faceFor(faces, []rune(text)[0]).Metrics()
+	dot := fixed.Point26_6{
+		X: fixed.I(cx) - width/2,
+		Y: fixed.I(cy) + (metrics.Ascent-metrics.Descent)/2,
+	}
+	src := image.NewUniform(col)
+	for _, r := range text {
+		face := faceFor(faces, r)
+		dr, mask, maskp, advance, ok := face.Glyph(dot, r)
+		if ok {
+			draw.DrawMask(dst, dr, src, image.Point{}, mask, maskp, draw.Over)
+		}
+		dot.X += advance
+	}
+}
+
+func (s *PlaceholderService) newFaces(size float64) []font.Face {
+	faces := make([]font.Face, 0, len(s.fonts))
+	for _, f := range s.fonts {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err == nil {
+			faces = append(faces, face)
+		}
+	}
+	return faces
+}
+
+// faceFor 找出第一個含有該字的字型，都沒有時使用第一個字型（顯示缺字方塊）
+func faceFor(faces []font.Face, r rune) font.Face {
+	for _, face := range faces {
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+	return faces[0]
+}
+
+func measureText(faces []font.Face, text string) fixed.Int26_6 {
+	width := fixed.Int26_6(0)
+	for _, r := range text {
+		advance, _ := faceFor(faces, r).GlyphAdvance(r)
+		width += advance
+	}
+	return width
+}
+
+func closeFaces(faces []font.Face) {
+	for _, face := range faces {
+		face.Close()
+	}
+}
+
+// strokeRect 沿矩形內緣繪製邊框
+func strokeRect(dst draw.Image, rect image.Rectangle, width int, col color.Color) {
+	src := image.NewUniform(col)
+	draw.Draw(dst, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+width), src, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(rect.Min.X, rect.Max.Y-width, rect.Max.X, rect.Max.Y), src, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+width, rect.Max.Y), src, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(rect.Max.X-width, rect.Min.Y, rect.Max.X, rect.Max.Y), src, image.Point{}, draw.Src)
+}
+
+// fillRoundedRect 繪製圓角矩形，圓角邊緣依像素中心到圓心的距離做反鋸齒
+func fillRoundedRect(dst draw.Image, rect image.Rectangle, radius int, col color.Color) {
+	if rect.Empty() {
+		return
+	}
+	radius = min(radius, rect.Dx()/2, rect.Dy()/2)
+	mask := image.NewAlpha(rect)
+	r := float64(radius)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// 像素中心到最近圓角圓心的水平與垂直距離，不在圓角區時為 0
+			px, py := float64(x)+0.5, float64(y)+0.5
+			dx := math.Max(math.Max(float64(rect.Min.X)+r-px, px-(float64(rect.Max.X)-r)), 0)
+			dy := math.Max(math.Max(float64(rect.Min.Y)+r-py, py-(float64(rect.Max.Y)-r)), 0)
+			coverage := 1.0
+			if dx > 0 && dy > 0 {
+				coverage = math.Min(math.Max(r+0.5-math.Hypot(dx, dy), 0), 1)
+			}
+			mask.SetAlpha(x, y, color.Alpha{A: uint8(coverage * 255)})
+		}
+	}
+	draw.DrawMask(dst, rect, image.NewUniform(col), image.Point{}, mask, rect.Min, draw.Over)
+}
+
+// renderPlaceholderSVG 產生 SVG 版本，文字經過跳脫後才放入標記
+func renderPlaceholderSVG(opts PlaceholderOptions) []byte {
+	w, h := opts.Width, opts.Height
+	text := html.EscapeString(opts.Text)
+	fontFamily := `'Noto Sans CJK TC', 'PingFang TC', 'Microsoft JhengHei', Arial, sans-serif`
+
+	if opts.Style == PlaceholderStyleProduct {
+		return []byte(fmt.Sprintf(`<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">
+	<rect width="%d" height="%d" fill="#f8f9fa" stroke="#dee2e6" stroke-width="2"/>
+	<rect x="10" y="10" width="%d" height="%d" fill="#e9ecef" stroke="#adb5bd" stroke-width="1"/>
+	<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-family="%s" font-size="%d" fill="#495057">%s</text>
+	<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-family="%s" font-size="%d" fill="#6c757d">%dx%d</text>
+</svg>`,
+			w, h, w, h, w-20, h-20,
+			w/2, h/2-10, fontFamily, max(w/20, minPlaceholderFontSize), text,
+			w/2, h/2+20, fontFamily, max(w/25, minPlaceholderFontSize), w, h))
+	}
+
+	return []byte(fmt.Sprintf(`<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">
+	<rect width="%d" height="%d" fill="#f8f9fa"/>
+	<rect x="%d" y="%d" width="%d" height="%d" fill="#e9ecef" rx="8"/>
+	<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-family="%s" font-size="%d" fill="#6c757d">%s</text>
+</svg>`,
+		w, h, w, h, w/4, h/4, w/2, h/2,
+		w/2, h/2, fontFamily, max(w/15, minPlaceholderFontSize), text))
+}
+
+// placeholderCache 依參數保存產生結果的記憶體 LRU 快取
+type placeholderCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	entries    map[string]*list.Element
+}
+
+type placeholderCacheItem struct {
+	key   string
+	image *PlaceholderImage
+}
+
+func newPlaceholderCache(maxEntries int) *placeholderCache {
+	return &placeholderCache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+func (c *placeholderCache) get(key string) (*PlaceholderImage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*placeholderCacheItem).image, true
+}
+
+func (c *placeholderCache) put(key string, image *PlaceholderImage) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*placeholderCacheItem).image = image
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&placeholderCacheItem{key: key, image: image})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*placeholderCacheItem).key)
+	}
+}
+
+var (
+	ErrInvalidPlaceholderFormat = errors.New("不支援的圖片格式，可用 png、webp 或 svg")
+)
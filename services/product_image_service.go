@@ -0,0 +1,342 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // 註冊 GIF 解碼器
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxImagesPerProduct = 10
+	maxImagePixels      = 40_000_000 // 長寬相乘的像素上限（40MP），避免解碼超大尺寸圖片耗盡記憶體
+	thumbnailQuality    = 85
+)
+
+// thumbnailSizes 縮圖尺寸（由小到大），圖片等比縮放到長邊不超過指定像素，較小的圖片不放大
+var thumbnailSizes = []struct {
+	name string
+	size int
+}{
+	{models.ProductImageSizeSmall, 150},
+	{models.ProductImageSizeMedium, 400},
+	{models.ProductImageSizeLarge, 800},
+}
+
+// imageExtensions 允許上傳的圖片格式（依內容判斷）與副檔名
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// ProductImageService 商品圖片上傳服務
+// 上傳的圖片依內容判斷格式，原圖與各尺寸縮圖寫入儲存後端後再連結到商品
+type ProductImageService struct {
+	db          *sql.DB
+	productRepo *models.ProductRepository
+	imageRepo   *models.ProductImageRepository
+	storage     ObjectStorage
+	maxBytes    int64
+}
+
+// NewProductImageService 創建商品圖片服務
+func NewProductImageService(db *sql.DB, cfg config.StorageConfig, storage ObjectStorage) *ProductImageService {
+	maxBytes := int64(cfg.MaxUploadMB) << 20
+	if maxBytes <= 0 {
+		maxBytes = 10 << 20
+	}
+
+	return &ProductImageService{
+		db:          db,
+		productRepo: models.NewProductRepository(db),
+		imageRepo:   models.NewProductImageRepository(db),
+		storage:     storage,
+		maxBytes:    maxBytes,
+	}
+}
+
+// MaxBytes 單一圖片大小上限
+func (s *ProductImageService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// GetImages 獲取商戶商品的上傳圖片
+func (s *ProductImageService) GetImages(merchantID, productID int) ([]*models.ProductImage, error) {
+	if err := s.checkOwner(merchantID, productID); err != nil {
+		return nil, err
+	}
+	return s.imageRepo.GetByProductID(productID)
+}
+
+// Upload 上傳一張商品圖片並產生縮圖
+func (s *ProductImageService) Upload(ctx context.Context, merchantID, productID int, r io.Reader) (*models.ProductImage, error) {
+	if err := s.checkOwner(merchantID, productID); err != nil {
+		return nil, err
+	}
+
+	count, err := s.imageRepo.CountByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxImagesPerProduct {
+		return nil, models.ErrTooManyProductImages
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, &models.DomainError{Code: "IMAGE_TOO_LARGE", Message: fmt.Sprintf("圖片不可超過 %dMB", s.maxBytes>>20)}
+	}
+
+	// 依檔案內容判斷格式，不信任副檔名與 Content-Type
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, models.ErrInvalidProductImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, models.ErrInvalidProductImage
+	}
+	if imageTooLarge(cfg) {
+		return nil, &models.DomainError{Code: "IMAGE_TOO_LARGE", Message: fmt.Sprintf("圖片不可超過 %d 萬像素", maxImagePixels/10000)}
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, models.ErrInvalidProductImage
+	}
+
+	dir := fmt.Sprintf("products/%d/%s", productID, randomKey())
+	productImage := &models.ProductImage{
+		ProductID:   productID,
+		MerchantID:  merchantID,
+		Storage:     s.storage.Name(),
+		StorageKey:  dir + "/original." + ext,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		SizeBytes:   len(data),
+		Thumbnails:  map[string]string{},
+	}
+
+	stored := []string{}
+	cleanup := func() {
+		for _, key := range stored {
+			if err := s.storage.Delete(context.Background(), key); err != nil {
+				logger.Error("清除上傳失敗的圖片檔案失敗", err, logrus.Fields{"key": key})
+			}
+		}
+	}
+
+	if err := s.storage.Put(ctx, productImage.StorageKey, contentType, data); err != nil {
+		return nil, err
+	}
+	stored = append(stored, productImage.StorageKey)
+	productImage.URL = s.storage.URL(productImage.StorageKey)
+
+	// 最大的縮圖由原圖縮出，較小的縮圖再由上一張縮出，只需讀取一次原圖像素
+	src := decoded
+	for i := len(thumbnailSizes) - 1; i >= 0; i-- {
+		size := thumbnailSizes[i]
+		resized := fitImage(src, size.size)
+		src = resized
+		thumbnail, thumbnailType, thumbnailExt, err := encodeThumbnail(resized)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		key := dir + "/" + size.name + "." + thumbnailExt
+		if err := s.storage.Put(ctx, key, thumbnailType, thumbnail); err != nil {
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, key)
+		productImage.Thumbnails[size.name] = s.storage.URL(key)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.imageRepo.CreateTx(tx, productImage); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return productImage, nil
+}
+
+// DeleteImage 刪除商品圖片，資料庫刪除成功後再移除儲存的檔案
+func (s *ProductImageService) DeleteImage(ctx context.Context, merchantID, productID, imageID int) error {
+	if err := s.checkOwner(merchantID, productID); err != nil {
+		return err
+	}
+
+	productImage, err := s.imageRepo.GetByID(productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.imageRepo.DeleteTx(tx, productImage); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 檔案刪除失敗只記錄，不影響商品資料
+	dir := path.Dir(productImage.StorageKey)
+	keys := []string{productImage.StorageKey}
+	for name, url := range productImage.Thumbnails {
+		keys = append(keys, dir+"/"+name+path.Ext(url))
+	}
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			logger.Error("刪除商品圖片檔案失敗", err, logrus.Fields{"key": key})
+		}
+	}
+
+	return nil
+}
+
+func (s *ProductImageService) checkOwner(merchantID, productID int) error {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrMerchantProductNotFound
+		}
+		return err
+	}
+	if product.MerchantID != merchantID {
+		return models.ErrMerchantProductNotFound
+	}
+	return nil
+}
+
+// imageTooLarge 圖片像素數是否超過上限（長寬相乘，避免細長圖片繞過單邊限制）
+func imageTooLarge(cfg image.Config) bool {
+	return cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxImagePixels
+}
+
+// fitImage 將圖片等比縮放到長邊不超過 size
+func fitImage(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+	return resizeImage(src, width, height)
+}
+
+// encodeThumbnail 編碼縮圖；含透明像素時輸出 PNG，否則輸出 JPEG
+func encodeThumbnail(thumbnail *image.NRGBA) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if !thumbnail.Opaque() {
+		if err := png.Encode(&buf, thumbnail); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), "image/png", "png", nil
+	}
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), "image/jpeg", "jpg", nil
+}
+
+// resizeImage 以區域平均（box filter）縮放圖片，縮小時每個目標像素取其涵蓋來源區域的平均色
+// 直接讀取解碼後的圖片，不先複製成整張 NRGBA
+func resizeImage(source image.Image, width, height int) *image.NRGBA {
+	bounds := source.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	nrgba, isNRGBA := source.(*image.NRGBA)
+	if isNRGBA && bounds.Min == (image.Point{}) && width == srcWidth && height == srcHeight {
+		return nrgba
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+
+			// 以 alpha 加權平均顏色，避免透明像素的顏色滲入邊緣
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					var c color.NRGBA
+					if isNRGBA {
+						offset := nrgba.PixOffset(bounds.Min.X+sx, bounds.Min.Y+sy)
+						c = color.NRGBA{nrgba.Pix[offset], nrgba.Pix[offset+1], nrgba.Pix[offset+2], nrgba.Pix[offset+3]}
+					} else {
+						c = color.NRGBAModel.Convert(source.At(bounds.Min.X+sx, bounds.Min.Y+sy)).(color.NRGBA)
+					}
+					alpha := uint64(c.A)
+					r += uint64(c.R) * alpha
+					g += uint64(c.G) * alpha
+					b += uint64(c.B) * alpha
+					a += alpha
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[offset] = uint8(r / a)
+				dst.Pix[offset+1] = uint8(g / a)
+				dst.Pix[offset+2] = uint8(b / a)
+			}
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+func randomKey() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
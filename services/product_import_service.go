@@ -0,0 +1,561 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-simple-app/models"
+)
+
+// 匯入匯出檔案格式
+const (
+	ProductFileCSV  = "csv"
+	ProductFileJSON = "json"
+)
+
+// maxImportRows 單次匯入的資料列上限
+const maxImportRows = 5000
+
+// ProductImportService 商戶商品批次匯入匯出服務
+// 匯入以 SKU 對應商戶既有商品（存在則更新、不存在則新增），庫存變動照常寫入異動帳
+type ProductImportService struct {
+	db               *sql.DB
+	productRepo      *models.ProductRepository
+	inventoryService *InventoryService
+}
+
+// NewProductImportService 創建商品匯入匯出服務
+func NewProductImportService(db *sql.DB, inventoryService *InventoryService) *ProductImportService {
+	return &ProductImportService{
+		db:               db,
+		productRepo:      models.NewProductRepository(db),
+		inventoryService: inventoryService,
+	}
+}
+
+// ProductImportRow 匯入檔案中的一列，只包含有提供值的欄位
+type ProductImportRow struct {
+	line   int
+	fields map[string]string
+}
+
+// ParseImportFile 解析 CSV（第一列為標題）或 JSON（物件陣列）匯入檔
+func (s *ProductImportService) ParseImportFile(format string, r io.Reader) ([]*ProductImportRow, error) {
+	var rows []*ProductImportRow
+	var err error
+	switch format {
+	case ProductFileCSV:
+		rows, err = parseImportCSV(r)
+	case ProductFileJSON:
+		rows, err = parseImportJSON(r)
+	default:
+		return nil, &models.DomainError{Code: "INVALID_IMPORT_FILE", Message: "不支援的檔案格式，僅支援 csv 與 json"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, &models.DomainError{Code: "INVALID_IMPORT_FILE", Message: "匯入檔案沒有資料"}
+	}
+	if len(rows) > maxImportRows {
+		return nil, &models.DomainError{Code: "INVALID_IMPORT_FILE", Message: fmt.Sprintf("單次最多匯入 %d 筆", maxImportRows)}
+	}
+	return rows, nil
+}
+
+func parseImportCSV(r io.Reader) ([]*ProductImportRow, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, importFileError(err.Error())
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // Excel 輸出的 UTF-8 BOM
+		}
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+		if !isProductTransferColumn(columns[i]) {
+			return nil, importFileError("未知的欄位: " + name)
+		}
+	}
+
+	var rows []*ProductImportRow
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, importFileError(err.Error())
+		}
+
+		row := &ProductImportRow{line: line, fields: map[string]string{}}
+		for i, value := range record {
+			if i >= len(columns) {
+				return nil, importFileError(fmt.Sprintf("第 %d 列的欄位數多於標題列", line))
+			}
+			if value = strings.TrimSpace(value); value != "" {
+				row.fields[columns[i]] = value
+			}
+		}
+		if len(row.fields) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+func parseImportJSON(r io.Reader) ([]*ProductImportRow, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var records []map[string]interface{}
+	if err := decoder.Decode(&records); err != nil {
+		return nil, importFileError("JSON 需為商品物件陣列: " + err.Error())
+	}
+
+	rows := make([]*ProductImportRow, 0, len(records))
+	for i, record := range records {
+		row := &ProductImportRow{line: i + 1, fields: map[string]string{}}
+		for key, value := range record {
+			name := strings.ToLower(strings.TrimSpace(key))
+			if !isProductTransferColumn(name) {
+				return nil, importFileError("未知的欄位: " + key)
+			}
+
+			switch v := value.(type) {
+			case nil:
+				continue
+			case string:
+				if v = strings.TrimSpace(v); v != "" {
+					row.fields[name] = v
+				}
+			case json.Number:
+				row.fields[name] = v.String()
+			case bool:
+				row.fields[name] = strconv.FormatBool(v)
+			default:
+				// 陣列與物件（tags、images、dimensions）保留為 JSON 字串
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return nil, importFileError(err.Error())
+				}
+				row.fields[name] = string(encoded)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Import 匯入商品；dryRun 時只回傳驗證報告，任何一列有錯誤時整批不寫入
+// 試算與正式匯入走同一套流程，在交易中實際寫入後依結果提交或回滾
+func (s *ProductImportService) Import(merchantID int, rows []*ProductImportRow, dryRun bool) (*models.ProductImportReport, error) {
+	report := &models.ProductImportReport{
+		DryRun:  dryRun,
+		Total:   len(rows),
+		Errors:  []*models.ProductImportRowError{},
+		Results: []*models.ProductImportRowResult{},
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	seen := map[string]int{}
+	for _, row := range rows {
+		sku := row.fields["sku"]
+		rowErrors := []*models.ProductImportRowError{}
+		fail := func(field, message string) {
+			rowErrors = append(rowErrors, &models.ProductImportRowError{Row: row.line, SKU: sku, Field: field, Message: message})
+		}
+
+		switch {
+		case sku == "":
+			fail("sku", "SKU 不可為空")
+		case len([]rune(sku)) > 100:
+			fail("sku", "SKU 不可超過100個字")
+		case seen[sku] > 0:
+			fail("sku", fmt.Sprintf("SKU 與第 %d 列重複", seen[sku]))
+		}
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			report.Failed++
+			continue
+		}
+		seen[sku] = row.line
+
+		existing, err := s.productRepo.GetByMerchantSKUTx(tx, merchantID, sku)
+		if err != nil {
+			if domainErr, ok := err.(*models.DomainError); ok {
+				fail("sku", domainErr.Message)
+				report.Errors = append(report.Errors, rowErrors...)
+				report.Failed++
+				continue
+			}
+			return nil, err
+		}
+
+		product := existing
+		action := models.ProductImportUpdate
+		if product == nil {
+			product = &models.Product{SKU: &sku, IsActive: true}
+			action = models.ProductImportCreate
+			if _, ok := row.fields["name"]; !ok {
+				fail("name", "新增商品需提供名稱")
+			}
+			if _, ok := row.fields["price"]; !ok {
+				fail("price", "新增商品需提供價格")
+			}
+		}
+		applyImportFields(product, row.fields, fail)
+
+		if len(rowErrors) == 0 {
+			if action == models.ProductImportCreate {
+				err = s.inventoryService.createMerchantProductTx(tx, merchantID, product)
+			} else {
+				err = s.inventoryService.updateMerchantProductTx(tx, merchantID, product)
+			}
+			if err != nil {
+				domainErr, ok := err.(*models.DomainError)
+				if !ok {
+					return nil, err
+				}
+				fail("", domainErr.Message)
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			report.Failed++
+			continue
+		}
+
+		result := &models.ProductImportRowResult{Row: row.line, SKU: sku, Action: action, ProductID: product.ID}
+		if action == models.ProductImportCreate {
+			report.Created++
+			if dryRun {
+				result.ProductID = 0
+			}
+		} else {
+			report.Updated++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if dryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// applyImportFields 將匯入列的欄位套用到商品，未提供的欄位維持原值
+func applyImportFields(product *models.Product, fields map[string]string, fail func(field, message string)) {
+	parseFloat := func(field string) (float64, bool) {
+		value, err := strconv.ParseFloat(fields[field], 64)
+		if err != nil || value < 0 {
+			fail(field, "需為不小於0的數字")
+			return 0, false
+		}
+		return value, true
+	}
+	parseBool := func(field string) (bool, bool) {
+		value, err := strconv.ParseBool(fields[field])
+		if err != nil {
+			fail(field, "需為 true 或 false")
+			return false, false
+		}
+		return value, true
+	}
+	optional := func(value string) *string {
+		return &value
+	}
+
+	for _, field := range models.ProductTransferColumns {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		switch field {
+		case "name":
+			if len([]rune(value)) > 200 {
+				fail(field, "名稱不可超過200個字")
+				continue
+			}
+			product.Name = value
+		case "description":
+			product.Description = value
+		case "price":
+			if price, ok := parseFloat(field); ok {
+				product.Price = price
+			}
+		case "original_price":
+			if price, ok := parseFloat(field); ok {
+				product.OriginalPrice = &price
+			}
+		case "category":
+			// 以名稱重新對應分類樹，子分類若未一併提供則清空
+			product.Category = value
+			product.CategoryID = nil
+			if _, ok := fields["sub_category"]; !ok {
+				product.SubCategory = nil
+			}
+		case "sub_category":
+			product.SubCategory = optional(value)
+		case "brand":
+			product.Brand = optional(value)
+		case "stock":
+			stock, err := strconv.Atoi(value)
+			if err != nil || stock < 0 {
+				fail(field, "需為不小於0的整數")
+				continue
+			}
+			product.Stock = stock
+		case "image_url":
+			product.ImageURL = optional(value)
+		case "images", "tags":
+			list, ok := importList(value)
+			if !ok {
+				fail(field, "需為 JSON 字串陣列或以 | 分隔的文字")
+				continue
+			}
+			if field == "images" {
+				product.Images = &list
+			} else {
+				product.Tags = &list
+			}
+		case "is_active":
+			if flag, ok := parseBool(field); ok {
+				product.IsActive = flag
+			}
+		case "is_featured":
+			if flag, ok := parseBool(field); ok {
+				product.IsFeatured = flag
+			}
+		case "weight":
+			if weight, ok := parseFloat(field); ok {
+				product.Weight = &weight
+			}
+		case "dimensions":
+			if !json.Valid([]byte(value)) {
+				fail(field, "需為 JSON 格式")
+				continue
+			}
+			product.Dimensions = optional(value)
+		}
+	}
+}
+
+// importList 將 JSON 字串陣列或以 | 分隔的文字轉為 JSON 陣列字串
+func importList(value string) (string, bool) {
+	var list []string
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return "", false
+		}
+	} else {
+		for _, item := range strings.Split(value, "|") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	if list == nil {
+		list = []string{}
+	}
+
+	encoded, _ := json.Marshal(list)
+	return string(encoded), true
+}
+
+// Export 將商戶所有商品以匯入相同的欄位串流寫出，每 100 筆刷新一次輸出
+func (s *ProductImportService) Export(merchantID int, format string, w io.Writer) error {
+	flush := func() {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	switch format {
+	case ProductFileCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(models.ProductTransferColumns); err != nil {
+			return err
+		}
+		count := 0
+		err := s.productRepo.EachByMerchant(merchantID, func(product *models.Product) error {
+			if err := writer.Write(exportCSVRecord(product)); err != nil {
+				return err
+			}
+			if count++; count%100 == 0 {
+				writer.Flush()
+				flush()
+			}
+			return writer.Error()
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		flush()
+		return writer.Error()
+
+	case ProductFileJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		count := 0
+		err := s.productRepo.EachByMerchant(merchantID, func(product *models.Product) error {
+			encoded, err := json.Marshal(exportJSONRecord(product))
+			if err != nil {
+				return err
+			}
+			separator := "\n"
+			if count > 0 {
+				separator = ",\n"
+			}
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+			if _, err := w.Write(encoded); err != nil {
+				return err
+			}
+			if count++; count%100 == 0 {
+				flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "\n]\n")
+		flush()
+		return err
+
+	default:
+		return &models.DomainError{Code: "INVALID_EXPORT_FORMAT", Message: "不支援的檔案格式，僅支援 csv 與 json"}
+	}
+}
+
+// productExportRecord JSON 匯出的商品，欄位與 ProductTransferColumns 相同
+type productExportRecord struct {
+	SKU           string          `json:"sku"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Price         float64         `json:"price"`
+	OriginalPrice *float64        `json:"original_price"`
+	Category      string          `json:"category"`
+	SubCategory   *string         `json:"sub_category"`
+	Brand         *string         `json:"brand"`
+	Stock         int             `json:"stock"`
+	ImageURL      *string         `json:"image_url"`
+	Images        json.RawMessage `json:"images"`
+	Tags          json.RawMessage `json:"tags"`
+	IsActive      bool            `json:"is_active"`
+	IsFeatured    bool            `json:"is_featured"`
+	Weight        *float64        `json:"weight"`
+	Dimensions    json.RawMessage `json:"dimensions"`
+}
+
+func exportJSONRecord(product *models.Product) *productExportRecord {
+	return &productExportRecord{
+		SKU:           stringValue(product.SKU),
+		Name:          product.Name,
+		Description:   product.Description,
+		Price:         product.Price,
+		OriginalPrice: product.OriginalPrice,
+		Category:      product.Category,
+		SubCategory:   product.SubCategory,
+		Brand:         product.Brand,
+		Stock:         product.Stock,
+		ImageURL:      product.ImageURL,
+		Images:        rawJSON(product.Images),
+		Tags:          rawJSON(product.Tags),
+		IsActive:      product.IsActive,
+		IsFeatured:    product.IsFeatured,
+		Weight:        product.Weight,
+		Dimensions:    rawJSON(product.Dimensions),
+	}
+}
+
+func exportCSVRecord(product *models.Product) []string {
+	formatFloat := func(value *float64) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*value, 'f', -1, 64)
+	}
+
+	return []string{
+		stringValue(product.SKU),
+		product.Name,
+		product.Description,
+		strconv.FormatFloat(product.Price, 'f', -1, 64),
+		formatFloat(product.OriginalPrice),
+		product.Category,
+		stringValue(product.SubCategory),
+		stringValue(product.Brand),
+		strconv.Itoa(product.Stock),
+		stringValue(product.ImageURL),
+		stringValue(product.Images),
+		stringValue(product.Tags),
+		strconv.FormatBool(product.IsActive),
+		strconv.FormatBool(product.IsFeatured),
+		formatFloat(product.Weight),
+		stringValue(product.Dimensions),
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// rawJSON 將以 JSON 字串存儲的欄位原樣輸出，空值或格式錯誤時輸出 null
+func rawJSON(value *string) json.RawMessage {
+	if value == nil || !json.Valid([]byte(*value)) {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(bytes.TrimSpace([]byte(*value)))
+}
+
+func isProductTransferColumn(name string) bool {
+	for _, column := range models.ProductTransferColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func importFileError(detail string) error {
+	return models.ErrInvalidImportFile.WithMessage(models.ErrInvalidImportFile.Message + ": " + detail)
+}
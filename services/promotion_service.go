@@ -0,0 +1,424 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-simple-app/models"
+)
+
+// PromotionService 促銷業務邏輯服務
+// 每個商戶與全站各自套用一個最優惠的自動促銷，客戶套用的優惠券再疊加於其適用範圍
+type PromotionService struct {
+	db            *sql.DB
+	promotionRepo *models.PromotionRepository
+}
+
+// CartPricing 購物車套用促銷後的金額
+type CartPricing struct {
+	Subtotal          float64                   `json:"subtotal"`
+	DiscountAmount    float64                   `json:"discount_amount"`
+	Total             float64                   `json:"total"`
+	CouponCode        string                    `json:"coupon_code,omitempty"`
+	CouponError       string                    `json:"coupon_error,omitempty"`
+	Promotions        []models.AppliedPromotion `json:"promotions"`
+	MerchantDiscounts map[int]float64           `json:"merchant_discounts"` // 各商戶子訂單分攤的折扣
+
+	couponErr  error
+	redemption []promotionRedemption
+}
+
+// promotionRedemption 下單時需寫入的促銷使用紀錄
+type promotionRedemption struct {
+	promotion *models.Promotion
+	discount  float64
+}
+
+// NewPromotionService 創建促銷服務
+func NewPromotionService(db *sql.DB) *PromotionService {
+	return &PromotionService{
+		db:            db,
+		promotionRepo: models.NewPromotionRepository(db),
+	}
+}
+
+// ApplyCoupon 將優惠券套用到客戶購物車，最低消費等條件在計價時檢查
+func (s *PromotionService) ApplyCoupon(customerID int, code string) error {
+	code = models.NormalizeCouponCode(code)
+	if code == "" {
+		return models.ErrCouponNotFound
+	}
+
+	promotion, err := s.promotionRepo.GetByCode(code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrCouponNotFound
+		}
+		return err
+	}
+	if err := s.checkAvailable(promotion, customerID, time.Now()); err != nil {
+		return err
+	}
+
+	return s.promotionRepo.SetCartCoupon(customerID, code)
+}
+
+// RemoveCoupon 移除客戶購物車套用的優惠券
+func (s *PromotionService) RemoveCoupon(customerID int) error {
+	return s.promotionRepo.RemoveCartCoupon(customerID)
+}
+
+// PriceCart 以客戶購物車套用的優惠券計算購物車折扣
+func (s *PromotionService) PriceCart(customerID int, items []models.CartItem) (*CartPricing, error) {
+	code, err := s.promotionRepo.GetCartCoupon(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Price(customerID, items, code)
+}
+
+// Price 計算購物車商品的折扣
+// 優惠券不可用時不會回傳錯誤，而是記錄在 CouponError，結帳時再由 CheckCoupon 拒絕
+func (s *PromotionService) Price(customerID int, items []models.CartItem, couponCode string) (*CartPricing, error) {
+	now := time.Now()
+	pricing := &CartPricing{
+		CouponCode:        couponCode,
+		Promotions:        []models.AppliedPromotion{},
+		MerchantDiscounts: make(map[int]float64),
+	}
+
+	// 依商戶計算小計，保留購物車中的先後順序
+	var merchantIDs []int
+	subtotals := make(map[int]float64)
+	for _, item := range items {
+		if item.Product == nil {
+			continue
+		}
+		merchantID := item.Product.MerchantID
+		if _, exists := subtotals[merchantID]; !exists {
+			merchantIDs = append(merchantIDs, merchantID)
+		}
+		subtotals[merchantID] += item.UnitPrice() * float64(item.Quantity)
+	}
+	remaining := make(map[int]float64)
+	for _, merchantID := range merchantIDs {
+		subtotals[merchantID] = roundAmount(subtotals[merchantID])
+		remaining[merchantID] = subtotals[merchantID]
+		pricing.Subtotal += subtotals[merchantID]
+	}
+	pricing.Subtotal = roundAmount(pricing.Subtotal)
+
+	automatic, err := s.promotionRepo.GetAutomatic(merchantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := s.loadCoupon(couponCode, customerID, now)
+	if err != nil {
+		pricing.couponErr = err
+		coupon = nil
+	}
+	if coupon != nil && coupon.MerchantID != nil {
+		if _, exists := subtotals[*coupon.MerchantID]; !exists {
+			pricing.couponErr = models.ErrCouponNotApplicable
+			coupon = nil
+		}
+	}
+
+	// 商戶促銷：以該商戶小計判斷最低消費
+	for _, merchantID := range merchantIDs {
+		var candidates []*models.Promotion
+		for _, promotion := range automatic {
+			if promotion.MerchantID != nil && *promotion.MerchantID == merchantID {
+				candidates = append(candidates, promotion)
+			}
+		}
+		if best := s.bestPromotion(candidates, customerID, subtotals[merchantID], remaining[merchantID], now); best != nil {
+			s.apply(pricing, best, []int{merchantID}, remaining)
+		}
+
+		if coupon != nil && coupon.MerchantID != nil && *coupon.MerchantID == merchantID {
+			if err := checkMinSpend(coupon, subtotals[merchantID]); err != nil {
+				pricing.couponErr = err
+			} else {
+				s.apply(pricing, coupon, []int{merchantID}, remaining)
+			}
+		}
+	}
+
+	// 全站促銷：以整個購物車小計判斷最低消費，折扣依各商戶剩餘金額分攤
+	var candidates []*models.Promotion
+	for _, promotion := range automatic {
+		if promotion.MerchantID == nil {
+			candidates = append(candidates, promotion)
+		}
+	}
+	if best := s.bestPromotion(candidates, customerID, pricing.Subtotal, sumAmounts(remaining), now); best != nil {
+		s.apply(pricing, best, merchantIDs, remaining)
+	}
+	if coupon != nil && coupon.MerchantID == nil {
+		if err := checkMinSpend(coupon, pricing.Subtotal); err != nil {
+			pricing.couponErr = err
+		} else {
+			s.apply(pricing, coupon, merchantIDs, remaining)
+		}
+	}
+
+	if pricing.couponErr != nil {
+		pricing.CouponError = pricing.couponErr.Error()
+	}
+	pricing.DiscountAmount = roundAmount(pricing.DiscountAmount)
+	pricing.Total = roundAmount(pricing.Subtotal - pricing.DiscountAmount)
+
+	return pricing, nil
+}
+
+// CheckCoupon 結帳前檢查優惠券，購物車套用了不可用的優惠券時返回錯誤
+func (p *CartPricing) CheckCoupon() error {
+	return p.couponErr
+}
+
+// RedeemTx 在交易中記錄本次結帳使用的促銷，並再次檢查使用次數上限
+func (s *PromotionService) RedeemTx(tx *sql.Tx, pricing *CartPricing, customerID, groupID int) error {
+	for _, redemption := range pricing.redemption {
+		if err := s.promotionRepo.RedeemTx(tx, redemption.promotion, customerID, groupID, redemption.discount); err != nil {
+			return err
+		}
+	}
+	return s.promotionRepo.RemoveCartCouponTx(tx, customerID)
+}
+
+// RevertRedemptionsTx 在交易中撤銷已取消或退款子訂單的促銷使用紀錄，讓使用次數與每人上限恢復
+// 商戶促銷只對應該商戶的子訂單，隨子訂單一起撤銷；全站促銷分攤到整個群組，groupClosed（群組內所有子訂單都已取消或退款）時才撤銷
+func (s *PromotionService) RevertRedemptionsTx(tx *sql.Tx, groupID, merchantID int, groupClosed bool) error {
+	return s.promotionRepo.RevertRedemptionsTx(tx, groupID, merchantID, groupClosed)
+}
+
+// ListPromotions 獲取促銷列表，merchantID 為 nil 時列出全部（管理員）
+func (s *PromotionService) ListPromotions(merchantID *int) ([]*models.Promotion, error) {
+	if merchantID != nil {
+		return s.promotionRepo.GetByMerchantID(*merchantID)
+	}
+	return s.promotionRepo.GetAll()
+}
+
+// CreatePromotion 創建促銷，merchantID 不為 nil 時表示商戶建立，促銷範圍固定為該商戶
+func (s *PromotionService) CreatePromotion(req *models.PromotionRequest, merchantID *int) (*models.Promotion, error) {
+	promotion := &models.Promotion{IsActive: true}
+	if err := applyPromotionRequest(promotion, req, merchantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Create(promotion); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, models.ErrDuplicateCouponCode
+		}
+		return nil, fmt.Errorf("創建促銷失敗: %w", err)
+	}
+
+	return promotion, nil
+}
+
+// UpdatePromotion 更新促銷，merchantID 不為 nil 時只能更新該商戶的促銷
+func (s *PromotionService) UpdatePromotion(id int, req *models.PromotionRequest, merchantID *int) (*models.Promotion, error) {
+	promotion, err := s.promotionRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	if merchantID != nil && (promotion.MerchantID == nil || *promotion.MerchantID != *merchantID) {
+		return nil, models.ErrPromotionNotFound
+	}
+
+	if err := applyPromotionRequest(promotion, req, merchantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Update(promotion); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, models.ErrDuplicateCouponCode
+		}
+		return nil, fmt.Errorf("更新促銷失敗: %w", err)
+	}
+
+	return promotion, nil
+}
+
+// loadCoupon 讀取並檢查優惠券是否可用（不含最低消費）
+func (s *PromotionService) loadCoupon(code string, customerID int, now time.Time) (*models.Promotion, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	coupon, err := s.promotionRepo.GetByCode(code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrCouponNotFound
+		}
+		return nil, err
+	}
+	if err := s.checkAvailable(coupon, customerID, now); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// checkAvailable 檢查促銷是否啟用、在有效期間內且未超過使用次數
+func (s *PromotionService) checkAvailable(promotion *models.Promotion, customerID int, now time.Time) error {
+	if !promotion.IsActive || !promotion.InWindow(now) {
+		return &models.DomainError{Code: "PROMOTION_NOT_ACTIVE", Message: "優惠「" + promotion.Name + "」不在有效期間內"}
+	}
+	if promotion.UsageLimit > 0 && promotion.UsedCount >= promotion.UsageLimit {
+		return models.ErrPromotionLimitReached
+	}
+	if promotion.PerCustomerLimit > 0 {
+		count, err := s.promotionRepo.CountCustomerRedemptions(promotion.ID, customerID)
+		if err != nil {
+			return err
+		}
+		if count >= promotion.PerCustomerLimit {
+			return models.ErrPromotionLimitReached
+		}
+	}
+	return nil
+}
+
+// bestPromotion 從候選促銷中挑出可用且折扣最高的一個
+func (s *PromotionService) bestPromotion(candidates []*models.Promotion, customerID int, subtotal, amount float64, now time.Time) *models.Promotion {
+	var best *models.Promotion
+	bestDiscount := 0.0
+	for _, promotion := range candidates {
+		if checkMinSpend(promotion, subtotal) != nil {
+			continue
+		}
+		if s.checkAvailable(promotion, customerID, now) != nil {
+			continue
+		}
+		if discount := promotion.Discount(amount); discount > bestDiscount {
+			best = promotion
+			bestDiscount = discount
+		}
+	}
+	return best
+}
+
+// apply 套用促銷並將折扣依各商戶剩餘金額比例分攤
+func (s *PromotionService) apply(pricing *CartPricing, promotion *models.Promotion, merchantIDs []int, remaining map[int]float64) {
+	base := 0.0
+	for _, merchantID := range merchantIDs {
+		base += remaining[merchantID]
+	}
+	discount := roundAmount(promotion.Discount(base))
+	if discount <= 0 {
+		return
+	}
+
+	allocated := 0.0
+	for i, merchantID := range merchantIDs {
+		share := roundAmount(discount * remaining[merchantID] / base)
+		if i == len(merchantIDs)-1 {
+			share = roundAmount(discount - allocated)
+		}
+		if share > remaining[merchantID] {
+			share = remaining[merchantID]
+		}
+		allocated += share
+		remaining[merchantID] = roundAmount(remaining[merchantID] - share)
+		pricing.MerchantDiscounts[merchantID] = roundAmount(pricing.MerchantDiscounts[merchantID] + share)
+	}
+
+	// 最後一個商戶的尾差超過其剩餘金額時只折抵到零，折扣以實際分攤的金額為準，與各子訂單的折扣加總一致
+	discount = roundAmount(allocated)
+	if discount <= 0 {
+		return
+	}
+
+	pricing.DiscountAmount += discount
+	pricing.Promotions = append(pricing.Promotions, models.AppliedPromotion{
+		PromotionID:    promotion.ID,
+		Code:           promotion.Code,
+		Name:           promotion.Name,
+		MerchantID:     promotion.MerchantID,
+		DiscountAmount: discount,
+	})
+	pricing.redemption = append(pricing.redemption, promotionRedemption{promotion: promotion, discount: discount})
+}
+
+// checkMinSpend 檢查是否達到最低消費
+func checkMinSpend(promotion *models.Promotion, subtotal float64) error {
+	if subtotal < promotion.MinSpend {
+		return &models.DomainError{
+			Code:    "MIN_SPEND_NOT_MET",
+			Message: fmt.Sprintf("優惠「%s」需消費滿 %.0f 元", promotion.Name, promotion.MinSpend),
+		}
+	}
+	return nil
+}
+
+// sumAmounts 加總金額
+func sumAmounts(amounts map[int]float64) float64 {
+	total := 0.0
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+// applyPromotionRequest 驗證請求並寫入促銷欄位
+func applyPromotionRequest(promotion *models.Promotion, req *models.PromotionRequest, merchantID *int) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return &models.DomainError{Code: "INVALID_PROMOTION", Message: "促銷名稱不能為空"}
+	}
+	switch req.DiscountType {
+	case models.DiscountTypePercentage:
+		if req.DiscountValue <= 0 || req.DiscountValue > 100 {
+			return &models.DomainError{Code: "INVALID_PROMOTION", Message: "百分比折扣需介於 0 到 100 之間"}
+		}
+	case models.DiscountTypeFixed:
+		if req.DiscountValue <= 0 {
+			return &models.DomainError{Code: "INVALID_PROMOTION", Message: "折扣金額必須大於0"}
+		}
+	default:
+		return &models.DomainError{Code: "INVALID_PROMOTION", Message: "無效的折扣類型"}
+	}
+	if req.MinSpend < 0 || req.UsageLimit < 0 || req.PerCustomerLimit < 0 {
+		return &models.DomainError{Code: "INVALID_PROMOTION", Message: "最低消費與使用次數不可為負數"}
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return &models.DomainError{Code: "INVALID_PROMOTION", Message: "結束時間必須晚於開始時間"}
+	}
+
+	promotion.Code = nil
+	if req.Code != nil {
+		if code := models.NormalizeCouponCode(*req.Code); code != "" {
+			promotion.Code = &code
+		}
+	}
+	promotion.Name = strings.TrimSpace(req.Name)
+	promotion.Description = req.Description
+	promotion.DiscountType = req.DiscountType
+	promotion.DiscountValue = req.DiscountValue
+	promotion.MaxDiscount = req.MaxDiscount
+	promotion.MinSpend = req.MinSpend
+	promotion.UsageLimit = req.UsageLimit
+	promotion.PerCustomerLimit = req.PerCustomerLimit
+	promotion.StartsAt = req.StartsAt
+	promotion.EndsAt = req.EndsAt
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+
+	// 商戶只能建立自己的促銷，管理員可指定商戶或留空為全站
+	if merchantID != nil {
+		promotion.MerchantID = merchantID
+	} else {
+		promotion.MerchantID = req.MerchantID
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"go-simple-app/models"
+)
+
+func TestPromotionApplyAllocation(t *testing.T) {
+	maxDiscount := 40.0
+	fixed := func(value float64) *models.Promotion {
+		return &models.Promotion{ID: 1, Name: "固定折扣", DiscountType: models.DiscountTypeFixed, DiscountValue: value}
+	}
+	percentage := func(value float64, max *float64) *models.Promotion {
+		return &models.Promotion{ID: 2, Name: "百分比折扣", DiscountType: models.DiscountTypePercentage, DiscountValue: value, MaxDiscount: max}
+	}
+
+	tests := []struct {
+		name          string
+		promotion     *models.Promotion
+		merchantIDs   []int
+		remaining     map[int]float64
+		wantDiscount  float64
+		wantShares    map[int]float64
+		wantRemaining map[int]float64
+	}{
+		{
+			name:          "固定金額依比例分攤",
+			promotion:     fixed(100),
+			merchantIDs:   []int{1, 2},
+			remaining:     map[int]float64{1: 300, 2: 700},
+			wantDiscount:  100,
+			wantShares:    map[int]float64{1: 30, 2: 70},
+			wantRemaining: map[int]float64{1: 270, 2: 630},
+		},
+		{
+			name:          "無法整除時尾差由最後一個商戶吸收",
+			promotion:     fixed(100),
+			merchantIDs:   []int{1, 2, 3},
+			remaining:     map[int]float64{1: 100, 2: 100, 3: 100},
+			wantDiscount:  100,
+			wantShares:    map[int]float64{1: 33.33, 2: 33.33, 3: 33.34},
+			wantRemaining: map[int]float64{1: 66.67, 2: 66.67, 3: 66.66},
+		},
+		{
+			name:          "百分比折扣四捨五入到分",
+			promotion:     percentage(10, nil),
+			merchantIDs:   []int{1, 2},
+			remaining:     map[int]float64{1: 33.33, 2: 66.67},
+			wantDiscount:  10,
+			wantShares:    map[int]float64{1: 3.33, 2: 6.67},
+			wantRemaining: map[int]float64{1: 30, 2: 60},
+		},
+		{
+			name:          "百分比折扣受上限限制",
+			promotion:     percentage(50, &maxDiscount),
+			merchantIDs:   []int{1, 2},
+			remaining:     map[int]float64{1: 50, 2: 150},
+			wantDiscount:  40,
+			wantShares:    map[int]float64{1: 10, 2: 30},
+			wantRemaining: map[int]float64{1: 40, 2: 120},
+		},
+		{
+			name:          "固定折扣超過金額時以金額為上限",
+			promotion:     fixed(500),
+			merchantIDs:   []int{1, 2},
+			remaining:     map[int]float64{1: 100, 2: 200},
+			wantDiscount:  300,
+			wantShares:    map[int]float64{1: 100, 2: 200},
+			wantRemaining: map[int]float64{1: 0, 2: 0},
+		},
+		{
+			name:          "小額商戶分攤為零",
+			promotion:     fixed(10),
+			merchantIDs:   []int{1, 2},
+			remaining:     map[int]float64{1: 0.01, 2: 99.99},
+			wantDiscount:  10,
+			wantShares:    map[int]float64{1: 0, 2: 10},
+			wantRemaining: map[int]float64{1: 0.01, 2: 89.99},
+		},
+		{
+			// 前三個商戶的分攤都捨去不到一分，尾差 0.02 超過最後一個商戶剩餘的 0.01
+			name:          "尾差超過最後商戶剩餘金額時以實際分攤為準",
+			promotion:     fixed(2),
+			merchantIDs:   []int{1, 2, 3, 4},
+			remaining:     map[int]float64{1: 1, 2: 1, 3: 1, 4: 0.01},
+			wantDiscount:  1.99,
+			wantShares:    map[int]float64{1: 0.66, 2: 0.66, 3: 0.66, 4: 0.01},
+			wantRemaining: map[int]float64{1: 0.34, 2: 0.34, 3: 0.34, 4: 0},
+		},
+		{
+			name:          "金額為零時不套用",
+			promotion:     fixed(100),
+			merchantIDs:   []int{1},
+			remaining:     map[int]float64{1: 0},
+			wantDiscount:  0,
+			wantShares:    map[int]float64{},
+			wantRemaining: map[int]float64{1: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PromotionService{}
+			pricing := &CartPricing{MerchantDiscounts: make(map[int]float64)}
+			s.apply(pricing, tt.promotion, tt.merchantIDs, tt.remaining)
+
+			if !amountEqual(pricing.DiscountAmount, tt.wantDiscount) {
+				t.Errorf("DiscountAmount = %.2f, want %.2f", pricing.DiscountAmount, tt.wantDiscount)
+			}
+			if tt.wantDiscount == 0 {
+				if len(pricing.Promotions) != 0 || len(pricing.redemption) != 0 {
+					t.Errorf("zero discount should not record promotion, got %d applied", len(pricing.Promotions))
+				}
+				return
+			}
+			if len(pricing.Promotions) != 1 || !amountEqual(pricing.Promotions[0].DiscountAmount, tt.wantDiscount) {
+				t.Errorf("Promotions = %+v, want one with discount %.2f", pricing.Promotions, tt.wantDiscount)
+			}
+
+			allocated := 0.0
+			for _, merchantID := range tt.merchantIDs {
+				share := pricing.MerchantDiscounts[merchantID]
+				allocated += share
+				if !amountEqual(share, tt.wantShares[merchantID]) {
+					t.Errorf("merchant %d share = %.2f, want %.2f", merchantID, share, tt.wantShares[merchantID])
+				}
+				if !amountEqual(tt.remaining[merchantID], tt.wantRemaining[merchantID]) {
+					t.Errorf("merchant %d remaining = %.2f, want %.2f", merchantID, tt.remaining[merchantID], tt.wantRemaining[merchantID])
+				}
+			}
+			if !amountEqual(allocated, tt.wantDiscount) {
+				t.Errorf("allocated shares sum to %.2f, want %.2f", allocated, tt.wantDiscount)
+			}
+		})
+	}
+}
+
+func TestPromotionApplyStacksAcrossScopes(t *testing.T) {
+	merchantID := 1
+	merchantPromotion := &models.Promotion{ID: 1, Name: "商戶折扣", MerchantID: &merchantID,
+		DiscountType: models.DiscountTypeFixed, DiscountValue: 50}
+	siteCoupon := &models.Promotion{ID: 2, Name: "全站九折", DiscountType: models.DiscountTypePercentage, DiscountValue: 10}
+
+	s := &PromotionService{}
+	pricing := &CartPricing{MerchantDiscounts: make(map[int]float64)}
+	remaining := map[int]float64{1: 550, 2: 450}
+
+	// 商戶促銷先套用，全站優惠以扣除後的金額計算
+	s.apply(pricing, merchantPromotion, []int{1}, remaining)
+	s.apply(pricing, siteCoupon, []int{1, 2}, remaining)
+
+	if !amountEqual(pricing.DiscountAmount, 145) {
+		t.Errorf("DiscountAmount = %.2f, want 145", pricing.DiscountAmount)
+	}
+	want := map[int]float64{1: 100, 2: 45}
+	for id, share := range want {
+		if !amountEqual(pricing.MerchantDiscounts[id], share) {
+			t.Errorf("merchant %d discount = %.2f, want %.2f", id, pricing.MerchantDiscounts[id], share)
+		}
+	}
+	if len(pricing.redemption) != 2 {
+		t.Errorf("redemption count = %d, want 2", len(pricing.redemption))
+	}
+}
+
+func amountEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+func TestCheckoutPromotionRedemption(t *testing.T) {
+	shop := newTestShop(t)
+	customerID := shop.customer(t)
+	merchantA := shop.merchant(t)
+	merchantB := shop.merchant(t)
+	productA := shop.product(t, merchantA, 300, 10)
+	productB := shop.product(t, merchantB, 600, 10)
+
+	merchantPromotion := shop.exec(t, `INSERT INTO promotions (name, merchant_id, discount_type, discount_value)
+		VALUES ('商戶折 50', ?, ?, 50)`, merchantA, models.DiscountTypeFixed)
+	coupon := shop.exec(t, `INSERT INTO promotions (code, name, discount_type, discount_value)
+		VALUES ('SAVE10', '全站九折', ?, 10)`, models.DiscountTypePercentage)
+
+	shop.addToCart(t, customerID, productA, 1, productB, 1)
+	if err := shop.carts.ApplyCoupon(customerID, "SAVE10"); err != nil {
+		t.Fatalf("ApplyCoupon: %v", err)
+	}
+	group := shop.checkout(t, customerID)
+
+	// 商戶促銷先折 50，全站九折以 250 + 600 計算為 85，依比例分攤 25 / 60
+	wantDiscounts := map[int]float64{merchantA: 75, merchantB: 60}
+	total := 0.0
+	for _, order := range group.Orders {
+		total += order.DiscountAmount
+		if !amountEqual(order.DiscountAmount, wantDiscounts[order.MerchantID]) {
+			t.Errorf("merchant %d discount = %.2f, want %.2f", order.MerchantID, order.DiscountAmount, wantDiscounts[order.MerchantID])
+		}
+	}
+	if !amountEqual(group.DiscountAmount, 135) || !amountEqual(total, group.DiscountAmount) {
+		t.Errorf("group discount = %.2f, sub-orders sum = %.2f, want 135", group.DiscountAmount, total)
+	}
+
+	usedCount := func(promotionID int) int {
+		var count int
+		shop.scalar(t, &count, `SELECT used_count FROM promotions WHERE id = ?`, promotionID)
+		return count
+	}
+	orderOf := func(merchantID int) int {
+		for _, order := range group.Orders {
+			if order.MerchantID == merchantID {
+				return order.ID
+			}
+		}
+		t.Fatalf("no sub-order for merchant %d", merchantID)
+		return 0
+	}
+
+	steps := []struct {
+		name         string
+		cancel       int
+		wantMerchant int
+		wantCoupon   int
+	}{
+		{"下單後", 0, 1, 1},
+		// 商戶促銷隨子訂單撤銷，全站優惠券在群組仍有未取消的子訂單時保留
+		{"取消商戶 A 子訂單", orderOf(merchantA), 0, 1},
+		{"取消全部子訂單", orderOf(merchantB), 0, 0},
+	}
+	for _, step := range steps {
+		if step.cancel > 0 {
+			if _, err := shop.orders.CancelCustomerOrder(customerID, step.cancel, nil); err != nil {
+				t.Fatalf("%s: CancelCustomerOrder: %v", step.name, err)
+			}
+		}
+		if got := usedCount(merchantPromotion); got != step.wantMerchant {
+			t.Errorf("%s: merchant promotion used_count = %d, want %d", step.name, got, step.wantMerchant)
+		}
+		if got := usedCount(coupon); got != step.wantCoupon {
+			t.Errorf("%s: coupon used_count = %d, want %d", step.name, got, step.wantCoupon)
+		}
+	}
+
+	var redemptions int
+	shop.scalar(t, &redemptions, `SELECT COUNT(*) FROM promotion_redemptions WHERE group_id = ?`, group.ID)
+	if redemptions != 0 {
+		t.Errorf("redemptions after cancelling the group = %d, want 0", redemptions)
+	}
+}
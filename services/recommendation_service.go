@@ -0,0 +1,100 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecommendationService 商品推薦批次計算服務
+// 背景排程定期依共同購買、共同加入購物車與共同瀏覽重算相關商品與個人化推薦
+type RecommendationService struct {
+	recommendationRepo *models.RecommendationRepository
+	interval           time.Duration
+	viewWindowDays     int
+	maxItems           int
+	ticker             *time.Ticker
+	stopChan           chan bool
+}
+
+// NewRecommendationService 創建商品推薦服務
+func NewRecommendationService(db *sql.DB, cfg config.RecommendationConfig) *RecommendationService {
+	interval := time.Duration(cfg.RefreshIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	viewWindowDays := cfg.ViewWindowDays
+	if viewWindowDays <= 0 {
+		viewWindowDays = 90
+	}
+	maxItems := cfg.MaxItems
+	if maxItems <= 0 {
+		maxItems = 20
+	}
+
+	return &RecommendationService{
+		recommendationRepo: models.NewRecommendationRepository(db),
+		interval:           interval,
+		viewWindowDays:     viewWindowDays,
+		maxItems:           maxItems,
+		stopChan:           make(chan bool),
+	}
+}
+
+// Recompute 重算所有相關商品與個人化推薦
+func (s *RecommendationService) Recompute() (*models.RecommendationStats, error) {
+	start := time.Now()
+	stats, err := s.recommendationRepo.Recompute(s.viewWindowDays, s.maxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("商品推薦已重算", logrus.Fields{
+		"relations":       stats.Relations,
+		"recommendations": stats.Recommendations,
+		"duration":        time.Since(start).String(),
+	})
+	return stats, nil
+}
+
+// StartScheduler 啟動背景排程，啟動時先重算一次
+func (s *RecommendationService) StartScheduler() {
+	s.ticker = time.NewTicker(s.interval)
+
+	go func() {
+		if _, err := s.Recompute(); err != nil {
+			logger.Error("商品推薦重算失敗", err)
+		}
+		for {
+			select {
+			case <-s.ticker.C:
+				if _, err := s.Recompute(); err != nil {
+					logger.Error("商品推薦重算失敗", err)
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	logger.Info("商品推薦排程已啟動", logrus.Fields{
+		"interval":         s.interval.String(),
+		"view_window_days": s.viewWindowDays,
+	})
+}
+
+// StopScheduler 停止背景排程
+func (s *RecommendationService) StopScheduler() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	select {
+	case s.stopChan <- true:
+	default:
+	}
+}
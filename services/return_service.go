@@ -0,0 +1,640 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReturnService 退貨退款業務邏輯服務
+// 客戶依訂單商品申請退貨，商戶（或管理員）核准後回補庫存並經金流退款；
+// 每個步驟都寫入退貨稽核紀錄，退款失敗時申請維持 approved，可重試退款
+type ReturnService struct {
+	db            *sql.DB
+	config        config.ReturnConfig
+	returnRepo    *models.ReturnRepository
+	orderRepo     *models.OrderRepository
+	inventory     *InventoryService
+	payments      *PaymentService
+	storage       ObjectStorage
+	maxPhotoBytes int64
+	// refundPendingTimeout 退款中超過此時間仍未記錄結果時可重試
+	refundPendingTimeout time.Duration
+}
+
+// NewReturnService 創建退貨退款服務
+func NewReturnService(db *sql.DB, cfg config.ReturnConfig, inventory *InventoryService, payments *PaymentService, storage ObjectStorage) *ReturnService {
+	maxPhotoBytes := int64(cfg.MaxPhotoMB) << 20
+	if maxPhotoBytes <= 0 {
+		maxPhotoBytes = 5 << 20
+	}
+	refundPendingTimeout := time.Duration(cfg.RefundPendingTimeoutMinutes) * time.Minute
+	if refundPendingTimeout <= 0 {
+		refundPendingTimeout = 10 * time.Minute
+	}
+
+	return &ReturnService{
+		db:                   db,
+		config:               cfg,
+		returnRepo:           models.NewReturnRepository(db),
+		orderRepo:            models.NewOrderRepository(db),
+		inventory:            inventory,
+		payments:             payments,
+		storage:              storage,
+		maxPhotoBytes:        maxPhotoBytes,
+		refundPendingTimeout: refundPendingTimeout,
+	}
+}
+
+// MaxPhotoBytes 單張退貨照片大小上限
+func (s *ReturnService) MaxPhotoBytes() int64 {
+	return s.maxPhotoBytes
+}
+
+// CreateReturn 客戶為已送達的訂單申請退貨
+// 退款金額依訂單折扣比例分攤到各商品，不含運費
+func (s *ReturnService) CreateReturn(customerID int, req *models.CreateReturnRequest) (*models.ReturnRequest, error) {
+	order, err := s.orderRepo.GetByID(req.OrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, models.ErrOrderNotFound
+	}
+	if err := s.checkEligible(order); err != nil {
+		return nil, err
+	}
+
+	orderItems, err := s.orderRepo.GetItems(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	itemsByID := map[int]models.OrderItem{}
+	subtotal := 0.0
+	for _, item := range orderItems {
+		itemsByID[item.ID] = item
+		subtotal += item.TotalPrice
+	}
+	// 折扣依商品金額比例分攤
+	ratio := 1.0
+	if subtotal > 0 && order.DiscountAmount > 0 {
+		ratio = (subtotal - order.DiscountAmount) / subtotal
+		if ratio < 0 {
+			ratio = 0
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	returned, err := s.returnRepo.ReturnedQuantitiesTx(tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.ReturnRequest{
+		ReturnNumber: generateReturnNumber(),
+		OrderID:      order.ID,
+		CustomerID:   customerID,
+		MerchantID:   order.MerchantID,
+		Status:       models.ReturnStatusRequested,
+		CustomerNote: trimmedNote(req.Note),
+	}
+	seen := map[int]bool{}
+	for _, input := range req.Items {
+		orderItem, ok := itemsByID[input.OrderItemID]
+		if !ok {
+			return nil, &models.DomainError{Code: "INVALID_RETURN_ITEM", Message: fmt.Sprintf("訂單中沒有商品 %d", input.OrderItemID)}
+		}
+		if seen[input.OrderItemID] {
+			return nil, &models.DomainError{Code: "INVALID_RETURN_ITEM", Message: "「" + orderItem.ProductName + "」重複申請"}
+		}
+		seen[input.OrderItemID] = true
+
+		if !models.IsValidReturnReason(input.Reason) {
+			return nil, &models.DomainError{Code: "INVALID_RETURN_REASON", Message: "無效的退貨原因: " + input.Reason}
+		}
+		detail := trimmedNote(input.ReasonDetail)
+		if input.Reason == models.ReturnReasonOther && detail == nil {
+			return nil, &models.DomainError{Code: "INVALID_RETURN_REASON", Message: "退貨原因為其他時需填寫說明"}
+		}
+
+		remaining := orderItem.Quantity - returned[orderItem.ID]
+		if input.Quantity > remaining {
+			return nil, models.ErrReturnQuantity.WithMessage(fmt.Sprintf("「%s」最多可退 %d 件", orderItem.ProductName, max(remaining, 0)))
+		}
+
+		amount := roundAmount(orderItem.TotalPrice * float64(input.Quantity) / float64(orderItem.Quantity) * ratio)
+		ret.Items = append(ret.Items, &models.ReturnItem{
+			OrderItemID:  orderItem.ID,
+			ProductID:    orderItem.ProductID,
+			VariantID:    orderItem.VariantID,
+			ProductName:  orderItem.ProductName,
+			Quantity:     input.Quantity,
+			Reason:       input.Reason,
+			ReasonDetail: detail,
+			RefundAmount: amount,
+		})
+		ret.RefundAmount += amount
+	}
+	ret.RefundAmount = roundAmount(ret.RefundAmount)
+
+	if err := s.returnRepo.CreateTx(tx, ret); err != nil {
+		return nil, err
+	}
+	err = s.returnRepo.AddEventTx(tx, &models.ReturnEvent{
+		ReturnID:  ret.ID,
+		Action:    models.ReturnActionRequested,
+		ToStatus:  models.ReturnStatusRequested,
+		ActorType: models.OrderActorCustomer,
+		ActorID:   customerID,
+		Note:      ret.CustomerNote,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	logger.Info("客戶申請退貨", logrus.Fields{
+		"return_number": ret.ReturnNumber,
+		"order_id":      order.ID,
+		"customer_id":   customerID,
+		"refund_amount": ret.RefundAmount,
+	})
+
+	return s.loadReturn(ret.ID, false)
+}
+
+// checkEligible 檢查訂單是否可申請退貨：需已送達（或已完成）且在退貨期限內
+func (s *ReturnService) checkEligible(order *models.Order) error {
+	if order.Status != models.OrderStatusDelivered && order.Status != models.OrderStatusCompleted {
+		return models.ErrReturnNotEligible
+	}
+	if s.config.WindowDays <= 0 {
+		return nil
+	}
+
+	deliveredAt, err := s.returnRepo.DeliveredAt(order.ID)
+	if err != nil {
+		return err
+	}
+	if deliveredAt == nil || time.Since(*deliveredAt) > time.Duration(s.config.WindowDays)*24*time.Hour {
+		return models.ErrReturnNotEligible.WithMessage(fmt.Sprintf("已超過送達後 %d 天的退貨期限", s.config.WindowDays))
+	}
+	return nil
+}
+
+// ListCustomerReturns 獲取客戶的退貨申請列表
+func (s *ReturnService) ListCustomerReturns(customerID int, status string, limit, offset int) ([]*models.ReturnRequest, int, error) {
+	return s.list(models.ReturnFilter{CustomerID: &customerID, Status: status}, limit, offset)
+}
+
+// GetCustomerReturn 獲取客戶的退貨申請（含商品、照片與處理紀錄）
+func (s *ReturnService) GetCustomerReturn(customerID, id int) (*models.ReturnRequest, error) {
+	ret, err := s.loadReturn(id, false)
+	if err != nil {
+		return nil, err
+	}
+	if ret.CustomerID != customerID {
+		return nil, models.ErrReturnNotFound
+	}
+	return ret, nil
+}
+
+// CancelCustomerReturn 客戶取消尚未審核的退貨申請
+func (s *ReturnService) CancelCustomerReturn(customerID, id int, note *string) (*models.ReturnRequest, error) {
+	if _, err := s.GetCustomerReturn(customerID, id); err != nil {
+		return nil, err
+	}
+
+	err := s.transition(id, models.ReturnStatusRequested, models.ReturnStatusCancelled, &models.ReturnEvent{
+		Action:    models.ReturnActionCancelled,
+		ActorType: models.OrderActorCustomer,
+		ActorID:   customerID,
+		Note:      trimmedNote(note),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loadReturn(id, false)
+}
+
+// AddPhoto 客戶為退貨商品上傳照片，只能在申請審核前上傳
+func (s *ReturnService) AddPhoto(ctx context.Context, customerID, returnID, itemID int, r io.Reader) (*models.ReturnPhoto, error) {
+	ret, err := s.GetCustomerReturn(customerID, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != models.ReturnStatusRequested {
+		return nil, models.ErrReturnStatusConflict
+	}
+	var item *models.ReturnItem
+	for _, candidate := range ret.Items {
+		if candidate.ID == itemID {
+			item = candidate
+		}
+	}
+	if item == nil {
+		return nil, models.ErrReturnItemNotFound
+	}
+	if len(item.Photos) >= models.MaxReturnPhotosPerItem {
+		return nil, models.ErrTooManyReturnPhotos
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxPhotoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxPhotoBytes {
+		return nil, &models.DomainError{Code: "IMAGE_TOO_LARGE", Message: fmt.Sprintf("照片不可超過 %dMB", s.maxPhotoBytes>>20)}
+	}
+
+	// 依檔案內容判斷格式，不信任副檔名與 Content-Type
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, &models.DomainError{Code: "INVALID_IMAGE", Message: "僅支援 JPEG、PNG、GIF 照片"}
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, &models.DomainError{Code: "INVALID_IMAGE", Message: "無法辨識的照片檔案"}
+	}
+	if imageTooLarge(cfg) {
+		return nil, &models.DomainError{Code: "IMAGE_TOO_LARGE", Message: fmt.Sprintf("照片不可超過 %d 萬像素", maxImagePixels/10000)}
+	}
+
+	photo := &models.ReturnPhoto{
+		ReturnItemID: item.ID,
+		Storage:      s.storage.Name(),
+		StorageKey:   fmt.Sprintf("returns/%d/%s.%s", ret.ID, randomKey(), ext),
+		ContentType:  contentType,
+		SizeBytes:    len(data),
+	}
+	if err := s.storage.Put(ctx, photo.StorageKey, contentType, data); err != nil {
+		return nil, err
+	}
+	photo.URL = s.storage.URL(photo.StorageKey)
+
+	cleanup := func() {
+		if err := s.storage.Delete(context.Background(), photo.StorageKey); err != nil {
+			logger.Error("清除上傳失敗的退貨照片失敗", err, logrus.Fields{"key": photo.StorageKey})
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.returnRepo.CreatePhotoTx(tx, photo); err != nil {
+		cleanup()
+		return nil, err
+	}
+	note := item.ProductName + " " + photo.URL
+	err = s.returnRepo.AddEventTx(tx, &models.ReturnEvent{
+		ReturnID:   ret.ID,
+		Action:     models.ReturnActionPhotoAdded,
+		FromStatus: &ret.Status,
+		ToStatus:   ret.Status,
+		ActorType:  models.OrderActorCustomer,
+		ActorID:    customerID,
+		Note:       &note,
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+// ListReturns 獲取退貨申請列表，merchantID 為 nil 時（管理員）列出所有商戶
+func (s *ReturnService) ListReturns(merchantID *int, status string, limit, offset int) ([]*models.ReturnRequest, int, error) {
+	return s.list(models.ReturnFilter{MerchantID: merchantID, Status: status}, limit, offset)
+}
+
+// GetReturn 獲取退貨申請詳情，merchantID 為 nil 時（管理員）可檢視任一申請並帶出金流退款紀錄
+func (s *ReturnService) GetReturn(merchantID *int, id int) (*models.ReturnRequest, error) {
+	ret, err := s.loadReturn(id, merchantID == nil)
+	if err != nil {
+		return nil, err
+	}
+	if merchantID != nil && ret.MerchantID != *merchantID {
+		return nil, models.ErrReturnNotFound
+	}
+	return ret, nil
+}
+
+// ApproveReturn 核准退貨：預設回補庫存，接著經金流退款
+// 退款失敗不會回滾核准，申請維持 approved 且 refund_status 為 failed，可透過 RetryRefund 重試
+func (s *ReturnService) ApproveReturn(ctx context.Context, merchantID *int, actorType string, actorID, id int, req *models.ReturnDecisionRequest) (*models.ReturnRequest, error) {
+	ret, err := s.GetReturn(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != models.ReturnStatusRequested {
+		return nil, models.ErrReturnStatusConflict
+	}
+
+	refundStatus := models.ReturnRefundPending
+	ret.Status = models.ReturnStatusApproved
+	ret.RefundStatus = &refundStatus
+	ret.Restock = req.Restock == nil || *req.Restock
+	ret.ResolutionNote = trimmedNote(req.Note)
+	ret.ResolvedByType = &actorType
+	ret.ResolvedByID = &actorID
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.returnRepo.ResolveTx(tx, ret); err != nil {
+		return nil, err
+	}
+	from := models.ReturnStatusRequested
+	err = s.returnRepo.AddEventTx(tx, &models.ReturnEvent{
+		ReturnID:   ret.ID,
+		Action:     models.ReturnActionApproved,
+		FromStatus: &from,
+		ToStatus:   models.ReturnStatusApproved,
+		ActorType:  actorType,
+		ActorID:    actorID,
+		Note:       ret.ResolutionNote,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Restock {
+		items, err := s.returnRepo.GetItemsTx(tx, ret.ID)
+		if err != nil {
+			return nil, err
+		}
+		restocked, err := s.inventory.RestockReturnTx(tx, ret.ID, items, actorType, actorID)
+		if err != nil {
+			return nil, err
+		}
+		note := fmt.Sprintf("回補庫存 %d 件", restocked)
+		err = s.returnRepo.AddEventTx(tx, &models.ReturnEvent{
+			ReturnID:   ret.ID,
+			Action:     models.ReturnActionRestocked,
+			FromStatus: &ret.Status,
+			ToStatus:   ret.Status,
+			ActorType:  actorType,
+			ActorID:    actorID,
+			Note:       &note,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := s.refund(ctx, ret, actorType, actorID); err != nil {
+		return nil, err
+	}
+
+	return s.GetReturn(merchantID, id)
+}
+
+// RejectReturn 拒絕退貨，需填寫原因
+func (s *ReturnService) RejectReturn(merchantID *int, actorType string, actorID, id int, req *models.ReturnDecisionRequest) (*models.ReturnRequest, error) {
+	note := trimmedNote(req.Note)
+	if note == nil {
+		return nil, &models.DomainError{Code: "INVALID_RETURN_NOTE", Message: "拒絕退貨需填寫原因"}
+	}
+
+	ret, err := s.GetReturn(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != models.ReturnStatusRequested {
+		return nil, models.ErrReturnStatusConflict
+	}
+
+	ret.Status = models.ReturnStatusRejected
+	ret.ResolutionNote = note
+	ret.ResolvedByType = &actorType
+	ret.ResolvedByID = &actorID
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.returnRepo.ResolveTx(tx, ret); err != nil {
+		return nil, err
+	}
+	from := models.ReturnStatusRequested
+	err = s.returnRepo.AddEventTx(tx, &models.ReturnEvent{
+		ReturnID:   ret.ID,
+		Action:     models.ReturnActionRejected,
+		FromStatus: &from,
+		ToStatus:   models.ReturnStatusRejected,
+		ActorType:  actorType,
+		ActorID:    actorID,
+		Note:       note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetReturn(merchantID, id)
+}
+
+// RetryRefund 重新發起退款失敗、或退款中逾時未完成的已核准退貨
+// 金流退款以 return:<id> 為冪等鍵，先前已退款成功時不會重複退款
+func (s *ReturnService) RetryRefund(ctx context.Context, merchantID *int, actorType string, actorID, id int) (*models.ReturnRequest, error) {
+	ret, err := s.GetReturn(merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	// 以條件式更新取得重試權，同時送出的重試只有一個會向金流商退款
+	if err := s.returnRepo.ClaimRefundRetry(ret.ID, s.refundPendingTimeout); err != nil {
+		if err == models.ErrReturnStatusConflict {
+			return nil, models.ErrReturnStatusConflict.WithMessage("只有退款失敗或退款逾時未完成的退貨可重試退款")
+		}
+		return nil, err
+	}
+
+	if err := s.refund(ctx, ret, actorType, actorID); err != nil {
+		return nil, err
+	}
+
+	return s.GetReturn(merchantID, id)
+}
+
+// refund 經金流退還退貨金額並記錄結果；金流退款失敗只記錄於申請與稽核紀錄，不回傳錯誤
+// 呼叫前申請須已是退款中（核准或取得重試權時設定），金流退款以 return:<id> 為冪等鍵
+func (s *ReturnService) refund(ctx context.Context, ret *models.ReturnRequest, actorType string, actorID int) error {
+	order, err := s.orderRepo.GetByID(ret.OrderID)
+	if err != nil {
+		return err
+	}
+
+	var refund *models.PaymentRefund
+	var refundErr error = models.ErrPaymentNotFound
+	if order.GroupID != nil {
+		refund, err = s.payments.RefundGroupPayment(ctx, *order.GroupID, ret.RefundAmount, "退貨 "+ret.ReturnNumber,
+			models.MovementReferenceReturn, ret.ID)
+		refundErr = err
+	}
+
+	refundStatus := models.ReturnRefundSucceeded
+	action := models.ReturnActionRefundSucceeded
+	toStatus := models.ReturnStatusRefunded
+	var refundRef *string
+	note := fmt.Sprintf("退款 %.0f 元", ret.RefundAmount)
+	if refundErr != nil {
+		refundStatus = models.ReturnRefundFailed
+		action = models.ReturnActionRefundFailed
+		toStatus = models.ReturnStatusApproved
+		note = "退款失敗: " + refundErr.Error()
+	} else if refund.ProviderRef != nil {
+		refundRef = refund.ProviderRef
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.returnRepo.UpdateRefundTx(tx, ret.ID, refundStatus, refundRef); err != nil {
+		return err
+	}
+	from := models.ReturnStatusApproved
+	err = s.returnRepo.AddEventTx(tx, &models.ReturnEvent{
+		ReturnID:   ret.ID,
+		Action:     action,
+		FromStatus: &from,
+		ToStatus:   toStatus,
+		ActorType:  actorType,
+		ActorID:    actorID,
+		Note:       &note,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// transition 在交易中變更退貨申請狀態並寫入稽核紀錄
+func (s *ReturnService) transition(id int, from, to string, event *models.ReturnEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.returnRepo.UpdateStatusTx(tx, id, from, to); err != nil {
+		return err
+	}
+	event.ReturnID = id
+	event.FromStatus = &from
+	event.ToStatus = to
+	if err := s.returnRepo.AddEventTx(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// list 依條件獲取退貨申請列表
+func (s *ReturnService) list(filter models.ReturnFilter, limit, offset int) ([]*models.ReturnRequest, int, error) {
+	if filter.Status != "" && !models.IsValidReturnStatus(filter.Status) {
+		return nil, 0, &models.DomainError{Code: "INVALID_STATUS", Message: "無效的退貨狀態"}
+	}
+
+	returns, err := s.returnRepo.List(filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.returnRepo.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return returns, total, nil
+}
+
+// loadReturn 獲取退貨申請並帶出商品、照片與稽核紀錄，withRefunds 時一併帶出金流退款紀錄
+func (s *ReturnService) loadReturn(id int, withRefunds bool) (*models.ReturnRequest, error) {
+	ret, err := s.returnRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrReturnNotFound
+		}
+		return nil, err
+	}
+
+	if ret.Items, err = s.returnRepo.GetItems(id); err != nil {
+		return nil, err
+	}
+	if ret.Events, err = s.returnRepo.GetEvents(id); err != nil {
+		return nil, err
+	}
+	if withRefunds {
+		if ret.Refunds, err = s.payments.GetRefunds(models.MovementReferenceReturn, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// trimmedNote 去除前後空白，空字串視為未填寫
+func trimmedNote(note *string) *string {
+	if note == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*note)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// generateReturnNumber 產生退貨編號，例如 RMA202501021530451234
+func generateReturnNumber() string {
+	return fmt.Sprintf("RMA%s%04d", time.Now().Format("20060102150405"), rand.Intn(10000))
+}
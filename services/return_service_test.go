@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"go-simple-app/config"
+	"go-simple-app/models"
+)
+
+// pay 建立付款並送出付款成功回調
+func (s *testShop) pay(t *testing.T, customerID int, group *models.OrderGroup) *models.Payment {
+	t.Helper()
+	ctx := context.Background()
+	payment, _, err := s.payments.CreatePayment(ctx, customerID, group.ID)
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	header, body, err := s.provider.BuildCallback(payment, models.PaymentStatusPaid)
+	if err != nil {
+		t.Fatalf("BuildCallback: %v", err)
+	}
+	if _, err := s.payments.HandleCallback(ctx, s.provider.Name(), header, body); err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	return payment
+}
+
+// deliveredReturn 下單付款並送達後，為第一張子訂單的第一項商品申請退貨
+func (s *testShop) deliveredReturn(t *testing.T, returns *ReturnService, quantity int) (*models.ReturnRequest, *models.Payment, int) {
+	t.Helper()
+	customerID := s.customer(t)
+	merchantID := s.merchant(t)
+	productID := s.product(t, merchantID, 300, 10)
+	group := s.placeOrder(t, customerID, productID, 2)
+	payment := s.pay(t, customerID, group)
+	order := group.Orders[0]
+	s.exec(t, `UPDATE orders SET status = ? WHERE id = ?`, models.OrderStatusDelivered, order.ID)
+
+	ret, err := returns.CreateReturn(customerID, &models.CreateReturnRequest{
+		OrderID: order.ID,
+		Items: []models.ReturnItemRequest{
+			{OrderItemID: order.Items[0].ID, Quantity: quantity, Reason: models.ReturnReasonDefective},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateReturn: %v", err)
+	}
+	return ret, payment, merchantID
+}
+
+func TestApproveReturnRestocksAndRefunds(t *testing.T) {
+	ctx := context.Background()
+	shop := newTestShop(t)
+	returns := NewReturnService(shop.db, config.ReturnConfig{}, shop.inventory, shop.payments, nil)
+	ret, payment, merchantID := shop.deliveredReturn(t, returns, 1)
+	productID := ret.Items[0].ProductID
+
+	approved, err := returns.ApproveReturn(ctx, &merchantID, models.OrderActorMerchant, merchantID, ret.ID, &models.ReturnDecisionRequest{})
+	if err != nil {
+		t.Fatalf("ApproveReturn: %v", err)
+	}
+	if approved.Status != models.ReturnStatusRefunded {
+		t.Errorf("return status = %s, want %s", approved.Status, models.ReturnStatusRefunded)
+	}
+	if stock, _ := shop.stock(t, productID); stock != 9 {
+		t.Errorf("stock = %d, want 9 (10 - 2 sold + 1 returned)", stock)
+	}
+	stored, err := shop.payments.GetCustomerPayment(ret.CustomerID, payment.PaymentNumber)
+	if err != nil {
+		t.Fatalf("GetCustomerPayment: %v", err)
+	}
+	if !amountEqual(stored.RefundedAmount, 300) {
+		t.Errorf("refunded = %.2f, want 300", stored.RefundedAmount)
+	}
+
+	// 已核准的申請不能再次核准
+	if _, err := returns.ApproveReturn(ctx, &merchantID, models.OrderActorMerchant, merchantID, ret.ID, &models.ReturnDecisionRequest{}); err != models.ErrReturnStatusConflict {
+		t.Errorf("second ApproveReturn err = %v, want %v", err, models.ErrReturnStatusConflict)
+	}
+}
+
+func TestRetryRefund(t *testing.T) {
+	tests := []struct {
+		name             string
+		refundStatus     string // 核准後退款停留的狀態
+		updatedAgo       string // 退款狀態最後更新距今的時間
+		providerRefunded bool   // 中斷前金流商是否已退款成功
+		wantConflict     bool   // 是否無法取得重試權
+	}{
+		{name: "退款失敗", refundStatus: models.ReturnRefundFailed, updatedAgo: "-0 seconds"},
+		{name: "退款中逾時", refundStatus: models.ReturnRefundPending, updatedAgo: "-1 hours"},
+		{name: "金流已退款但未記錄結果", refundStatus: models.ReturnRefundPending, updatedAgo: "-1 hours", providerRefunded: true},
+		{name: "退款中未逾時", refundStatus: models.ReturnRefundPending, updatedAgo: "-0 seconds", wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop(t)
+			returns := NewReturnService(shop.db, config.ReturnConfig{RefundPendingTimeoutMinutes: 10}, shop.inventory, shop.payments, nil)
+			ret, payment, merchantID := shop.deliveredReturn(t, returns, 1)
+
+			// 模擬核准後退款處理中斷
+			shop.exec(t, `UPDATE return_requests SET status = ?, refund_status = ?, updated_at = datetime('now', ?) WHERE id = ?`,
+				models.ReturnStatusApproved, tt.refundStatus, tt.updatedAgo, ret.ID)
+			if tt.providerRefunded {
+				order, err := shop.orders.orderRepo.GetByID(ret.OrderID)
+				if err != nil {
+					t.Fatalf("GetByID: %v", err)
+				}
+				if _, err := shop.payments.RefundGroupPayment(ctx, *order.GroupID, ret.RefundAmount, "退貨 "+ret.ReturnNumber,
+					models.MovementReferenceReturn, ret.ID); err != nil {
+					t.Fatalf("RefundGroupPayment: %v", err)
+				}
+			}
+
+			retried, err := returns.RetryRefund(ctx, &merchantID, models.OrderActorMerchant, merchantID, ret.ID)
+			if tt.wantConflict {
+				domainErr, ok := err.(*models.DomainError)
+				if !ok || domainErr.Code != models.ErrReturnStatusConflict.Code {
+					t.Fatalf("RetryRefund err = %v, want %s", err, models.ErrReturnStatusConflict.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RetryRefund: %v", err)
+			}
+			if retried.Status != models.ReturnStatusRefunded {
+				t.Errorf("return status = %s, want %s", retried.Status, models.ReturnStatusRefunded)
+			}
+
+			// 以 return:<id> 為冪等鍵，重試不會重複退款
+			refunds, err := shop.payments.GetRefunds(models.MovementReferenceReturn, ret.ID)
+			if err != nil {
+				t.Fatalf("GetRefunds: %v", err)
+			}
+			if len(refunds) != 1 || refunds[0].Status != models.PaymentRefundSucceeded {
+				t.Errorf("refunds = %+v, want one succeeded refund", refunds)
+			}
+			stored, err := shop.payments.GetCustomerPayment(ret.CustomerID, payment.PaymentNumber)
+			if err != nil {
+				t.Fatalf("GetCustomerPayment: %v", err)
+			}
+			if !amountEqual(stored.RefundedAmount, ret.RefundAmount) {
+				t.Errorf("refunded = %.2f, want %.2f", stored.RefundedAmount, ret.RefundAmount)
+			}
+		})
+	}
+}
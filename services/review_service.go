@@ -0,0 +1,282 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+	"unicode/utf8"
+
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 評價內容長度上限（字元數）
+const (
+	maxReviewTitleLength   = 100
+	maxReviewContentLength = 2000
+	maxReviewReplyLength   = 1000
+)
+
+// ReviewService 商品評價業務邏輯服務
+// 評價的新增、修改與審核都在同一交易內重新計算商品的 rating 與 review_count
+type ReviewService struct {
+	db          *sql.DB
+	reviewRepo  *models.ReviewRepository
+	productRepo *models.ProductRepository
+}
+
+// NewReviewService 創建評價服務
+func NewReviewService(db *sql.DB) *ReviewService {
+	return &ReviewService{
+		db:          db,
+		reviewRepo:  models.NewReviewRepository(db),
+		productRepo: models.NewProductRepository(db),
+	}
+}
+
+// GetProductReviews 獲取商品已發佈的評價與統計
+func (s *ReviewService) GetProductReviews(productID, limit, offset int) ([]*models.ProductReview, *models.ReviewSummary, error) {
+	if _, err := s.getActiveProduct(productID); err != nil {
+		return nil, nil, err
+	}
+
+	reviews, err := s.reviewRepo.GetPublishedByProductID(productID, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary, err := s.reviewRepo.GetSummary(productID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reviews, summary, nil
+}
+
+// CreateReview 顧客評價已收到的商品，每個商品只能評價一次
+func (s *ReviewService) CreateReview(customerID, productID int, req *models.ReviewRequest) (*models.ProductReview, error) {
+	title, content, err := normalizeReviewRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getActiveProduct(productID); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.reviewRepo.ExistsForCustomer(productID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, models.ErrReviewExists
+	}
+
+	orderID, err := s.reviewRepo.FindEligibleOrderID(customerID, productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrReviewNotEligible
+		}
+		return nil, err
+	}
+
+	review := &models.ProductReview{
+		ProductID:  productID,
+		CustomerID: customerID,
+		OrderID:    &orderID,
+		Rating:     req.Rating,
+		Title:      title,
+		Content:    content,
+	}
+
+	return s.withRecompute(productID, func(tx *sql.Tx) (int, error) {
+		if err := s.reviewRepo.CreateTx(tx, review); err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return 0, models.ErrReviewExists
+			}
+			return 0, err
+		}
+		return review.ID, nil
+	})
+}
+
+// UpdateReview 顧客修改自己的評價
+func (s *ReviewService) UpdateReview(customerID, reviewID int, req *models.ReviewRequest) (*models.ProductReview, error) {
+	title, content, err := normalizeReviewRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.CustomerID != customerID {
+		return nil, models.ErrReviewNotFound
+	}
+
+	review.Rating = req.Rating
+	review.Title = title
+	review.Content = content
+
+	return s.withRecompute(review.ProductID, func(tx *sql.Tx) (int, error) {
+		return review.ID, s.reviewRepo.UpdateContentTx(tx, review)
+	})
+}
+
+// GetMerchantReviews 獲取商戶商品的評價
+func (s *ReviewService) GetMerchantReviews(merchantID, productID, limit, offset int) ([]*models.ProductReview, int, error) {
+	return s.reviewRepo.GetByMerchantID(merchantID, productID, limit, offset)
+}
+
+// ReplyReview 商戶回覆自己商品的評價，重複回覆會覆蓋原回覆
+func (s *ReviewService) ReplyReview(merchantID, reviewID int, req *models.ReviewReplyRequest) (*models.ProductReview, error) {
+	reply := strings.TrimSpace(req.Reply)
+	if reply == "" {
+		return nil, &models.DomainError{Code: "INVALID_REVIEW_REPLY", Message: "回覆內容不能為空"}
+	}
+	if utf8.RuneCountInString(reply) > maxReviewReplyLength {
+		return nil, &models.DomainError{Code: "INVALID_REVIEW_REPLY", Message: "回覆內容過長"}
+	}
+
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(review.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product.MerchantID != merchantID {
+		return nil, models.ErrReviewNotFound
+	}
+
+	if err := s.reviewRepo.SetReply(reviewID, reply); err != nil {
+		return nil, err
+	}
+
+	return s.reviewRepo.GetByID(reviewID)
+}
+
+// ListReviews 管理員獲取評價
+func (s *ReviewService) ListReviews(status string, limit, offset int) ([]*models.ProductReview, int, error) {
+	return s.reviewRepo.GetAll(status, limit, offset)
+}
+
+// ModerateReview 管理員隱藏或恢復評價
+func (s *ReviewService) ModerateReview(adminID, reviewID int, req *models.ReviewModerationRequest) (*models.ProductReview, error) {
+	if req.Status != models.ReviewStatusPublished && req.Status != models.ReviewStatusHidden {
+		return nil, &models.DomainError{Code: "INVALID_REVIEW_STATUS", Message: "無效的評價狀態"}
+	}
+
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	var note *string
+	if trimmed := strings.TrimSpace(req.Note); trimmed != "" {
+		note = &trimmed
+	}
+
+	moderated, err := s.withRecompute(review.ProductID, func(tx *sql.Tx) (int, error) {
+		return review.ID, s.reviewRepo.ModerateTx(tx, review.ID, req.Status, note, adminID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("評價審核狀態變更", logrus.Fields{
+		"review_id":  review.ID,
+		"product_id": review.ProductID,
+		"admin_id":   adminID,
+		"from":       review.Status,
+		"to":         req.Status,
+	})
+
+	return moderated, nil
+}
+
+// withRecompute 在交易中執行評價異動並重新計算商品評分，回傳異動後的評價
+func (s *ReviewService) withRecompute(productID int, change func(tx *sql.Tx) (int, error)) (*models.ProductReview, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reviewID, err := change(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reviewRepo.RecomputeProductRatingTx(tx, productID); err != nil {
+		return nil, err
+	}
+
+	review, err := s.reviewRepo.GetByIDTx(tx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// getActiveProduct 獲取上架中的商品
+func (s *ReviewService) getActiveProduct(productID int) (*models.Product, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrMerchantProductNotFound
+		}
+		return nil, err
+	}
+	if !product.IsActive {
+		return nil, models.ErrMerchantProductNotFound
+	}
+	return product, nil
+}
+
+// getReview 獲取評價，不存在時返回 ErrReviewNotFound
+func (s *ReviewService) getReview(reviewID int) (*models.ProductReview, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrReviewNotFound
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+// normalizeReviewRequest 驗證星等與評價內容並整理標題
+func normalizeReviewRequest(req *models.ReviewRequest) (*string, *string, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return nil, nil, models.ErrInvalidReview
+	}
+
+	var title *string
+	if req.Title != nil {
+		if trimmed := strings.TrimSpace(*req.Title); trimmed != "" {
+			if utf8.RuneCountInString(trimmed) > maxReviewTitleLength {
+				return nil, nil, &models.DomainError{Code: "INVALID_REVIEW", Message: "評價標題過長"}
+			}
+			title = &trimmed
+		}
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, nil, &models.DomainError{Code: "INVALID_REVIEW", Message: "評價內容不能為空"}
+	}
+	if utf8.RuneCountInString(content) > maxReviewContentLength {
+		return nil, nil, &models.DomainError{Code: "INVALID_REVIEW", Message: "評價內容過長"}
+	}
+
+	return title, &content, nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SaleService 限時特價業務邏輯服務
+// 背景排程定期檢查活動，到開始時間套用特價，到結束時間或售完時還原價格，並同步到含該商品的購物車
+type SaleService struct {
+	db          *sql.DB
+	saleRepo    *models.SaleCampaignRepository
+	productRepo *models.ProductRepository
+	variantRepo *models.ProductVariantRepository
+	cartService *CartService
+	interval    time.Duration
+	ticker      *time.Ticker
+	stopChan    chan bool
+}
+
+// NewSaleService 創建特價服務
+func NewSaleService(db *sql.DB, cfg config.SaleConfig, cartService *CartService) *SaleService {
+	interval := time.Duration(cfg.SchedulerIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &SaleService{
+		db:          db,
+		saleRepo:    models.NewSaleCampaignRepository(db),
+		productRepo: models.NewProductRepository(db),
+		variantRepo: models.NewProductVariantRepository(db),
+		cartService: cartService,
+		interval:    interval,
+		stopChan:    make(chan bool),
+	}
+}
+
+// CreateCampaign 商戶建立特價活動，開始時間已到的活動會立即套用；有規格的商品不能建立特價活動
+func (s *SaleService) CreateCampaign(merchantID int, req *models.CreateSaleCampaignRequest) (*models.SaleCampaign, error) {
+	product, err := s.productRepo.GetByID(req.ProductID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrMerchantProductNotFound
+		}
+		return nil, err
+	}
+	if product.MerchantID != merchantID {
+		return nil, models.ErrMerchantProductNotFound
+	}
+	// 有規格的商品以規格價格結帳，商品層級的特價不會生效
+	hasVariants, err := s.variantRepo.HasActiveVariants(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hasVariants {
+		return nil, models.ErrSaleVariantProduct
+	}
+
+	now := time.Now()
+	switch {
+	case strings.TrimSpace(req.Name) == "":
+		return nil, &models.DomainError{Code: "INVALID_SALE_CAMPAIGN", Message: "活動名稱不能為空"}
+	case req.SalePrice <= 0:
+		return nil, &models.DomainError{Code: "INVALID_SALE_CAMPAIGN", Message: "特價必須大於0"}
+	case req.QuantityCap < 0:
+		return nil, &models.DomainError{Code: "INVALID_SALE_CAMPAIGN", Message: "特價數量上限不可為負數"}
+	case !req.EndsAt.After(req.StartsAt):
+		return nil, &models.DomainError{Code: "INVALID_SALE_CAMPAIGN", Message: "結束時間必須晚於開始時間"}
+	case !req.EndsAt.After(now):
+		return nil, &models.DomainError{Code: "INVALID_SALE_CAMPAIGN", Message: "結束時間必須晚於現在"}
+	}
+
+	// 同一商品同時間只能有一個特價活動
+	open, err := s.saleRepo.GetOpenByProductID(req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range open {
+		if req.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(req.EndsAt) {
+			return nil, models.ErrSaleCampaignOverlap
+		}
+	}
+
+	campaign := &models.SaleCampaign{
+		MerchantID:  merchantID,
+		ProductID:   req.ProductID,
+		Name:        strings.TrimSpace(req.Name),
+		SalePrice:   req.SalePrice,
+		QuantityCap: req.QuantityCap,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+	}
+	if err := s.saleRepo.Create(campaign); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.process(campaign, now); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// GetMerchantCampaigns 獲取商戶的特價活動
+func (s *SaleService) GetMerchantCampaigns(merchantID int, status string) ([]*models.SaleCampaign, error) {
+	return s.saleRepo.GetByMerchantID(merchantID, status)
+}
+
+// CancelCampaign 商戶取消特價活動，進行中的活動會立即還原價格
+func (s *SaleService) CancelCampaign(merchantID, campaignID int) (*models.SaleCampaign, error) {
+	campaign, err := s.saleRepo.GetByID(campaignID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrSaleCampaignNotFound
+		}
+		return nil, err
+	}
+	if campaign.MerchantID != merchantID {
+		return nil, models.ErrSaleCampaignNotFound
+	}
+	if campaign.Status != models.SaleStatusScheduled && campaign.Status != models.SaleStatusActive {
+		return nil, &models.DomainError{Code: "SALE_CAMPAIGN_CLOSED", Message: "特價活動已結束"}
+	}
+
+	if err := s.end(campaign, models.SaleStatusCancelled); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// ProcessDue 套用到期開始的活動並還原已結束或售完的活動，回傳狀態有變動的活動數
+func (s *SaleService) ProcessDue(now time.Time) (int, error) {
+	campaigns, err := s.saleRepo.GetOpen()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, campaign := range campaigns {
+		ok, err := s.process(campaign, now)
+		if err != nil {
+			logger.Error("處理特價活動失敗", err, logrus.Fields{
+				"campaign_id": campaign.ID,
+			})
+			continue
+		}
+		if ok {
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// process 依時間與售出數量推進單一活動的狀態
+func (s *SaleService) process(campaign *models.SaleCampaign, now time.Time) (bool, error) {
+	switch campaign.Status {
+	case models.SaleStatusScheduled:
+		if !now.Before(campaign.EndsAt) {
+			return true, s.end(campaign, models.SaleStatusEnded)
+		}
+		if !now.Before(campaign.StartsAt) {
+			return true, s.activate(campaign)
+		}
+	case models.SaleStatusActive:
+		if !now.Before(campaign.EndsAt) || campaign.SoldOut() {
+			return true, s.end(campaign, models.SaleStatusEnded)
+		}
+	}
+	return false, nil
+}
+
+// activate 開始特價並同步購物車
+func (s *SaleService) activate(campaign *models.SaleCampaign) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.saleRepo.ActivateTx(tx, campaign); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.Info("特價活動開始", logrus.Fields{
+		"campaign_id": campaign.ID,
+		"product_id":  campaign.ProductID,
+		"sale_price":  campaign.SalePrice,
+	})
+
+	s.syncCarts(campaign.ProductID)
+	return nil
+}
+
+// end 結束或取消活動，進行中的活動會還原價格並同步購物車
+func (s *SaleService) end(campaign *models.SaleCampaign, status string) error {
+	wasActive := campaign.Status == models.SaleStatusActive
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.saleRepo.EndTx(tx, campaign, status); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.Info("特價活動結束", logrus.Fields{
+		"campaign_id":   campaign.ID,
+		"product_id":    campaign.ProductID,
+		"status":        status,
+		"sold_quantity": campaign.SoldQuantity,
+	})
+
+	if wasActive {
+		s.syncCarts(campaign.ProductID)
+	}
+	return nil
+}
+
+// syncCarts 將商品新價格同步到購物車，失敗只記錄日誌（購物車摘要仍會以最新價格重新同步）
+func (s *SaleService) syncCarts(productID int) {
+	if s.cartService == nil {
+		return
+	}
+	if _, err := s.cartService.SyncProductInCarts(productID); err != nil {
+		logger.Error("同步購物車特價失敗", err, logrus.Fields{
+			"product_id": productID,
+		})
+	}
+}
+
+// StartScheduler 啟動背景排程
+func (s *SaleService) StartScheduler() {
+	s.ticker = time.NewTicker(s.interval)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				if _, err := s.ProcessDue(time.Now()); err != nil {
+					logger.Error("特價活動排程執行失敗", err)
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	logger.Info("特價活動排程已啟動", logrus.Fields{
+		"interval": s.interval.String(),
+	})
+}
+
+// StopScheduler 停止背景排程
+func (s *SaleService) StopScheduler() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	select {
+	case s.stopChan <- true:
+	default:
+	}
+}
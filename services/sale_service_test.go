@@ -0,0 +1,159 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/models"
+)
+
+// productPricing 商品目前的售價、原價與特價標記
+func (s *testShop) productPricing(t *testing.T, productID int) (price float64, originalPrice sql.NullFloat64, onSale bool) {
+	t.Helper()
+	err := s.db.QueryRow(`SELECT price, original_price, is_on_sale FROM products WHERE id = ?`, productID).
+		Scan(&price, &originalPrice, &onSale)
+	if err != nil {
+		t.Fatalf("query pricing: %v", err)
+	}
+	return price, originalPrice, onSale
+}
+
+// startSale 建立立即開始的特價活動
+func startSale(t *testing.T, sales *SaleService, merchantID, productID int, salePrice float64, quantityCap int) *models.SaleCampaign {
+	t.Helper()
+	campaign, err := sales.CreateCampaign(merchantID, &models.CreateSaleCampaignRequest{
+		ProductID:   productID,
+		Name:        "限時特價",
+		SalePrice:   salePrice,
+		QuantityCap: quantityCap,
+		StartsAt:    time.Now().Add(-time.Minute),
+		EndsAt:      time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateCampaign: %v", err)
+	}
+	if campaign.Status != models.SaleStatusActive {
+		t.Fatalf("campaign status = %s, want %s", campaign.Status, models.SaleStatusActive)
+	}
+	return campaign
+}
+
+func TestSaleCampaignEndRestoresPrice(t *testing.T) {
+	tests := []struct {
+		name        string
+		merchantSQL string  // 活動期間商戶對商品的修改
+		wantPrice   float64 // 活動結束後的售價
+		wantOnSale  bool
+	}{
+		{name: "活動期間未改價", wantPrice: 500},
+		{name: "活動期間商戶改價", merchantSQL: `UPDATE products SET price = 450 WHERE id = ?`, wantPrice: 450, wantOnSale: true},
+		{name: "活動期間商戶取消特價標記", merchantSQL: `UPDATE products SET is_on_sale = 0 WHERE id = ?`, wantPrice: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			sales := NewSaleService(shop.db, config.SaleConfig{}, shop.carts)
+			merchantID := shop.merchant(t)
+			productID := shop.product(t, merchantID, 500, 10)
+
+			campaign := startSale(t, sales, merchantID, productID, 399, 0)
+			if price, original, onSale := shop.productPricing(t, productID); price != 399 || original.Float64 != 500 || !onSale {
+				t.Fatalf("active pricing = %.2f, %v, %v, want 399, 500, true", price, original, onSale)
+			}
+			if tt.merchantSQL != "" {
+				shop.exec(t, tt.merchantSQL, productID)
+			}
+
+			if _, err := sales.CancelCampaign(merchantID, campaign.ID); err != nil {
+				t.Fatalf("CancelCampaign: %v", err)
+			}
+			price, _, onSale := shop.productPricing(t, productID)
+			if price != tt.wantPrice || onSale != tt.wantOnSale {
+				t.Errorf("pricing after end = %.2f, %v, want %.2f, %v", price, onSale, tt.wantPrice, tt.wantOnSale)
+			}
+		})
+	}
+}
+
+func TestSaleCampaignSoldOutReopensOnCancel(t *testing.T) {
+	shop := newTestShop(t)
+	sales := NewSaleService(shop.db, config.SaleConfig{}, shop.carts)
+	merchantID := shop.merchant(t)
+	productID := shop.product(t, merchantID, 500, 10)
+	campaign := startSale(t, sales, merchantID, productID, 399, 2)
+
+	customerID := shop.customer(t)
+	group := shop.placeOrder(t, customerID, productID, 2)
+	if total := group.Orders[0].Items[0].TotalPrice; total != 798 {
+		t.Errorf("line total = %.2f, want 798", total)
+	}
+
+	var status string
+	var sold int
+	shop.scalar(t, &status, `SELECT status FROM sale_campaigns WHERE id = ?`, campaign.ID)
+	if status != models.SaleStatusEnded {
+		t.Errorf("campaign status after sell-out = %s, want %s", status, models.SaleStatusEnded)
+	}
+	if price, _, _ := shop.productPricing(t, productID); price != 500 {
+		t.Errorf("price after sell-out = %.2f, want 500", price)
+	}
+
+	// 取消訂單扣回售出數量，活動仍在期間內時重新套用特價
+	if _, err := shop.orders.CancelCustomerOrder(customerID, group.Orders[0].ID, nil); err != nil {
+		t.Fatalf("CancelCustomerOrder: %v", err)
+	}
+	shop.scalar(t, &status, `SELECT status FROM sale_campaigns WHERE id = ?`, campaign.ID)
+	shop.scalar(t, &sold, `SELECT sold_quantity FROM sale_campaigns WHERE id = ?`, campaign.ID)
+	if status != models.SaleStatusActive || sold != 0 {
+		t.Errorf("campaign after cancel = %s, sold %d, want %s, sold 0", status, sold, models.SaleStatusActive)
+	}
+	if price, _, _ := shop.productPricing(t, productID); price != 399 {
+		t.Errorf("price after cancel = %.2f, want 399", price)
+	}
+}
+
+func TestSaleCampaignVariantProducts(t *testing.T) {
+	variants := &models.ProductVariantsRequest{
+		Options:  []models.ProductOptionInput{{Name: "尺寸", Values: []string{"S", "M"}}},
+		Variants: []models.ProductVariantInput{{SKU: "TEE-S", Options: map[string]string{"尺寸": "S"}, Stock: 5}},
+	}
+
+	t.Run("有規格的商品不能建立活動", func(t *testing.T) {
+		shop := newTestShop(t)
+		sales := NewSaleService(shop.db, config.SaleConfig{}, shop.carts)
+		merchantID := shop.merchant(t)
+		productID := shop.product(t, merchantID, 500, 0)
+		if _, _, err := shop.inventory.SetProductVariants(merchantID, productID, variants); err != nil {
+			t.Fatalf("SetProductVariants: %v", err)
+		}
+
+		_, err := sales.CreateCampaign(merchantID, &models.CreateSaleCampaignRequest{
+			ProductID: productID, Name: "限時特價", SalePrice: 399,
+			StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour),
+		})
+		if err != models.ErrSaleVariantProduct {
+			t.Errorf("CreateCampaign err = %v, want %v", err, models.ErrSaleVariantProduct)
+		}
+	})
+
+	t.Run("活動結束前不能設定規格", func(t *testing.T) {
+		shop := newTestShop(t)
+		sales := NewSaleService(shop.db, config.SaleConfig{}, shop.carts)
+		merchantID := shop.merchant(t)
+		productID := shop.product(t, merchantID, 500, 10)
+		campaign := startSale(t, sales, merchantID, productID, 399, 0)
+
+		if _, _, err := shop.inventory.SetProductVariants(merchantID, productID, variants); err != models.ErrSaleCampaignOpen {
+			t.Errorf("SetProductVariants err = %v, want %v", err, models.ErrSaleCampaignOpen)
+		}
+		if _, err := sales.CancelCampaign(merchantID, campaign.ID); err != nil {
+			t.Fatalf("CancelCampaign: %v", err)
+		}
+		if _, _, err := shop.inventory.SetProductVariants(merchantID, productID, variants); err != nil {
+			t.Errorf("SetProductVariants after cancel: %v", err)
+		}
+	})
+}
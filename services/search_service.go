@@ -0,0 +1,43 @@
+package services
+
+import (
+	"database/sql"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SearchService 商品全文檢索服務
+// 索引在啟動時建立或重建，之後由 ProductRepository 在商品新增、修改與刪除時同步
+type SearchService struct {
+	searchRepo *models.ProductSearchRepository
+}
+
+// NewSearchService 創建商品全文檢索服務
+func NewSearchService(db *sql.DB, cfg config.SearchConfig) *SearchService {
+	return &SearchService{
+		searchRepo: models.NewProductSearchRepository(db, cfg.Tokenizer),
+	}
+}
+
+// EnsureIndex 建立全文檢索索引，分詞方式變更或與商品資料不一致時重建
+func (s *SearchService) EnsureIndex() error {
+	rebuilt, err := s.searchRepo.EnsureIndex()
+	if err != nil {
+		return err
+	}
+	if rebuilt {
+		logger.Info("已重建商品全文檢索索引", logrus.Fields{
+			"tokenizer": s.searchRepo.Tokenizer(),
+		})
+	}
+	return nil
+}
+
+// Search 搜尋上架商品，依相關度排序並附命中摘要，回傳結果與符合總數
+func (s *SearchService) Search(keyword string, limit, offset int) ([]*models.ProductSearchResult, int, error) {
+	return s.searchRepo.Search(keyword, limit, offset)
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"go-simple-app/config"
+	"go-simple-app/models"
+)
+
+// ShippingService 運費計算服務
+// 商戶有啟用中的運送方式時只提供商戶自己的方式，否則使用平台預設；每個商戶的子訂單各自計算運費
+type ShippingService struct {
+	db           *sql.DB
+	config       config.ShippingConfig
+	shippingRepo *models.ShippingRepository
+}
+
+// ShippingQuote 購物車各商戶的運送方式與運費
+type ShippingQuote struct {
+	ShippingFee float64            `json:"shipping_fee"`
+	Merchants   []MerchantShipping `json:"merchants"`
+}
+
+// MerchantShipping 單一商戶的包裹重量、可選運送方式與目前選擇
+type MerchantShipping struct {
+	MerchantID       int                     `json:"merchant_id"`
+	Subtotal         float64                 `json:"subtotal"`          // 折扣後小計，用於判斷免運
+	Weight           float64                 `json:"weight"`            // 實際重量（公斤）
+	VolumetricWeight float64                 `json:"volumetric_weight"` // 材積重（公斤），依系統預設除數計算
+	Options          []models.ShippingOption `json:"options"`
+	ShippingMethodID *int                    `json:"shipping_method_id,omitempty"`
+	ShippingMethod   string                  `json:"shipping_method,omitempty"`
+	ShippingFee      float64                 `json:"shipping_fee"`
+	Error            string                  `json:"error,omitempty"`
+
+	err *models.DomainError
+}
+
+// parcelDimensions 商品尺寸（公分），對應 products.dimensions 的 JSON 格式
+type parcelDimensions struct {
+	Length float64 `json:"length"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// parcel 商戶包裹的重量與體積
+type parcel struct {
+	weight float64 // 公斤
+	volume float64 // 立方公分
+}
+
+// NewShippingService 創建運費計算服務
+func NewShippingService(db *sql.DB, cfg config.ShippingConfig) *ShippingService {
+	return &ShippingService{
+		db:           db,
+		config:       cfg,
+		shippingRepo: models.NewShippingRepository(db),
+	}
+}
+
+// QuoteCart 以客戶購物車的運送方式選擇計算運費
+// selections 為本次指定的運送方式（merchant_id => shipping_method_id），優先於購物車的選擇且必須可用
+func (s *ShippingService) QuoteCart(customerID int, items []models.CartItem, pricing *CartPricing, selections map[int]int) (*ShippingQuote, error) {
+	saved, err := s.shippingRepo.GetCartSelections(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchantIDs []int
+	subtotals := make(map[int]float64)
+	parcels := make(map[int]*parcel)
+	for _, item := range items {
+		if item.Product == nil {
+			continue
+		}
+		merchantID := item.Product.MerchantID
+		if _, exists := parcels[merchantID]; !exists {
+			merchantIDs = append(merchantIDs, merchantID)
+			parcels[merchantID] = &parcel{}
+		}
+		subtotals[merchantID] += item.UnitPrice() * float64(item.Quantity)
+		s.addToParcel(parcels[merchantID], item.Product, item.Quantity)
+	}
+
+	methods, err := s.shippingRepo.GetActive(merchantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &ShippingQuote{Merchants: []MerchantShipping{}}
+	for _, merchantID := range merchantIDs {
+		subtotal := subtotals[merchantID]
+		if pricing != nil {
+			subtotal -= pricing.MerchantDiscounts[merchantID]
+		}
+		merchant := s.quoteMerchant(merchantID, roundAmount(subtotal), parcels[merchantID], methodsForMerchant(methods, merchantID))
+
+		if methodID, ok := selections[merchantID]; ok {
+			if !merchant.selectMethod(methodID) {
+				merchant.err = models.ErrShippingMethodUnavailable
+			}
+		} else if methodID, ok := saved[merchantID]; !ok || !merchant.selectMethod(methodID) {
+			merchant.selectCheapest()
+		}
+		if merchant.ShippingMethodID == nil && merchant.err == nil {
+			merchant.err = models.ErrNoShippingMethod
+		}
+		if merchant.err != nil {
+			merchant.Error = merchant.err.Error()
+		}
+
+		quote.ShippingFee += merchant.ShippingFee
+		quote.Merchants = append(quote.Merchants, *merchant)
+	}
+	quote.ShippingFee = roundAmount(quote.ShippingFee)
+
+	return quote, nil
+}
+
+// SelectMethod 設定客戶購物車某商戶的運送方式，重量是否超過上限於計算運費時檢查
+func (s *ShippingService) SelectMethod(customerID, merchantID, methodID int) error {
+	method, err := s.shippingRepo.GetByID(methodID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrShippingMethodNotFound
+		}
+		return err
+	}
+
+	methods, err := s.shippingRepo.GetActive([]int{merchantID})
+	if err != nil {
+		return err
+	}
+	for _, candidate := range methodsForMerchant(methods, merchantID) {
+		if candidate.ID == method.ID {
+			return s.shippingRepo.SetCartSelection(customerID, merchantID, methodID)
+		}
+	}
+	return models.ErrShippingMethodUnavailable
+}
+
+// ClearCartSelectionsTx 在交易中清除客戶購物車的運送方式選擇
+func (s *ShippingService) ClearCartSelectionsTx(tx *sql.Tx, customerID int) error {
+	return s.shippingRepo.ClearCartSelectionsTx(tx, customerID)
+}
+
+// ListMethods 獲取運送方式列表，merchantID 為 nil 時列出全部（管理員）
+func (s *ShippingService) ListMethods(merchantID *int) ([]*models.ShippingMethod, error) {
+	if merchantID != nil {
+		return s.shippingRepo.GetByMerchantID(*merchantID)
+	}
+	return s.shippingRepo.GetAll()
+}
+
+// CreateMethod 創建運送方式，merchantID 不為 nil 時表示商戶建立，固定屬於該商戶
+func (s *ShippingService) CreateMethod(req *models.ShippingMethodRequest, merchantID *int) (*models.ShippingMethod, error) {
+	method := &models.ShippingMethod{IsActive: true}
+	if err := applyShippingMethodRequest(method, req, merchantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.shippingRepo.Create(method); err != nil {
+		return nil, fmt.Errorf("創建運送方式失敗: %w", err)
+	}
+
+	return method, nil
+}
+
+// UpdateMethod 更新運送方式，merchantID 不為 nil 時只能更新該商戶的運送方式
+func (s *ShippingService) UpdateMethod(id int, req *models.ShippingMethodRequest, merchantID *int) (*models.ShippingMethod, error) {
+	method, err := s.shippingRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrShippingMethodNotFound
+		}
+		return nil, err
+	}
+	if merchantID != nil && (method.MerchantID == nil || *method.MerchantID != *merchantID) {
+		return nil, models.ErrShippingMethodNotFound
+	}
+
+	if err := applyShippingMethodRequest(method, req, merchantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.shippingRepo.Update(method); err != nil {
+		return nil, fmt.Errorf("更新運送方式失敗: %w", err)
+	}
+
+	return method, nil
+}
+
+// addToParcel 將商品重量與體積加入包裹，未設定重量時使用預設重量，尺寸格式錯誤時不計材積
+func (s *ShippingService) addToParcel(p *parcel, product *models.Product, quantity int) {
+	weight := s.config.DefaultWeightKg
+	if product.Weight != nil && *product.Weight > 0 {
+		weight = *product.Weight
+	}
+	p.weight += weight * float64(quantity)
+
+	if product.Dimensions == nil || *product.Dimensions == "" {
+		return
+	}
+	var dimensions parcelDimensions
+	if err := json.Unmarshal([]byte(*product.Dimensions), &dimensions); err != nil {
+		return
+	}
+	if dimensions.Length > 0 && dimensions.Width > 0 && dimensions.Height > 0 {
+		p.volume += dimensions.Length * dimensions.Width * dimensions.Height * float64(quantity)
+	}
+}
+
+// quoteMerchant 計算商戶包裹在各運送方式下的運費
+func (s *ShippingService) quoteMerchant(merchantID int, subtotal float64, p *parcel, methods []*models.ShippingMethod) *MerchantShipping {
+	merchant := &MerchantShipping{
+		MerchantID:       merchantID,
+		Subtotal:         subtotal,
+		Weight:           roundWeight(p.weight),
+		VolumetricWeight: roundWeight(s.volumetricWeight(p, nil)),
+		Options:          []models.ShippingOption{},
+	}
+
+	for _, method := range methods {
+		weight := p.weight
+		if method.RateType == models.ShippingRateVolumetric {
+			weight = math.Max(weight, s.volumetricWeight(p, method.VolumetricDivisor))
+		}
+		weight = roundWeight(weight)
+
+		option := models.ShippingOption{
+			ShippingMethodID: method.ID,
+			Name:             method.Name,
+			Carrier:          method.Carrier,
+			RateType:         method.RateType,
+			FreeThreshold:    method.FreeThreshold,
+		}
+		fee, ok := method.Fee(weight)
+		if !ok {
+			option.Reason = fmt.Sprintf("計費重量 %.2f 公斤超過上限 %.0f 公斤", weight, method.MaxWeight())
+			merchant.Options = append(merchant.Options, option)
+			continue
+		}
+
+		option.Available = true
+		option.OriginalFee = roundAmount(fee)
+		option.Fee = option.OriginalFee
+		if method.FreeThreshold != nil && subtotal >= *method.FreeThreshold {
+			option.Fee = 0
+			option.FreeShipping = true
+		}
+		merchant.Options = append(merchant.Options, option)
+	}
+
+	return merchant
+}
+
+// volumetricWeight 以體積換算材積重，divisor 為 nil 時使用系統預設除數
+func (s *ShippingService) volumetricWeight(p *parcel, divisor *float64) float64 {
+	d := s.config.VolumetricDivisor
+	if divisor != nil && *divisor > 0 {
+		d = *divisor
+	}
+	if d <= 0 {
+		return 0
+	}
+	return p.volume / d
+}
+
+// selectMethod 選擇指定的運送方式，不在可用選項中時回傳 false
+func (m *MerchantShipping) selectMethod(methodID int) bool {
+	for _, option := range m.Options {
+		if option.ShippingMethodID == methodID && option.Available {
+			m.choose(option)
+			return true
+		}
+	}
+	return false
+}
+
+// selectCheapest 選擇運費最低的可用運送方式，運費相同時依排序在前者
+func (m *MerchantShipping) selectCheapest() {
+	var cheapest *models.ShippingOption
+	for i := range m.Options {
+		option := &m.Options[i]
+		if option.Available && (cheapest == nil || option.Fee < cheapest.Fee) {
+			cheapest = option
+		}
+	}
+	if cheapest != nil {
+		m.choose(*cheapest)
+	}
+}
+
+func (m *MerchantShipping) choose(option models.ShippingOption) {
+	methodID := option.ShippingMethodID
+	m.ShippingMethodID = &methodID
+	m.ShippingMethod = option.Name
+	m.ShippingFee = option.Fee
+}
+
+// Check 結帳前檢查每個商戶都選到可用的運送方式
+func (q *ShippingQuote) Check() error {
+	for _, merchant := range q.Merchants {
+		if merchant.err != nil {
+			return merchant.err.WithMessage(fmt.Sprintf("商戶 %d：%s", merchant.MerchantID, merchant.Error))
+		}
+	}
+	return nil
+}
+
+// Merchant 獲取商戶的運費計算結果
+func (q *ShippingQuote) Merchant(merchantID int) *MerchantShipping {
+	for i := range q.Merchants {
+		if q.Merchants[i].MerchantID == merchantID {
+			return &q.Merchants[i]
+		}
+	}
+	return nil
+}
+
+// methodsForMerchant 商戶有自己的運送方式時只使用商戶的，否則使用平台預設
+func methodsForMerchant(methods []*models.ShippingMethod, merchantID int) []*models.ShippingMethod {
+	var own, platform []*models.ShippingMethod
+	for _, method := range methods {
+		switch {
+		case method.MerchantID == nil:
+			platform = append(platform, method)
+		case *method.MerchantID == merchantID:
+			own = append(own, method)
+		}
+	}
+	if len(own) > 0 {
+		return own
+	}
+	return platform
+}
+
+// roundWeight 重量四捨五入到小數點後三位
+func roundWeight(weight float64) float64 {
+	return math.Round(weight*1000) / 1000
+}
+
+// applyShippingMethodRequest 驗證請求並寫入運送方式欄位
+func applyShippingMethodRequest(method *models.ShippingMethod, req *models.ShippingMethodRequest, merchantID *int) error {
+	if strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.Carrier) == "" {
+		return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "運送方式名稱與物流業者不能為空"}
+	}
+	if req.BaseFee < 0 {
+		return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "運費不可為負數"}
+	}
+	if req.FreeThreshold != nil && *req.FreeThreshold < 0 {
+		return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "免運門檻不可為負數"}
+	}
+	if req.VolumetricDivisor != nil && *req.VolumetricDivisor <= 0 {
+		return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "材積換算除數必須大於0"}
+	}
+
+	tiers := []models.ShippingTier{}
+	switch req.RateType {
+	case models.ShippingRateFlat:
+	case models.ShippingRateWeight, models.ShippingRateVolumetric:
+		if len(req.Tiers) == 0 {
+			return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "依重量計費需設定運費級距"}
+		}
+		tiers = append(tiers, req.Tiers...)
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].MaxWeight < tiers[j].MaxWeight })
+		for i, tier := range tiers {
+			if tier.MaxWeight <= 0 || tier.Fee < 0 {
+				return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "級距重量必須大於0且運費不可為負數"}
+			}
+			if i > 0 && tier.MaxWeight == tiers[i-1].MaxWeight {
+				return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "級距重量不可重複"}
+			}
+		}
+	default:
+		return &models.DomainError{Code: "INVALID_SHIPPING_METHOD", Message: "無效的運費計算方式"}
+	}
+
+	method.Name = strings.TrimSpace(req.Name)
+	method.Carrier = strings.TrimSpace(req.Carrier)
+	method.RateType = req.RateType
+	method.BaseFee = req.BaseFee
+	method.Tiers = tiers
+	method.VolumetricDivisor = req.VolumetricDivisor
+	method.FreeThreshold = req.FreeThreshold
+	method.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		method.IsActive = *req.IsActive
+	}
+
+	// 商戶只能建立自己的運送方式，管理員可指定商戶或留空為平台預設；管理員更新時未帶 merchant_id 則保留原歸屬
+	if merchantID != nil {
+		method.MerchantID = merchantID
+	} else if method.ID == 0 || req.MerchantIDSet {
+		method.MerchantID = req.MerchantID
+	}
+
+	return nil
+}
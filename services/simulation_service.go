@@ -6,6 +6,8 @@ import (
 	"math/rand"
 	"strings"
 	"time"
+
+	"go-simple-app/models"
 )
 
 // SimulationService 模拟AI服务
@@ -163,7 +165,7 @@ func (s *SimulationService) generateStockAnalysisResponse(message string, stockC
 			case "investment_advice":
 				response += s.generateInvestmentAdviceAnalysis(currentPrice, change)
 			case "technical_analysis":
-				response += s.generateTechnicalAnalysisDetails(currentPrice)
+				response += s.generateTechnicalAnalysisDetails(stockContext)
 			case "risk_analysis":
 				response += s.generateRiskAnalysisDetails(currentPrice, change)
 			case "fundamental_analysis":
@@ -173,7 +175,7 @@ func (s *SimulationService) generateStockAnalysisResponse(message string, stockC
 				response += "**歷史股價分析：**\n"
 				response += s.generateHistoricalAnalysis(currentPrice)
 				response += "**技術指標分析：**\n"
-				response += s.generateTechnicalIndicators(currentPrice)
+				response += s.generateTechnicalIndicators(stockContext)
 				response += "**支撐位與阻力位：**\n"
 				response += s.generateSupportResistance(currentPrice)
 			}
@@ -206,20 +208,13 @@ func (s *SimulationService) generateHistoricalAnalysis(currentPrice float64) str
 		fmt.Sprintf("• 最近1年：股價波動範圍在 %.0f-%.0f 元之間\n\n", basePrice-yearRange, basePrice+yearRange)
 }
 
-// generateTechnicalIndicators 生成技術指標
-func (s *SimulationService) generateTechnicalIndicators(currentPrice float64) string {
-	// 基於當前價格生成動態技術指標
-	rsi := 50.0 + (currentPrice-200)/10 // RSI 基於價格變化
-	if rsi > 80 { rsi = 80 }
-	if rsi < 20 { rsi = 20 }
-	
-	kValue := rsi + 5
-	dValue := rsi - 2
-	
-	return fmt.Sprintf("• RSI (14)：%.1f - 處於中性偏多區域\n", rsi) +
-		fmt.Sprintf("• MACD：金叉形成，多頭動能增強\n") +
-		fmt.Sprintf("• KD指標：K值 %.1f，D值 %.1f - 偏多訊號\n", kValue, dValue) +
-		fmt.Sprintf("• 移動平均線：股價站上5日、10日、20日均線\n\n")
+// generateTechnicalIndicators 生成技術指標（採用伺服器依歷史日K計算的數值）
+func (s *SimulationService) generateTechnicalIndicators(stockContext map[string]interface{}) string {
+	indicatorText := describeTechnicalIndicators(stockContext)
+	if indicatorText == "" {
+		return "• 本站尚無足夠的歷史日K資料，暫時無法計算技術指標\n\n"
+	}
+	return indicatorText + "\n"
 }
 
 // generateSupportResistance 生成支撐阻力位
@@ -282,59 +277,99 @@ func (s *SimulationService) generateInvestmentAdviceAnalysis(currentPrice, chang
 	return response
 }
 
-// generateTechnicalAnalysisDetails 生成技術分析詳細內容
-func (s *SimulationService) generateTechnicalAnalysisDetails(currentPrice float64) string {
+// generateTechnicalAnalysisDetails 生成技術分析詳細內容（採用伺服器依歷史日K計算的數值）
+func (s *SimulationService) generateTechnicalAnalysisDetails(stockContext map[string]interface{}) string {
 	response := "**📈 技術指標詳細分析：**\n\n"
 	
-	// RSI分析
-	rsi := 50.0 + (currentPrice-200)/10
-	if rsi > 80 { rsi = 80 }
-	if rsi < 20 { rsi = 20 }
+	result, _ := stockContext["technical_indicators"].(*models.StockIndicators)
+	if result == nil || result.Latest == nil {
+		response += "• 本站尚無足夠的歷史日K資料，暫時無法計算技術指標\n\n"
+		return response
+	}
+	p := result.Latest
+	response += fmt.Sprintf("（依 %s 收盤 %.2f 元計算）\n\n", p.Time.Format("2006-01-02"), p.Close)
 	
+	// RSI分析
 	response += "**RSI相對強弱指標：**\n"
-	response += fmt.Sprintf("• 當前RSI(14)：%.1f\n", rsi)
-	if rsi > 70 {
-		response += "• 信號：超買區域，需注意回調風險\n"
-	} else if rsi < 30 {
-		response += "• 信號：超賣區域，可能出現反彈\n"
-	} else {
-		response += "• 信號：中性區域，趨勢持續性較好\n"
+	response += fmt.Sprintf("• 當前RSI(14)：%s\n", formatIndicator(p.RSI14))
+	if p.RSI14 != nil {
+		if *p.RSI14 > 70 {
+			response += "• 信號：超買區域，需注意回調風險\n"
+		} else if *p.RSI14 < 30 {
+			response += "• 信號：超賣區域，可能出現反彈\n"
+		} else {
+			response += "• 信號：中性區域\n"
+		}
 	}
 	response += "\n"
 	
 	// MACD分析
 	response += "**MACD動量指標：**\n"
-	response += "• DIF線：0.85，DEA線：0.72\n"
-	response += "• 信號：金叉形成，多頭動能增強\n"
-	response += "• 柱狀圖：正值擴大，買盤力道強勁\n\n"
+	response += fmt.Sprintf("• DIF：%s，訊號線：%s，柱狀體：%s\n",
+		formatIndicator(p.MACDDIF), formatIndicator(p.MACDSignal), formatIndicator(p.MACDHistogram))
+	if p.MACDHistogram != nil {
+		if *p.MACDHistogram > 0 {
+			response += "• 信號：DIF 位於訊號線之上，多頭動能較強\n"
+		} else {
+			response += "• 信號：DIF 位於訊號線之下，空頭動能較強\n"
+		}
+	}
+	response += "\n"
 	
 	// KD分析
-	kValue := rsi + 5
-	dValue := rsi - 2
 	response += "**KD隨機指標：**\n"
-	response += fmt.Sprintf("• K值：%.1f，D值：%.1f\n", kValue, dValue)
-	if kValue > 80 && dValue > 80 {
-		response += "• 信號：超買區域，短期可能回調\n"
-	} else if kValue < 20 && dValue < 20 {
-		response += "• 信號：超賣區域，短期可能反彈\n"
-	} else {
-		response += "• 信號：偏多訊號，趨勢向上\n"
+	response += fmt.Sprintf("• K值：%s，D值：%s\n", formatIndicator(p.K), formatIndicator(p.D))
+	if p.K != nil && p.D != nil {
+		if *p.K > 80 && *p.D > 80 {
+			response += "• 信號：超買區域，短期可能回調\n"
+		} else if *p.K < 20 && *p.D < 20 {
+			response += "• 信號：超賣區域，短期可能反彈\n"
+		} else if *p.K > *p.D {
+			response += "• 信號：K 值在 D 值之上，偏多\n"
+		} else {
+			response += "• 信號：K 值在 D 值之下，偏空\n"
+		}
 	}
 	response += "\n"
 	
 	// 移動平均線分析
 	response += "**移動平均線系統：**\n"
-	response += "• 5日均線：股價上方，短期趨勢向上\n"
-	response += "• 10日均線：股價上方，中期趨勢向上\n"
-	response += "• 20日均線：股價上方，長期趨勢向上\n"
-	response += "• 均線排列：多頭排列，趨勢明確\n\n"
+	for _, ma := range []struct {
+		label string
+		value *float64
+	}{{"5日均線", p.MA5}, {"10日均線", p.MA10}, {"20日均線", p.MA20}, {"60日均線", p.MA60}} {
+		if ma.value == nil {
+			response += fmt.Sprintf("• %s：資料不足\n", ma.label)
+		} else if p.Close >= *ma.value {
+			response += fmt.Sprintf("• %s：%.2f，股價位於均線上方\n", ma.label, *ma.value)
+		} else {
+			response += fmt.Sprintf("• %s：%.2f，股價位於均線下方\n", ma.label, *ma.value)
+		}
+	}
+	response += "\n"
 	
 	// 布林帶分析
 	response += "**布林帶通道：**\n"
-	response += "• 上軌：股價接近上軌，需注意回調\n"
-	response += "• 中軌：股價站穩中軌，趨勢偏多\n"
-	response += "• 下軌：支撐位明確，下跌空間有限\n"
-	response += "• 通道寬度：正常範圍，波動適中\n\n"
+	response += fmt.Sprintf("• 上軌：%s，中軌：%s，下軌：%s\n",
+		formatIndicator(p.BollUpper), formatIndicator(p.BollMiddle), formatIndicator(p.BollLower))
+	if p.BollUpper != nil && p.BollLower != nil {
+		if p.Close > *p.BollUpper {
+			response += "• 股價突破上軌，短線過熱需注意回調\n"
+		} else if p.Close < *p.BollLower {
+			response += "• 股價跌破下軌，短線超跌\n"
+		} else {
+			response += "• 股價位於通道內\n"
+		}
+	}
+	response += "\n"
+	
+	if len(result.Signals) > 0 {
+		response += "**綜合訊號：**\n"
+		for _, signal := range result.Signals {
+			response += fmt.Sprintf("• %s\n", signal)
+		}
+		response += "\n"
+	}
 	
 	// 成交量分析
 	response += "**成交量指標：**\n"
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"go-simple-app/config"
+	"go-simple-app/logger"
+	"go-simple-app/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// taipeiLocation 台股交易所在時區（UTC+8，無日光節約時間）
+var taipeiLocation = time.FixedZone("CST", 8*60*60)
+
+// maxStockHistoryBars 單次查詢最多回傳的 K 棒數，超過時保留最新的部分
+const maxStockHistoryBars = 5000
+
+// stockHistoryDefaultSpans 未指定 from 時各週期預設往前查詢的區間
+var stockHistoryDefaultSpans = map[string]time.Duration{
+	models.StockInterval1m: 24 * time.Hour,
+	models.StockInterval5m: 5 * 24 * time.Hour,
+	models.StockInterval1d: 365 * 24 * time.Hour,
+}
+
+// stockIntradayIntervals 盤中逐筆行情同步更新的分 K 週期
+var stockIntradayIntervals = []struct {
+	interval string
+	duration time.Duration
+}{
+	{models.StockInterval1m, time.Minute},
+	{models.StockInterval5m, 5 * time.Minute},
+}
+
+// StockHistoryService 股價歷史業務邏輯服務
+// 每次行情更新時寫入逐筆行情並同步更新 1 分 K、5 分 K 與日 K，背景排程依保留天數逐層清理舊資料
+type StockHistoryService struct {
+	db                     *sql.DB
+	historyRepo            *models.StockHistoryRepository
+	tickRetention          time.Duration
+	minuteBarRetention     time.Duration
+	fiveMinuteBarRetention time.Duration
+	interval               time.Duration
+	mu                     sync.Mutex
+	ticker                 *time.Ticker
+	stopChan               chan bool
+}
+
+// NewStockHistoryService 創建股價歷史服務
+func NewStockHistoryService(db *sql.DB, cfg config.StockHistoryConfig) *StockHistoryService {
+	interval := time.Duration(cfg.CompactIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &StockHistoryService{
+		db:                     db,
+		historyRepo:            models.NewStockHistoryRepository(db),
+		tickRetention:          retentionDays(cfg.TickRetentionDays),
+		minuteBarRetention:     retentionDays(cfg.MinuteBarRetentionDays),
+		fiveMinuteBarRetention: retentionDays(cfg.FiveMinuteBarRetentionDays),
+		interval:               interval,
+		stopChan:               make(chan bool),
+	}
+}
+
+// retentionDays 將保留天數轉為時間長度，0 或負數表示永久保留
+func retentionDays(days int) time.Duration {
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// tradingDay 行情時間所屬的交易日（台灣時間當日零時）
+func tradingDay(t time.Time) time.Time {
+	local := t.In(taipeiLocation)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, taipeiLocation)
+}
+
+// RecordPrice 將剛寫入 stock_prices 的最新行情記入歷史
+// inSession 為 true 時寫入逐筆行情並更新分 K（行情未變動則略過），盤後強制更新只更新當日日 K
+func (s *StockHistoryService) RecordPrice(price *models.StockPrice, inSession bool) error {
+	at := price.UpdatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	day := tradingDay(at)
+	local := at.In(taipeiLocation)
+
+	// 週末與開盤前的行情仍是前一交易日的資料，不記入當日
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday || local.Hour() < 9 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last *models.StockTick
+	if inSession {
+		var err error
+		last, err = s.historyRepo.LatestTick(price.StockCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 前一交易日的日 K 與目前行情完全相同時，多半是休市日沿用的舊資料
+	prevDaily, err := s.historyRepo.LatestBarBefore(price.StockCode, models.StockInterval1d, day)
+	if err != nil {
+		return err
+	}
+	stale := prevDaily != nil && prevDaily.Close == price.Price && prevDaily.Volume == price.Volume
+	if stale || price.OpenPrice <= 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if inSession && (last == nil || last.Price != price.Price || last.Volume != price.Volume) {
+		tick := &models.StockTick{
+			StockCode: price.StockCode,
+			Price:     price.Price,
+			Volume:    price.Volume,
+			Amount:    price.Amount,
+			TradedAt:  at,
+		}
+		if err := s.historyRepo.CreateTickTx(tx, tick); err != nil {
+			return err
+		}
+
+		// 成交量與成交金額為當日累計值，分 K 只累加與同日前一筆行情的差額
+		volumeDelta, amountDelta := price.Volume, price.Amount
+		if last != nil && !last.TradedAt.Before(day) {
+			volumeDelta = price.Volume - last.Volume
+			amountDelta = price.Amount - last.Amount
+		}
+		if volumeDelta < 0 {
+			volumeDelta = 0
+		}
+		if amountDelta < 0 {
+			amountDelta = 0
+		}
+
+		for _, iv := range stockIntradayIntervals {
+			bar := &models.StockBar{
+				StockCode:   price.StockCode,
+				Interval:    iv.interval,
+				BucketStart: at.Truncate(iv.duration),
+				Open:        price.Price,
+				High:        price.Price,
+				Low:         price.Price,
+				Close:       price.Price,
+				Volume:      volumeDelta,
+				Amount:      amountDelta,
+			}
+			if err := s.historyRepo.AccumulateBarTx(tx, bar); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 日 K 直接採用交易所提供的當日開高低與累計成交量
+	daily := &models.StockBar{
+		StockCode:   price.StockCode,
+		Interval:    models.StockInterval1d,
+		BucketStart: day,
+		Open:        price.OpenPrice,
+		High:        price.HighPrice,
+		Low:         price.LowPrice,
+		Close:       price.Price,
+		Volume:      price.Volume,
+		Amount:      price.Amount,
+	}
+	if daily.High < price.Price {
+		daily.High = price.Price
+	}
+	if daily.Low <= 0 || daily.Low > price.Price {
+		daily.Low = price.Price
+	}
+	if err := s.historyRepo.UpsertBarTx(tx, daily); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetHistory 查詢股票 K 棒，from 與 to 可為 RFC3339 時間或 YYYY-MM-DD（台灣時間，to 含當日）
+func (s *StockHistoryService) GetHistory(code, interval, from, to string) (*models.StockHistory, error) {
+	if interval == "" {
+		interval = models.StockInterval1d
+	}
+	if !models.IsValidStockInterval(interval) {
+		return nil, &models.DomainError{Code: "INVALID_STOCK_HISTORY_QUERY", Message: "interval 只支援 1m、5m、1d"}
+	}
+
+	end := time.Now()
+	if to != "" {
+		parsed, err := parseStockHistoryTime(to, true)
+		if err != nil {
+			return nil, &models.DomainError{Code: "INVALID_STOCK_HISTORY_QUERY", Message: "to 格式錯誤，請使用 RFC3339 或 YYYY-MM-DD"}
+		}
+		end = parsed
+	}
+
+	start := end.Add(-stockHistoryDefaultSpans[interval])
+	if from != "" {
+		parsed, err := parseStockHistoryTime(from, false)
+		if err != nil {
+			return nil, &models.DomainError{Code: "INVALID_STOCK_HISTORY_QUERY", Message: "from 格式錯誤，請使用 RFC3339 或 YYYY-MM-DD"}
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		return nil, &models.DomainError{Code: "INVALID_STOCK_HISTORY_QUERY", Message: "from 必須早於 to"}
+	}
+
+	// 日 K 以交易日零時為起點，區間起點對齊到當日才不會漏掉 from 當天
+	queryStart := start
+	if interval == models.StockInterval1d {
+		queryStart = tradingDay(start)
+	}
+
+	bars, err := s.historyRepo.GetBars(code, interval, queryStart, end, maxStockHistoryBars)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bars {
+		bars[i].BucketStart = bars[i].BucketStart.In(taipeiLocation)
+	}
+
+	return &models.StockHistory{
+		StockCode: code,
+		Interval:  interval,
+		From:      start.In(taipeiLocation),
+		To:        end.In(taipeiLocation),
+		Bars:      bars,
+	}, nil
+}
+
+// parseStockHistoryTime 解析查詢時間，日期格式以台灣時間解讀，作為結束時間時延伸到隔日零時
+func parseStockHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, taipeiLocation)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Compact 依保留天數清理過期資料：逐筆行情 → 1 分 K → 5 分 K，日 K 永久保留
+// K 棒在寫入行情時已同步更新，刪除較細的資料不會影響較粗週期的 K 棒
+func (s *StockHistoryService) Compact(now time.Time) (*models.StockHistoryCompaction, error) {
+	result := &models.StockHistoryCompaction{}
+	var err error
+
+	if s.tickRetention > 0 {
+		if result.TicksDeleted, err = s.historyRepo.DeleteTicksBefore(now.Add(-s.tickRetention)); err != nil {
+			return nil, err
+		}
+	}
+	if s.minuteBarRetention > 0 {
+		if result.MinuteBarsDeleted, err = s.historyRepo.DeleteBarsBefore(models.StockInterval1m, now.Add(-s.minuteBarRetention)); err != nil {
+			return nil, err
+		}
+	}
+	if s.fiveMinuteBarRetention > 0 {
+		if result.FiveMinuteBarsDeleted, err = s.historyRepo.DeleteBarsBefore(models.StockInterval5m, now.Add(-s.fiveMinuteBarRetention)); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// StartCompactor 啟動背景清理排程
+func (s *StockHistoryService) StartCompactor() {
+	s.ticker = time.NewTicker(s.interval)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				result, err := s.Compact(time.Now())
+				if err != nil {
+					logger.Error("股價歷史清理失敗", err)
+					continue
+				}
+				if result.TicksDeleted+result.MinuteBarsDeleted+result.FiveMinuteBarsDeleted > 0 {
+					logger.Info("股價歷史清理完成", logrus.Fields{
+						"ticks_deleted":            result.TicksDeleted,
+						"minute_bars_deleted":      result.MinuteBarsDeleted,
+						"five_minute_bars_deleted": result.FiveMinuteBarsDeleted,
+					})
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	logger.Info("股價歷史清理排程已啟動", logrus.Fields{
+		"interval": s.interval.String(),
+	})
+}
+
+// StopCompactor 停止背景清理排程
+func (s *StockHistoryService) StopCompactor() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	select {
+	case s.stopChan <- true:
+	default:
+	}
+}
@@ -14,15 +14,21 @@ import (
 // StockService 股票服務
 type StockService struct {
 	stockRepo models.StockRepository
+	history   *StockHistoryService
+	candles   *CandleService
+	indicators *IndicatorService
 	httpClient *http.Client
 	ticker    *time.Ticker
 	stopChan  chan bool
 }
 
 // NewStockService 創建股票服務實例
-func NewStockService(stockRepo models.StockRepository) *StockService {
+func NewStockService(stockRepo models.StockRepository, history *StockHistoryService, candles *CandleService, indicators *IndicatorService) *StockService {
 	return &StockService{
 		stockRepo: stockRepo,
+		history:   history,
+		candles:   candles,
+		indicators: indicators,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -69,6 +75,45 @@ func (s *StockService) GetStockByCode(code string) (*models.StockWithPrice, erro
 	return s.stockRepo.GetStockByCode(code)
 }
 
+// GetStockHistory 獲取股票歷史 K 棒
+func (s *StockService) GetStockHistory(code, interval, from, to string) (*models.StockHistory, error) {
+	stock, err := s.stockRepo.GetStockByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil {
+		return nil, models.ErrStockNotFound
+	}
+	
+	return s.history.GetHistory(code, interval, from, to)
+}
+
+// GetStockCandles 獲取股票K線
+func (s *StockService) GetStockCandles(code, resolution, from, to string) (*models.CandleSeries, error) {
+	stock, err := s.stockRepo.GetStockByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil {
+		return nil, models.ErrStockNotFound
+	}
+	
+	return s.candles.GetCandles(code, resolution, from, to)
+}
+
+// GetStockIndicators 獲取股票技術指標
+func (s *StockService) GetStockIndicators(code, resolution string, limit int) (*models.StockIndicators, error) {
+	stock, err := s.stockRepo.GetStockByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil {
+		return nil, models.ErrStockNotFound
+	}
+	
+	return s.indicators.GetIndicators(code, resolution, limit)
+}
+
 // GetStockCategories 獲取股票分類列表
 func (s *StockService) GetStockCategories() ([]models.StockCategory, error) {
 	return s.stockRepo.GetCategories()
@@ -462,6 +507,7 @@ func (s *StockService) UpdateStockPricesFromTSEWithForce(forceUpdate bool) error
 	// 分批處理（每次最多20個股票代碼）
 	batchSize := 20
 	tseAPI := NewTSEAPIService()
+	inSession := s.isTradingTime(time.Now())
 	
 	for i := 0; i < len(codes); i += batchSize {
 		end := i + batchSize
@@ -487,6 +533,13 @@ func (s *StockService) UpdateStockPricesFromTSEWithForce(forceUpdate bool) error
 					errorCount++
 				} else {
 					successCount++
+					// 最新價格之外另記入歷史行情與K棒
+					if err := s.history.RecordPrice(stockPrice, inSession); err != nil {
+						fmt.Printf("寫入股價歷史失敗 (%s): %v\n", stockPrice.StockCode, err)
+					} else {
+						// 只重算形成中的K線，已收盤的K線沿用快取
+						s.candles.Refresh(stockPrice.StockCode, stockPrice.UpdatedAt)
+					}
 				}
 			}
 		}
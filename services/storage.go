@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go-simple-app/config"
+)
+
+// ObjectStorage 定義上傳檔案儲存後端的通用接口
+type ObjectStorage interface {
+	// Name 儲存後端名稱
+	Name() string
+
+	// Put 寫入物件，相同 key 會被覆蓋
+	Put(ctx context.Context, key, contentType string, data []byte) error
+
+	// Delete 刪除物件，物件不存在時不視為錯誤
+	Delete(ctx context.Context, key string) error
+
+	// URL 物件對外的網址
+	URL(key string) string
+}
+
+// NewObjectStorage 根據配置建立儲存後端
+func NewObjectStorage(cfg config.StorageConfig) ObjectStorage {
+	switch cfg.Driver {
+	case "s3":
+		return NewS3Storage(cfg.S3)
+	default:
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicBaseURL)
+	}
+}
+
+// LocalStorage 本機檔案系統儲存，檔案由 PublicBaseURL 對應的靜態路由提供
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage 創建本機檔案系統儲存
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Dir 儲存的根目錄
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStorage) Name() string {
+	return "local"
+}
+
+// Put 先寫入暫存檔再改名，避免讀取到寫一半的檔案
+func (s *LocalStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(target, data)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path 將 key 轉為根目錄下的路徑，拒絕跳出根目錄的 key
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("無效的儲存路徑: %s", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
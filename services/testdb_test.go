@@ -0,0 +1,152 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-simple-app/config"
+	"go-simple-app/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestDB 建立暫存的 SQLite 資料庫並依序執行 migrations 目錄的遷移檔案
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob(filepath.Join("..", "migrations", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("find migrations: %v", err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		// 與 database.runMigrations 相同，欄位已存在時略過
+		if _, err := db.Exec(string(content)); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			t.Fatalf("migrate %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return db
+}
+
+// testShop 以測試資料庫組成的結帳、付款與退貨服務
+type testShop struct {
+	db        *sql.DB
+	carts     *CartService
+	orders    *OrderService
+	payments  *PaymentService
+	inventory *InventoryService
+	provider  *MockPaymentProvider
+	seq       int
+}
+
+func newTestShop(t *testing.T) *testShop {
+	t.Helper()
+
+	db := newTestDB(t)
+	shipping := NewShippingService(db, config.ShippingConfig{DefaultWeightKg: 0.5, VolumetricDivisor: 6000})
+	orders := NewOrderService(db, shipping)
+	provider := NewMockPaymentProvider(config.MockPaymentConfig{Secret: "test-secret"})
+	return &testShop{
+		db:        db,
+		carts:     NewCartService(db, shipping),
+		orders:    orders,
+		payments:  NewPaymentService(db, config.PaymentConfig{CallbackBaseURL: "http://localhost"}, provider, orders),
+		inventory: NewInventoryService(db, config.InventoryConfig{ReservationTTLMinutes: 15}),
+		provider:  provider,
+	}
+}
+
+// exec 執行 SQL，失敗時中止測試並回傳新增資料的ID
+func (s *testShop) exec(t *testing.T, query string, args ...interface{}) int {
+	t.Helper()
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+	id, _ := result.LastInsertId()
+	return int(id)
+}
+
+// scalar 查詢單一值，失敗時中止測試
+func (s *testShop) scalar(t *testing.T, dest interface{}, query string, args ...interface{}) {
+	t.Helper()
+	if err := s.db.QueryRow(query, args...).Scan(dest); err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+}
+
+func (s *testShop) customer(t *testing.T) int {
+	s.seq++
+	return s.exec(t, `INSERT INTO customers (name, email, password) VALUES (?, ?, 'x')`,
+		fmt.Sprintf("客戶%d", s.seq), fmt.Sprintf("customer%d@example.com", s.seq))
+}
+
+func (s *testShop) merchant(t *testing.T) int {
+	s.seq++
+	return s.exec(t, `INSERT INTO merchants (name, email, password) VALUES (?, ?, 'x')`,
+		fmt.Sprintf("商戶%d", s.seq), fmt.Sprintf("merchant%d@example.com", s.seq))
+}
+
+func (s *testShop) product(t *testing.T, merchantID int, price float64, stock int) int {
+	s.seq++
+	return s.exec(t, `INSERT INTO products (name, description, price, category, sub_category, brand, stock, image_url, merchant_id)
+		VALUES (?, '', ?, '其他', '', '', ?, '', ?)`, fmt.Sprintf("商品%d", s.seq), price, stock, merchantID)
+}
+
+// stock 商品目前的庫存與保留數量
+func (s *testShop) stock(t *testing.T, productID int) (stock, reserved int) {
+	t.Helper()
+	if err := s.db.QueryRow(`SELECT stock, reserved_stock FROM products WHERE id = ?`, productID).Scan(&stock, &reserved); err != nil {
+		t.Fatalf("query stock: %v", err)
+	}
+	return stock, reserved
+}
+
+// addToCart 將商品加入購物車，items 依序為商品ID與數量
+func (s *testShop) addToCart(t *testing.T, customerID int, items ...int) {
+	t.Helper()
+	for i := 0; i+1 < len(items); i += 2 {
+		if err := s.carts.AddToCart(customerID, items[i], 0, items[i+1]); err != nil {
+			t.Fatalf("AddToCart(%d): %v", items[i], err)
+		}
+	}
+}
+
+// checkout 以購物車內容下單
+func (s *testShop) checkout(t *testing.T, customerID int) *models.OrderGroup {
+	t.Helper()
+	group, err := s.orders.PlaceOrder(customerID, &models.PlaceOrderRequest{ShippingAddress: "台北市信義區市府路1號"})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	return group
+}
+
+// placeOrder 將商品加入購物車後下單，items 依序為商品ID與數量
+func (s *testShop) placeOrder(t *testing.T, customerID int, items ...int) *models.OrderGroup {
+	t.Helper()
+	s.addToCart(t, customerID, items...)
+	return s.checkout(t, customerID)
+}
+
+// orderStatus 訂單目前的狀態
+func (s *testShop) orderStatus(t *testing.T, orderID int) string {
+	t.Helper()
+	var status string
+	s.scalar(t, &status, `SELECT status FROM orders WHERE id = ?`, orderID)
+	return status
+}
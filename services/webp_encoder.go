@@ -0,0 +1,452 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+)
+
+// WebP 無失真（VP8L）編碼器
+// 標準庫與 golang.org/x/image 都只有 WebP 解碼器，這裡實作產生佔位圖片所需的最小子集：
+// 不使用轉換與顏色快取，像素以前綴碼（Huffman）編碼，與左方或上方像素相同的連續區段以回溯複製表示。
+// 佔位圖片大多是大片單色區塊，回溯複製就能讓檔案比 PNG 更小。
+
+const (
+	vp8lSignature       = 0x2f
+	vp8lMaxDimension    = 1 << 14
+	vp8lMaxCopyLength   = 4096
+	vp8lMinCopyLength   = 3
+	vp8lLengthPrefixes  = 24
+	vp8lDistanceSymbols = 40
+	vp8lMaxCodeLength   = 15
+	vp8lMaxCLCodeLength = 7
+
+	// 距離以「平面碼」表示，1 為正上方像素，2 為左方像素
+	vp8lPlaneCodeAbove = 1
+	vp8lPlaneCodeLeft  = 2
+)
+
+// vp8lCodeLengthOrder 前綴碼長度的編碼長度依此順序寫入
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// vp8lToken 一個字面像素（argb）或一段回溯複製（length、planeCode）
+type vp8lToken struct {
+	argb      uint32
+	length    int
+	planeCode int
+}
+
+// encodeWebPLossless 將圖片編碼為無失真 WebP
+func encodeWebPLossless(img *image.NRGBA) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return nil, errors.New("WebP 圖片尺寸超出範圍")
+	}
+
+	pixels := make([]uint32, 0, width*height)
+	hasAlpha := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := img.PixOffset(bounds.Min.X, y)
+		for x := 0; x < width; x++ {
+			p := img.Pix[offset : offset+4]
+			pixels = append(pixels, uint32(p[3])<<24|uint32(p[0])<<16|uint32(p[1])<<8|uint32(p[2]))
+			hasAlpha = hasAlpha || p[3] != 0xff
+			offset += 4
+		}
+	}
+
+	tokens := vp8lTokenize(pixels, width)
+
+	// 統計五組前綴碼的符號次數：綠色（含複製長度）、紅、藍、alpha、距離
+	green := make([]int, 256+vp8lLengthPrefixes)
+	red := make([]int, 256)
+	blue := make([]int, 256)
+	alpha := make([]int, 256)
+	distance := make([]int, vp8lDistanceSymbols)
+	for _, token := range tokens {
+		if token.length > 0 {
+			lengthSymbol, _, _ := vp8lPrefixEncode(token.length)
+			distanceSymbol, _, _ := vp8lPrefixEncode(token.planeCode)
+			green[256+lengthSymbol]++
+			distance[distanceSymbol]++
+			continue
+		}
+		green[(token.argb>>8)&0xff]++
+		red[(token.argb>>16)&0xff]++
+		blue[token.argb&0xff]++
+		alpha[token.argb>>24]++
+	}
+
+	w := &bitWriter{}
+	w.write(vp8lSignature, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	if hasAlpha {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+	w.write(0, 3) // 版本
+	w.write(0, 1) // 不使用轉換
+	w.write(0, 1) // 不使用顏色快取
+	w.write(0, 1) // 整張圖片使用同一組前綴碼
+
+	codes := make([]*prefixCode, 5)
+	for i, histogram := range [][]int{green, red, blue, alpha, distance} {
+		codes[i] = buildPrefixCode(histogram, vp8lMaxCodeLength)
+		codes[i].writeTo(w)
+	}
+
+	for _, token := range tokens {
+		if token.length > 0 {
+			lengthSymbol, lengthBits, lengthExtra := vp8lPrefixEncode(token.length)
+			distanceSymbol, distanceBits, distanceExtra := vp8lPrefixEncode(token.planeCode)
+			codes[0].writeSymbol(w, 256+lengthSymbol)
+			w.write(lengthExtra, lengthBits)
+			codes[4].writeSymbol(w, distanceSymbol)
+			w.write(distanceExtra, distanceBits)
+			continue
+		}
+		codes[0].writeSymbol(w, int((token.argb>>8)&0xff))
+		codes[1].writeSymbol(w, int((token.argb>>16)&0xff))
+		codes[2].writeSymbol(w, int(token.argb&0xff))
+		codes[3].writeSymbol(w, int(token.argb>>24))
+	}
+
+	data := w.bytes()
+	chunkSize := len(data)
+	padded := chunkSize + chunkSize&1
+
+	out := make([]byte, 0, 20+padded)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(4+8+padded))
+	out = append(out, "WEBPVP8L"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(chunkSize))
+	out = append(out, data...)
+	if chunkSize&1 == 1 {
+		out = append(out, 0)
+	}
+	return out, nil
+}
+
+// vp8lTokenize 以貪婪法找出與上方或左方像素相同的連續區段，其餘像素以字面值輸出
+func vp8lTokenize(pixels []uint32, width int) []vp8lToken {
+	tokens := []vp8lToken{}
+	for i := 0; i < len(pixels); {
+		above := 0
+		if i >= width {
+			for i+above < len(pixels) && above < vp8lMaxCopyLength && pixels[i+above] == pixels[i+above-width] {
+				above++
+			}
+		}
+		left := 0
+		if i >= 1 {
+			for i+left < len(pixels) && left < vp8lMaxCopyLength && pixels[i+left] == pixels[i+left-1] {
+				left++
+			}
+		}
+
+		switch {
+		case above >= vp8lMinCopyLength && above >= left:
+			tokens = append(tokens, vp8lToken{length: above, planeCode: vp8lPlaneCodeAbove})
+			i += above
+		case left >= vp8lMinCopyLength:
+			tokens = append(tokens, vp8lToken{length: left, planeCode: vp8lPlaneCodeLeft})
+			i += left
+		default:
+			tokens = append(tokens, vp8lToken{argb: pixels[i]})
+			i++
+		}
+	}
+	return tokens
+}
+
+// vp8lPrefixEncode 將複製長度或距離（>= 1）轉為前綴符號與額外位元
+func vp8lPrefixEncode(value int) (symbol int, extraBits uint, extra uint32) {
+	n := value - 1
+	if n < 4 {
+		return n, 0, 0
+	}
+	highest := 0
+	for v := n; v > 1; v >>= 1 {
+		highest++
+	}
+	second := (n >> (highest - 1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(n & (1<<extraBits - 1))
+}
+
+// prefixCode 一組前綴碼；codes 已反轉位元順序，可直接由低位元寫入
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint32
+	symbols []int // 實際用到的符號，simple 編碼時使用
+	simple  bool  // 只有一或兩個小於 256 的符號時使用簡易編碼
+}
+
+// buildPrefixCode 依符號次數建立長度不超過 maxLength 的標準前綴碼
+func buildPrefixCode(histogram []int, maxLength int) *prefixCode {
+	code := &prefixCode{}
+	for symbol, count := range histogram {
+		if count > 0 {
+			code.symbols = append(code.symbols, symbol)
+		}
+	}
+	if len(code.symbols) == 0 {
+		code.symbols = []int{0}
+	}
+
+	code.simple = len(code.symbols) <= 2
+	for _, symbol := range code.symbols {
+		code.simple = code.simple && symbol < 256
+	}
+
+	counts := append([]int(nil), histogram...)
+	if code.simple {
+		// 簡易編碼中只有一個符號時不佔位元；兩個符號時各為 1 位元
+		for i := range counts {
+			counts[i] = 0
+		}
+		for _, symbol := range code.symbols {
+			counts[symbol] = 1
+		}
+		if len(code.symbols) == 1 {
+			code.lengths = make([]uint8, len(histogram))
+			code.codes = make([]uint32, len(histogram))
+			return code
+		}
+	} else if len(code.symbols) == 1 {
+		// 一般編碼至少需要兩個符號才是完整的前綴碼，補一個不會用到的符號
+		if code.symbols[0] == 0 {
+			counts[1] = 1
+		} else {
+			counts[0] = 1
+		}
+	}
+
+	code.lengths = huffmanLengths(counts, maxLength)
+	code.codes = canonicalCodes(code.lengths)
+	return code
+}
+
+// writeTo 寫入前綴碼本身的描述
+func (c *prefixCode) writeTo(w *bitWriter) {
+	if c.simple {
+		w.write(1, 1)
+		w.write(uint32(len(c.symbols)-1), 1)
+		if c.symbols[0] <= 1 {
+			w.write(0, 1)
+			w.write(uint32(c.symbols[0]), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(c.symbols[0]), 8)
+		}
+		if len(c.symbols) == 2 {
+			w.write(uint32(c.symbols[1]), 8)
+		}
+		return
+	}
+
+	// 碼長序列：連續的 0 以 17（3-10 個）或 18（11-138 個）表示，其餘直接寫入長度
+	type lengthToken struct {
+		symbol    int
+		extraBits uint
+		extra     uint32
+	}
+	tokens := []lengthToken{}
+	for i := 0; i < len(c.lengths); {
+		if c.lengths[i] != 0 {
+			tokens = append(tokens, lengthToken{symbol: int(c.lengths[i])})
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(c.lengths) && c.lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run >= 11:
+			tokens = append(tokens, lengthToken{symbol: 18, extraBits: 7, extra: uint32(run - 11)})
+		case run >= 3:
+			tokens = append(tokens, lengthToken{symbol: 17, extraBits: 3, extra: uint32(run - 3)})
+		default:
+			for j := 0; j < run; j++ {
+				tokens = append(tokens, lengthToken{symbol: 0})
+			}
+		}
+		i += run
+	}
+
+	histogram := make([]int, 19)
+	for _, token := range tokens {
+		histogram[token.symbol]++
+	}
+	used := 0
+	for _, count := range histogram {
+		if count > 0 {
+			used++
+		}
+	}
+	if used == 1 {
+		// 碼長編碼同樣至少需要兩個符號
+		if histogram[0] == 0 {
+			histogram[0] = 1
+		} else {
+			histogram[1] = 1
+		}
+	}
+	lengthCode := &prefixCode{lengths: huffmanLengths(histogram, vp8lMaxCLCodeLength)}
+	lengthCode.codes = canonicalCodes(lengthCode.lengths)
+
+	count := len(vp8lCodeLengthOrder)
+	for count > 4 && lengthCode.lengths[vp8lCodeLengthOrder[count-1]] == 0 {
+		count--
+	}
+
+	w.write(0, 1) // 一般編碼
+	w.write(uint32(count-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:count] {
+		w.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	w.write(0, 1) // 碼長數量等於字母表大小
+	for _, token := range tokens {
+		lengthCode.writeSymbol(w, token.symbol)
+		w.write(token.extra, token.extraBits)
+	}
+}
+
+func (c *prefixCode) writeSymbol(w *bitWriter, symbol int) {
+	w.write(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// huffmanLengths 計算各符號的 Huffman 碼長；超過長度上限時把次數減半後重算
+func huffmanLengths(counts []int, maxLength int) []uint8 {
+	counts = append([]int(nil), counts...)
+	for {
+		lengths := huffmanLengthsOnce(counts)
+		longest := uint8(0)
+		for _, length := range lengths {
+			if length > longest {
+				longest = length
+			}
+		}
+		if int(longest) <= maxLength {
+			return lengths
+		}
+		for i, count := range counts {
+			if count > 0 {
+				counts[i] = max(1, count/2)
+			}
+		}
+	}
+}
+
+func huffmanLengthsOnce(counts []int) []uint8 {
+	type node struct {
+		count       int
+		symbol      int
+		left, right int
+	}
+	nodes := []node{}
+	active := []int{}
+	for symbol, count := range counts {
+		if count > 0 {
+			nodes = append(nodes, node{count: count, symbol: symbol, left: -1, right: -1})
+			active = append(active, len(nodes)-1)
+		}
+	}
+
+	// 每次合併次數最小的兩個節點，符號數不超過幾百個，線性搜尋即可
+	popMin := func() int {
+		best := 0
+		for i := range active {
+			if nodes[active[i]].count < nodes[active[best]].count {
+				best = i
+			}
+		}
+		index := active[best]
+		active = append(active[:best], active[best+1:]...)
+		return index
+	}
+	for len(active) > 1 {
+		a, b := popMin(), popMin()
+		nodes = append(nodes, node{count: nodes[a].count + nodes[b].count, symbol: -1, left: a, right: b})
+		active = append(active, len(nodes)-1)
+	}
+
+	lengths := make([]uint8, len(counts))
+	var walk func(index int, depth uint8)
+	walk = func(index int, depth uint8) {
+		if nodes[index].symbol >= 0 {
+			// 只有一個符號時深度為 0，仍給 1 位元
+			if depth == 0 {
+				depth = 1
+			}
+			lengths[nodes[index].symbol] = depth
+			return
+		}
+		walk(nodes[index].left, depth+1)
+		walk(nodes[index].right, depth+1)
+	}
+	if len(active) == 1 {
+		walk(active[0], 0)
+	}
+	return lengths
+}
+
+// canonicalCodes 依碼長與符號順序分配標準前綴碼（與 DEFLATE 相同），並反轉位元以便由低位元寫入
+func canonicalCodes(lengths []uint8) []uint32 {
+	var lengthCounts [vp8lMaxCodeLength + 1]uint32
+	for _, length := range lengths {
+		if length > 0 {
+			lengthCounts[length]++
+		}
+	}
+	var next [vp8lMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		code = (code + lengthCounts[length-1]) << 1
+		next[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		value := next[length]
+		next[length]++
+		reversed := uint32(0)
+		for i := uint8(0); i < length; i++ {
+			reversed = reversed<<1 | (value>>i)&1
+		}
+		codes[symbol] = reversed
+	}
+	return codes
+}
+
+// bitWriter 由低位元開始寫入的位元串流
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) write(bits uint32, n uint) {
+	w.acc |= uint64(bits) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
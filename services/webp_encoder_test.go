@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPLosslessRoundTrip(t *testing.T) {
+	fill := func(width, height int, pixel func(x, y int) color.NRGBA) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.SetNRGBA(x, y, pixel(x, y))
+			}
+		}
+		return img
+	}
+	random := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{"單一不透明像素", fill(1, 1, func(x, y int) color.NRGBA { return color.NRGBA{0x12, 0x34, 0x56, 0xff} })},
+		{"單一透明像素", fill(1, 1, func(x, y int) color.NRGBA { return color.NRGBA{0x12, 0x34, 0x56, 0x00} })},
+		{"單色大圖（複製長度超過上限）", fill(120, 90, func(x, y int) color.NRGBA { return color.NRGBA{0xf8, 0xf9, 0xfa, 0xff} })},
+		{"水平條紋", fill(33, 17, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(y * 15), 0x80, uint8(255 - y*15), 0xff}
+		})},
+		{"垂直條紋", fill(17, 33, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 15), uint8(x * 7), 0x20, 0xff}
+		})},
+		{"棋盤格", fill(40, 40, func(x, y int) color.NRGBA {
+			if (x/4+y/4)%2 == 0 {
+				return color.NRGBA{0, 0, 0, 0xff}
+			}
+			return color.NRGBA{0xff, 0xff, 0xff, 0xff}
+		})},
+		{"漸層含半透明", fill(64, 48, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 4), uint8(y * 5), uint8(x + y), uint8(255 - x*2)}
+		})},
+		{"隨機雜訊", fill(57, 31, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(random.Intn(256)), uint8(random.Intn(256)), uint8(random.Intn(256)), uint8(random.Intn(256))}
+		})},
+		{"少量顏色隨機分布", fill(50, 50, func(x, y int) color.NRGBA {
+			palette := []color.NRGBA{{0xde, 0xe2, 0xe6, 0xff}, {0x49, 0x50, 0x57, 0xff}, {0xad, 0xb5, 0xbd, 0xff}}
+			return palette[random.Intn(len(palette))]
+		})},
+		{"單列寬圖", fill(5000, 1, func(x, y int) color.NRGBA { return color.NRGBA{uint8(x / 100), 0, 0, 0xff} })},
+		{"單行高圖", fill(1, 300, func(x, y int) color.NRGBA { return color.NRGBA{0, uint8(y), 0, 0xff} })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertWebPRoundTrip(t, tt.img)
+		})
+	}
+}
+
+func TestEncodeWebPLosslessSubImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 10), uint8(y * 10), 0x40, 0xff})
+		}
+	}
+	assertWebPRoundTrip(t, img.SubImage(image.Rect(5, 3, 17, 11)).(*image.NRGBA))
+}
+
+func TestEncodeWebPLosslessInvalidSize(t *testing.T) {
+	tests := []struct {
+		name string
+		rect image.Rectangle
+	}{
+		{"空白圖片", image.Rect(0, 0, 0, 10)},
+		{"寬度超過上限", image.Rect(0, 0, vp8lMaxDimension+1, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := encodeWebPLossless(image.NewNRGBA(tt.rect)); err == nil {
+				t.Error("encodeWebPLossless() error = nil, want size error")
+			}
+		})
+	}
+}
+
+// assertWebPRoundTrip 編碼後以 golang.org/x/image/webp 解碼，逐像素比對是否無失真
+func assertWebPRoundTrip(t *testing.T, img *image.NRGBA) {
+	t.Helper()
+
+	data, err := encodeWebPLossless(img)
+	if err != nil {
+		t.Fatalf("encodeWebPLossless() error = %v", err)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("webp.Decode() error = %v", err)
+	}
+
+	bounds := img.Bounds()
+	if decoded.Bounds().Dx() != bounds.Dx() || decoded.Bounds().Dy() != bounds.Dy() {
+		t.Fatalf("decoded size = %v, want %dx%d", decoded.Bounds().Size(), bounds.Dx(), bounds.Dy())
+	}
+	origin := decoded.Bounds().Min
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			want := img.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+			got := color.NRGBAModel.Convert(decoded.At(origin.X+x, origin.Y+y)).(color.NRGBA)
+			if got != want {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"database/sql"
+
+	"go-simple-app/models"
+)
+
+// WishlistService 收藏清單業務邏輯服務
+// 補貨通知由 ProductRepository 在商品恢復可購買時排入，顧客讀取通知列表時視為送達
+type WishlistService struct {
+	wishlistRepo *models.WishlistRepository
+	productRepo  *models.ProductRepository
+}
+
+// NewWishlistService 創建收藏清單服務
+func NewWishlistService(db *sql.DB) *WishlistService {
+	return &WishlistService{
+		wishlistRepo: models.NewWishlistRepository(db),
+		productRepo:  models.NewProductRepository(db),
+	}
+}
+
+// GetWishlist 獲取顧客的收藏清單
+func (s *WishlistService) GetWishlist(customerID int) ([]*models.WishlistItem, error) {
+	return s.wishlistRepo.GetByCustomerID(customerID)
+}
+
+// AddItem 加入收藏，缺貨或下架的商品也可收藏以等待補貨通知
+func (s *WishlistService) AddItem(customerID int, req *models.AddWishlistRequest) error {
+	if _, err := s.productRepo.GetByID(req.ProductID); err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrMerchantProductNotFound
+		}
+		return err
+	}
+
+	notify := true
+	if req.NotifyOnRestock != nil {
+		notify = *req.NotifyOnRestock
+	}
+
+	return s.wishlistRepo.Upsert(customerID, req.ProductID, notify)
+}
+
+// RemoveItem 移除收藏
+func (s *WishlistService) RemoveItem(customerID, productID int) error {
+	return s.wishlistRepo.Remove(customerID, productID)
+}
+
+// GetNotifications 獲取補貨通知並將待送出的通知標記為已送達，回傳通知與未讀數量
+func (s *WishlistService) GetNotifications(customerID int, unreadOnly bool, limit int) ([]*models.WishlistNotification, int, error) {
+	if err := s.wishlistRepo.MarkDelivered(customerID); err != nil {
+		return nil, 0, err
+	}
+
+	notifications, err := s.wishlistRepo.GetNotifications(customerID, unreadOnly, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unread, err := s.wishlistRepo.CountUnreadNotifications(customerID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, unread, nil
+}
+
+// MarkNotificationRead 將通知標記為已讀
+func (s *WishlistService) MarkNotificationRead(customerID, notificationID int) error {
+	return s.wishlistRepo.MarkRead(customerID, notificationID)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+
+	"go-simple-app/models"
+)
+
+func TestBackInStockNotification(t *testing.T) {
+	// 每個案例由持有者占用商品唯一的庫存，收藏者開啟補貨通知後執行歸還庫存的操作
+	tests := []struct {
+		name      string
+		restock   func(t *testing.T, shop *testShop, holder, productID int)
+		wantCount int
+	}{
+		{
+			name: "取消訂單回補庫存",
+			restock: func(t *testing.T, shop *testShop, holder, productID int) {
+				group := shop.checkout(t, holder)
+				if _, err := shop.orders.CancelCustomerOrder(holder, group.Orders[0].ID, nil); err != nil {
+					t.Fatalf("CancelCustomerOrder: %v", err)
+				}
+			},
+			wantCount: 1,
+		},
+		{
+			name: "取消結帳釋放保留",
+			restock: func(t *testing.T, shop *testShop, holder, productID int) {
+				if err := shop.inventory.CancelCheckout(holder); err != nil {
+					t.Fatalf("CancelCheckout: %v", err)
+				}
+			},
+			wantCount: 1,
+		},
+		{
+			name: "結帳保留過期",
+			restock: func(t *testing.T, shop *testShop, holder, productID int) {
+				shop.expireReservations(t, holder)
+				if _, err := shop.inventory.ReleaseExpired(); err != nil {
+					t.Fatalf("ReleaseExpired: %v", err)
+				}
+			},
+			wantCount: 1,
+		},
+		{
+			name: "重新結帳再次保留不通知",
+			restock: func(t *testing.T, shop *testShop, holder, productID int) {
+				if _, err := shop.inventory.StartCheckout(holder); err != nil {
+					t.Fatalf("StartCheckout: %v", err)
+				}
+			},
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			holder := shop.customer(t)
+			watcher := shop.customer(t)
+			productID := shop.product(t, shop.merchant(t), 100, 1)
+
+			shop.addToCart(t, holder, productID, 1)
+			if _, err := shop.inventory.StartCheckout(holder); err != nil {
+				t.Fatalf("StartCheckout: %v", err)
+			}
+			shop.exec(t, `INSERT INTO wishlists (customer_id, product_id, notify_on_restock) VALUES (?, ?, 1)`, watcher, productID)
+
+			tt.restock(t, shop, holder, productID)
+
+			var count int
+			shop.scalar(t, &count, `SELECT COUNT(*) FROM wishlist_notifications WHERE customer_id = ? AND product_id = ? AND type = ?`,
+				watcher, productID, models.NotificationTypeBackInStock)
+			if count != tt.wantCount {
+				t.Errorf("notifications = %d, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestBackInStockNotificationOnVariantStock(t *testing.T) {
+	shop := newTestShop(t)
+	merchantID := shop.merchant(t)
+	watcher := shop.customer(t)
+	productID := shop.product(t, merchantID, 500, 0)
+	shop.exec(t, `INSERT INTO wishlists (customer_id, product_id, notify_on_restock) VALUES (?, ?, 1)`, watcher, productID)
+
+	// 設定規格後商品庫存改為規格加總
+	_, _, err := shop.inventory.SetProductVariants(merchantID, productID, &models.ProductVariantsRequest{
+		Options:  []models.ProductOptionInput{{Name: "尺寸", Values: []string{"S"}}},
+		Variants: []models.ProductVariantInput{{SKU: "TEE-S", Options: map[string]string{"尺寸": "S"}, Stock: 5}},
+	})
+	if err != nil {
+		t.Fatalf("SetProductVariants: %v", err)
+	}
+
+	var count int
+	shop.scalar(t, &count, `SELECT COUNT(*) FROM wishlist_notifications WHERE customer_id = ? AND product_id = ?`, watcher, productID)
+	if count != 1 {
+		t.Errorf("notifications = %d, want 1", count)
+	}
+}